@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+)
+
+var baselineOutput string
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage the baseline of known issues",
+}
+
+var baselineCreateCmd = &cobra.Command{
+	Use:   "create [path...]",
+	Short: "Snapshot the current issues into a baseline file",
+	Long: `Render and lint the configured sources and record the fingerprints of all
+the reported issues. Runs with --baseline only report, and fail on, the
+issues not recorded in the baseline.`,
+	RunE: baselineCreate,
+}
+
+func init() {
+	baselineCreateCmd.Flags().StringVarP(&baselineOutput, "output", "o", report.DefaultBaselineFile, "baseline file to write")
+
+	baselineCmd.AddCommand(baselineCreateCmd)
+	rootCmd.AddCommand(baselineCmd)
+}
+
+func baselineCreate(cmd *cobra.Command, args []string) error {
+	result, err := lint(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if err := report.NewBaseline(result.issues).Save(baselineOutput); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Baseline with %d issue(s) written to %s\n", len(result.issues), baselineOutput)
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/bench"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+var (
+	benchCorpus    string
+	benchOut       string
+	benchCompare   string
+	benchThreshold float64
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark the enabled linters against a synthetic corpus",
+	Long: `Benchmark every enabled linter, including custom linters, against a
+deterministic synthetic corpus and print the results as JSON.
+
+With --compare, the results are compared with a previous report and the
+command exits with code 1 if a linter got slower than the threshold.`,
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().StringVar(&benchCorpus, "corpus", bench.CorpusMedium, "corpus size (small|medium|huge)")
+	benchCmd.Flags().StringVar(&benchOut, "out", "", "write the report to a file instead of stdout")
+	benchCmd.Flags().StringVar(&benchCompare, "compare", "", "previous report to compare the results with")
+	benchCmd.Flags().Float64Var(&benchThreshold, "threshold", 0.2, "maximum allowed slowdown per object (0.2 means 20%)")
+
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile, cfgKey)
+	if err != nil {
+		return err
+	}
+
+	enabledLinters := cfg.Linters.Enable
+	if len(enableLinters) > 0 {
+		enabledLinters = enableLinters
+	}
+
+	disabledLinters := cfg.Linters.Disable
+	if len(disableLinters) > 0 {
+		disabledLinters = disableLinters
+	}
+
+	runner, err := linter.NewRunner(&linter.RunnerConfig{
+		EnabledLinters:  enabledLinters,
+		DisabledLinters: disabledLinters,
+		Settings:        cfg.Linters.Settings,
+		CustomLinters:   cfg.Linters.Custom,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create runner: %w", err)
+	}
+
+	objects, err := bench.Corpus(benchCorpus)
+	if err != nil {
+		return err
+	}
+
+	report, err := bench.Run(benchCorpus, objects, runner.Linters())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if benchOut != "" {
+		if err := os.WriteFile(benchOut, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write benchmark report: %w", err)
+		}
+	} else {
+		fmt.Println(string(data))
+	}
+
+	if benchCompare == "" {
+		return nil
+	}
+
+	baseline, err := bench.Load(benchCompare)
+	if err != nil {
+		return err
+	}
+
+	if baseline.Corpus != report.Corpus {
+		return fmt.Errorf("cannot compare %q corpus results with %q corpus results", report.Corpus, baseline.Corpus)
+	}
+
+	regressions := bench.Compare(baseline, report, benchThreshold)
+	if len(regressions) == 0 {
+		return nil
+	}
+
+	for _, r := range regressions {
+		fmt.Fprintf(os.Stderr, "%-30s %8dns/object -> %8dns/object (+%.0f%%)\n", r.Linter, r.Old, r.New, r.Increase*100)
+	}
+
+	os.Exit(1)
+	return nil
+}
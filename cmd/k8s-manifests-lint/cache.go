@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/cache"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/cachedir"
+)
+
+var cacheCleanAll bool
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of rendered objects, charts and remote bases",
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove the cached rendered objects",
+	Long: `Remove the objects cached by previous runs for the helm and kustomize
+sources whose inputs have not changed, from the cache directory of the run
+and of every source. With --all the downloaded charts, remote kustomize bases,
+manifests and JSON schemas are removed as well, the next runs need network
+access.`,
+	RunE: cacheClean,
+}
+
+func init() {
+	cacheCleanCmd.Flags().BoolVar(&cacheCleanAll, "all", false, "also remove the downloaded charts, remote bases, manifests and schemas")
+
+	cacheCmd.AddCommand(cacheCleanCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func cacheClean(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile, cfgKey)
+	if err != nil {
+		return err
+	}
+
+	bases := []string{cfg.Run.CacheDir}
+	if cacheDir != "" {
+		bases = []string{cacheDir}
+	} else {
+		for _, source := range cfg.Sources {
+			if source.CacheDir != "" {
+				bases = append(bases, source.CacheDir)
+			}
+		}
+	}
+
+	dirs := []string{cache.Dir}
+	if cacheCleanAll {
+		dirs = append(dirs, "charts", "kustomize", "manifests", "schemas")
+	}
+
+	seen := make(map[string]bool)
+	for _, base := range bases {
+		for _, dir := range dirs {
+			removed, err := cachedir.Remove(base, dir)
+			if err != nil {
+				return err
+			}
+
+			if !seen[removed] {
+				seen[removed] = true
+				fmt.Fprintf(os.Stderr, "Removed %s\n", removed)
+			}
+		}
+	}
+
+	return nil
+}
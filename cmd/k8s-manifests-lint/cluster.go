@@ -0,0 +1,68 @@
+package main
+
+import (
+	"time"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+)
+
+var (
+	kubeconfig     string
+	kubeContext    string
+	asUser         string
+	asGroups       []string
+	requestTimeout time.Duration
+
+	lintCluster       bool
+	clusterNamespaces []string
+	clusterKinds      []string
+	clusterSelector   string
+)
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "path to the kubeconfig file used by cluster modes")
+	flags.StringVar(&kubeContext, "context", "", "kubeconfig context used by cluster modes")
+	flags.StringVar(&asUser, "as", "", "username to impersonate in cluster modes")
+	flags.StringSliceVar(&asGroups, "as-group", nil, "group to impersonate in cluster modes, can be repeated")
+	flags.DurationVar(&requestTimeout, "request-timeout", 0, "timeout of a single request to the cluster (0 means no timeout)")
+
+	runCmd.Flags().BoolVar(&lintCluster, "cluster", false, "lint the resources deployed in the cluster of the kubeconfig context instead of the sources")
+	runCmd.Flags().StringSliceVar(&clusterNamespaces, "namespace", nil, "namespace the --cluster resources are listed from, can be repeated, * lists all of them (default: the namespace of the context)")
+	runCmd.Flags().StringSliceVar(&clusterKinds, "kinds", nil, "kinds, resources or short names of the --cluster resources, e.g. deploy,sts (default: the common workload resources)")
+	runCmd.Flags().StringVar(&clusterSelector, "selector", "", "label selector of the --cluster resources")
+}
+
+// clusterSource returns the cluster source of run --cluster
+func clusterSource() config.Source {
+	return config.Source{
+		Type:       config.SourceTypeCluster,
+		Namespaces: clusterNamespaces,
+		Kinds:      clusterKinds,
+		Selector:   clusterSelector,
+	}
+}
+
+// clusterConfig returns the cluster configuration with the command line flags
+// taking precedence over the configuration file
+func clusterConfig(cfg *config.Config) config.ClusterConfig {
+	result := cfg.Cluster
+
+	if kubeconfig != "" {
+		result.Kubeconfig = kubeconfig
+	}
+	if kubeContext != "" {
+		result.Context = kubeContext
+	}
+	if asUser != "" {
+		result.As = asUser
+	}
+	if len(asGroups) > 0 {
+		result.AsGroups = asGroups
+	}
+	if requestTimeout > 0 {
+		result.RequestTimeout = requestTimeout
+	}
+
+	return result
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/demo"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+var demoCheck bool
+
+var demoCmd = &cobra.Command{
+	Use:   "demo [dir]",
+	Short: "Lint a sample project on which every built-in linter reports issues",
+	Long: `Write a sample project, made of a Helm chart, kustomize overlays, plain
+manifests and custom linters, into dir or a new temporary directory and run
+the linters against it, so that every built-in linter can be seen in action
+without writing fixtures. The project is kept, to be explored and linted
+again with run.
+
+With --check, the issues are counted per linter instead and the command
+fails if a linter reported none, which makes the sample project an end to
+end test of the linters.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDemo,
+}
+
+func init() {
+	demoCmd.Flags().BoolVar(&demoCheck, "check", false, "fail if a linter reports no issue instead of printing the issues")
+
+	rootCmd.AddCommand(demoCmd)
+}
+
+func runDemo(cmd *cobra.Command, args []string) error {
+	var dir string
+	if len(args) > 0 {
+		dir = args[0]
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	} else {
+		tmp, err := os.MkdirTemp("", "k8s-manifests-lint-demo-")
+		if err != nil {
+			return fmt.Errorf("failed to create the demo directory: %w", err)
+		}
+		dir = tmp
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := demo.Write(dir); err != nil {
+		return fmt.Errorf("failed to write the demo project: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Demo project written to %s\n\n", dir)
+
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("failed to change working directory: %w", err)
+	}
+
+	cfgFile = demo.ConfigFile
+
+	if !demoCheck {
+		return runLint(cmd, nil)
+	}
+
+	result, err := lint(cmd, nil)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, issue := range result.issues {
+		counts[issue.Linter]++
+	}
+
+	var missing []string
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, l := range result.linters {
+		// the custom linter types only report issues through the custom
+		// linters of the configuration
+		if linter.IsType(l.Name()) {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%d\n", l.Name(), counts[l.Name()])
+
+		if counts[l.Name()] == 0 {
+			missing = append(missing, l.Name())
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("no issue reported by %d linter(s): %v", len(missing), missing)
+	}
+
+	return nil
+}
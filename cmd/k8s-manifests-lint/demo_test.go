@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+// TestDemoCorpus runs demo --check, the demo project is the end to end test
+// corpus of the linters: it fails if a linter reports no issue on it
+func TestDemoCorpus(t *testing.T) {
+	// the demo changes the working directory and sets the config file
+	t.Chdir(t.TempDir())
+	t.Cleanup(func() {
+		demoCheck = false
+		noCache = false
+		cfgFile = ""
+	})
+
+	demoCheck = true
+	// the rendered sources are not cached in the user cache directory
+	noCache = true
+	demoCmd.SetContext(t.Context())
+
+	if err := runDemo(demoCmd, []string{t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/credentials"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/fix"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/vcs"
+)
+
+const fixCommitTitle = "Fix k8s-manifests-lint issues"
+
+var (
+	fixCommit   bool
+	fixBranch   string
+	fixOpenPR   bool
+	fixRemote   string
+	fixProvider string
+
+	fixKustomizePatches  bool
+	fixKustomizePatchDir string
+
+	fixNoVerify bool
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix [path...]",
+	Short: "Apply the available fixes to the YAML sources",
+	Long: `Render and lint the configured sources and apply the machine-applicable
+fixes to the YAML files the resources come from. With --commit the fixes are
+committed on a new branch, with --open-pr the branch is pushed and a GitHub
+pull request or GitLab merge request is opened (using GITHUB_TOKEN or
+GITLAB_TOKEN). The provider is detected from the host of the remote, hosts
+which are neither github.com, gitlab.com nor named after one of them, such as
+a GitHub Enterprise instance at git.example.com, need --provider.
+
+Resources built by kustomize cannot be edited in place, with
+--kustomize-patches their fixes are written as patches of the kustomization,
+or overlay, they have been built from, leaving the shared bases untouched.
+
+Once applied, the fixed sources are rendered and linted again and the issues
+resolved by the fixes are reported together with the ones remaining, such as
+fixes which could not be applied; --no-verify skips this second run.`,
+	RunE: runFix,
+}
+
+func init() {
+	fixCmd.Flags().BoolVar(&fixCommit, "commit", false, "commit the fixes on a new branch")
+	fixCmd.Flags().StringVar(&fixBranch, "branch", "", "branch to commit the fixes on (default: k8s-manifests-lint/fix-<timestamp>)")
+	fixCmd.Flags().BoolVar(&fixOpenPR, "open-pr", false, "push the branch and open a pull request, implies --commit")
+	fixCmd.Flags().StringVar(&fixRemote, "remote", "origin", "git remote to push the branch to")
+	fixCmd.Flags().StringVar(&fixProvider, "provider", "", "provider hosting the remote, one of: "+strings.Join(vcs.Providers, ", ")+" (default: detected from the host)")
+	fixCmd.Flags().BoolVar(&fixKustomizePatches, "kustomize-patches", false, "write the fixes of resources built by kustomize as patches of their kustomization")
+	fixCmd.Flags().StringVar(&fixKustomizePatchDir, "kustomize-patch-dir", fix.DefaultKustomizePatchDir, "directory, relative to the kustomization, the --kustomize-patches files are written to")
+	fixCmd.Flags().BoolVar(&fixNoVerify, "no-verify", false, "do not lint the fixed sources again to report the resolved and remaining issues")
+
+	rootCmd.AddCommand(fixCmd)
+}
+
+func runFix(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	git := &vcs.Git{}
+
+	// the provider of the remote is resolved upfront, not to find out it is
+	// unknown once the fixes have been committed
+	var remote string
+	var repo vcs.Repository
+	if fixOpenPR {
+		var err error
+		if remote, repo, err = fixRepository(cmd, git); err != nil {
+			return err
+		}
+	}
+
+	result, err := lint(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	patches, err := fix.Collect(result.objects, result.issues)
+	if err != nil {
+		return err
+	}
+
+	var applicable, kustomized []fix.Patch
+	for _, p := range patches {
+		if p.File == "" || len(p.Operations) == 0 {
+			continue
+		}
+
+		// objects built by kustomize are attributed to the kustomization
+		// directory, which cannot be edited in place
+		switch {
+		case isFile(p.File):
+			applicable = append(applicable, p)
+		case fixKustomizePatches && fix.Kustomization(p.File) != "":
+			kustomized = append(kustomized, p)
+		}
+	}
+
+	if len(applicable) == 0 && len(kustomized) == 0 {
+		fmt.Fprintln(os.Stderr, "No fixable issues found")
+		return nil
+	}
+
+	commit := fixCommit || fixOpenPR
+
+	var base, branch string
+	if commit {
+		base, err = git.CurrentBranch(ctx)
+		if err != nil {
+			return err
+		}
+
+		branch = fixBranch
+		if branch == "" {
+			branch = "k8s-manifests-lint/fix-" + time.Now().Format("20060102150405")
+		}
+
+		if err := git.CreateBranch(ctx, branch); err != nil {
+			return err
+		}
+	}
+
+	files, err := fix.Apply(applicable)
+	if err != nil {
+		return err
+	}
+
+	if len(files) > 0 {
+		fmt.Fprintf(os.Stderr, "Fixes applied to %d file(s)\n", len(files))
+	}
+
+	if len(kustomized) > 0 {
+		written, err := fix.WriteKustomize(kustomized, fixKustomizePatchDir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Kustomize patches written to %d file(s)\n", len(written))
+
+		files = append(files, written...)
+		applicable = append(applicable, kustomized...)
+	}
+
+	var resolved []linter.Issue
+	for _, p := range applicable {
+		resolved = append(resolved, p.Issues...)
+	}
+
+	if !fixNoVerify {
+		resolved, err = verifyFixes(cmd, args, resolved)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !commit {
+		return nil
+	}
+
+	body := fixSummary(resolved)
+
+	if err := git.Commit(ctx, fixCommitTitle+"\n\n"+body, files...); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Fixes committed on branch %s\n", branch)
+
+	if !fixOpenPR {
+		return nil
+	}
+
+	if c := credentials.Match(result.config.Credentials, credentials.Host(remote)); c != nil {
+		basic, err := credentials.Resolve(*c)
+		if err != nil {
+			return fmt.Errorf("failed to resolve credentials for remote %s: %w", fixRemote, err)
+		}
+		git.Env = credentials.GitEnv(basic)
+	}
+
+	if err := git.Push(ctx, fixRemote, branch); err != nil {
+		return err
+	}
+
+	url, err := vcs.OpenPullRequest(ctx, repo, vcs.PullRequest{
+		Title: fixCommitTitle,
+		Body:  body,
+		Head:  branch,
+		Base:  base,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Pull request opened: %s\n", url)
+
+	return nil
+}
+
+// fixRepository returns the URL of the remote the fixes are pushed to and
+// the repository pull requests are opened on, with its provider set from
+// --provider or detected from the host
+func fixRepository(cmd *cobra.Command, git *vcs.Git) (string, vcs.Repository, error) {
+	remote, err := git.RemoteURL(cmd.Context(), fixRemote)
+	if err != nil {
+		return "", vcs.Repository{}, err
+	}
+
+	repo, err := vcs.ParseRemote(remote)
+	if err != nil {
+		return "", vcs.Repository{}, err
+	}
+
+	switch {
+	case fixProvider == "":
+		if repo.Provider, err = vcs.DetectProvider(repo.Host); err != nil {
+			return "", vcs.Repository{}, fmt.Errorf("%w with --provider", err)
+		}
+	case slices.Contains(vcs.Providers, fixProvider):
+		repo.Provider = fixProvider
+	default:
+		return "", vcs.Repository{}, fmt.Errorf("invalid --provider %q, must be one of: %s", fixProvider, strings.Join(vcs.Providers, ", "))
+	}
+
+	return remote, repo, nil
+}
+
+// verifyFixes renders and lints the fixed sources again and reports which of
+// the fixed issues are resolved and which remain, it returns the resolved
+// ones
+func verifyFixes(cmd *cobra.Command, args []string, fixed []linter.Issue) ([]linter.Issue, error) {
+	result, err := lint(cmd, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lint the fixed sources: %w", err)
+	}
+
+	remaining := make(map[string]bool, len(result.issues))
+	for _, issue := range result.issues {
+		remaining[issue.Fingerprint()] = true
+	}
+
+	var resolved, unresolved []linter.Issue
+	for _, issue := range fixed {
+		if remaining[issue.Fingerprint()] {
+			unresolved = append(unresolved, issue)
+		} else {
+			resolved = append(resolved, issue)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%d issue(s) resolved by the fixes\n", len(resolved))
+
+	if len(unresolved) > 0 {
+		fmt.Fprintf(os.Stderr, "%d fixed issue(s) remain, their fixes could not be applied:\n", len(unresolved))
+		for _, issue := range unresolved {
+			fmt.Fprintf(os.Stderr, "  %s\n", issueLine(issue))
+		}
+	}
+
+	if others := len(result.issues) - len(unresolved); others > 0 {
+		fmt.Fprintf(os.Stderr, "%d issue(s) without a fix remain\n", others)
+	}
+
+	return resolved, nil
+}
+
+// fixSummary lists the resolved checks with their number of issues,
+// followed by every resolved issue
+func fixSummary(resolved []linter.Issue) string {
+	counts := make(map[string]int)
+
+	var issues []string
+	for _, issue := range resolved {
+		counts[issue.Linter]++
+		issues = append(issues, "- "+issueLine(issue))
+	}
+
+	checks := make([]string, 0, len(counts))
+	for name, n := range counts {
+		checks = append(checks, fmt.Sprintf("- %s: %d", name, n))
+	}
+	sort.Strings(checks)
+
+	var sb strings.Builder
+	sb.WriteString("Resolved checks:\n")
+	sb.WriteString(strings.Join(checks, "\n"))
+	sb.WriteString("\n\nResolved issues:\n")
+	sb.WriteString(strings.Join(issues, "\n"))
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// issueLine describes an issue on a single line, as in
+// shop/Deployment/web: message (linter)
+func issueLine(issue linter.Issue) string {
+	resource := fmt.Sprintf("%s/%s", issue.Resource.Kind, issue.Resource.Name)
+	if issue.Resource.Namespace != "" {
+		resource = fmt.Sprintf("%s/%s", issue.Resource.Namespace, resource)
+	}
+
+	return fmt.Sprintf("%s: %s (%s)", resource, issue.Message, issue.Linter)
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -1,31 +1,105 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/credentials"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/events"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/exclude"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/fix"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
 	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/metrics"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/markdown"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/text"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/provenance"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/url"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/yaml"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/severity"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/version"
 )
 
+// stepSummaryResources is the number of resources detailed in the GitHub
+// Actions job summary
+const stepSummaryResources = 50
+
 var (
-	cfgFile        string
-	enableLinters  []string
-	disableLinters []string
-	outputFormat   string
-	noColor        bool
-	failOnWarning  bool
+	cfgFile         string
+	cfgKey          string
+	workingDir      string
+	pathPrefix      string
+	enableLinters   []string
+	disableLinters  []string
+	outputFormat    string
+	noColor         bool
+	colorFlag       string
+	failOnWarning   bool
+	failOn          string
+	linterTimeout   time.Duration
+	logFormat       string
+	concurrency     int
+	showSuppressed  bool
+	baselineFile    string
+	newSince        string
+	fixDryRun       bool
+	outPath         string
+	metricsFile     string
+	metricsGateway  string
+	metricsJob      string
+	patchDir        string
+	noStepSummary   bool
+	showStats       bool
+	showLinterStats bool
+	cpuProfile      string
+	memProfile      string
+	maxIssues       int
+	maxSameIssues   int
+	groupBy         string
+	sortBy          []string
+	reproducible    bool
+	cacheDir        string
+	offline         bool
+	matrixConfig    bool
 )
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// an interrupt cancels the context, so that renderers and linters stop
+	// promptly instead of completing the run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	switch {
+	case errors.Is(err, errNewIssues):
+		// already reported by the command
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
@@ -35,7 +109,9 @@ var rootCmd = &cobra.Command{
 	Short: "A pluggable linter for Kubernetes manifests",
 	Long: `k8s-manifests-lint is a pluggable linter for Kubernetes manifests inspired by golangci-lint.
 It provides a unified interface for running multiple linters against Kubernetes resources.`,
-	SilenceUsage: true,
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	PersistentPreRunE: changeWorkingDir,
 }
 
 var runCmd = &cobra.Command{
@@ -48,8 +124,29 @@ var lintersCmd = &cobra.Command{
 	Use:   "linters",
 	Short: "List all available linters",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		var since *semver.Version
+		if newSince != "" {
+			v, err := semver.NewVersion(newSince)
+			if err != nil {
+				return fmt.Errorf("invalid --new-since version %q: %w", newSince, err)
+			}
+			since = v
+		}
+
 		for _, l := range linter.All() {
-			fmt.Printf("%-30s %s\n", l.Name(), l.Description())
+			if since != nil {
+				v, err := semver.NewVersion(linter.SinceOf(l))
+				if err != nil || !v.GreaterThan(since) {
+					continue
+				}
+			}
+
+			description := l.Description()
+			if linter.StabilityOf(l) == linter.StabilityExperimental {
+				description += " [experimental]"
+			}
+
+			fmt.Printf("%-30s %-8s %s\n", l.Name(), linter.SinceOf(l), description)
 		}
 		return nil
 	},
@@ -64,7 +161,7 @@ var configValidateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validate configuration file",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.Load(cfgFile)
+		cfg, err := config.Load(cfgFile, cfgKey)
 		if err != nil {
 			return err
 		}
@@ -73,6 +170,43 @@ var configValidateCmd = &cobra.Command{
 			return fmt.Errorf("configuration validation failed: %w", err)
 		}
 
+		if err := renderer.Validate(cfg.Sources); err != nil {
+			return fmt.Errorf("configuration validation failed: %w", err)
+		}
+
+		if _, err := exclude.New(cfg.Exclude); err != nil {
+			return fmt.Errorf("configuration validation failed: %w", err)
+		}
+
+		for _, source := range cfg.Sources {
+			if _, err := exclude.New(source.Exclude); err != nil {
+				return fmt.Errorf("configuration validation failed: source %q: %w", source.Name, err)
+			}
+		}
+
+		if err := severity.Register(cfg.Severity); err != nil {
+			return fmt.Errorf("configuration validation failed: %w", err)
+		}
+
+		if _, err := severity.New(cfg.Severity); err != nil {
+			return fmt.Errorf("configuration validation failed: %w", err)
+		}
+
+		for _, profile := range cfg.Profiles {
+			profileCfg, err := cfg.Profile(profile.Name)
+			if err != nil {
+				return fmt.Errorf("configuration validation failed: %w", err)
+			}
+
+			if err := renderer.Validate(profileCfg.Sources); err != nil {
+				return fmt.Errorf("configuration validation failed: profile %q: %w", profile.Name, err)
+			}
+
+			if _, err := exclude.New(profileCfg.Exclude); err != nil {
+				return fmt.Errorf("configuration validation failed: profile %q: %w", profile.Name, err)
+			}
+		}
+
 		fmt.Println("Configuration is valid")
 		return nil
 	},
@@ -93,17 +227,50 @@ var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("k8s-manifests-lint version 0.1.0")
+		fmt.Printf("k8s-manifests-lint version %s\n", version.Version)
 	},
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default: .k8s-manifests-lint.yaml)")
-	rootCmd.PersistentFlags().StringSliceVar(&enableLinters, "enable-linter", nil, "enable specific linter(s)")
-	rootCmd.PersistentFlags().StringSliceVar(&disableLinters, "disable-linter", nil, "disable specific linter(s)")
-	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "text", "output format (text|json|yaml|github-actions|sarif)")
+	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file in YAML, TOML or JSON (default: .k8s-manifests-lint.{yaml,yml,toml,json} found walking up to the git root)")
+	rootCmd.PersistentFlags().StringVar(&workingDir, "working-dir", "", "directory to run from, source paths and path arguments are resolved against it")
+	rootCmd.PersistentFlags().StringVar(&pathPrefix, "path-prefix", "", "prefix added to the file paths of reported issues (default: output.path-prefix)")
+	rootCmd.PersistentFlags().StringVar(&cfgKey, "config-key", "", "read the configuration from a dotted key of the config file (e.g. tool.k8s-manifests-lint)")
+	rootCmd.PersistentFlags().StringSliceVar(&enableLinters, "enable-linter", nil, "enable specific linter(s), glob patterns and /regexp/ are supported")
+	rootCmd.PersistentFlags().StringSliceVar(&disableLinters, "disable-linter", nil, "disable specific linter(s), glob patterns and /regexp/ are supported")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "text", "output format (text|json|yaml|github-actions|azure-devops|sarif|code-climate|junit|markdown|html)")
+	rootCmd.PersistentFlags().StringVar(&colorFlag, "color", "", "when to color the output (always|auto|never), auto honors NO_COLOR and CLICOLOR_FORCE (default: output.color or auto)")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output")
+	_ = rootCmd.PersistentFlags().MarkDeprecated("no-color", "use --color=never instead")
 	rootCmd.PersistentFlags().BoolVar(&failOnWarning, "fail-on-warning", false, "exit with error on warnings")
+	rootCmd.PersistentFlags().StringVar(&failOn, "fail-on", "", "lowest severity failing the run: a built-in or custom severity level or none (default: run.fail-on or error)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "log format (text|json), json emits NDJSON progress events on stderr")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 0, "number of objects linted in parallel (default: number of CPUs)")
+	rootCmd.PersistentFlags().BoolVar(&showSuppressed, "show-suppressed", false, "report on stderr how many objects each linter has been disabled for by annotation")
+	rootCmd.PersistentFlags().BoolVar(&showLinterStats, "show-stats", false, "report on stderr the time spent in each linter and the issues it reported")
+	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpu-profile", "", "write a pprof CPU profile of rendering and linting to the file")
+	rootCmd.PersistentFlags().StringVar(&memProfile, "mem-profile", "", "write a pprof heap profile, taken after linting, to the file")
+	rootCmd.PersistentFlags().DurationVar(&linterTimeout, "linter-timeout", 0, "maximum time a linter can spend on a single object (0 means no limit)")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "directory Helm charts and remote kustomize bases are cached in (default: run.cache-dir or the user cache directory)")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "render charts and remote bases from the cache only, without network access")
+
+	runCmd.Flags().BoolVar(&fixDryRun, "fix-dry-run", false, "write the available fixes as JSON patch files instead of editing the sources")
+	runCmd.Flags().StringVar(&patchDir, "patch-dir", "patches", "directory the --fix-dry-run patch files are written to")
+	runCmd.Flags().StringVar(&outPath, "out", "", "write the report to the given file instead of stdout (default: output.file)")
+	runCmd.Flags().StringVar(&metricsFile, "metrics-file", "", "write Prometheus metrics of the run to the file, for the node exporter textfile collector")
+	runCmd.Flags().StringVar(&metricsGateway, "metrics-pushgateway", "", "push Prometheus metrics of the run to the Pushgateway at the given URL")
+	runCmd.Flags().StringVar(&metricsJob, "metrics-job", "k8s-manifests-lint", "job name the metrics are pushed under")
+	runCmd.Flags().BoolVar(&showStats, "stats", false, "add a summary of the run to the report: objects scanned and issue counts by severity, linter and kind (default: output.stats)")
+	runCmd.Flags().IntVar(&maxIssues, "max-issues", 0, "maximum number of issues reported, 0 means no limit (default: output.max-issues)")
+	runCmd.Flags().IntVar(&maxSameIssues, "max-same-issues", 0, "maximum number of issues with the same linter and message reported, 0 means no limit (default: output.max-same-issues)")
+	runCmd.Flags().StringVar(&groupBy, "group-by", "", "group text output issues by linter, resource, severity, file or namespace, namespace also adds a per namespace summary to the json, yaml and html reports (default: output.group-by)")
+	runCmd.Flags().BoolVar(&reproducible, "reproducibility-report", false, "record the tool version, configuration and rule pack hashes, source revisions and CI environment in the json, yaml and sarif reports")
+	runCmd.Flags().StringSliceVar(&sortBy, "sort-by", nil, "sort text output issues by the given keys: linter, resource, severity, file, namespace (default: output.sort-by or resource,linter)")
+	runCmd.Flags().BoolVar(&noStepSummary, "no-step-summary", false, "do not append a Markdown summary to $GITHUB_STEP_SUMMARY in GitHub Actions")
+	runCmd.Flags().BoolVar(&matrixConfig, "matrix-config", false, "run every profile of the configuration and report their issues together, each profile fails the run according to its fail-on policy")
+	runCmd.Flags().StringVar(&baselineFile, "baseline", "", "only report issues not recorded in the given baseline file (see baseline create)")
+
+	lintersCmd.Flags().StringVar(&newSince, "new-since", "", "only list linters introduced after the given version (e.g. v0.1.0)")
 
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(lintersCmd)
@@ -114,50 +281,343 @@ func init() {
 	configCmd.AddCommand(configInitCmd)
 }
 
+// lintResult holds the outcome of rendering and linting the configured sources
+type lintResult struct {
+	config  *config.Config
+	objects []unstructured.Unstructured
+	issues  []linter.Issue
+	// duration is the time spent rendering and linting
+	duration time.Duration
+	// sources is the number of sources, or command line paths, rendered
+	sources int
+	// paths are the command line paths linted when there are no sources
+	paths []string
+	// linters are the linters run
+	linters []linter.Linter
+	// profiles are the profiles run by --matrix-config
+	profiles []config.Profile
+	// reproducibility describes what has been linted, with which rules, when
+	// --reproducibility-report is set
+	reproducibility *provenance.Report
+}
+
 func runLint(cmd *cobra.Command, args []string) error {
-	cfg, err := config.Load(cfgFile)
+	result, err := lint(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	cfg := result.config
+	issues := result.issues
+
+	if baselineFile != "" {
+		baseline, err := report.LoadBaseline(baselineFile)
+		if err != nil {
+			return err
+		}
+
+		var known int
+		issues, known = baseline.Filter(issues)
+
+		if known > 0 {
+			fmt.Fprintf(os.Stderr, "%d known issue(s) from baseline %s not reported\n", known, baselineFile)
+		}
+	}
+
+	if fixDryRun {
+		patches, err := fix.Collect(result.objects, issues)
+		if err != nil {
+			return err
+		}
+
+		files, err := fix.Write(patchDir, patches)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "%d patch file(s) written to %s\n", len(files), patchDir)
+	}
+
+	// the fix engine needs the real paths, so only the reported issues, a
+	// copy, are prefixed
+	prefix := cfg.Output.PathPrefix
+	if pathPrefix != "" {
+		prefix = pathPrefix
+	}
+	issues = prefixPaths(issues, prefix)
+
+	if err := writeMetrics(cmd, result, issues); err != nil {
+		return err
+	}
+
+	format := cfg.Output.Format
+	if outputFormat != "text" {
+		format = outputFormat
+	}
+
+	colorMode, err := resolveColorMode(cfg)
+	if err != nil {
+		return err
+	}
+
+	outFile := cfg.Output.File
+	if outPath != "" {
+		outFile = outPath
+	}
+
+	opts := output.Options{
+		UseColor:   output.UseColor(colorMode, os.Stdout),
+		ShowDocURL: output.IsTerminal(os.Stdout),
+	}
+
+	// files only get colors when explicitly requested
+	if outFile != "" {
+		opts = output.Options{UseColor: colorMode == output.ColorAlways}
+	}
+
+	opts.Reproducibility = result.reproducibility
+
+	opts.GroupBy = cfg.Output.GroupBy
+	if groupBy != "" {
+		opts.GroupBy = groupBy
+	}
+
+	opts.SortBy = cfg.Output.SortBy
+	if len(sortBy) > 0 {
+		opts.SortBy = sortBy
+	}
+
+	if opts.GroupBy != "" && !text.ValidKey(opts.GroupBy) {
+		return fmt.Errorf("invalid --group-by %q (supported: %s)", opts.GroupBy, strings.Join(text.Keys, ", "))
+	}
+	for _, key := range opts.SortBy {
+		if !text.ValidKey(key) {
+			return fmt.Errorf("invalid --sort-by %q (supported: %s)", key, strings.Join(text.Keys, ", "))
+		}
+	}
+
+	if showStats || cfg.Output.Stats {
+		opts.Stats = report.NewStats(issues)
+		opts.Stats.Sources = result.sources
+		opts.Stats.Objects = len(result.objects)
+		opts.Stats.Linters = len(result.linters)
+		opts.Stats.Duration = result.duration.Round(time.Millisecond).String()
+	}
+
+	limitIssues := cfg.Output.MaxIssues
+	if cmd.Flags().Changed("max-issues") {
+		limitIssues = maxIssues
+	}
+
+	limitSame := cfg.Output.MaxSameIssues
+	if cmd.Flags().Changed("max-same-issues") {
+		limitSame = maxSameIssues
+	}
+
+	// the limits only apply to the report, the exit code still reflects
+	// every issue
+	reported, dropped := report.Limit(issues, limitIssues, limitSame)
+
+	formatter, err := output.NewFormatter(format, opts)
 	if err != nil {
 		return err
 	}
 
+	if outFile == "" {
+		if err := formatter.Format(os.Stdout, reported); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+	} else {
+		err := output.WriteFile(outFile, func(w io.Writer) error {
+			if err := formatter.Format(w, reported); err != nil {
+				return fmt.Errorf("failed to format output: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "%d more issue(s) not reported, over the max-issues (%d) or max-same-issues (%d) limits\n", dropped, limitIssues, limitSame)
+	}
+
+	// formats which cannot embed the stats get them on stderr
+	if opts.Stats != nil && !output.EmbedsStats(format) {
+		fmt.Fprintln(os.Stderr)
+		if err := opts.Stats.Write(os.Stderr); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Output.StepSummary && !noStepSummary {
+		if err := writeStepSummary(reported, outFile); err != nil {
+			return err
+		}
+	}
+
+	code := exitCode(issues, cfg)
+	if len(result.profiles) > 0 {
+		code = profilesExitCode(issues, result.profiles, cfg)
+	}
+
+	if code != 0 {
+		os.Exit(code)
+	}
+
+	return nil
+}
+
+// lint loads the configuration, renders all the sources and runs the enabled
+// linters against the rendered objects
+func lint(cmd *cobra.Command, args []string) (*lintResult, error) {
+	start := time.Now()
+
+	stopProfile, err := startCPUProfile()
+	if err != nil {
+		return nil, err
+	}
+	defer stopProfile()
+
+	cfg, err := config.Load(cfgFile, cfgKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// run --cluster audits what is deployed instead of the sources
+	if lintCluster {
+		cfg.Sources = []config.Source{clusterSource()}
+	}
+
 	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if err := severity.Register(cfg.Severity); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if failOn != "" && failOn != config.FailOnNone && !cfg.IsSeverity(failOn) {
+		return nil, fmt.Errorf("invalid --fail-on: unknown severity %q", failOn)
+	}
+
+	var result *lintResult
+	if matrixConfig {
+		result, err = lintProfiles(cmd, cfg, args)
+	} else {
+		result, err = lintConfig(cmd, cfg, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeMemProfile(); err != nil {
+		return nil, err
+	}
+
+	if reproducible {
+		result.reproducibility, err = provenance.Collect(cmd.Context(), result.config, result.paths, result.linters)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.duration = time.Since(start)
+
+	return result, nil
+}
+
+// lintConfig renders the sources of the configuration, or the command line
+// paths when it has none, and runs the enabled linters against the rendered
+// objects
+func lintConfig(cmd *cobra.Command, cfg *config.Config, args []string) (*lintResult, error) {
+	start := time.Now()
+
+	emitter, err := events.New(os.Stderr, logFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := renderer.Validate(cfg.Sources); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
 	var allObjects []unstructured.Unstructured
+	var renderIssues []linter.Issue
+	var sources int
+	var paths []string
+
+	scopes := make(map[string]linter.Scope)
+
+	renderCtx := cmd.Context()
+	if emitter.Enabled() {
+		renderCtx = progress.WithFunc(renderCtx, func(e progress.Event) {
+			emitter.RenderProgress(e.Source, e.Path, e.File, e.Objects)
+		})
+	}
 
 	if len(cfg.Sources) > 0 {
-		for _, source := range cfg.Sources {
-			r, err := renderer.NewFromSource(source)
-			if err != nil {
-				return fmt.Errorf("failed to create renderer for source type %q: %w", source.Type, err)
-			}
+		sources = len(cfg.Sources)
 
-			path := source.Path
-			if path == "" {
-				path = "."
-			}
+		rendered, err := renderSources(renderCtx, cfg, emitter)
+		if err != nil {
+			return nil, err
+		}
 
-			objects, err := r.Render(cmd.Context(), path)
-			if err != nil {
-				return fmt.Errorf("failed to render manifests from source (type: %s, path: %s): %w", source.Type, path, err)
+		for i, source := range cfg.Sources {
+			allObjects = append(allObjects, rendered[i].objects...)
+			renderIssues = append(renderIssues, rendered[i].issues...)
+
+			if rendered[i].scope != nil {
+				scopes[source.Name] = *rendered[i].scope
 			}
-			allObjects = append(allObjects, objects...)
 		}
 	} else {
-		paths := args
+		paths = args
 		if len(paths) == 0 {
 			paths = []string{"."}
 		}
 
-		r := yaml.New(config.Source{})
+		sources = len(paths)
+
+		r := yaml.New(config.Source{MaxFileSize: cfg.Run.MaxFileSize, Discovery: cfg.Run.Discovery()})
 		for _, path := range paths {
-			objects, err := r.Render(cmd.Context(), path)
+			sourceType := config.SourceTypeYAML
+
+			// URLs are fetched, e.g. to review the published install
+			// manifests of an operator
+			var render renderer.Renderer = r
+			if url.IsURL(path) {
+				sourceType = config.SourceTypeURL
+				dir := cacheDir
+				if dir == "" {
+					dir = cfg.Run.CacheDir
+				}
+				render = url.New(config.Source{
+					Type:        config.SourceTypeURL,
+					MaxFileSize: cfg.Run.MaxFileSize,
+					CacheDir:    dir,
+					Offline:     cfg.Run.Offline || offline,
+					Credentials: credentials.Match(cfg.Credentials, credentials.Host(path)),
+				})
+			}
+
+			renderStart := time.Now()
+			emitter.RenderStart(sourceType.String(), path)
+
+			objects, err := render.Render(renderCtx, path)
+			emitter.RenderDone(sourceType.String(), path, len(objects), time.Since(renderStart), err)
 			if err != nil {
-				return fmt.Errorf("failed to render manifests from %q: %w", path, err)
+				return nil, fmt.Errorf("failed to render manifests from %q: %w", path, err)
 			}
+			attribute(objects, nil, linter.SourceRef{Type: sourceType.String()})
 			allObjects = append(allObjects, objects...)
 		}
+
+		yamlIssues := r.Issues()
+		attribute(nil, yamlIssues, linter.SourceRef{Type: config.SourceTypeYAML.String()})
+		renderIssues = append(renderIssues, yamlIssues...)
 	}
 
 	enabledLinters := cfg.Linters.Enable
@@ -170,60 +630,452 @@ func runLint(cmd *cobra.Command, args []string) error {
 		disabledLinters = disableLinters
 	}
 
+	timeout := cfg.Run.LinterTimeout
+	if linterTimeout > 0 {
+		timeout = linterTimeout
+	}
+
+	workers := cfg.Run.Concurrency
+	if concurrency > 0 {
+		workers = concurrency
+	}
+
+	filter, err := exclude.New(cfg.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	overrides, err := severity.New(cfg.Severity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	runner, err := linter.NewRunner(&linter.RunnerConfig{
 		EnabledLinters:  enabledLinters,
 		DisabledLinters: disabledLinters,
 		Settings:        cfg.Linters.Settings,
 		CustomLinters:   cfg.Linters.Custom,
+		Stability:       linter.Stability(cfg.Linters.Stability),
+		Concurrency:     workers,
+		Timeout:         timeout,
+		Timeouts:        cfg.Run.LinterTimeouts,
+		Exclude:         filter.Excluded,
+		Scopes:          scopes,
+		OnObjectLinted: func(obj unstructured.Unstructured, issues []linter.Issue) {
+			emitter.ObjectLinted(linter.ResourceRef{
+				APIVersion: obj.GetAPIVersion(),
+				Kind:       obj.GetKind(),
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+			}, issues)
+		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create runner: %w", err)
+		return nil, fmt.Errorf("failed to create runner: %w", err)
 	}
 
 	issues, err := runner.Run(cmd.Context(), allObjects)
 	if err != nil {
-		return fmt.Errorf("linting failed: %w", err)
+		return nil, fmt.Errorf("linting failed: %w", err)
 	}
 
-	format := cfg.Output.Format
-	if outputFormat != "text" {
-		format = outputFormat
+	issues = overrides.Apply(append(filter.Apply(renderIssues), issues...))
+
+	suppressed := runner.Suppressed()
+	emitter.RunSummary(len(allObjects), issues, suppressed, time.Since(start))
+
+	if showSuppressed {
+		printSuppressed(suppressed)
+	}
+
+	if showLinterStats {
+		printLinterStats(runner.Stats())
+	}
+
+	return &lintResult{
+		config:  cfg,
+		objects: linter.StripAllInternalAnnotations(allObjects),
+		issues:  issues,
+		sources: sources,
+		paths:   paths,
+		linters: runner.Linters(),
+	}, nil
+}
+
+// lintProfiles lints every profile of the configuration and combines their
+// results, issues are tagged with the profile reporting them. Profiles
+// sharing the same sources contribute their objects once.
+func lintProfiles(cmd *cobra.Command, cfg *config.Config, args []string) (*lintResult, error) {
+	if len(cfg.Profiles) == 0 {
+		return nil, fmt.Errorf("--matrix-config requires profiles in the configuration")
+	}
+
+	combined := &lintResult{
+		profiles: cfg.Profiles,
+	}
+
+	var rendered [][]config.Source
+	linters := make(map[string]bool)
+
+	for _, profile := range cfg.Profiles {
+		profileCfg, err := cfg.Profile(profile.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		// the custom linters registered by the profile only run as part of
+		// it, the built-in ones are reset to their defaults by every runner
+		registered := make(map[string]bool)
+		for _, name := range linter.Names() {
+			registered[name] = true
+		}
+
+		result, err := lintConfig(cmd, profileCfg, args)
+
+		for _, c := range profileCfg.Linters.Custom {
+			if !registered[c.Name] {
+				linter.Unregister(c.Name)
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", profile.Name, err)
+		}
+
+		for i := range result.issues {
+			result.issues[i].Profile = profile.Name
+		}
+		combined.issues = append(combined.issues, result.issues...)
+
+		if !slices.ContainsFunc(rendered, func(sources []config.Source) bool {
+			return reflect.DeepEqual(sources, profileCfg.Sources)
+		}) {
+			rendered = append(rendered, profileCfg.Sources)
+
+			combined.objects = append(combined.objects, result.objects...)
+			combined.sources += result.sources
+			combined.paths = result.paths
+		}
+
+		for _, l := range result.linters {
+			if !linters[l.Name()] {
+				linters[l.Name()] = true
+				combined.linters = append(combined.linters, l)
+			}
+		}
+	}
+
+	// the reproducibility report records the sources of every profile
+	effective := *cfg
+	effective.Sources = nil
+	for _, sources := range rendered {
+		effective.Sources = append(effective.Sources, sources...)
+	}
+	combined.config = &effective
+
+	return combined, nil
+}
+
+// printSuppressed writes the number of objects each linter has been
+// disabled for by annotation to stderr
+func printSuppressed(suppressed map[string]int) {
+	names := make([]string, 0, len(suppressed))
+	for name := range suppressed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "%s: suppressed on %d object(s)\n", name, suppressed[name])
+	}
+}
+
+// printLinterStats writes the time spent in each linter, slowest first, to
+// stderr
+func printLinterStats(stats []linter.LinterStats) {
+	fmt.Fprintf(os.Stderr, "%-30s %12s %8s %12s %8s\n", "LINTER", "TIME", "OBJECTS", "PER OBJECT", "ISSUES")
+
+	for _, s := range stats {
+		var perObject time.Duration
+		if s.Calls > 0 {
+			perObject = s.Duration / time.Duration(s.Calls)
+		}
+
+		fmt.Fprintf(os.Stderr, "%-30s %12s %8d %12s %8d\n",
+			s.Name, s.Duration.Round(time.Microsecond), s.Calls, perObject.Round(time.Microsecond), s.Issues)
+	}
+}
+
+// startCPUProfile starts the --cpu-profile CPU profile, the returned
+// function stops it
+func startCPUProfile() (func(), error) {
+	if cpuProfile == "" {
+		return func() {}, nil
 	}
 
-	useColor := !noColor && cfg.Output.Color != "never"
-	formatter, err := output.NewFormatter(format, useColor)
+	f, err := os.Create(cpuProfile)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to create CPU profile: %w", err)
 	}
 
-	if err := formatter.Format(os.Stdout, issues); err != nil {
-		return fmt.Errorf("failed to format output: %w", err)
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
 	}
 
-	fatalCount := 0
-	errorCount := 0
-	warningCount := 0
-	for _, issue := range issues {
-		if issue.Severity == linter.SeverityFatal {
-			fatalCount++
-		} else if issue.Severity == linter.SeverityError {
-			errorCount++
-		} else if issue.Severity == linter.SeverityWarning {
-			warningCount++
+	return func() {
+		pprof.StopCPUProfile()
+		_ = f.Close()
+	}, nil
+}
+
+// writeMemProfile writes the --mem-profile heap profile
+func writeMemProfile() error {
+	if memProfile == "" {
+		return nil
+	}
+
+	f, err := os.Create(memProfile)
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile: %w", err)
+	}
+	defer f.Close()
+
+	runtime.GC()
+
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+
+	return nil
+}
+
+// changeWorkingDir switches to --working-dir, the files given on the command
+// line, other than the paths to lint, keep being resolved against the
+// directory the tool was started from
+func changeWorkingDir(cmd *cobra.Command, args []string) error {
+	if workingDir == "" {
+		return nil
+	}
+
+	for _, p := range []*string{&cfgFile, &outPath, &baselineFile, &metricsFile, &patchDir, &cpuProfile, &memProfile} {
+		if *p == "" || filepath.IsAbs(*p) {
+			continue
 		}
+
+		abs, err := filepath.Abs(*p)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", *p, err)
+		}
+		*p = abs
 	}
 
-	if fatalCount > 0 {
-		os.Exit(2)
+	if err := os.Chdir(workingDir); err != nil {
+		return fmt.Errorf("failed to change working directory: %w", err)
 	}
 
-	if errorCount > 0 {
-		os.Exit(1)
+	return nil
+}
+
+// attribute annotates the objects with the source they have been rendered
+// from, the runner attributes their issues to it, and attributes the issues
+// reported by the renderer of the source, about its files, to it
+func attribute(objects []unstructured.Unstructured, issues []linter.Issue, source linter.SourceRef) {
+	for i := range objects {
+		annotations := objects[i].GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[linter.SourceTypeAnnotation] = source.Type
+		if source.Name != "" {
+			annotations[linter.SourceNameAnnotation] = source.Name
+		}
+		objects[i].SetAnnotations(annotations)
+	}
+
+	for i := range issues {
+		issues[i].Source = &source
+		if issues[i].File == "" && issues[i].Resource.Kind == "File" {
+			issues[i].File = issues[i].Resource.Name
+		}
+	}
+}
+
+// prefixPaths prepends the prefix to the file path of the issues, so that
+// paths relative to the working directory match the layout expected by the
+// consumer of the report (e.g. the repository root for SARIF). The issues
+// are left untouched, a prefixed copy is returned.
+func prefixPaths(issues []linter.Issue, prefix string) []linter.Issue {
+	if prefix == "" {
+		return issues
+	}
+
+	issues = slices.Clone(issues)
+	for i := range issues {
+		if file := issues[i].File; file != "" && !filepath.IsAbs(file) {
+			issues[i].File = path.Join(filepath.ToSlash(prefix), filepath.ToSlash(file))
+		}
+
+		p := issues[i].Position
+		if p == nil || p.File == "" || filepath.IsAbs(p.File) {
+			continue
+		}
+
+		prefixed := *p
+		prefixed.File = path.Join(filepath.ToSlash(prefix), filepath.ToSlash(p.File))
+		issues[i].Position = &prefixed
+	}
+
+	return issues
+}
+
+// writeMetrics exports the metrics of the run when requested
+func writeMetrics(cmd *cobra.Command, result *lintResult, issues []linter.Issue) error {
+	if metricsFile == "" && metricsGateway == "" {
+		return nil
+	}
+
+	run := &metrics.Run{
+		Objects:  len(result.objects),
+		Issues:   issues,
+		Duration: result.duration,
+		Time:     time.Now(),
+	}
+
+	if metricsFile != "" {
+		if err := run.WriteTextfile(metricsFile); err != nil {
+			return err
+		}
+	}
+
+	if metricsGateway != "" {
+		if err := run.Push(cmd.Context(), metricsGateway, metricsJob); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeStepSummary appends a Markdown summary of the issues to the job
+// summary when running in GitHub Actions, unless the report itself is
+// written there
+func writeStepSummary(issues []linter.Issue, outFile string) error {
+	summary := os.Getenv("GITHUB_STEP_SUMMARY")
+	if os.Getenv("GITHUB_ACTIONS") != "true" || summary == "" {
+		return nil
 	}
 
-	if failOnWarning && warningCount > 0 {
-		os.Exit(4)
+	if outFile != "" && filepath.Clean(outFile) == filepath.Clean(summary) {
+		return nil
+	}
+
+	f, err := os.OpenFile(summary, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open job summary: %w", err)
+	}
+
+	// job summaries are limited to 1MiB, only the resources with the most
+	// severe issues are detailed
+	formatter := &markdown.Formatter{MaxResources: stepSummaryResources}
+	if err := formatter.Format(f, issues); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write job summary: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write job summary: %w", err)
 	}
 
 	return nil
 }
+
+// resolveColorMode returns the color mode from the command line, falling
+// back to the configuration
+func resolveColorMode(cfg *config.Config) (output.ColorMode, error) {
+	switch {
+	case colorFlag != "":
+		return output.ParseColorMode(colorFlag)
+	case noColor:
+		return output.ColorNever, nil
+	default:
+		return output.ParseColorMode(cfg.Output.Color)
+	}
+}
+
+// exitCode returns the process exit code matching the most severe issue
+// failing the run under --fail-on, --fail-on-warning or run.fail-on
+func exitCode(issues []linter.Issue, cfg *config.Config) int {
+	policy := config.FailOnError
+	switch {
+	case failOn != "":
+		policy = failOn
+	case failOnWarning:
+		policy = config.FailOnWarning
+	case cfg.Run.FailOn != "":
+		policy = cfg.Run.FailOn
+	}
+
+	return failOnExitCode(issues, policy)
+}
+
+// failOnExitCode returns the process exit code matching the most severe
+// issue failing the run under the fail-on policy: issues at least as severe
+// as the policy fail the run, with the exit code of the built-in severity
+// they are reported as
+func failOnExitCode(issues []linter.Issue, failOn string) int {
+	if failOn == config.FailOnNone {
+		return 0
+	}
+
+	threshold := linter.Severity(failOn).Rank()
+
+	code := 0
+	for _, issue := range issues {
+		if issue.Severity.Rank() > threshold {
+			continue
+		}
+
+		switch issue.Severity.Builtin() {
+		case linter.SeverityFatal:
+			return 2
+		case linter.SeverityError:
+			code = 1
+		default:
+			if code == 0 {
+				code = 4
+			}
+		}
+	}
+
+	return code
+}
+
+// profilesExitCode returns the process exit code of a --matrix-config run:
+// the most severe of the exit codes of the profiles, each under its own
+// fail-on policy, profiles without one follow --fail-on, --fail-on-warning
+// and run.fail-on
+func profilesExitCode(issues []linter.Issue, profiles []config.Profile, cfg *config.Config) int {
+	byProfile := make(map[string][]linter.Issue, len(profiles))
+	for _, issue := range issues {
+		byProfile[issue.Profile] = append(byProfile[issue.Profile], issue)
+	}
+
+	codes := make(map[int]bool)
+	for _, profile := range profiles {
+		if profile.FailOn == "" {
+			codes[exitCode(byProfile[profile.Name], cfg)] = true
+		} else {
+			codes[failOnExitCode(byProfile[profile.Name], profile.FailOn)] = true
+		}
+	}
+
+	// from the most to the least severe
+	for _, code := range []int{2, 1, 4} {
+		if codes[code] {
+			return code
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// TestLintProfilesIsolation lints the same manifests with a strict profile,
+// configuring a built-in linter and adding a custom one, then with a relaxed
+// profile: none of the strict settings must leak into the relaxed run
+func TestLintProfilesIsolation(t *testing.T) {
+	dir := t.TempDir()
+
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+`
+	if err := os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Profiles: []config.Profile{
+			{
+				Name: "strict",
+				Linters: config.LintersConfig{
+					Enable: []string{"required-labels", "strict-replicas"},
+					Settings: map[string]map[string]interface{}{
+						"required-labels": {"labels": []interface{}{"team"}},
+					},
+					Custom: []config.CustomLinter{{
+						Name: "strict-replicas",
+						Type: "jq",
+						Settings: map[string]interface{}{
+							"rules": []interface{}{
+								map[string]interface{}{
+									"expression": `$object.spec.replicas < 2`,
+									"message":    "Deployments must run at least 2 replicas",
+								},
+							},
+						},
+					}},
+				},
+			},
+			{
+				Name: "relaxed",
+				Linters: config.LintersConfig{
+					// a leaked strict-replicas linter would match the pattern
+					Enable: []string{"/^(required-labels|strict-replicas)$/"},
+				},
+			},
+		},
+	}
+
+	runCmd.SetContext(t.Context())
+
+	result, err := lintProfiles(runCmd, cfg, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reported := make(map[string]map[string]int)
+	for _, issue := range result.issues {
+		if reported[issue.Profile] == nil {
+			reported[issue.Profile] = make(map[string]int)
+		}
+		reported[issue.Profile][issue.Linter]++
+	}
+
+	for _, name := range []string{"required-labels", "strict-replicas"} {
+		if reported["strict"][name] != 1 {
+			t.Errorf("strict: expected 1 %s issue, got %d", name, reported["strict"][name])
+		}
+		if reported["relaxed"][name] != 0 {
+			t.Errorf("relaxed: expected no %s issue, got %d", name, reported["relaxed"][name])
+		}
+	}
+
+	if _, err := linter.Get("strict-replicas"); err == nil {
+		t.Error("custom linter strict-replicas is still registered")
+	}
+}
+
+// TestPrefixPaths prefixes a copy of the issues, the fix engine keeps
+// locating the sources by their real paths
+func TestPrefixPaths(t *testing.T) {
+	issues := []linter.Issue{
+		{File: "deploy/app.yaml", Position: &linter.Position{File: "deploy/app.yaml", Line: 3}},
+		{File: "/abs/app.yaml", Position: &linter.Position{File: "/abs/app.yaml"}},
+		{Message: "no position"},
+	}
+
+	prefixed := prefixPaths(issues, "repo")
+
+	if got := prefixed[0].Position.File; got != "repo/deploy/app.yaml" {
+		t.Errorf("expected the position to be prefixed, got %s", got)
+	}
+	if got := prefixed[0].File; got != "repo/deploy/app.yaml" {
+		t.Errorf("expected the file to be prefixed, got %s", got)
+	}
+	if got := prefixed[1].Position.File; got != "/abs/app.yaml" {
+		t.Errorf("expected absolute paths to be kept, got %s", got)
+	}
+
+	if issues[0].File != "deploy/app.yaml" || issues[0].Position.File != "deploy/app.yaml" {
+		t.Errorf("expected the issues to be left untouched, got %s and %s", issues[0].File, issues[0].Position.File)
+	}
+}
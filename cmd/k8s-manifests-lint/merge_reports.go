@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/json"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+)
+
+var mergeReportsCmd = &cobra.Command{
+	Use:   "merge-reports report...",
+	Short: "Merge JSON or SARIF reports into a single deduplicated report",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  mergeReports,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeReportsCmd)
+}
+
+func mergeReports(cmd *cobra.Command, args []string) error {
+	reports := make([][]linter.Issue, 0, len(args))
+	for _, path := range args {
+		issues, err := report.Load(path)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, issues)
+	}
+
+	merged := report.Merge(reports...)
+
+	// merged reports are meant to be consumed by tools, so default to json
+	// unless a format has been explicitly requested
+	format := "json"
+	if cmd.Flags().Changed("format") {
+		format = outputFormat
+	}
+
+	if format == "json" {
+		return json.Encode(os.Stdout, merged)
+	}
+
+	formatter, err := output.NewFormatter(format, output.Options{})
+	if err != nil {
+		return err
+	}
+
+	if err := formatter.Format(os.Stdout, merged.Issues); err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	return nil
+}
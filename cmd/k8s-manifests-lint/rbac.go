@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/rbac"
+)
+
+var rbacCmd = &cobra.Command{
+	Use:   "rbac",
+	Short: "Review the RBAC resources of the manifests",
+}
+
+var rbacReportCmd = &cobra.Command{
+	Use:   "report [path...]",
+	Short: "Aggregate the Roles, ClusterRoles and bindings into a permissions matrix",
+	Long: `Render and lint the configured sources, then aggregate the permissions
+granted by the RoleBindings and ClusterRoleBindings, through their Roles and
+ClusterRoles, into a matrix of subject, namespace, resource and verbs.
+
+Entries whose binding or role has issues, e.g. reported by
+cluster-role-binding-security or forbidden-resources, are flagged as
+violating the RBAC policies, and bindings to roles which are not part of the
+manifests, such as the built-in ClusterRoles, are listed apart.
+
+The matrix is written as a table, or with --format json, yaml or html. The
+command exits with code 1 when the violations would make the run fail.`,
+	RunE: rbacReport,
+}
+
+func init() {
+	rootCmd.AddCommand(rbacCmd)
+	rbacCmd.AddCommand(rbacReportCmd)
+}
+
+func rbacReport(cmd *cobra.Command, args []string) error {
+	result, err := lint(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	matrix := rbac.Build(result.objects, result.issues)
+
+	switch outputFormat {
+	case "text":
+		err = rbac.WriteText(os.Stdout, matrix)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(matrix)
+	case "yaml":
+		var data []byte
+		if data, err = yaml.Marshal(matrix); err == nil {
+			_, err = os.Stdout.Write(data)
+		}
+	case "html":
+		err = rbac.WriteHTML(os.Stdout, matrix)
+	default:
+		return fmt.Errorf("unsupported format for rbac report: %s", outputFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+
+	var violations []linter.Issue
+	for _, e := range matrix.Entries {
+		violations = append(violations, e.Violations...)
+	}
+
+	if code := exitCode(violations, result.config); code != 0 {
+		os.Exit(code)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/credentials"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/events"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/exclude"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/cache"
+)
+
+var (
+	renderConcurrency int
+	noCache           bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&renderConcurrency, "render-concurrency", 0, "number of sources rendered in parallel (default: run.render-concurrency or the number of CPUs)")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "render every source instead of reusing the objects cached by a previous run")
+}
+
+// renderedSource holds the outcome of rendering a configured source
+type renderedSource struct {
+	objects []unstructured.Unstructured
+	// issues are the issues reported by the renderer, such as skipped files
+	issues []linter.Issue
+	// scope holds the linters and exclusions of scoped sources
+	scope *linter.Scope
+}
+
+// renderSources renders the configured sources in parallel, bounded by the
+// render concurrency. The results are in the order of the sources, so the
+// objects do not depend on scheduling, and every source is rendered even when
+// some fail: their errors are reported together.
+func renderSources(ctx context.Context, cfg *config.Config, emitter *events.Emitter) ([]renderedSource, error) {
+	workers := cfg.Run.RenderConcurrency
+	if renderConcurrency > 0 {
+		workers = renderConcurrency
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	results := make([]renderedSource, len(cfg.Sources))
+	errs := make([]error, len(cfg.Sources))
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, source := range cfg.Sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i], errs[i] = renderSource(ctx, cfg, source, emitter)
+		}()
+	}
+
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		return results, nil
+	case 1:
+		return nil, failed[0]
+	default:
+		return nil, fmt.Errorf("failed to render %d sources:\n%w", len(failed), errors.Join(failed...))
+	}
+}
+
+// renderSource renders a source, with the run defaults applied, and
+// attributes its objects and issues to it
+func renderSource(ctx context.Context, cfg *config.Config, source config.Source, emitter *events.Emitter) (renderedSource, error) {
+	if source.MaxFileSize == "" {
+		source.MaxFileSize = cfg.Run.MaxFileSize
+	}
+
+	if cacheDir != "" {
+		source.CacheDir = cacheDir
+	} else if source.CacheDir == "" {
+		source.CacheDir = cfg.Run.CacheDir
+	}
+	source.Offline = source.Offline || cfg.Run.Offline || offline
+	source.Sandbox = cfg.Run.Sandbox
+	source.Cluster = clusterConfig(cfg)
+	source.Discovery = cfg.Run.Discovery()
+
+	if source.Credentials == nil {
+		ref := source.Chart
+		switch {
+		case source.Repo != "":
+			ref = source.Repo
+		case source.URL != "":
+			ref = source.URL
+		}
+		source.Credentials = credentials.Match(cfg.Credentials, credentials.Host(ref))
+	}
+
+	r, err := renderer.NewFromSource(source)
+	if err != nil {
+		return renderedSource{}, fmt.Errorf("failed to create renderer for source type %q: %w", source.Type, err)
+	}
+
+	path := source.Path
+	if path == "" {
+		path = "."
+	}
+
+	renderStart := time.Now()
+	emitter.RenderStart(source.Type.String(), path)
+
+	entry, err := renderCached(ctx, r, source, path, !noCache && !cfg.Run.NoCache)
+	emitter.RenderDone(source.Type.String(), path, len(entry.Objects), time.Since(renderStart), err)
+	if err != nil {
+		return renderedSource{}, fmt.Errorf("failed to render manifests from source (type: %s, path: %s): %w", source.Type, path, err)
+	}
+
+	sourceType := source.Type
+	if sourceType == "" {
+		sourceType = config.SourceTypeYAML
+	}
+
+	result := renderedSource{
+		objects: renderer.ExpandLists(entry.Objects),
+		issues:  entry.Issues,
+	}
+
+	// objects of named sources are attributed to them, so that the runner
+	// lints them with the linters and exclusions of the source
+	attribute(result.objects, result.issues, linter.SourceRef{Name: source.Name, Type: sourceType.String()})
+
+	if source.Scoped() {
+		sourceFilter, err := exclude.New(source.Exclude)
+		if err != nil {
+			return renderedSource{}, fmt.Errorf("invalid configuration: source %q: %w", source.Name, err)
+		}
+
+		result.scope = &linter.Scope{
+			EnabledLinters:  source.Linters.Enable,
+			DisabledLinters: source.Linters.Disable,
+			Exclude:         sourceFilter.Excluded,
+		}
+
+		result.issues = sourceFilter.Apply(result.issues)
+	}
+
+	return result, nil
+}
+
+// renderCached renders the source, unless the objects rendered from the
+// same inputs by a previous run are cached. Caching is best effort: a cache
+// which cannot be read or written never fails the run.
+func renderCached(ctx context.Context, r renderer.Renderer, source config.Source, path string, useCache bool) (cache.Entry, error) {
+	var c *cache.Cache
+	var key string
+
+	if cacheable, ok := r.(renderer.Cacheable); ok && useCache {
+		if inputs, ok := cacheable.Inputs(path); ok {
+			if k, err := cache.Key(source, inputs); err == nil {
+				key = k
+				c, _ = cache.New(source.CacheDir)
+			}
+		}
+	}
+
+	if c != nil {
+		if entry, ok := c.Load(key); ok {
+			return *entry, nil
+		}
+	}
+
+	objects, err := r.Render(ctx, path)
+	if err != nil {
+		return cache.Entry{}, err
+	}
+
+	entry := cache.Entry{Objects: objects}
+	if reporter, ok := r.(renderer.IssueReporter); ok {
+		entry.Issues = reporter.Issues()
+	}
+
+	if c != nil {
+		_ = c.Store(key, entry)
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+)
+
+// errNewIssues is returned by report diff when the new report has issues the
+// old one has not, main exits with code 1 without printing it
+var errNewIssues = errors.New("the new report has new issues")
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Work with previously generated reports",
+}
+
+var reportDiffCmd = &cobra.Command{
+	Use:   "diff old-report new-report",
+	Short: "Show added, resolved and unchanged issues between two reports",
+	Long: `Compare two JSON or SARIF reports using issue fingerprints.
+
+The command exits with code 1 when the new report contains issues that are
+not present in the old one.`,
+	Args: cobra.ExactArgs(2),
+	RunE: reportDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportDiffCmd)
+}
+
+func reportDiff(cmd *cobra.Command, args []string) error {
+	oldIssues, err := report.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	newIssues, err := report.Load(args[1])
+	if err != nil {
+		return err
+	}
+
+	diff := report.Compare(oldIssues, newIssues)
+
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+	case "text":
+		printDiffSection(os.Stdout, "+", "Added", diff.Added)
+		printDiffSection(os.Stdout, "-", "Resolved", diff.Resolved)
+		fmt.Fprintf(os.Stdout, "\n%d added, %d resolved, %d unchanged\n",
+			len(diff.Added), len(diff.Resolved), len(diff.Unchanged))
+	default:
+		return fmt.Errorf("unsupported format for report diff: %s", outputFormat)
+	}
+
+	if len(diff.Added) > 0 {
+		return errNewIssues
+	}
+
+	return nil
+}
+
+func printDiffSection(w io.Writer, marker string, title string, issues []linter.Issue) {
+	if len(issues) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "%s (%d):\n", title, len(issues))
+	for _, issue := range issues {
+		resource := fmt.Sprintf("%s/%s", issue.Resource.Kind, issue.Resource.Name)
+		if issue.Resource.Namespace != "" {
+			resource = fmt.Sprintf("%s/%s", issue.Resource.Namespace, resource)
+		}
+		fmt.Fprintf(w, "%s [%s] %s: %s (%s)\n", marker, issue.Severity, resource, issue.Message, issue.Linter)
+	}
+}
@@ -0,0 +1,210 @@
+// Package bench measures the performance of linters against synthetic
+// manifest corpora and compares the results with previous runs.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest/generate"
+)
+
+const (
+	CorpusSmall  = "small"
+	CorpusMedium = "medium"
+	CorpusHuge   = "huge"
+)
+
+// corpusSeed is fixed so every run benchmarks exactly the same objects
+const corpusSeed = 2249
+
+var corpusSizes = map[string]int{
+	CorpusSmall:  50,
+	CorpusMedium: 1000,
+	CorpusHuge:   10000,
+}
+
+// Corpus returns a deterministic set of well-formed objects of the given size
+func Corpus(name string) ([]unstructured.Unstructured, error) {
+	size, ok := corpusSizes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown corpus %q (supported: %s, %s, %s)", name, CorpusSmall, CorpusMedium, CorpusHuge)
+	}
+
+	g := generate.New(corpusSeed, generate.WithMissingFieldRate(0.05), generate.WithOddTypeRate(0))
+	return g.Objects(size), nil
+}
+
+type Result struct {
+	NsPerOp     int64 `json:"nsPerOp"`
+	NsPerObject int64 `json:"nsPerObject"`
+	AllocsPerOp int64 `json:"allocsPerOp"`
+	BytesPerOp  int64 `json:"bytesPerOp"`
+}
+
+type Report struct {
+	Corpus  string            `json:"corpus"`
+	Objects int               `json:"objects"`
+	Linters map[string]Result `json:"linters"`
+}
+
+// Regression describes a linter slower than the baseline beyond the threshold
+type Regression struct {
+	Linter   string
+	Old      int64
+	New      int64
+	Increase float64
+}
+
+// benchTime is the minimum time each linter is measured for, the number of
+// operations grows until it is reached
+const benchTime = time.Second
+
+// maxOps bounds the number of operations of a measurement
+const maxOps = 1_000_000_000
+
+// Run benchmarks every linter against the objects, one benchmark per linter
+// where each operation lints the whole set
+func Run(corpus string, objects []unstructured.Unstructured, linters []linter.Linter) (*Report, error) {
+	report := Report{
+		Corpus:  corpus,
+		Objects: len(objects),
+		Linters: make(map[string]Result, len(linters)),
+	}
+
+	ctx := linter.WithAllObjects(context.Background(), objects)
+
+	for _, l := range linters {
+		result, err := measure(func() error {
+			return lint(ctx, l, objects)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("linter %q failed: %w", l.Name(), err)
+		}
+
+		if len(objects) > 0 {
+			result.NsPerObject = result.NsPerOp / int64(len(objects))
+		}
+
+		report.Linters[l.Name()] = result
+	}
+
+	return &report, nil
+}
+
+// lint runs one operation of a benchmark: the linter against every object
+func lint(ctx context.Context, l linter.Linter, objects []unstructured.Unstructured) error {
+	if sl, ok := l.(linter.SetLinter); ok {
+		_, err := sl.LintSet(ctx, objects)
+		return err
+	}
+
+	for i, obj := range objects {
+		if _, err := l.Lint(linter.WithObjectIndex(ctx, i), obj); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// measure runs op an increasing number of times, the same way as go test
+// -bench does, until the runs take at least benchTime and returns the time
+// and the allocations per operation of the last round
+func measure(op func() error) (Result, error) {
+	var (
+		before runtime.MemStats
+		after  runtime.MemStats
+	)
+
+	n := int64(1)
+
+	for {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+
+		for range n {
+			if err := op(); err != nil {
+				return Result{}, err
+			}
+		}
+
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+
+		if elapsed >= benchTime || n >= maxOps {
+			return Result{
+				NsPerOp:     elapsed.Nanoseconds() / n,
+				AllocsPerOp: int64(after.Mallocs-before.Mallocs) / n,
+				BytesPerOp:  int64(after.TotalAlloc-before.TotalAlloc) / n,
+			}, nil
+		}
+
+		n = nextOps(n, elapsed)
+	}
+}
+
+// nextOps predicts the number of operations needed to reach benchTime,
+// overshooting by 20% and growing at most 100x per round
+func nextOps(n int64, elapsed time.Duration) int64 {
+	next := n * 100
+	if ns := elapsed.Nanoseconds(); ns > 0 {
+		next = min(next, n*benchTime.Nanoseconds()/ns*6/5)
+	}
+
+	return min(max(next, n+1), maxOps)
+}
+
+// Load reads a report previously written with Save
+func Load(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark report %q: %w", path, err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode benchmark report %q: %w", path, err)
+	}
+
+	return &report, nil
+}
+
+// Compare returns the linters whose time per object increased by more than
+// threshold (0.2 means 20%) compared to the old report. Linters missing from
+// either report are ignored.
+func Compare(oldReport *Report, newReport *Report, threshold float64) []Regression {
+	var regressions []Regression
+
+	for name, n := range newReport.Linters {
+		o, ok := oldReport.Linters[name]
+		if !ok || o.NsPerObject == 0 {
+			continue
+		}
+
+		increase := float64(n.NsPerObject-o.NsPerObject) / float64(o.NsPerObject)
+		if increase > threshold {
+			regressions = append(regressions, Regression{
+				Linter:   name,
+				Old:      o.NsPerObject,
+				New:      n.NsPerObject,
+				Increase: increase,
+			})
+		}
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		return regressions[i].Linter < regressions[j].Linter
+	})
+
+	return regressions
+}
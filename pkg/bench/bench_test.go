@@ -0,0 +1,70 @@
+package bench_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/bench"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters"
+)
+
+// BenchmarkLinters benchmarks every built-in linter against the small
+// corpus, each operation lints the whole corpus
+func BenchmarkLinters(b *testing.B) {
+	objects, err := bench.Corpus(bench.CorpusSmall)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	ctx := linter.WithAllObjects(context.Background(), objects)
+
+	for _, l := range linter.All() {
+		b.Run(l.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for b.Loop() {
+				if sl, ok := l.(linter.SetLinter); ok {
+					if _, err := sl.LintSet(ctx, objects); err != nil {
+						b.Fatal(err)
+					}
+					continue
+				}
+
+				for i, obj := range objects {
+					if _, err := l.Lint(linter.WithObjectIndex(ctx, i), obj); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	oldReport := &bench.Report{Linters: map[string]bench.Result{
+		"slower":     {NsPerObject: 100},
+		"steady":     {NsPerObject: 100},
+		"faster":     {NsPerObject: 100},
+		"unmeasured": {NsPerObject: 0},
+		"removed":    {NsPerObject: 100},
+	}}
+
+	newReport := &bench.Report{Linters: map[string]bench.Result{
+		"slower":     {NsPerObject: 150},
+		"steady":     {NsPerObject: 110},
+		"faster":     {NsPerObject: 50},
+		"unmeasured": {NsPerObject: 1000},
+		"added":      {NsPerObject: 1000},
+	}}
+
+	regressions := bench.Compare(oldReport, newReport, 0.2)
+	if len(regressions) != 1 || regressions[0].Linter != "slower" {
+		t.Fatalf("Compare() = %+v, want a single regression of slower", regressions)
+	}
+
+	if regressions[0].Old != 100 || regressions[0].New != 150 || regressions[0].Increase != 0.5 {
+		t.Errorf("Compare() = %+v, want 100 -> 150 (+50%%)", regressions[0])
+	}
+}
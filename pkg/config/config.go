@@ -2,10 +2,20 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
 )
 
 type SourceType string
@@ -16,60 +26,290 @@ const (
 	SourceTypeKustomize  SourceType = "kustomize"
 	SourceTypeGoTemplate SourceType = "gotemplate"
 	SourceTypeTemplate   SourceType = "template"
+	SourceTypeURL        SourceType = "url"
+	SourceTypeYtt        SourceType = "ytt"
+	SourceTypeCUE        SourceType = "cue"
+	SourceTypeExec       SourceType = "exec"
+	SourceTypeCluster    SourceType = "cluster"
 )
 
 func (s SourceType) String() string {
 	return string(s)
 }
 
-func (s SourceType) IsValid() bool {
-	switch s {
-	case SourceTypeYAML, SourceTypeHelm, SourceTypeKustomize, SourceTypeGoTemplate, SourceTypeTemplate, "":
-		return true
-	default:
-		return false
-	}
-}
-
 type Config struct {
 	Sources []Source      `mapstructure:"sources"`
 	Linters LintersConfig `mapstructure:"linters"`
 	Output  OutputConfig  `mapstructure:"output"`
 	Exclude ExcludeConfig `mapstructure:"exclude"`
 	Run     RunConfig     `mapstructure:"run"`
+	Cluster ClusterConfig `mapstructure:"cluster"`
+	// Credentials authenticate to the OCI registries and git remotes whose
+	// host matches, unless a source sets its own
+	Credentials []Credentials `mapstructure:"credentials"`
+	// Profiles are the named runs of run --matrix-config
+	Profiles []Profile `mapstructure:"profiles"`
+	// Severity defines custom severity levels and overrides the severity of
+	// issues
+	Severity SeverityConfig `mapstructure:"severity"`
+
+	// File is the configuration file the configuration has been loaded
+	// from, empty when none has been found
+	File string `mapstructure:"-"`
 }
 
 type Source struct {
+	// Name identifies the source, it is required to scope linters and
+	// exclude to the objects of the source
+	Name   string                 `mapstructure:"name"`
 	Type   SourceType             `mapstructure:"type"`
 	Path   string                 `mapstructure:"path"`
 	Chart  string                 `mapstructure:"chart"`
-	Values string                 `mapstructure:"values"`
+	Values []string               `mapstructure:"values"`
 	Data   map[string]interface{} `mapstructure:"data"`
+	// MaxFileSize is the size, as a quantity such as 10Mi, above which YAML
+	// files are skipped, it defaults to run.max-file-size
+	MaxFileSize string `mapstructure:"max-file-size"`
+	// Files restricts the files of yaml sources loaded from Path, such as
+	// only base/**.yaml or everything but **/crds/**
+	Files FilesConfig `mapstructure:"files"`
+	// Envsubst substitutes ${VAR} references with environment variables in
+	// the raw YAML files before decoding them
+	Envsubst bool `mapstructure:"envsubst"`
+	// EnvsubstVars restricts the substitution to the listed variables,
+	// references to other variables are left untouched
+	EnvsubstVars []string `mapstructure:"envsubst-vars"`
+	// EnvsubstStrict fails when a substituted variable is not set, instead of
+	// replacing it with an empty string
+	EnvsubstStrict bool `mapstructure:"envsubst-strict"`
+	// Set are Helm key=value overrides, applied on top of the values files
+	// and Data the way helm template --set does, for ytt sources they are
+	// passed as --data-value-yaml and for cue sources they are injected into
+	// the @tag() attributes
+	Set []string `mapstructure:"set"`
+	// Releases renders a Helm chart once per release, so that several
+	// variants of the same chart are linted, it defaults to a single release
+	// named after the releaseName and namespace keys of Data
+	Releases []Release `mapstructure:"releases"`
+	// Repo is the URL of the Helm repository Chart, a chart name, is
+	// downloaded from
+	Repo string `mapstructure:"repo"`
+	// Version is the version, or semver constraint, of a chart downloaded
+	// from Repo or pulled from an OCI registry without a tag, the latest
+	// version is used when empty
+	Version string `mapstructure:"version"`
+	// KubeVersion is the Kubernetes version Helm charts, including the ones
+	// inflated by kustomize, are rendered for, as seen by
+	// .Capabilities.KubeVersion and checked against the chart kubeVersion
+	// constraint, it defaults to the Helm default
+	KubeVersion string `mapstructure:"kube-version"`
+	// APIVersions are the API versions, as group/version or
+	// group/version/Kind, added to the ones of .Capabilities.APIVersions
+	APIVersions []string `mapstructure:"api-versions"`
+	// Overlays are the kustomizations, relative to Path, built instead of
+	// Path itself, every object is attributed to the overlay producing it
+	Overlays []string `mapstructure:"overlays"`
+	// LoadRestrictor is the kustomize load restrictor, root-only (default)
+	// or none to load files outside of the kustomization directory
+	LoadRestrictor string `mapstructure:"load-restrictor"`
+	// EnableHelm lets kustomize inflate helmCharts with HelmCommand
+	EnableHelm  bool   `mapstructure:"enable-helm"`
+	HelmCommand string `mapstructure:"helm-command"`
+	// EnablePlugins lets kustomize run exec and KRM function plugins, like
+	// kustomize build --enable-alpha-plugins
+	EnablePlugins bool `mapstructure:"enable-plugins"`
+	// CacheDir is the directory downloaded Helm charts and remote kustomize
+	// bases are cached in, it defaults to run.cache-dir
+	CacheDir string `mapstructure:"cache-dir"`
+	// Offline renders charts and remote bases from the cache only, without
+	// network access, it defaults to run.offline
+	Offline bool `mapstructure:"offline"`
+	// URL is the HTTP(S) URL of the manifests of url sources, such as the
+	// published install.yaml of an operator
+	URL string `mapstructure:"url"`
+	// Headers are the HTTP headers sent when fetching URL, ${VAR} references
+	// are replaced with environment variables so that tokens never live in
+	// the configuration
+	Headers map[string]string `mapstructure:"headers"`
+	// SHA256 is the expected digest of the content of URL, the run fails when
+	// it does not match. Content with a digest is cached and used offline.
+	SHA256 string `mapstructure:"sha256"`
+	// YttCommand is the ytt binary ytt sources are rendered with, it
+	// defaults to ytt looked up in PATH and must be listed in
+	// run.sandbox.allowed-binaries
+	YttCommand string `mapstructure:"ytt-command"`
+	// CUECommand is the cue binary cue sources are exported with, it
+	// defaults to cue looked up in PATH and must be listed in
+	// run.sandbox.allowed-binaries
+	CUECommand string `mapstructure:"cue-command"`
+	// CUEExpression is the expression of the package exported by cue
+	// sources, such as objects, it defaults to the whole package
+	CUEExpression string `mapstructure:"cue-expression"`
+	// Command is the generator exec sources run, followed by its arguments,
+	// such as cdk8s synth --stdout, the objects are read from its YAML or
+	// JSON standard output. It runs in Path, within the sandbox work dir,
+	// and must be listed in run.sandbox.allowed-binaries.
+	Command []string `mapstructure:"command"`
+	// Env are the environment variables passed to the command of exec
+	// sources, as names or NAME=value, on top of the ones of run.sandbox.env
+	Env []string `mapstructure:"env"`
+	// Timeout bounds the command of exec sources, it defaults to
+	// run.sandbox.timeout, and the download of url sources, it defaults to
+	// one minute
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Namespaces are the namespaces cluster sources list resources from, it
+	// defaults to the namespace of the kubeconfig context, * lists all of
+	// them
+	Namespaces []string `mapstructure:"namespaces"`
+	// Kinds are the resources cluster sources list, as kinds, resource
+	// names or short names such as deploy, optionally qualified by group as
+	// in deployments.apps, it defaults to the common workload resources
+	Kinds []string `mapstructure:"kinds"`
+	// Selector is the label selector of the resources listed by cluster
+	// sources
+	Selector string `mapstructure:"selector"`
+	// Credentials authenticate to the OCI registry the chart is pulled from,
+	// or to the host of URL, they take precedence over the top level
+	// credentials
+	Credentials *Credentials `mapstructure:"credentials"`
+	// Linters enable and disable replace the top level ones for the objects
+	// of the source, so that e.g. a legacy chart is linted with a relaxed
+	// set of linters
+	Linters SourceLintersConfig `mapstructure:"linters"`
+	// Exclude is added to the top level exclusions for the objects of the
+	// source
+	Exclude ExcludeConfig `mapstructure:"exclude"`
+	// Sandbox is the policy external binaries, such as ytt, are run with, it
+	// is set from run.sandbox
+	Sandbox SandboxConfig `mapstructure:"-"`
+	// Cluster is the cluster cluster sources list resources from, it is set
+	// from the cluster configuration
+	Cluster ClusterConfig `mapstructure:"-"`
+	// Discovery controls the directories walked by yaml sources, it is set
+	// from the run configuration
+	Discovery Discovery `mapstructure:"-"`
+}
+
+// sha256Re matches hex encoded sha256 digests
+var sha256Re = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Kustomize load restrictors
+const (
+	LoadRestrictorRootOnly = "root-only"
+	LoadRestrictorNone     = "none"
+)
+
+// Credentials are a username and password, or token, read from environment
+// variables or files so that secrets never live in the configuration.
+// Hosts are glob patterns of the hosts the credentials apply to, they are
+// only used by the top level credentials.
+type Credentials struct {
+	Hosts        []string `mapstructure:"hosts"`
+	Username     string   `mapstructure:"username"`
+	UsernameEnv  string   `mapstructure:"username-env"`
+	PasswordEnv  string   `mapstructure:"password-env"`
+	PasswordFile string   `mapstructure:"password-file"`
+}
+
+// Release is a Helm release a chart is rendered as, Set overrides are
+// applied on top of the ones of the source
+type Release struct {
+	Name      string   `mapstructure:"name"`
+	Namespace string   `mapstructure:"namespace"`
+	Set       []string `mapstructure:"set"`
 }
 
 type LintersConfig struct {
+	Include  []string                          `mapstructure:"include"`
 	Enable   []string                          `mapstructure:"enable"`
 	Disable  []string                          `mapstructure:"disable"`
 	Settings map[string]map[string]interface{} `mapstructure:"settings"`
 	Custom   []CustomLinter                    `mapstructure:"custom"`
+	// Stability, when set to stable, skips experimental linters unless they
+	// are explicitly enabled
+	Stability string `mapstructure:"stability"`
+}
+
+// SourceLintersConfig selects the linters run against the objects of a
+// source
+type SourceLintersConfig struct {
+	Enable  []string `mapstructure:"enable"`
+	Disable []string `mapstructure:"disable"`
+}
+
+// FilesConfig selects the files of a source by globs relative to its path,
+// ** matches any number of directories. Files are loaded when they match one
+// of the include globs, or when there are none, and none of the exclude ones.
+type FilesConfig struct {
+	Include []string `mapstructure:"include"`
+	Exclude []string `mapstructure:"exclude"`
+}
+
+// Scoped reports whether the source sets its own linters or exclusions
+func (s Source) Scoped() bool {
+	return len(s.Linters.Enable) > 0 || len(s.Linters.Disable) > 0 ||
+		len(s.Exclude.Resources) > 0 || len(s.Exclude.Paths) > 0 || len(s.Exclude.Rules) > 0
 }
 
 type CustomLinter struct {
 	Name        string                 `mapstructure:"name"`
 	Description string                 `mapstructure:"description"`
 	Type        string                 `mapstructure:"type"`
+	DocURL      string                 `mapstructure:"doc-url"`
 	Settings    map[string]interface{} `mapstructure:"settings"`
+	Disabled    bool                   `mapstructure:"disabled"`
 }
 
 type OutputConfig struct {
 	Format     string `mapstructure:"format"`
 	ShowSource bool   `mapstructure:"show-source"`
 	Color      string `mapstructure:"color"`
+	// File is where the report is written, stdout when empty
+	File string `mapstructure:"file"`
+	// PathPrefix is prepended to the file paths of the reported issues
+	PathPrefix string `mapstructure:"path-prefix"`
+	// StepSummary appends a Markdown summary to $GITHUB_STEP_SUMMARY when
+	// running in GitHub Actions
+	StepSummary bool `mapstructure:"step-summary"`
+	// GroupBy groups the issues of the text output under a heading, by
+	// linter, resource, severity, file or namespace. Grouping by namespace
+	// adds a per namespace summary to the json, yaml and html reports.
+	GroupBy string `mapstructure:"group-by"`
+	// SortBy are the keys the issues of the text output are sorted by
+	SortBy []string `mapstructure:"sort-by"`
+	// MaxIssues caps the number of reported issues, zero means no limit
+	MaxIssues int `mapstructure:"max-issues"`
+	// MaxSameIssues caps the number of reported issues sharing the same
+	// linter and message, zero means no limit
+	MaxSameIssues int `mapstructure:"max-same-issues"`
+	// Stats adds a summary of the run to the report
+	Stats bool `mapstructure:"stats"`
+}
+
+// validOrderKeys are the keys the text output can be grouped and sorted by
+var validOrderKeys = map[string]bool{
+	"linter":    true,
+	"resource":  true,
+	"severity":  true,
+	"file":      true,
+	"namespace": true,
 }
 
 type ExcludeConfig struct {
 	Resources []ResourceFilter `mapstructure:"resources"`
 	Paths     []string         `mapstructure:"paths"`
+	Rules     []ExcludeRule    `mapstructure:"rules"`
+}
+
+// ExcludeRule excludes the issues matching all of its conditions, like
+// golangci-lint issues.exclude-rules
+type ExcludeRule struct {
+	// Linters the rule applies to, glob patterns and /regexp/ are supported
+	Linters []string `mapstructure:"linters"`
+	// Text is a regular expression matched against the issue message
+	Text string `mapstructure:"text"`
+	// Path is a glob matched against the file the resource comes from
+	Path string `mapstructure:"path"`
 }
 
 type ResourceFilter struct {
@@ -79,36 +319,145 @@ type ResourceFilter struct {
 }
 
 type RunConfig struct {
-	SkipDirs []string `mapstructure:"skip-dirs"`
+	SkipDirs       []string                 `mapstructure:"skip-dirs"`
+	Concurrency    int                      `mapstructure:"concurrency"`
+	LinterTimeout  time.Duration            `mapstructure:"linter-timeout"`
+	LinterTimeouts map[string]time.Duration `mapstructure:"linter-timeouts"`
+	Sandbox        SandboxConfig            `mapstructure:"sandbox"`
+	MaxFileSize    string                   `mapstructure:"max-file-size"`
+	// RenderConcurrency is the number of sources rendered in parallel, it
+	// defaults to the number of CPUs
+	RenderConcurrency int `mapstructure:"render-concurrency"`
+	// CacheDir is the directory downloaded Helm charts and remote kustomize
+	// bases are cached in, it defaults to k8s-manifests-lint in the user
+	// cache directory
+	CacheDir string `mapstructure:"cache-dir"`
+	// Offline renders from the cache only, sources needing the network fail
+	Offline bool `mapstructure:"offline"`
+	// NoCache renders every source instead of reusing the objects cached by
+	// a previous run for the helm and kustomize sources whose inputs have
+	// not changed
+	NoCache bool `mapstructure:"no-cache"`
+	// FailOn is the lowest severity failing the run, a built-in or custom
+	// level or none, it defaults to error
+	FailOn string `mapstructure:"fail-on"`
+	// SkipDirsUseDefault adds DefaultSkipDirs to SkipDirs, it defaults to
+	// true
+	SkipDirsUseDefault bool `mapstructure:"skip-dirs-use-default"`
+	// SkipHiddenDirs skips the directories whose name starts with a dot,
+	// such as .git, it defaults to true
+	SkipHiddenDirs bool `mapstructure:"skip-hidden-dirs"`
+	// FollowSymlinks walks the directories symlinks point to, every
+	// directory is walked once so that cycles are broken
+	FollowSymlinks bool `mapstructure:"follow-symlinks"`
+}
+
+// DefaultSkipDirs are the directories skipped unless
+// run.skip-dirs-use-default is false
+var DefaultSkipDirs = []string{"vendor", "node_modules"}
+
+// Discovery controls the directories walked by yaml sources. SkipDirs are
+// globs: the ones without a / match the name of a directory at any depth,
+// such as vendor, the others its path relative to the source path.
+type Discovery struct {
+	SkipDirs       []string
+	SkipHidden     bool
+	FollowSymlinks bool
+}
+
+// Discovery returns the directory discovery options of the run
+func (r RunConfig) Discovery() Discovery {
+	skipDirs := slices.Clone(r.SkipDirs)
+	if r.SkipDirsUseDefault {
+		skipDirs = append(skipDirs, DefaultSkipDirs...)
+	}
+
+	return Discovery{
+		SkipDirs:       skipDirs,
+		SkipHidden:     r.SkipHiddenDirs,
+		FollowSymlinks: r.FollowSymlinks,
+	}
+}
+
+// SandboxConfig restricts what sources and linters shelling out to external
+// binaries are allowed to do
+type SandboxConfig struct {
+	AllowedBinaries []string      `mapstructure:"allowed-binaries"`
+	Env             []string      `mapstructure:"env"`
+	WorkDir         string        `mapstructure:"work-dir"`
+	Timeout         time.Duration `mapstructure:"timeout"`
 }
 
-func Load(configFile string) (*Config, error) {
+// ClusterConfig holds the standard kubeconfig options used by every mode
+// talking to a live cluster
+type ClusterConfig struct {
+	Kubeconfig     string        `mapstructure:"kubeconfig"`
+	Context        string        `mapstructure:"context"`
+	As             string        `mapstructure:"as"`
+	AsGroups       []string      `mapstructure:"as-groups"`
+	RequestTimeout time.Duration `mapstructure:"request-timeout"`
+}
+
+// ConfigName is the base name of the configuration file, any extension
+// supported by viper (yaml, yml, toml, json) is accepted
+const ConfigName = ".k8s-manifests-lint"
+
+// configExtensions are the extensions looked up, in order, when discovering
+// the configuration file
+var configExtensions = []string{"yaml", "yml", "toml", "json"}
+
+// Load reads the configuration from configFile or, when empty, from the
+// first configuration file found walking up from the current directory to
+// the git root. When configKey is set the configuration is read from that
+// dotted key of the file, such as tool.k8s-manifests-lint in a pyproject
+// style central file.
+func Load(configFile string, configKey string) (*Config, error) {
 	v := viper.New()
 
 	v.SetDefault("output.format", "text")
 	v.SetDefault("output.show-source", true)
 	v.SetDefault("output.color", "auto")
+	v.SetDefault("output.step-summary", true)
 	v.SetDefault("run.timeout", "5m")
+	v.SetDefault("run.skip-dirs-use-default", true)
+	v.SetDefault("run.skip-hidden-dirs", true)
 
-	if configFile != "" {
-		v.SetConfigFile(configFile)
-	} else {
-		cwd, err := os.Getwd()
+	if configFile == "" {
+		if configKey != "" {
+			return nil, fmt.Errorf("a config key requires an explicit config file")
+		}
+
+		found, err := Discover()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get current directory: %w", err)
+			return nil, err
 		}
 
-		v.SetConfigName(".k8s-manifests-lint")
-		v.SetConfigType("yaml")
-		v.AddConfigPath(cwd)
-		v.AddConfigPath(filepath.Join(cwd, ".config"))
-		v.AddConfigPath(".")
+		configFile = found
 	}
 
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+	used := ""
+
+	if configFile != "" {
+		file := viper.New()
+		file.SetConfigFile(configFile)
+
+		if err := file.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		settings := file.AllSettings()
+		if configKey != "" {
+			if !file.IsSet(configKey) {
+				return nil, fmt.Errorf("config key %q not found in %s", configKey, configFile)
+			}
+			settings = file.GetStringMap(configKey)
+		}
+
+		if err := v.MergeConfigMap(settings); err != nil {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
+
+		used = configFile
 	}
 
 	var cfg Config
@@ -116,9 +465,63 @@ func Load(configFile string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if used != "" {
+		if err := cfg.readSettings(used, configKey); err != nil {
+			return nil, err
+		}
+	}
+
+	baseDir := "."
+	if used != "" {
+		baseDir = filepath.Dir(used)
+	}
+
+	if err := cfg.applyRulePacks(baseDir); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.applyProfileRulePacks(baseDir); err != nil {
+		return nil, err
+	}
+
+	cfg.File = used
+
 	return &cfg, nil
 }
 
+// Discover returns the first configuration file found walking up from the
+// current directory, in the directory itself or in its .config subdirectory,
+// stopping at the root of the git repository. An empty path is returned when
+// there is none.
+func Discover() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	for {
+		for _, d := range []string{dir, filepath.Join(dir, ".config")} {
+			for _, ext := range configExtensions {
+				path := filepath.Join(d, ConfigName+"."+ext)
+				if info, err := os.Stat(path); err == nil && !info.IsDir() {
+					return path, nil
+				}
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
 func (c *Config) Validate() error {
 	validFormats := map[string]bool{
 		"text":           true,
@@ -126,17 +529,282 @@ func (c *Config) Validate() error {
 		"yaml":           true,
 		"github-actions": true,
 		"sarif":          true,
+		"code-climate":   true,
+		"gitlab":         true,
+		"azure-devops":   true,
+		"junit":          true,
+		"circleci":       true,
+		"markdown":       true,
+		"html":           true,
 	}
 
 	if !validFormats[c.Output.Format] {
 		return fmt.Errorf("invalid output format: %s", c.Output.Format)
 	}
 
+	switch c.Output.Color {
+	case "", "auto", "always", "never":
+	default:
+		return fmt.Errorf("invalid output.color: %s", c.Output.Color)
+	}
+
+	if c.Output.MaxIssues < 0 {
+		return fmt.Errorf("invalid output.max-issues: %d", c.Output.MaxIssues)
+	}
+
+	if c.Output.MaxSameIssues < 0 {
+		return fmt.Errorf("invalid output.max-same-issues: %d", c.Output.MaxSameIssues)
+	}
+
+	if c.Output.GroupBy != "" && !validOrderKeys[c.Output.GroupBy] {
+		return fmt.Errorf("invalid output.group-by: %s", c.Output.GroupBy)
+	}
+
+	for _, key := range c.Output.SortBy {
+		if !validOrderKeys[key] {
+			return fmt.Errorf("invalid output.sort-by: %s", key)
+		}
+	}
+
+	switch c.Linters.Stability {
+	case "", "stable", "experimental":
+	default:
+		return fmt.Errorf("invalid linters.stability: %s", c.Linters.Stability)
+	}
+
+	if err := validateSize(c.Run.MaxFileSize); err != nil {
+		return fmt.Errorf("invalid run.max-file-size: %w", err)
+	}
+
+	for _, pattern := range c.Run.SkipDirs {
+		if _, err := glob.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid run.skip-dirs: invalid glob %q: %w", pattern, err)
+		}
+	}
+
+	if err := c.validateSeverity(); err != nil {
+		return err
+	}
+
+	if c.Run.FailOn != "" && c.Run.FailOn != FailOnNone && !c.IsSeverity(c.Run.FailOn) {
+		return fmt.Errorf("invalid run.fail-on: unknown severity %q", c.Run.FailOn)
+	}
+
+	names := make(map[string]bool, len(c.Sources))
+
 	for i, source := range c.Sources {
-		if !source.Type.IsValid() {
-			return fmt.Errorf("invalid source type at index %d: %s", i, source.Type)
+		if source.Name != "" {
+			if names[source.Name] {
+				return fmt.Errorf("invalid sources[%d].name: duplicate source %q", i, source.Name)
+			}
+			names[source.Name] = true
+		}
+
+		if source.Scoped() && source.Name == "" {
+			return fmt.Errorf("invalid sources[%d].name: name is required by sources setting linters or exclude", i)
+		}
+
+		if err := validateSize(source.MaxFileSize); err != nil {
+			return fmt.Errorf("invalid sources[%d].max-file-size: %w", i, err)
+		}
+
+		if len(source.Files.Include) > 0 || len(source.Files.Exclude) > 0 {
+			if source.Type != "" && source.Type != SourceTypeYAML {
+				return fmt.Errorf("invalid sources[%d].files: only supported by yaml sources", i)
+			}
+
+			for _, pattern := range append(slices.Clone(source.Files.Include), source.Files.Exclude...) {
+				if _, err := glob.Compile(pattern); err != nil {
+					return fmt.Errorf("invalid sources[%d].files: invalid glob %q: %w", i, pattern, err)
+				}
+			}
+		}
+
+		if source.Envsubst && source.Type != "" && source.Type != SourceTypeYAML {
+			return fmt.Errorf("invalid sources[%d].envsubst: only supported by yaml sources", i)
+		}
+
+		if len(source.Values) > 0 && !slices.Contains([]SourceType{SourceTypeHelm, SourceTypeYtt, SourceTypeCUE}, source.Type) {
+			return fmt.Errorf("invalid sources[%d].values: only supported by helm, ytt and cue sources", i)
+		}
+
+		if len(source.Set) > 0 && !slices.Contains([]SourceType{SourceTypeHelm, SourceTypeYtt, SourceTypeCUE}, source.Type) {
+			return fmt.Errorf("invalid sources[%d].set: only supported by helm, ytt and cue sources", i)
+		}
+
+		if source.Type != SourceTypeCUE {
+			for _, option := range []struct {
+				key string
+				set bool
+			}{
+				{key: "cue-command", set: source.CUECommand != ""},
+				{key: "cue-expression", set: source.CUEExpression != ""},
+			} {
+				if option.set {
+					return fmt.Errorf("invalid sources[%d].%s: only supported by cue sources", i, option.key)
+				}
+			}
+		}
+
+		if source.Type == SourceTypeExec {
+			if len(source.Command) == 0 || source.Command[0] == "" {
+				return fmt.Errorf("invalid sources[%d].command: a command is required by exec sources", i)
+			}
+
+		} else {
+			for _, option := range []struct {
+				key string
+				set bool
+			}{
+				{key: "command", set: len(source.Command) > 0},
+				{key: "env", set: len(source.Env) > 0},
+			} {
+				if option.set {
+					return fmt.Errorf("invalid sources[%d].%s: only supported by exec sources", i, option.key)
+				}
+			}
+		}
+
+		if source.Type == SourceTypeExec || source.Type == SourceTypeURL {
+			if source.Timeout < 0 {
+				return fmt.Errorf("invalid sources[%d].timeout: %s", i, source.Timeout)
+			}
+		} else if source.Timeout != 0 {
+			return fmt.Errorf("invalid sources[%d].timeout: only supported by exec and url sources", i)
+		}
+
+		if source.Type != SourceTypeCluster {
+			for _, option := range []struct {
+				key string
+				set bool
+			}{
+				{key: "namespaces", set: len(source.Namespaces) > 0},
+				{key: "kinds", set: len(source.Kinds) > 0},
+				{key: "selector", set: source.Selector != ""},
+			} {
+				if option.set {
+					return fmt.Errorf("invalid sources[%d].%s: only supported by cluster sources", i, option.key)
+				}
+			}
+		} else if source.Selector != "" {
+			if _, err := labels.Parse(source.Selector); err != nil {
+				return fmt.Errorf("invalid sources[%d].selector: %w", i, err)
+			}
+		}
+
+		if source.YttCommand != "" && source.Type != SourceTypeYtt {
+			return fmt.Errorf("invalid sources[%d].ytt-command: only supported by ytt sources", i)
+		}
+
+		if len(source.Releases) > 0 && source.Type != SourceTypeHelm {
+			return fmt.Errorf("invalid sources[%d].releases: only supported by helm sources", i)
+		}
+
+		if source.Repo != "" && (source.Type != SourceTypeHelm || source.Chart == "") {
+			return fmt.Errorf("invalid sources[%d].repo: only supported by helm sources with a chart", i)
+		}
+
+		if source.Version != "" && source.Repo == "" && !strings.HasPrefix(source.Chart, "oci://") {
+			return fmt.Errorf("invalid sources[%d].version: only supported by helm charts from repositories or OCI registries", i)
+		}
+
+		if source.KubeVersion != "" {
+			if source.Type != SourceTypeHelm && !source.EnableHelm {
+				return fmt.Errorf("invalid sources[%d].kube-version: only supported by helm sources and kustomize sources with enable-helm", i)
+			}
+			if _, err := version.ParseGeneric(source.KubeVersion); err != nil {
+				return fmt.Errorf("invalid sources[%d].kube-version: %w", i, err)
+			}
+		}
+
+		if len(source.APIVersions) > 0 && source.Type != SourceTypeHelm && !source.EnableHelm {
+			return fmt.Errorf("invalid sources[%d].api-versions: only supported by helm sources and kustomize sources with enable-helm", i)
+		}
+
+		if source.Type != SourceTypeKustomize {
+			for _, option := range []struct {
+				key string
+				set bool
+			}{
+				{key: "overlays", set: len(source.Overlays) > 0},
+				{key: "load-restrictor", set: source.LoadRestrictor != ""},
+				{key: "enable-helm", set: source.EnableHelm},
+				{key: "helm-command", set: source.HelmCommand != ""},
+				{key: "enable-plugins", set: source.EnablePlugins},
+			} {
+				if option.set {
+					return fmt.Errorf("invalid sources[%d].%s: only supported by kustomize sources", i, option.key)
+				}
+			}
 		}
+
+		switch source.LoadRestrictor {
+		case "", LoadRestrictorRootOnly, LoadRestrictorNone:
+		default:
+			return fmt.Errorf("invalid sources[%d].load-restrictor: %s (supported: %s, %s)", i, source.LoadRestrictor, LoadRestrictorRootOnly, LoadRestrictorNone)
+		}
+
+		if source.Type == SourceTypeURL {
+			if u, err := url.Parse(source.URL); source.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid sources[%d].url: an http or https URL is required by url sources", i)
+			}
+		} else {
+			for _, option := range []struct {
+				key string
+				set bool
+			}{
+				{key: "url", set: source.URL != ""},
+				{key: "headers", set: len(source.Headers) > 0},
+				{key: "sha256", set: source.SHA256 != ""},
+			} {
+				if option.set {
+					return fmt.Errorf("invalid sources[%d].%s: only supported by url sources", i, option.key)
+				}
+			}
+		}
+
+		if source.SHA256 != "" && !sha256Re.MatchString(strings.TrimPrefix(source.SHA256, "sha256:")) {
+			return fmt.Errorf("invalid sources[%d].sha256: %s is not a hex encoded sha256 digest", i, source.SHA256)
+		}
+
+		if source.Credentials != nil {
+			if err := source.Credentials.validate(false); err != nil {
+				return fmt.Errorf("invalid sources[%d].credentials: %w", i, err)
+			}
+		}
+	}
+
+	for i, credentials := range c.Credentials {
+		if err := credentials.validate(true); err != nil {
+			return fmt.Errorf("invalid credentials[%d]: %w", i, err)
+		}
+	}
+
+	return c.validateProfiles()
+}
+
+func (c *Credentials) validate(requireHosts bool) error {
+	if requireHosts && len(c.Hosts) == 0 {
+		return fmt.Errorf("hosts is required")
+	}
+
+	if c.Username != "" && c.UsernameEnv != "" {
+		return fmt.Errorf("username and username-env are mutually exclusive")
+	}
+
+	if (c.PasswordEnv == "") == (c.PasswordFile == "") {
+		return fmt.Errorf("exactly one of password-env and password-file is required")
 	}
 
 	return nil
 }
+
+func validateSize(size string) error {
+	if size == "" {
+		return nil
+	}
+
+	_, err := resource.ParseQuantity(size)
+
+	return err
+}
@@ -0,0 +1,150 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+)
+
+// pattern is a linter pattern viper would split on the dots and lowercase
+const pattern = `/^(?i:IMAGE-TAG.)$/`
+
+func write(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// TestLoadSettingsVerbatim reads the keys of the linter settings as they are
+// written, whatever the format
+func TestLoadSettingsVerbatim(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		configKey string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `
+linters:
+  settings:
+    "` + pattern + `":
+      disallow-latest: false
+      allowed-registries: [Registry.example.com]
+profiles:
+  - name: strict
+    linters:
+      settings:
+        "` + pattern + `":
+          disallow-latest: false
+`,
+		},
+		{
+			name: "json",
+			file: "config.json",
+			content: `{
+  "linters": {"settings": {"/^(?i:IMAGE-TAG.)$/": {"disallow-latest": false, "allowed-registries": ["Registry.example.com"]}}},
+  "profiles": [{"name": "strict", "linters": {"settings": {"/^(?i:IMAGE-TAG.)$/": {"disallow-latest": false}}}}]
+}`,
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: `
+[linters.settings."/^(?i:IMAGE-TAG.)$/"]
+disallow-latest = false
+allowed-registries = ["Registry.example.com"]
+
+[[profiles]]
+name = "strict"
+
+[profiles.linters.settings."/^(?i:IMAGE-TAG.)$/"]
+disallow-latest = false
+`,
+		},
+		{
+			name: "config key",
+			file: "tools.yaml",
+			content: `
+tool:
+  k8s-manifests-lint:
+    linters:
+      settings:
+        "` + pattern + `":
+          disallow-latest: false
+          allowed-registries: [Registry.example.com]
+    profiles:
+      - name: strict
+        linters:
+          settings:
+            "` + pattern + `":
+              disallow-latest: false
+`,
+			configKey: "tool.k8s-manifests-lint",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := write(t, t.TempDir(), tt.file, tt.content)
+
+			cfg, err := config.Load(file, tt.configKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			settings, ok := cfg.Linters.Settings[pattern]
+			if !ok || len(cfg.Linters.Settings) != 1 {
+				t.Fatalf("expected the settings of %s, got %v", pattern, cfg.Linters.Settings)
+			}
+
+			if settings["disallow-latest"] != false {
+				t.Errorf("expected disallow-latest to be false, got %v", settings["disallow-latest"])
+			}
+
+			registries, _ := settings["allowed-registries"].([]interface{})
+			if len(registries) != 1 || registries[0] != "Registry.example.com" {
+				t.Errorf("expected the allowed registries to be kept as they are, got %v", settings["allowed-registries"])
+			}
+
+			if len(cfg.Profiles) != 1 {
+				t.Fatalf("expected 1 profile, got %d", len(cfg.Profiles))
+			}
+			if _, ok := cfg.Profiles[0].Linters.Settings[pattern]; !ok {
+				t.Errorf("expected the profile settings of %s, got %v", pattern, cfg.Profiles[0].Linters.Settings)
+			}
+		})
+	}
+}
+
+func TestLoadRulePackSettingsVerbatim(t *testing.T) {
+	dir := t.TempDir()
+
+	write(t, dir, "pack.yaml", `
+settings:
+  "`+pattern+`":
+    disallow-latest: false
+`)
+	file := write(t, dir, "config.yaml", `
+linters:
+  include: [pack.yaml]
+`)
+
+	cfg, err := config.Load(file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if settings, ok := cfg.Linters.Settings[pattern]; !ok || settings["disallow-latest"] != false {
+		t.Errorf("expected the rule pack settings of %s, got %v", pattern, cfg.Linters.Settings)
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import "fmt"
+
+// Failure policies of a profile, the lowest severity failing the run
+const (
+	FailOnFatal   = "fatal"
+	FailOnError   = "error"
+	FailOnWarning = "warning"
+	FailOnNone    = "none"
+)
+
+// Profile is a named run of run --matrix-config. Its linters, exclusions and
+// sources are layered on top of the top level configuration, so that e.g. a
+// security profile and a hygiene profile lint the same sources with
+// different linter sets and failure policies.
+type Profile struct {
+	Name string `mapstructure:"name"`
+	// Sources replace the top level sources when set
+	Sources []Source `mapstructure:"sources"`
+	// Linters enable, disable and stability replace the top level ones when
+	// set, settings and custom linters are merged over the top level ones
+	Linters LintersConfig `mapstructure:"linters"`
+	// Exclude is added to the top level exclusions
+	Exclude ExcludeConfig `mapstructure:"exclude"`
+	// FailOn is the lowest severity failing the run: fatal, error (default),
+	// warning, a custom severity level or none
+	FailOn string `mapstructure:"fail-on"`
+}
+
+// applyProfileRulePacks merges the rule packs included by every profile into
+// the profile linters, the way the top level ones are merged
+func (c *Config) applyProfileRulePacks(baseDir string) error {
+	for i := range c.Profiles {
+		profile := &Config{Linters: c.Profiles[i].Linters}
+
+		if err := profile.applyRulePacks(baseDir); err != nil {
+			return fmt.Errorf("profile %q: %w", c.Profiles[i].Name, err)
+		}
+
+		c.Profiles[i].Linters = profile.Linters
+	}
+
+	return nil
+}
+
+// Profile returns the configuration of the named profile: the top level
+// configuration with the profile layered on top of it
+func (c *Config) Profile(name string) (*Config, error) {
+	var profile *Profile
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			profile = &c.Profiles[i]
+			break
+		}
+	}
+
+	if profile == nil {
+		return nil, fmt.Errorf("profile %q not found", name)
+	}
+
+	result := *c
+	result.Profiles = nil
+
+	if len(profile.Sources) > 0 {
+		result.Sources = profile.Sources
+	}
+
+	if len(profile.Linters.Enable) > 0 {
+		result.Linters.Enable = profile.Linters.Enable
+	}
+	if len(profile.Linters.Disable) > 0 {
+		result.Linters.Disable = profile.Linters.Disable
+	}
+	if profile.Linters.Stability != "" {
+		result.Linters.Stability = profile.Linters.Stability
+	}
+
+	// rule packs of the profile are already merged, they are only recorded
+	// for the reproducibility report
+	result.Linters.Include = append(append([]string(nil), c.Linters.Include...), profile.Linters.Include...)
+
+	settings := make(map[string]map[string]interface{})
+	mergeSettings(settings, c.Linters.Settings)
+	mergeSettings(settings, profile.Linters.Settings)
+	result.Linters.Settings = settings
+
+	custom, err := MergeCustomLinters(c.Linters.Custom, profile.Linters.Custom)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	result.Linters.Custom = custom
+
+	result.Exclude = ExcludeConfig{
+		Resources: append(append([]ResourceFilter(nil), c.Exclude.Resources...), profile.Exclude.Resources...),
+		Paths:     append(append([]string(nil), c.Exclude.Paths...), profile.Exclude.Paths...),
+		Rules:     append(append([]ExcludeRule(nil), c.Exclude.Rules...), profile.Exclude.Rules...),
+	}
+
+	return &result, nil
+}
+
+func (c *Config) validateProfiles() error {
+	names := make(map[string]bool, len(c.Profiles))
+
+	for i, profile := range c.Profiles {
+		if profile.Name == "" {
+			return fmt.Errorf("invalid profiles[%d].name: name is required", i)
+		}
+
+		if names[profile.Name] {
+			return fmt.Errorf("invalid profiles[%d].name: duplicate profile %q", i, profile.Name)
+		}
+		names[profile.Name] = true
+
+		if profile.FailOn != "" && profile.FailOn != FailOnNone && !c.IsSeverity(profile.FailOn) {
+			return fmt.Errorf("invalid profiles[%d].fail-on: unknown severity %q", i, profile.FailOn)
+		}
+
+		cfg, err := c.Profile(profile.Name)
+		if err != nil {
+			return fmt.Errorf("invalid profiles[%d]: %w", i, err)
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid profiles[%d] (%s): %w", i, profile.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// RulePack is a shareable file providing custom linters and default linter
+// settings, referenced from the main configuration via linters.include
+type RulePack struct {
+	Custom   []CustomLinter                    `mapstructure:"custom"`
+	Settings map[string]map[string]interface{} `mapstructure:"settings"`
+}
+
+// LoadRulePack reads a rule pack file
+func LoadRulePack(file string) (*RulePack, error) {
+	v := viper.New()
+	v.SetConfigFile(file)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read rule pack %q: %w", file, err)
+	}
+
+	var pack RulePack
+	if err := v.Unmarshal(&pack); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rule pack %q: %w", file, err)
+	}
+
+	// the settings are keyed by linter patterns, read verbatim
+	raw, err := readRaw(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule pack %q: %w", file, err)
+	}
+	if pack.Settings, err = settingsAt(raw, "settings"); err != nil {
+		return nil, fmt.Errorf("invalid rule pack %q: %w", file, err)
+	}
+
+	return &pack, nil
+}
+
+// applyRulePacks merges the rule packs listed in linters.include into the
+// configuration. Packs are applied in order and the main configuration is
+// applied last, so it always has the final say.
+func (c *Config) applyRulePacks(baseDir string) error {
+	layers := make([][]CustomLinter, 0, len(c.Linters.Include)+1)
+	settings := make(map[string]map[string]interface{})
+
+	for _, include := range c.Linters.Include {
+		file := include
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(baseDir, file)
+		}
+
+		pack, err := LoadRulePack(file)
+		if err != nil {
+			return err
+		}
+
+		layers = append(layers, pack.Custom)
+		mergeSettings(settings, pack.Settings)
+	}
+
+	layers = append(layers, c.Linters.Custom)
+	mergeSettings(settings, c.Linters.Settings)
+
+	custom, err := MergeCustomLinters(layers...)
+	if err != nil {
+		return err
+	}
+
+	c.Linters.Custom = custom
+	c.Linters.Settings = settings
+
+	return nil
+}
+
+// MergeCustomLinters merges layers of custom linter definitions by name, later
+// layers taking precedence over earlier ones:
+//
+//   - an entry with a new name is appended
+//   - an entry with an existing name overrides its type and description when
+//     set, and its settings key by key
+//   - an entry with disabled: true removes every previously defined linter
+//     whose name matches, the name can be a glob pattern
+func MergeCustomLinters(layers ...[]CustomLinter) ([]CustomLinter, error) {
+	var result []CustomLinter
+
+	for _, layer := range layers {
+		for _, entry := range layer {
+			if entry.Disabled {
+				if _, err := path.Match(entry.Name, ""); err != nil {
+					return nil, fmt.Errorf("invalid custom linter pattern %q: %w", entry.Name, err)
+				}
+
+				kept := result[:0]
+				for _, existing := range result {
+					if matched, _ := path.Match(entry.Name, existing.Name); !matched {
+						kept = append(kept, existing)
+					}
+				}
+				result = kept
+				continue
+			}
+
+			index := -1
+			for i := range result {
+				if result[i].Name == entry.Name {
+					index = i
+					break
+				}
+			}
+
+			if index == -1 {
+				result = append(result, entry)
+				continue
+			}
+
+			existing := &result[index]
+			if entry.Type != "" {
+				existing.Type = entry.Type
+			}
+			if entry.Description != "" {
+				existing.Description = entry.Description
+			}
+			if entry.DocURL != "" {
+				existing.DocURL = entry.DocURL
+			}
+			if entry.Settings != nil {
+				merged := make(map[string]interface{}, len(existing.Settings)+len(entry.Settings))
+				for k, v := range existing.Settings {
+					merged[k] = v
+				}
+				for k, v := range entry.Settings {
+					merged[k] = v
+				}
+				existing.Settings = merged
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func mergeSettings(dst map[string]map[string]interface{}, src map[string]map[string]interface{}) {
+	for name, settings := range src {
+		if dst[name] == nil {
+			dst[name] = make(map[string]interface{}, len(settings))
+		}
+		for k, v := range settings {
+			dst[name][k] = v
+		}
+	}
+}
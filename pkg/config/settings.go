@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// readRaw decodes the file without viper, which splits the keys on dots and
+// lowercases them: the linter settings are keyed by linter names, globs and
+// /regexp/ patterns which must be read verbatim
+func readRaw(file string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]interface{})
+
+	// JSON is YAML
+	if strings.EqualFold(filepath.Ext(file), ".toml") {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
+
+// lookup returns the value at the path of keys, matched case-insensitively
+// as viper does
+func lookup(value interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		value = nil
+		if v, ok := m[key]; ok {
+			value = v
+			continue
+		}
+		for k, v := range m {
+			if strings.EqualFold(k, key) {
+				value = v
+				break
+			}
+		}
+	}
+
+	return value
+}
+
+// settingsAt returns the linter settings at the path of keys
+func settingsAt(value interface{}, keys ...string) (map[string]map[string]interface{}, error) {
+	value = lookup(value, keys...)
+	if value == nil {
+		return nil, nil
+	}
+
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a map of linter settings", strings.Join(keys, "."))
+	}
+
+	settings := make(map[string]map[string]interface{}, len(m))
+	for key, v := range m {
+		if v == nil {
+			settings[key] = map[string]interface{}{}
+			continue
+		}
+
+		s, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s.%s must be a map", strings.Join(keys, "."), key)
+		}
+		settings[key] = s
+	}
+
+	return settings, nil
+}
+
+// readSettings replaces the linter settings, top level and of the profiles,
+// decoded by viper with the ones of the file read verbatim
+func (c *Config) readSettings(file string, configKey string) error {
+	raw, err := readRaw(file)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var root interface{} = raw
+	if configKey != "" {
+		root = lookup(raw, strings.Split(configKey, ".")...)
+	}
+
+	if c.Linters.Settings, err = settingsAt(root, "linters", "settings"); err != nil {
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+
+	profiles, _ := lookup(root, "profiles").([]interface{})
+	for i := range c.Profiles {
+		if i >= len(profiles) {
+			break
+		}
+
+		if c.Profiles[i].Linters.Settings, err = settingsAt(profiles[i], "linters", "settings"); err != nil {
+			return fmt.Errorf("invalid config file: profile %q: %w", c.Profiles[i].Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// builtinSeverities are the severities reported by the linters
+var builtinSeverities = []string{"fatal", "error", "warning", "info"}
+
+// SeverityConfig extends the built-in severities with custom levels, such
+// as blocker or nit, and overrides the severity of the issues
+type SeverityConfig struct {
+	// Levels lists the severity levels, the most severe first. When set it
+	// must list the built-in fatal, error, warning and info levels, the custom
+	// levels are ordered among them.
+	Levels []SeverityLevel `mapstructure:"levels"`
+	// Rules override the severity of the matching issues, the first matching
+	// rule wins
+	Rules []SeverityRule `mapstructure:"rules"`
+}
+
+type SeverityLevel struct {
+	Name string `mapstructure:"name"`
+	// Like is the built-in severity a custom level is reported as by formats
+	// with a fixed set of levels, such as SARIF, and which sets the exit code
+	Like string `mapstructure:"like"`
+	// Color is the color of the level in the text output, such as red, gray
+	// or bold-magenta, it defaults to the color of the level it is like
+	Color string `mapstructure:"color"`
+}
+
+// SeverityRule matches issues the way exclusion rules do, and reports them
+// with the given severity
+type SeverityRule struct {
+	ExcludeRule `mapstructure:",squash"`
+	Severity    string `mapstructure:"severity"`
+}
+
+// IsSeverity reports whether the severity is a built-in or a custom level
+func (c *Config) IsSeverity(severity string) bool {
+	if slices.Contains(builtinSeverities, severity) {
+		return true
+	}
+
+	return slices.ContainsFunc(c.Severity.Levels, func(l SeverityLevel) bool {
+		return l.Name == severity
+	})
+}
+
+func (c *Config) validateSeverity() error {
+	names := make(map[string]bool, len(c.Severity.Levels))
+
+	for i, level := range c.Severity.Levels {
+		if level.Name == "" {
+			return fmt.Errorf("invalid severity.levels[%d].name: name is required", i)
+		}
+
+		if names[level.Name] {
+			return fmt.Errorf("invalid severity.levels[%d].name: duplicate level %q", i, level.Name)
+		}
+		names[level.Name] = true
+
+		builtin := slices.Contains(builtinSeverities, level.Name)
+		switch {
+		case builtin && level.Like != "" && level.Like != level.Name:
+			return fmt.Errorf("invalid severity.levels[%d].like: the built-in level %s cannot be like %s", i, level.Name, level.Like)
+		case !builtin && !slices.Contains(builtinSeverities, level.Like):
+			return fmt.Errorf("invalid severity.levels[%d].like: %q (supported: fatal, error, warning, info)", i, level.Like)
+		}
+	}
+
+	if len(c.Severity.Levels) > 0 {
+		for _, s := range builtinSeverities {
+			if !names[s] {
+				return fmt.Errorf("invalid severity.levels: the built-in level %s is missing", s)
+			}
+		}
+	}
+
+	for i, rule := range c.Severity.Rules {
+		if len(rule.Linters) == 0 && rule.Text == "" && rule.Path == "" {
+			return fmt.Errorf("invalid severity.rules[%d]: at least one of linters, text or path is required", i)
+		}
+
+		if !c.IsSeverity(rule.Severity) {
+			return fmt.Errorf("invalid severity.rules[%d].severity: unknown severity %q", i, rule.Severity)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+)
+
+// Basic holds resolved username and password credentials. Registries and
+// git hosts accepting tokens take them as the password, with any username
+// when none is configured.
+type Basic struct {
+	Username string
+	Password string
+}
+
+// tokenUsername is the username sent along a token when none is configured,
+// the hosting services ignore it
+const tokenUsername = "x-access-token"
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Resolve reads the credentials from the environment or the password file.
+// The password is remembered so that Redact masks it from any message.
+func Resolve(c config.Credentials) (*Basic, error) {
+	b := &Basic{Username: c.Username}
+
+	if c.UsernameEnv != "" {
+		b.Username = os.Getenv(c.UsernameEnv)
+	}
+
+	switch {
+	case c.PasswordEnv != "":
+		value, ok := os.LookupEnv(c.PasswordEnv)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", c.PasswordEnv)
+		}
+		b.Password = value
+	case c.PasswordFile != "":
+		data, err := os.ReadFile(c.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password file: %w", err)
+		}
+		b.Password = strings.TrimSpace(string(data))
+	}
+
+	if b.Username == "" {
+		b.Username = tokenUsername
+	}
+
+	register(b.Password)
+
+	return b, nil
+}
+
+// Match returns the first credentials with a host pattern matching the host,
+// or nil
+func Match(list []config.Credentials, host string) *config.Credentials {
+	if host == "" {
+		return nil
+	}
+
+	for i := range list {
+		for _, pattern := range list[i].Hosts {
+			if matched, _ := path.Match(pattern, host); matched {
+				return &list[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// Host returns the host of an OCI reference, URL or scp-like git remote
+// such as git@github.com:org/repo.git, or an empty string for local paths
+func Host(ref string) string {
+	if strings.Contains(ref, "://") {
+		u, err := url.Parse(ref)
+		if err != nil {
+			return ""
+		}
+		return u.Hostname()
+	}
+
+	// scp-like syntax, user@host:path
+	if at := strings.Index(ref, "@"); at >= 0 {
+		if colon := strings.Index(ref[at:], ":"); colon >= 0 {
+			return ref[at+1 : at+colon]
+		}
+	}
+
+	return ""
+}
+
+// GitEnv returns the environment variables making git send the credentials
+// to HTTP remotes. They are passed through GIT_CONFIG_* variables rather
+// than on the command line, where they would be visible to other processes.
+func GitEnv(b *Basic) []string {
+	auth := base64.StdEncoding.EncodeToString([]byte(b.Username + ":" + b.Password))
+	register(auth)
+
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + auth,
+		"GIT_TERMINAL_PROMPT=0",
+	}
+}
+
+// Redact masks the resolved passwords, and the authorization headers built
+// from them, found in the message
+func Redact(message string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, secret := range secrets {
+		message = strings.ReplaceAll(message, secret, "***")
+	}
+
+	return message
+}
+
+func register(secret string) {
+	if secret == "" {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	secrets = append(secrets, secret)
+}
@@ -0,0 +1,51 @@
+// Package demo holds the sample project of the demo command: Helm charts,
+// kustomize overlays and plain manifests on which every built-in linter
+// reports issues, for new users and linter authors to explore and for the
+// project to check end to end.
+package demo
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ConfigFile is the configuration file of the sample project
+const ConfigFile = ".k8s-manifests-lint.yaml"
+
+//go:embed all:project
+var project embed.FS
+
+// Write materializes the sample project into dir, existing files are
+// overwritten
+func Write(dir string) error {
+	root, err := fs.Sub(project, "project")
+	if err != nil {
+		return err
+	}
+
+	return fs.WalkDir(root, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(path))
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+
+		data, err := fs.ReadFile(root, path)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+
+		return nil
+	})
+}
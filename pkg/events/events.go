@@ -0,0 +1,148 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+type Type string
+
+const (
+	TypeRenderStart    Type = "render-start"
+	TypeRenderProgress Type = "render-progress"
+	TypeRenderDone     Type = "render-done"
+	TypeObjectLinted   Type = "object-linted"
+	TypeIssueFound     Type = "issue-found"
+	TypeRunSummary     Type = "run-summary"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type Event struct {
+	Type       Type                    `json:"type"`
+	Time       time.Time               `json:"time"`
+	Source     string                  `json:"source,omitempty"`
+	Path       string                  `json:"path,omitempty"`
+	File       string                  `json:"file,omitempty"`
+	Objects    int                     `json:"objects,omitempty"`
+	Resource   *linter.ResourceRef     `json:"resource,omitempty"`
+	Issue      *linter.Issue           `json:"issue,omitempty"`
+	Issues     *int                    `json:"issues,omitempty"`
+	Severity   map[linter.Severity]int `json:"severity,omitempty"`
+	Suppressed map[string]int          `json:"suppressed,omitempty"`
+	Duration   string                  `json:"duration,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+// Emitter writes events as newline delimited JSON. A nil Emitter or one
+// created for the text log format silently discards events.
+type Emitter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func New(w io.Writer, format string) (*Emitter, error) {
+	switch format {
+	case FormatText, "":
+		return &Emitter{}, nil
+	case FormatJSON:
+		return &Emitter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", format)
+	}
+}
+
+// Enabled reports whether events are actually written
+func (e *Emitter) Enabled() bool {
+	return e != nil && e.w != nil
+}
+
+func (e *Emitter) Emit(event Event) {
+	if !e.Enabled() {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, _ = e.w.Write(append(data, '\n'))
+}
+
+func (e *Emitter) RenderStart(source string, path string) {
+	e.Emit(Event{Type: TypeRenderStart, Source: source, Path: path})
+}
+
+// RenderProgress emits a render-progress event for a file loaded, or a
+// source rendered as a whole, while rendering a source
+func (e *Emitter) RenderProgress(source string, path string, file string, objects int) {
+	e.Emit(Event{Type: TypeRenderProgress, Source: source, Path: path, File: file, Objects: objects})
+}
+
+func (e *Emitter) RenderDone(source string, path string, objects int, duration time.Duration, err error) {
+	event := Event{
+		Type:     TypeRenderDone,
+		Source:   source,
+		Path:     path,
+		Objects:  objects,
+		Duration: duration.String(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	e.Emit(event)
+}
+
+// ObjectLinted emits an object-linted event followed by an issue-found event
+// for each issue reported for the object
+func (e *Emitter) ObjectLinted(ref linter.ResourceRef, issues []linter.Issue) {
+	if !e.Enabled() {
+		return
+	}
+
+	count := len(issues)
+	e.Emit(Event{Type: TypeObjectLinted, Resource: &ref, Issues: &count})
+
+	for i := range issues {
+		e.Emit(Event{Type: TypeIssueFound, Issue: &issues[i]})
+	}
+}
+
+// RunSummary emits the run-summary event, suppressed holds per linter the
+// number of objects the linter has been skipped for by annotation
+func (e *Emitter) RunSummary(objects int, issues []linter.Issue, suppressed map[string]int, duration time.Duration) {
+	if !e.Enabled() {
+		return
+	}
+
+	severity := make(map[linter.Severity]int)
+	for _, issue := range issues {
+		severity[issue.Severity]++
+	}
+
+	count := len(issues)
+	e.Emit(Event{
+		Type:       TypeRunSummary,
+		Objects:    objects,
+		Issues:     &count,
+		Severity:   severity,
+		Suppressed: suppressed,
+		Duration:   duration.String(),
+	})
+}
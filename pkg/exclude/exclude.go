@@ -0,0 +1,175 @@
+package exclude
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+)
+
+// Filter drops the issues matching the exclude configuration
+type Filter struct {
+	resources []config.ResourceFilter
+	paths     []*regexp.Regexp
+	rules     []*Rule
+}
+
+// Rule matches issues by linter, message and file
+type Rule struct {
+	linters []string
+	text    *regexp.Regexp
+	path    *regexp.Regexp
+}
+
+// NewRule compiles a rule, at least one of its linters, text or path is
+// required
+func NewRule(r config.ExcludeRule) (*Rule, error) {
+	if len(r.Linters) == 0 && r.Text == "" && r.Path == "" {
+		return nil, fmt.Errorf("at least one of linters, text or path is required")
+	}
+
+	compiled := &Rule{linters: r.Linters}
+
+	if r.Text != "" {
+		re, err := regexp.Compile(r.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid text %q: %w", r.Text, err)
+		}
+		compiled.text = re
+	}
+
+	if r.Path != "" {
+		re, err := glob.Compile(r.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", r.Path, err)
+		}
+		compiled.path = re
+	}
+
+	return compiled, nil
+}
+
+// New compiles the exclude configuration into a Filter
+func New(cfg config.ExcludeConfig) (*Filter, error) {
+	f := &Filter{
+		resources: cfg.Resources,
+	}
+
+	for _, p := range cfg.Paths {
+		re, err := glob.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude path %q: %w", p, err)
+		}
+		f.paths = append(f.paths, re)
+	}
+
+	for i, r := range cfg.Rules {
+		compiled, err := NewRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("exclude rule %d: %w", i, err)
+		}
+
+		f.rules = append(f.rules, compiled)
+	}
+
+	return f, nil
+}
+
+// Apply returns the issues which are not excluded, for issues reported
+// about files, such as the ones skipped by renderers, the resource name is
+// the path of the file
+func (f *Filter) Apply(issues []linter.Issue) []linter.Issue {
+	result := make([]linter.Issue, 0, len(issues))
+
+	for _, issue := range issues {
+		file := ""
+		if issue.Resource.Kind == "File" && issue.Resource.APIVersion == "" {
+			file = issue.Resource.Name
+		}
+
+		if !f.Excluded(issue, file) {
+			result = append(result, issue)
+		}
+	}
+
+	return result
+}
+
+// Excluded reports whether the issue, reported for a resource loaded from
+// the given file, is excluded
+func (f *Filter) Excluded(issue linter.Issue, file string) bool {
+	file = glob.Clean(file)
+
+	for _, r := range f.resources {
+		if matchResource(r, issue.Resource) {
+			return true
+		}
+	}
+
+	if file != "" {
+		for _, re := range f.paths {
+			if re.MatchString(file) {
+				return true
+			}
+		}
+	}
+
+	for _, r := range f.rules {
+		if r.Matches(issue, file) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches reports whether the issue, reported for a resource loaded from the
+// given file, matches the rule
+func (r *Rule) Matches(issue linter.Issue, file string) bool {
+	file = glob.Clean(file)
+
+	if len(r.linters) > 0 {
+		matched := false
+		for _, ref := range r.linters {
+			if ok, err := linter.Match(ref, issue.Linter); err == nil && ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if r.text != nil && !r.text.MatchString(issue.Message) {
+		return false
+	}
+
+	if r.path != nil && (file == "" || !r.path.MatchString(file)) {
+		return false
+	}
+
+	return true
+}
+
+// matchResource reports whether the resource matches the kind, name and
+// namespace globs of the filter, empty fields match anything
+func matchResource(filter config.ResourceFilter, ref linter.ResourceRef) bool {
+	for _, m := range [][2]string{
+		{filter.Kind, ref.Kind},
+		{filter.Name, ref.Name},
+		{filter.Namespace, ref.Namespace},
+	} {
+		if m[0] == "" {
+			continue
+		}
+		if ok, err := path.Match(m[0], m[1]); err != nil || !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,327 @@
+package fix
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	goyaml "gopkg.in/yaml.v3"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Apply applies the patches to the YAML and JSON files the resources have
+// been loaded from. The modified files are re-encoded as a whole: the order
+// of the fields and most comments are kept, but blank lines are dropped, the
+// indentation becomes two spaces and comments may move to a nearby node.
+// Patches of resources not coming from a YAML file are skipped. The paths of
+// the modified files are returned.
+func Apply(patches []Patch) ([]string, error) {
+	byFile := make(map[string][]Patch)
+	for _, p := range patches {
+		if p.File == "" || len(p.Operations) == 0 {
+			continue
+		}
+		byFile[p.File] = append(byFile[p.File], p)
+	}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		if err := applyFile(file, byFile[file]); err != nil {
+			return nil, fmt.Errorf("failed to apply fixes to %s: %w", file, err)
+		}
+	}
+
+	return files, nil
+}
+
+func applyFile(file string, patches []Patch) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var docs []*goyaml.Node
+
+	yd := goyaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc goyaml.Node
+		if err := yd.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		docs = append(docs, &doc)
+	}
+
+	for _, p := range patches {
+		if p.Document >= len(docs) || len(docs[p.Document].Content) == 0 {
+			return fmt.Errorf("document %d not found", p.Document)
+		}
+
+		root := docs[p.Document].Content[0]
+		if p.Item != nil {
+			items, _ := nodeChild(root, "items")
+			if items == nil || items.Kind != goyaml.SequenceNode || *p.Item >= len(items.Content) {
+				return fmt.Errorf("item %d of document %d not found", *p.Item, p.Document)
+			}
+			root = items.Content[*p.Item]
+		}
+
+		for _, op := range p.Operations {
+			if err := applyNode(root, op); err != nil {
+				return fmt.Errorf("%s %s: %w", op.Op, op.Path, err)
+			}
+		}
+	}
+
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		out, err := encodeJSON(docs)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(file, out, info.Mode().Perm())
+	}
+
+	var out bytes.Buffer
+
+	enc := goyaml.NewEncoder(&out)
+	enc.SetIndent(2)
+
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, out.Bytes(), info.Mode().Perm())
+}
+
+// applyNode applies a JSON patch operation to a YAML node tree
+func applyNode(root *goyaml.Node, op linter.PatchOperation) error {
+	tokens, err := tokens(op.Path)
+	if err != nil {
+		return err
+	}
+
+	if len(tokens) == 0 {
+		return fmt.Errorf("cannot patch the document root")
+	}
+
+	parent := root
+	for _, token := range tokens[:len(tokens)-1] {
+		next, _ := nodeChild(parent, token)
+		if next == nil {
+			return fmt.Errorf("%q not found", token)
+		}
+		parent = next
+	}
+
+	last := tokens[len(tokens)-1]
+
+	var value *goyaml.Node
+	if op.Op != "remove" {
+		value = &goyaml.Node{}
+		if err := value.Encode(op.Value); err != nil {
+			return err
+		}
+		blockStyle(value)
+	}
+
+	switch op.Op {
+	case "add", "replace":
+		return setNode(parent, last, value, op.Op == "add")
+	case "remove":
+		return removeNode(parent, last)
+	default:
+		return fmt.Errorf("unsupported operation %q", op.Op)
+	}
+}
+
+// nodeChild returns the child of a mapping or sequence node and its index in
+// the node content
+func nodeChild(node *goyaml.Node, token string) (*goyaml.Node, int) {
+	switch node.Kind {
+	case goyaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == token {
+				return node.Content[i+1], i + 1
+			}
+		}
+	case goyaml.SequenceNode:
+		i, err := strconv.Atoi(token)
+		if err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i], i
+		}
+	}
+
+	return nil, -1
+}
+
+func setNode(parent *goyaml.Node, token string, value *goyaml.Node, insert bool) error {
+	switch parent.Kind {
+	case goyaml.MappingNode:
+		if _, i := nodeChild(parent, token); i >= 0 {
+			parent.Content[i] = value
+			return nil
+		}
+
+		if !insert {
+			return fmt.Errorf("%q not found", token)
+		}
+
+		key := &goyaml.Node{Kind: goyaml.ScalarNode, Tag: "!!str", Value: token}
+		parent.Content = append(parent.Content, key, value)
+		return nil
+	case goyaml.SequenceNode:
+		if token == "-" && insert {
+			parent.Content = append(parent.Content, value)
+			return nil
+		}
+
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i > len(parent.Content) || (!insert && i == len(parent.Content)) {
+			return fmt.Errorf("invalid index %q", token)
+		}
+
+		if !insert {
+			parent.Content[i] = value
+			return nil
+		}
+
+		parent.Content = append(parent.Content, nil)
+		copy(parent.Content[i+1:], parent.Content[i:])
+		parent.Content[i] = value
+		return nil
+	default:
+		return fmt.Errorf("cannot set %q on a scalar", token)
+	}
+}
+
+func removeNode(parent *goyaml.Node, token string) error {
+	_, i := nodeChild(parent, token)
+	if i < 0 {
+		return fmt.Errorf("%q not found", token)
+	}
+
+	switch parent.Kind {
+	case goyaml.MappingNode:
+		parent.Content = append(parent.Content[:i-1], parent.Content[i+1:]...)
+	case goyaml.SequenceNode:
+		parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+	}
+
+	return nil
+}
+
+// blockStyle switches empty collections, which are encoded in flow style,
+// to block style so that the values added into them read like the rest of
+// the file
+func blockStyle(node *goyaml.Node) {
+	if node.Kind == goyaml.MappingNode || node.Kind == goyaml.SequenceNode {
+		node.Style &^= goyaml.FlowStyle
+	}
+
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// encodeJSON writes the documents of a JSON file back as indented JSON, the
+// fields are kept in the order of the nodes
+func encodeJSON(docs []*goyaml.Node) ([]byte, error) {
+	var out bytes.Buffer
+
+	for _, doc := range docs {
+		var compact bytes.Buffer
+		if err := writeJSON(&compact, doc); err != nil {
+			return nil, err
+		}
+
+		if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+	}
+
+	return out.Bytes(), nil
+}
+
+func writeJSON(out *bytes.Buffer, node *goyaml.Node) error {
+	switch node.Kind {
+	case goyaml.DocumentNode:
+		if len(node.Content) == 0 {
+			out.WriteString("null")
+			return nil
+		}
+		return writeJSON(out, node.Content[0])
+	case goyaml.AliasNode:
+		return writeJSON(out, node.Alias)
+	case goyaml.MappingNode:
+		out.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+
+			key, err := json.Marshal(node.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			out.Write(key)
+			out.WriteByte(':')
+
+			if err := writeJSON(out, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case goyaml.SequenceNode:
+		out.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := writeJSON(out, item); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		out.Write(data)
+	}
+
+	return nil
+}
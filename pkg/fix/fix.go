@@ -0,0 +1,341 @@
+package fix
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Patch holds the fixes of all the issues reported for a resource as a
+// single JSON patch, which can be applied with kubectl patch --type=json or
+// referenced from a kustomization
+type Patch struct {
+	Resource linter.ResourceRef
+	// File and Document locate the resource in its source, File is empty
+	// when the resource has not been loaded from a YAML file. Item is the
+	// index of the resource within the items of the document when it is a
+	// List.
+	File       string
+	Document   int
+	Item       *int
+	Operations []linter.PatchOperation
+	// Issues are the issues the patch resolves
+	Issues []linter.Issue
+}
+
+type patchKey struct {
+	file     string
+	document int
+	resource linter.ResourceRef
+}
+
+// Collect groups the fixes of the given issues by resource. Operations are
+// checked against a copy of the object they apply to: duplicates are dropped
+// and the missing parent fields are created first, so that every patch
+// applies cleanly to the linted object.
+func Collect(objects []unstructured.Unstructured, issues []linter.Issue) ([]Patch, error) {
+	var patches []Patch
+
+	index := make(map[patchKey]int)
+	docs := make(map[patchKey]interface{})
+
+	for _, issue := range issues {
+		if len(issue.Fix) == 0 {
+			continue
+		}
+
+		key := patchKey{resource: issue.Resource}
+		if issue.Position != nil {
+			key.file = issue.Position.File
+			key.document = issue.Position.Document
+		}
+
+		i, ok := index[key]
+		if !ok {
+			obj, found := find(objects, issue.Resource)
+			if !found {
+				continue
+			}
+
+			i = len(patches)
+			index[key] = i
+			docs[key] = runtime.DeepCopyJSONValue(obj.Object)
+
+			patch := Patch{Resource: issue.Resource, File: key.file, Document: key.document}
+			if issue.Position != nil {
+				patch.Item = issue.Position.Item
+			}
+
+			patches = append(patches, patch)
+		}
+
+		for _, op := range issue.Fix {
+			ops, doc, err := apply(docs[key], op)
+			if err != nil {
+				return nil, fmt.Errorf("invalid fix reported by %s for %s: %w", issue.Linter, resource(issue.Resource), err)
+			}
+
+			docs[key] = doc
+			patches[i].Operations = append(patches[i].Operations, ops...)
+		}
+
+		patches[i].Issues = append(patches[i].Issues, issue)
+	}
+
+	return patches, nil
+}
+
+// Write stores every patch as a JSON file in dir, named after the resource,
+// and returns the paths of the written files
+func Write(dir string, patches []Patch) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create patch directory: %w", err)
+	}
+
+	names := make(map[string]int)
+	files := make([]string, 0, len(patches))
+
+	for _, p := range patches {
+		if len(p.Operations) == 0 {
+			continue
+		}
+
+		name := fileName(p.Resource)
+		if n := names[name]; n > 0 {
+			names[name]++
+			name = fmt.Sprintf("%s-%d", name, n+1)
+		} else {
+			names[name] = 1
+		}
+
+		data, err := json.MarshalIndent(p.Operations, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode patch for %s: %w", resource(p.Resource), err)
+		}
+
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write patch %s: %w", path, err)
+		}
+
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+// apply applies the operation to doc, preceded by the operations creating
+// its missing parents, and returns the operations actually needed
+func apply(doc interface{}, op linter.PatchOperation) ([]linter.PatchOperation, interface{}, error) {
+	if op.Op != "add" {
+		return []linter.PatchOperation{op}, doc, nil
+	}
+
+	tokens, err := tokens(op.Path)
+	if err != nil {
+		return nil, doc, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, doc, fmt.Errorf("cannot add the object root")
+	}
+
+	var ops []linter.PatchOperation
+
+	// parents holds the containers along the path, so that the values
+	// created while walking it can be linked back into their parent
+	parents := make([]interface{}, len(tokens))
+	current := doc
+
+	for i, token := range tokens[:len(tokens)-1] {
+		parents[i] = current
+
+		next, ok := child(current, token)
+		if !ok {
+			created := container(tokens[i+1])
+
+			pointer := "/" + strings.Join(escape(tokens[:i+1]), "/")
+			ops = append(ops, linter.PatchOperation{Op: "add", Path: pointer, Value: container(tokens[i+1])})
+
+			if !set(current, token, created) {
+				return nil, doc, fmt.Errorf("cannot create %s", pointer)
+			}
+
+			next = created
+		}
+
+		current = next
+	}
+
+	last := tokens[len(tokens)-1]
+
+	// a value already in place makes the operation a no-op, which happens
+	// when several issues share the same fix
+	if existing, ok := child(current, last); ok && last != "-" && reflect.DeepEqual(existing, op.Value) {
+		return ops, doc, nil
+	}
+
+	if list, ok := current.([]interface{}); ok && last == "-" {
+		for _, v := range list {
+			if reflect.DeepEqual(v, op.Value) {
+				return ops, doc, nil
+			}
+		}
+	}
+
+	// the document is modified by later operations, so it gets its own copy
+	// of the value
+	updated, ok := add(current, last, runtime.DeepCopyJSONValue(op.Value))
+	if !ok {
+		return nil, doc, fmt.Errorf("cannot add %s", op.Path)
+	}
+
+	// appending to a slice reallocates it, so the new slice replaces the old
+	// one in its parent
+	if len(tokens) > 1 {
+		set(parents[len(tokens)-2], tokens[len(tokens)-2], updated)
+	} else {
+		doc = updated
+	}
+
+	return append(ops, op), doc, nil
+}
+
+func child(v interface{}, token string) (interface{}, bool) {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		value, ok := c[token]
+		return value, ok && value != nil
+	case []interface{}:
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(c) {
+			return nil, false
+		}
+		return c[i], true
+	default:
+		return nil, false
+	}
+}
+
+func set(v interface{}, token string, value interface{}) bool {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		c[token] = value
+		return true
+	case []interface{}:
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(c) {
+			return false
+		}
+		c[i] = value
+		return true
+	default:
+		return false
+	}
+}
+
+func add(v interface{}, token string, value interface{}) (interface{}, bool) {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		c[token] = value
+		return c, true
+	case []interface{}:
+		if token == "-" {
+			return append(c, value), true
+		}
+
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i > len(c) {
+			return nil, false
+		}
+
+		c = append(c, nil)
+		copy(c[i+1:], c[i:])
+		c[i] = value
+		return c, true
+	default:
+		return nil, false
+	}
+}
+
+func tokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	parts := strings.Split(pointer[1:], "/")
+	for i, p := range parts {
+		parts[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(p)
+	}
+
+	return parts, nil
+}
+
+func escape(tokens []string) []string {
+	escaped := make([]string, len(tokens))
+	for i, t := range tokens {
+		escaped[i] = strings.NewReplacer("~", "~0", "/", "~1").Replace(t)
+	}
+	return escaped
+}
+
+// container returns the empty value holding the given child token
+func container(token string) interface{} {
+	if isIndex(token) {
+		return []interface{}{}
+	}
+	return map[string]interface{}{}
+}
+
+func isIndex(token string) bool {
+	if token == "-" {
+		return true
+	}
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
+
+func find(objects []unstructured.Unstructured, ref linter.ResourceRef) (unstructured.Unstructured, bool) {
+	for _, obj := range objects {
+		if obj.GetAPIVersion() == ref.APIVersion &&
+			obj.GetKind() == ref.Kind &&
+			obj.GetNamespace() == ref.Namespace &&
+			obj.GetName() == ref.Name {
+			return obj, true
+		}
+	}
+	return unstructured.Unstructured{}, false
+}
+
+var unsafeRe = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+func fileName(ref linter.ResourceRef) string {
+	parts := []string{strings.ToLower(ref.Kind)}
+	if ref.Namespace != "" {
+		parts = append(parts, ref.Namespace)
+	}
+	parts = append(parts, ref.Name)
+
+	return unsafeRe.ReplaceAllString(strings.ToLower(strings.Join(parts, "_")), "_")
+}
+
+func resource(ref linter.ResourceRef) string {
+	if ref.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s", ref.Namespace, ref.Kind, ref.Name)
+	}
+	return fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+}
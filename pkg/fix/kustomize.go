@@ -0,0 +1,195 @@
+package fix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	goyaml "gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// DefaultKustomizePatchDir is the directory, relative to the kustomization,
+// the overlay patches are written to
+const DefaultKustomizePatchDir = "k8s-manifests-lint-patches"
+
+// kustomizationFiles are the file names kustomize recognizes, in order of
+// precedence
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// Kustomization returns the kustomization file of the directory, or an empty
+// string when the directory is not a kustomization
+func Kustomization(dir string) string {
+	for _, name := range kustomizationFiles {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+			return file
+		}
+	}
+
+	return ""
+}
+
+// WriteKustomize writes the patches of the resources built by kustomize as
+// patches of the kustomization they have been built from, rather than
+// editing its bases, which are often shared by several overlays. Every patch
+// is written to dir, relative to the kustomization, and referenced by a
+// patches entry targeting the resource. Patches of resources not built by
+// kustomize are skipped. The paths of the written files are returned.
+func WriteKustomize(patches []Patch, dir string) ([]string, error) {
+	byKustomization := make(map[string][]Patch)
+	for _, p := range patches {
+		if p.File == "" || len(p.Operations) == 0 || Kustomization(p.File) == "" {
+			continue
+		}
+		byKustomization[p.File] = append(byKustomization[p.File], p)
+	}
+
+	dirs := make([]string, 0, len(byKustomization))
+	for d := range byKustomization {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+
+	var files []string
+	for _, d := range dirs {
+		written, err := writeKustomization(d, dir, byKustomization[d])
+		if err != nil {
+			return nil, fmt.Errorf("failed to write patches of kustomization %s: %w", d, err)
+		}
+		files = append(files, written...)
+	}
+
+	return files, nil
+}
+
+func writeKustomization(kustomizationDir string, dir string, patches []Patch) ([]string, error) {
+	file := Kustomization(kustomizationDir)
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc goyaml.Node
+	if err := goyaml.NewDecoder(bytes.NewReader(content)).Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		doc = goyaml.Node{Kind: goyaml.DocumentNode, Content: []*goyaml.Node{{Kind: goyaml.MappingNode}}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != goyaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a mapping", file)
+	}
+
+	written, err := Write(filepath.Join(kustomizationDir, dir), patches)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, _ := nodeChild(root, "patches")
+	if entries == nil {
+		entries = &goyaml.Node{Kind: goyaml.SequenceNode}
+		root.Content = append(root.Content, &goyaml.Node{Kind: goyaml.ScalarNode, Tag: "!!str", Value: "patches"}, entries)
+	}
+
+	existing := make(map[string]bool)
+	for _, entry := range entries.Content {
+		if p, _ := nodeChild(entry, "path"); p != nil {
+			existing[p.Value] = true
+		}
+	}
+
+	// Write skips the patches without operations, so the written files are
+	// matched back to the patches they hold in order
+	i := 0
+	for _, p := range patches {
+		if len(p.Operations) == 0 {
+			continue
+		}
+
+		rel := path.Join(filepath.ToSlash(dir), filepath.Base(written[i]))
+		i++
+
+		if existing[rel] {
+			continue
+		}
+
+		entry := &goyaml.Node{}
+		if err := entry.Encode(map[string]interface{}{
+			"path":   rel,
+			"target": target(root, p.Resource),
+		}); err != nil {
+			return nil, err
+		}
+		blockStyle(entry)
+
+		entries.Content = append(entries.Content, entry)
+	}
+
+	var out bytes.Buffer
+
+	enc := goyaml.NewEncoder(&out)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(file, out.Bytes(), info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+
+	return append(written, file), nil
+}
+
+// target returns the patch target selecting the resource. Patches are applied
+// before the name prefix, suffix and namespace of the kustomization, so they
+// are left out of the selector.
+func target(kustomization *goyaml.Node, ref linter.ResourceRef) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	if gv, err := schema.ParseGroupVersion(ref.APIVersion); err == nil {
+		if gv.Group != "" {
+			result["group"] = gv.Group
+		}
+		result["version"] = gv.Version
+	}
+
+	result["kind"] = ref.Kind
+
+	name := ref.Name
+	if prefix, _ := nodeChild(kustomization, "namePrefix"); prefix != nil {
+		name = strings.TrimPrefix(name, prefix.Value)
+	}
+	if suffix, _ := nodeChild(kustomization, "nameSuffix"); suffix != nil {
+		name = strings.TrimSuffix(name, suffix.Value)
+	}
+	result["name"] = name
+
+	if ns, _ := nodeChild(kustomization, "namespace"); ns == nil && ref.Namespace != "" {
+		result["namespace"] = ref.Namespace
+	}
+
+	return result
+}
@@ -0,0 +1,161 @@
+// Package lint is the entrypoint of Go programs embedding k8s-manifests-lint:
+// it runs the built-in and custom linters against unstructured objects,
+// configured with functional options. The built-in linters are configured
+// with their typed Config structs, checked at compile time, rather than with
+// the settings maps of the configuration file.
+package lint
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters"
+)
+
+// runMu serializes the runs, the linters are registered once per process
+// and configured by every run
+var runMu sync.Mutex
+
+// Option configures a run
+type Option func(*options)
+
+type options struct {
+	enabled     []string
+	disabled    []string
+	settings    map[string]map[string]interface{}
+	custom      []config.CustomLinter
+	stability   linter.Stability
+	concurrency int
+	timeout     time.Duration
+	err         error
+}
+
+// WithEnabledLinters only runs the given linters, glob patterns and /regexp/
+// are supported
+func WithEnabledLinters(names ...string) Option {
+	return func(o *options) {
+		o.enabled = append(o.enabled, names...)
+	}
+}
+
+// WithDisabledLinters skips the given linters, glob patterns and /regexp/ are
+// supported
+func WithDisabledLinters(names ...string) Option {
+	return func(o *options) {
+		o.disabled = append(o.disabled, names...)
+	}
+}
+
+// WithSettings sets settings of a linter as in linters.settings of the
+// configuration file, e.g. enable-checks and disable-checks, on top of its
+// typed configuration
+func WithSettings(name string, settings map[string]interface{}) Option {
+	return func(o *options) {
+		if o.settings[name] == nil {
+			o.settings[name] = make(map[string]interface{}, len(settings))
+		}
+		for k, v := range settings {
+			o.settings[name][k] = v
+		}
+	}
+}
+
+// WithCustomLinters adds custom linters, such as jq or assert linters
+func WithCustomLinters(custom ...config.CustomLinter) Option {
+	return func(o *options) {
+		o.custom = append(o.custom, custom...)
+	}
+}
+
+// WithStability skips the experimental linters, unless enabled by name, when
+// set to linter.StabilityStable
+func WithStability(stability linter.Stability) Option {
+	return func(o *options) {
+		o.stability = stability
+	}
+}
+
+// WithConcurrency sets the number of objects linted in parallel
+func WithConcurrency(concurrency int) Option {
+	return func(o *options) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithTimeout sets the maximum time a linter can spend on a single object
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// withConfig replaces the configuration of a built-in linter with a typed one
+func withConfig(name string, cfg interface{}) Option {
+	return func(o *options) {
+		settings, err := linter.EncodeSettings(cfg)
+		if err != nil {
+			o.err = fmt.Errorf("invalid %s options: %w", name, err)
+			return
+		}
+
+		o.settings[name] = settings
+	}
+}
+
+// Lint runs the linters against the objects. Every run starts from the
+// default configuration of the built-in linters, the runner resets the
+// linters implementing linter.Defaulted, with the options applied on top of
+// it, so that the options of a run never leak into the next one.
+func Lint(ctx context.Context, objects []unstructured.Unstructured, opts ...Option) ([]linter.Issue, error) {
+	o := options{settings: make(map[string]map[string]interface{})}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	// the custom linters are registered by the runner, they are removed
+	// afterwards so that they only run as part of this run
+	registered := make(map[string]bool)
+	for _, name := range linter.Names() {
+		registered[name] = true
+	}
+	defer func() {
+		for _, c := range o.custom {
+			if !registered[c.Name] {
+				linter.Unregister(c.Name)
+			}
+		}
+	}()
+
+	runner, err := linter.NewRunner(&linter.RunnerConfig{
+		EnabledLinters:  o.enabled,
+		DisabledLinters: o.disabled,
+		Settings:        o.settings,
+		CustomLinters:   o.custom,
+		Stability:       o.stability,
+		Concurrency:     o.concurrency,
+		Timeout:         o.timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner: %w", err)
+	}
+
+	issues, err := runner.Run(ctx, objects)
+	if err != nil {
+		return nil, fmt.Errorf("linting failed: %w", err)
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,181 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/lint"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/annotations"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/applyorder"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/clusterrolebindingsecurity"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/configreferences"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/egresspolicies"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/forbiddenresources"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/healthprobes"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/imagepullsecrets"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/imagetags"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/namespacelabels"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/openapischema"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podcomplexity"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/poddisruptionbudgets"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podsecurity"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podtemplatemetadata"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/requiredlabels"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/resourcelimits"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/rolloutsafety"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/secretleak"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/securitycontext"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceaccountreferences"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceaccounttokens"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceselectors"
+)
+
+const manifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.27
+`
+
+// count returns the number of issues reported per linter
+func count(issues []linter.Issue) map[string]int {
+	result := make(map[string]int)
+	for _, issue := range issues {
+		result[issue.Linter]++
+	}
+	return result
+}
+
+// TestLintIsolation runs the linters back to back with different options,
+// the options of a run must not leak into the next one
+func TestLintIsolation(t *testing.T) {
+	objects := lintertest.ParseObjects(t, manifests)
+
+	custom := config.CustomLinter{
+		Name: "lint-test-replicas",
+		Type: "jq",
+		Settings: map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{
+					"expression": `$object.spec.replicas < 2`,
+					"message":    "Deployments must run at least 2 replicas",
+				},
+			},
+		},
+	}
+
+	requireDigest := imagetags.DefaultConfig()
+	requireDigest.RequireDigest = true
+
+	runs := []struct {
+		name     string
+		opts     []lint.Option
+		expected map[string]int
+	}{
+		{
+			name: "configured",
+			opts: []lint.Option{
+				lint.WithEnabledLinters("annotations", "required-labels", "image-tags", custom.Name),
+				lint.WithSettings("annotations", map[string]interface{}{
+					"required": []interface{}{map[string]interface{}{"key": "owner"}},
+				}),
+				lint.WithRequiredLabelsOptions(requiredlabels.Config{Labels: []string{"team"}}),
+				lint.WithImageTagsOptions(requireDigest),
+				lint.WithCustomLinters(custom),
+			},
+			expected: map[string]int{
+				"annotations":     1,
+				"required-labels": 1,
+				"image-tags":      1,
+				custom.Name:       1,
+			},
+		},
+		{
+			name: "defaults",
+			opts: []lint.Option{
+				lint.WithEnabledLinters("annotations", "required-labels", "image-tags"),
+			},
+			expected: map[string]int{},
+		},
+	}
+
+	for _, run := range runs {
+		issues, err := lint.Lint(t.Context(), objects, run.opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", run.name, err)
+		}
+
+		reported := count(issues)
+		for _, name := range []string{"annotations", "required-labels", "image-tags", custom.Name} {
+			if reported[name] != run.expected[name] {
+				t.Errorf("%s: expected %d %s issue(s), got %d", run.name, run.expected[name], name, reported[name])
+			}
+		}
+	}
+
+	if _, err := linter.Get(custom.Name); err == nil {
+		t.Errorf("custom linter %s is still registered", custom.Name)
+	}
+}
+
+// TestLintTypedOptions replaces the whole configuration of a linter, lists
+// left unset clear the default ones
+func TestLintTypedOptions(t *testing.T) {
+	objects := lintertest.ParseObjects(t, manifests)
+
+	issues, err := lint.Lint(t.Context(), objects,
+		lint.WithEnabledLinters("required-labels"),
+		lint.WithSettings("required-labels", map[string]interface{}{"labels": []interface{}{"team"}}),
+		lint.WithRequiredLabelsOptions(requiredlabels.Config{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := count(issues)["required-labels"]; n != 0 {
+		t.Errorf("expected no required-labels issue, got %d", n)
+	}
+}
+
+// TestLintDefaultOptions configures every built-in linter with its typed
+// default configuration
+func TestLintDefaultOptions(t *testing.T) {
+	objects := lintertest.ParseObjects(t, manifests)
+
+	if _, err := lint.Lint(t.Context(), objects,
+		lint.WithAnnotationsOptions(annotations.DefaultConfig()),
+		lint.WithApplyOrderOptions(applyorder.DefaultConfig()),
+		lint.WithClusterRoleBindingSecurityOptions(clusterrolebindingsecurity.DefaultConfig()),
+		lint.WithConfigReferencesOptions(configreferences.DefaultConfig()),
+		lint.WithEgressPoliciesOptions(egresspolicies.DefaultConfig()),
+		lint.WithForbiddenResourcesOptions(forbiddenresources.DefaultConfig()),
+		lint.WithHealthProbesOptions(healthprobes.DefaultConfig()),
+		lint.WithImagePullSecretsOptions(imagepullsecrets.DefaultConfig()),
+		lint.WithImageTagsOptions(imagetags.DefaultConfig()),
+		lint.WithNamespaceLabelsOptions(namespacelabels.DefaultConfig()),
+		lint.WithOpenAPISchemaOptions(openapischema.DefaultConfig()),
+		lint.WithPodComplexityOptions(podcomplexity.DefaultConfig()),
+		lint.WithPodDisruptionBudgetsOptions(poddisruptionbudgets.DefaultConfig()),
+		lint.WithPodSecurityOptions(podsecurity.DefaultConfig()),
+		lint.WithPodTemplateMetadataOptions(podtemplatemetadata.DefaultConfig()),
+		lint.WithRequiredLabelsOptions(requiredlabels.DefaultConfig()),
+		lint.WithResourceLimitsOptions(resourcelimits.DefaultConfig()),
+		lint.WithRolloutSafetyOptions(rolloutsafety.DefaultConfig()),
+		lint.WithSecretLeakOptions(secretleak.DefaultConfig()),
+		lint.WithSecurityContextOptions(securitycontext.DefaultConfig()),
+		lint.WithServiceAccountReferencesOptions(serviceaccountreferences.DefaultConfig()),
+		lint.WithServiceAccountTokensOptions(serviceaccounttokens.DefaultConfig()),
+		lint.WithServiceSelectorsOptions(serviceselectors.DefaultConfig()),
+	); err != nil {
+		t.Fatal(err)
+	}
+}
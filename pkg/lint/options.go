@@ -0,0 +1,150 @@
+package lint
+
+import (
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/annotations"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/applyorder"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/clusterrolebindingsecurity"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/configreferences"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/egresspolicies"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/forbiddenresources"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/healthprobes"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/imagepullsecrets"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/imagetags"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/namespacelabels"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/openapischema"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podcomplexity"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/poddisruptionbudgets"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podsecurity"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podtemplatemetadata"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/requiredlabels"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/resourcelimits"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/rolloutsafety"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/secretleak"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/securitycontext"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceaccountreferences"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceaccounttokens"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceselectors"
+)
+
+// The typed options replace the whole configuration of a built-in linter,
+// fields left unset take their zero value: start from the DefaultConfig of
+// the linter package to only change some of them, as in
+//
+//	cfg := imagetags.DefaultConfig()
+//	cfg.RequireDigest = true
+//	issues, err := lint.Lint(ctx, objects, lint.WithImageTagsOptions(cfg))
+
+// WithAnnotationsOptions configures the annotations linter
+func WithAnnotationsOptions(cfg annotations.Config) Option {
+	return withConfig(annotations.Name, cfg)
+}
+
+// WithApplyOrderOptions configures the apply-order linter
+func WithApplyOrderOptions(cfg applyorder.Config) Option {
+	return withConfig(applyorder.Name, cfg)
+}
+
+// WithClusterRoleBindingSecurityOptions configures the cluster-role-binding-security linter
+func WithClusterRoleBindingSecurityOptions(cfg clusterrolebindingsecurity.Config) Option {
+	return withConfig(clusterrolebindingsecurity.Name, cfg)
+}
+
+// WithConfigReferencesOptions configures the config-references linter
+func WithConfigReferencesOptions(cfg configreferences.Config) Option {
+	return withConfig(configreferences.Name, cfg)
+}
+
+// WithEgressPoliciesOptions configures the egress-policies linter
+func WithEgressPoliciesOptions(cfg egresspolicies.Config) Option {
+	return withConfig(egresspolicies.Name, cfg)
+}
+
+// WithForbiddenResourcesOptions configures the forbidden-resources linter
+func WithForbiddenResourcesOptions(cfg forbiddenresources.Config) Option {
+	return withConfig(forbiddenresources.Name, cfg)
+}
+
+// WithHealthProbesOptions configures the health-probes linter
+func WithHealthProbesOptions(cfg healthprobes.Config) Option {
+	return withConfig(healthprobes.Name, cfg)
+}
+
+// WithImagePullSecretsOptions configures the image-pull-secrets linter
+func WithImagePullSecretsOptions(cfg imagepullsecrets.Config) Option {
+	return withConfig(imagepullsecrets.Name, cfg)
+}
+
+// WithImageTagsOptions configures the image-tags linter
+func WithImageTagsOptions(cfg imagetags.Config) Option {
+	return withConfig(imagetags.Name, cfg)
+}
+
+// WithNamespaceLabelsOptions configures the namespace-labels linter
+func WithNamespaceLabelsOptions(cfg namespacelabels.Config) Option {
+	return withConfig(namespacelabels.Name, cfg)
+}
+
+// WithOpenAPISchemaOptions configures the openapi-schema linter
+func WithOpenAPISchemaOptions(cfg openapischema.Config) Option {
+	return withConfig(openapischema.Name, cfg)
+}
+
+// WithPodComplexityOptions configures the pod-complexity linter
+func WithPodComplexityOptions(cfg podcomplexity.Config) Option {
+	return withConfig(podcomplexity.Name, cfg)
+}
+
+// WithPodDisruptionBudgetsOptions configures the pod-disruption-budgets linter
+func WithPodDisruptionBudgetsOptions(cfg poddisruptionbudgets.Config) Option {
+	return withConfig(poddisruptionbudgets.Name, cfg)
+}
+
+// WithPodSecurityOptions configures the pod-security linter
+func WithPodSecurityOptions(cfg podsecurity.Config) Option {
+	return withConfig(podsecurity.Name, cfg)
+}
+
+// WithPodTemplateMetadataOptions configures the pod-template-metadata linter
+func WithPodTemplateMetadataOptions(cfg podtemplatemetadata.Config) Option {
+	return withConfig(podtemplatemetadata.Name, cfg)
+}
+
+// WithRequiredLabelsOptions configures the required-labels linter
+func WithRequiredLabelsOptions(cfg requiredlabels.Config) Option {
+	return withConfig(requiredlabels.Name, cfg)
+}
+
+// WithResourceLimitsOptions configures the resource-limits linter
+func WithResourceLimitsOptions(cfg resourcelimits.Config) Option {
+	return withConfig(resourcelimits.Name, cfg)
+}
+
+// WithRolloutSafetyOptions configures the rollout-safety linter
+func WithRolloutSafetyOptions(cfg rolloutsafety.Config) Option {
+	return withConfig(rolloutsafety.Name, cfg)
+}
+
+// WithSecretLeakOptions configures the secret-leak linter
+func WithSecretLeakOptions(cfg secretleak.Config) Option {
+	return withConfig(secretleak.Name, cfg)
+}
+
+// WithSecurityContextOptions configures the security-context linter
+func WithSecurityContextOptions(cfg securitycontext.Config) Option {
+	return withConfig(securitycontext.Name, cfg)
+}
+
+// WithServiceAccountReferencesOptions configures the service-account-references linter
+func WithServiceAccountReferencesOptions(cfg serviceaccountreferences.Config) Option {
+	return withConfig(serviceaccountreferences.Name, cfg)
+}
+
+// WithServiceAccountTokensOptions configures the service-account-tokens linter
+func WithServiceAccountTokensOptions(cfg serviceaccounttokens.Config) Option {
+	return withConfig(serviceaccounttokens.Name, cfg)
+}
+
+// WithServiceSelectorsOptions configures the service-selectors linter
+func WithServiceSelectorsOptions(cfg serviceselectors.Config) Option {
+	return withConfig(serviceselectors.Name, cfg)
+}
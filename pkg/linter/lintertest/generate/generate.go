@@ -0,0 +1,405 @@
+// Package generate produces randomized but schema-plausible Kubernetes
+// objects, meant to be used by fuzz tests to make sure linters do not choke
+// on missing fields or values of unexpected types.
+package generate
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	DefaultMissingFieldRate = 0.1
+	DefaultOddTypeRate      = 0.05
+)
+
+type Option func(*Generator)
+
+// WithMissingFieldRate sets the probability of an optional field being omitted
+func WithMissingFieldRate(rate float64) Option {
+	return func(g *Generator) {
+		g.missingFieldRate = rate
+	}
+}
+
+// WithOddTypeRate sets the probability of a field holding a value of an
+// unexpected type (e.g. a string where a map is expected)
+func WithOddTypeRate(rate float64) Option {
+	return func(g *Generator) {
+		g.oddTypeRate = rate
+	}
+}
+
+type Generator struct {
+	rand             *rand.Rand
+	missingFieldRate float64
+	oddTypeRate      float64
+}
+
+// New creates a generator with a deterministic seed
+func New(seed uint64, opts ...Option) *Generator {
+	g := &Generator{
+		rand:             rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+		missingFieldRate: DefaultMissingFieldRate,
+		oddTypeRate:      DefaultOddTypeRate,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// FromBytes creates a generator seeded from arbitrary bytes, typically the
+// input of a fuzz target
+func FromBytes(data []byte, opts ...Option) *Generator {
+	seed := make([]byte, 8)
+	copy(seed, data)
+	return New(binary.LittleEndian.Uint64(seed), opts...)
+}
+
+// Object returns an object of a randomly selected kind
+func (g *Generator) Object() unstructured.Unstructured {
+	generators := []func() unstructured.Unstructured{
+		g.Deployment,
+		g.StatefulSet,
+		g.DaemonSet,
+		g.Job,
+		g.CronJob,
+		g.Pod,
+		g.Service,
+		g.ServiceAccount,
+		g.ConfigMap,
+		g.Role,
+		g.ClusterRole,
+		g.RoleBinding,
+		g.ClusterRoleBinding,
+	}
+
+	return generators[g.rand.IntN(len(generators))]()
+}
+
+// Objects returns n objects of random kinds
+func (g *Generator) Objects(n int) []unstructured.Unstructured {
+	objects := make([]unstructured.Unstructured, 0, n)
+	for range n {
+		objects = append(objects, g.Object())
+	}
+	return objects
+}
+
+func (g *Generator) Deployment() unstructured.Unstructured {
+	spec := map[string]interface{}{}
+	g.set(spec, "replicas", int64(g.rand.IntN(5)))
+	g.set(spec, "selector", map[string]interface{}{"matchLabels": g.labels()})
+	g.set(spec, "template", g.podTemplate())
+	return g.object("apps/v1", "Deployment", true, spec)
+}
+
+func (g *Generator) StatefulSet() unstructured.Unstructured {
+	spec := map[string]interface{}{}
+	g.set(spec, "replicas", int64(g.rand.IntN(5)))
+	g.set(spec, "serviceName", g.name())
+	g.set(spec, "selector", map[string]interface{}{"matchLabels": g.labels()})
+	g.set(spec, "template", g.podTemplate())
+	return g.object("apps/v1", "StatefulSet", true, spec)
+}
+
+func (g *Generator) DaemonSet() unstructured.Unstructured {
+	spec := map[string]interface{}{}
+	g.set(spec, "selector", map[string]interface{}{"matchLabels": g.labels()})
+	g.set(spec, "template", g.podTemplate())
+	return g.object("apps/v1", "DaemonSet", true, spec)
+}
+
+func (g *Generator) Job() unstructured.Unstructured {
+	spec := map[string]interface{}{}
+	g.set(spec, "backoffLimit", int64(g.rand.IntN(10)))
+	g.set(spec, "template", g.podTemplate())
+	return g.object("batch/v1", "Job", true, spec)
+}
+
+func (g *Generator) CronJob() unstructured.Unstructured {
+	jobSpec := map[string]interface{}{}
+	g.set(jobSpec, "template", g.podTemplate())
+
+	spec := map[string]interface{}{}
+	g.set(spec, "schedule", "*/5 * * * *")
+	g.set(spec, "jobTemplate", map[string]interface{}{"spec": jobSpec})
+	return g.object("batch/v1", "CronJob", true, spec)
+}
+
+func (g *Generator) Pod() unstructured.Unstructured {
+	return g.object("v1", "Pod", true, g.podSpec())
+}
+
+func (g *Generator) Service() unstructured.Unstructured {
+	ports := make([]interface{}, 0)
+	for range g.rand.IntN(3) + 1 {
+		port := map[string]interface{}{}
+		g.set(port, "port", int64(g.rand.IntN(65535)+1))
+		g.set(port, "targetPort", int64(g.rand.IntN(65535)+1))
+		g.set(port, "protocol", g.pick("TCP", "UDP"))
+		ports = append(ports, port)
+	}
+
+	spec := map[string]interface{}{}
+	g.set(spec, "type", g.pick("ClusterIP", "NodePort", "LoadBalancer"))
+	g.set(spec, "selector", g.labels())
+	g.set(spec, "ports", ports)
+	return g.object("v1", "Service", true, spec)
+}
+
+func (g *Generator) ServiceAccount() unstructured.Unstructured {
+	obj := g.object("v1", "ServiceAccount", true, nil)
+	g.set(obj.Object, "automountServiceAccountToken", g.rand.IntN(2) == 0)
+	return obj
+}
+
+func (g *Generator) ConfigMap() unstructured.Unstructured {
+	data := map[string]interface{}{}
+	for range g.rand.IntN(4) {
+		data[g.name()] = g.name()
+	}
+
+	obj := g.object("v1", "ConfigMap", true, nil)
+	g.set(obj.Object, "data", data)
+	return obj
+}
+
+func (g *Generator) Role() unstructured.Unstructured {
+	obj := g.object("rbac.authorization.k8s.io/v1", "Role", true, nil)
+	g.set(obj.Object, "rules", g.policyRules())
+	return obj
+}
+
+func (g *Generator) ClusterRole() unstructured.Unstructured {
+	obj := g.object("rbac.authorization.k8s.io/v1", "ClusterRole", false, nil)
+	g.set(obj.Object, "rules", g.policyRules())
+	return obj
+}
+
+func (g *Generator) RoleBinding() unstructured.Unstructured {
+	obj := g.object("rbac.authorization.k8s.io/v1", "RoleBinding", true, nil)
+	g.set(obj.Object, "roleRef", g.roleRef("Role"))
+	g.set(obj.Object, "subjects", g.subjects())
+	return obj
+}
+
+func (g *Generator) ClusterRoleBinding() unstructured.Unstructured {
+	obj := g.object("rbac.authorization.k8s.io/v1", "ClusterRoleBinding", false, nil)
+	g.set(obj.Object, "roleRef", g.roleRef("ClusterRole"))
+	g.set(obj.Object, "subjects", g.subjects())
+	return obj
+}
+
+func (g *Generator) object(apiVersion string, kind string, namespaced bool, spec map[string]interface{}) unstructured.Unstructured {
+	metadata := map[string]interface{}{
+		"name": g.name(),
+	}
+	if namespaced {
+		g.set(metadata, "namespace", g.pick("default", "kube-system", "team-a", "team-b"))
+	}
+	g.set(metadata, "labels", g.labels())
+	g.set(metadata, "annotations", g.labels())
+
+	obj := map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   metadata,
+	}
+	if spec != nil {
+		g.set(obj, "spec", spec)
+	}
+
+	return unstructured.Unstructured{Object: obj}
+}
+
+func (g *Generator) podTemplate() map[string]interface{} {
+	template := map[string]interface{}{}
+	g.set(template, "metadata", map[string]interface{}{"labels": g.labels()})
+	g.set(template, "spec", g.podSpec())
+	return template
+}
+
+func (g *Generator) podSpec() map[string]interface{} {
+	containers := make([]interface{}, 0)
+	for range g.rand.IntN(3) + 1 {
+		containers = append(containers, g.container())
+	}
+
+	spec := map[string]interface{}{}
+	g.set(spec, "containers", containers)
+	g.set(spec, "serviceAccountName", g.name())
+	g.set(spec, "securityContext", g.securityContext())
+
+	if g.rand.IntN(2) == 0 {
+		g.set(spec, "initContainers", []interface{}{g.container()})
+	}
+
+	if g.rand.IntN(2) == 0 {
+		volume := map[string]interface{}{"name": g.name()}
+		g.set(volume, "configMap", map[string]interface{}{"name": g.name()})
+		g.set(spec, "volumes", []interface{}{volume})
+	}
+
+	return spec
+}
+
+func (g *Generator) container() map[string]interface{} {
+	container := map[string]interface{}{}
+	g.set(container, "name", g.name())
+	g.set(container, "image", g.image())
+	g.set(container, "resources", g.resources())
+	g.set(container, "securityContext", g.securityContext())
+	g.set(container, "livenessProbe", g.probe())
+	g.set(container, "readinessProbe", g.probe())
+	g.set(container, "ports", []interface{}{
+		map[string]interface{}{"containerPort": int64(g.rand.IntN(65535) + 1)},
+	})
+	g.set(container, "env", []interface{}{
+		map[string]interface{}{"name": "NAME", "value": g.name()},
+	})
+	return container
+}
+
+func (g *Generator) resources() map[string]interface{} {
+	limits := map[string]interface{}{}
+	g.set(limits, "cpu", g.pick("100m", "1", "2000m"))
+	g.set(limits, "memory", g.pick("64Mi", "1Gi", "512M"))
+
+	requests := map[string]interface{}{}
+	g.set(requests, "cpu", g.pick("10m", "500m"))
+	g.set(requests, "memory", g.pick("32Mi", "256Mi"))
+
+	resources := map[string]interface{}{}
+	g.set(resources, "limits", limits)
+	g.set(resources, "requests", requests)
+	return resources
+}
+
+func (g *Generator) securityContext() map[string]interface{} {
+	sc := map[string]interface{}{}
+	g.set(sc, "runAsNonRoot", g.rand.IntN(2) == 0)
+	g.set(sc, "runAsUser", int64(g.rand.IntN(2000)))
+	g.set(sc, "privileged", g.rand.IntN(4) == 0)
+	g.set(sc, "allowPrivilegeEscalation", g.rand.IntN(2) == 0)
+	g.set(sc, "readOnlyRootFilesystem", g.rand.IntN(2) == 0)
+	g.set(sc, "capabilities", map[string]interface{}{
+		"add":  []interface{}{g.pick("NET_ADMIN", "SYS_ADMIN", "NET_BIND_SERVICE")},
+		"drop": []interface{}{g.pick("ALL", "NET_RAW")},
+	})
+	return sc
+}
+
+func (g *Generator) probe() map[string]interface{} {
+	probe := map[string]interface{}{}
+	g.set(probe, "httpGet", map[string]interface{}{
+		"path": g.pick("/", "/healthz", "/ready"),
+		"port": int64(8080),
+	})
+	g.set(probe, "periodSeconds", int64(g.rand.IntN(30)+1))
+	g.set(probe, "timeoutSeconds", int64(g.rand.IntN(30)+1))
+	g.set(probe, "failureThreshold", int64(g.rand.IntN(5)+1))
+	return probe
+}
+
+func (g *Generator) policyRules() []interface{} {
+	rules := make([]interface{}, 0)
+	for range g.rand.IntN(3) + 1 {
+		rule := map[string]interface{}{}
+		g.set(rule, "apiGroups", []interface{}{g.pick("", "apps", "*")})
+		g.set(rule, "resources", []interface{}{g.pick("pods", "secrets", "deployments", "*")})
+		g.set(rule, "verbs", []interface{}{g.pick("get", "list", "create", "*")})
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (g *Generator) roleRef(kind string) map[string]interface{} {
+	ref := map[string]interface{}{}
+	g.set(ref, "apiGroup", "rbac.authorization.k8s.io")
+	g.set(ref, "kind", kind)
+	g.set(ref, "name", g.pick("cluster-admin", "admin", "view", g.name()))
+	return ref
+}
+
+func (g *Generator) subjects() []interface{} {
+	subjects := make([]interface{}, 0)
+	for range g.rand.IntN(3) + 1 {
+		subject := map[string]interface{}{}
+		switch g.rand.IntN(3) {
+		case 0:
+			g.set(subject, "kind", "Group")
+			g.set(subject, "name", g.pick("system:authenticated", "system:serviceaccounts", "system:serviceaccounts:team-a", g.name()))
+		case 1:
+			g.set(subject, "kind", "User")
+			g.set(subject, "name", g.name())
+		default:
+			g.set(subject, "kind", "ServiceAccount")
+			g.set(subject, "name", g.name())
+			g.set(subject, "namespace", g.pick("default", "team-a"))
+		}
+		subjects = append(subjects, subject)
+	}
+	return subjects
+}
+
+func (g *Generator) labels() map[string]interface{} {
+	labels := map[string]interface{}{}
+	for range g.rand.IntN(4) {
+		labels[g.pick("app", "version", "team", "app.kubernetes.io/name")] = g.name()
+	}
+	return labels
+}
+
+func (g *Generator) image() string {
+	registry := g.pick("", "docker.io/", "gcr.io/", "quay.io/", "localhost:5000/")
+	tag := g.pick("", ":latest", ":1.2.3", ":v2", "@sha256:0123456789abcdef")
+	return registry + g.pick("nginx", "library/redis", "org/app") + tag
+}
+
+func (g *Generator) name() string {
+	return fmt.Sprintf("%s-%d", g.pick("app", "web", "db", "worker"), g.rand.IntN(100))
+}
+
+func (g *Generator) pick(values ...string) string {
+	return values[g.rand.IntN(len(values))]
+}
+
+// set assigns the value to the key, unless the field is randomly omitted or
+// replaced by a value of an unexpected type
+func (g *Generator) set(m map[string]interface{}, key string, value interface{}) {
+	if g.rand.Float64() < g.missingFieldRate {
+		return
+	}
+
+	if g.rand.Float64() < g.oddTypeRate {
+		m[key] = g.oddValue(value)
+		return
+	}
+
+	m[key] = value
+}
+
+func (g *Generator) oddValue(value interface{}) interface{} {
+	candidates := []interface{}{
+		nil,
+		"unexpected",
+		int64(42),
+		true,
+		[]interface{}{"unexpected"},
+		map[string]interface{}{"unexpected": "value"},
+	}
+
+	for {
+		odd := candidates[g.rand.IntN(len(candidates))]
+		if fmt.Sprintf("%T", odd) != fmt.Sprintf("%T", value) {
+			return odd
+		}
+	}
+}
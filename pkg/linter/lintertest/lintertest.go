@@ -0,0 +1,212 @@
+// Package lintertest provides helpers to write tests for linters: loading
+// fixtures into unstructured objects, running a linter the same way the
+// runner does and asserting on the reported issues.
+package lintertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lib/pkg/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// UpdateGoldenEnv is the environment variable that, when set to a non empty
+// value, makes AssertGolden rewrite golden files instead of comparing them
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// Expectation describes an expected issue. Empty fields are not compared and
+// Message matches as a substring.
+type Expectation struct {
+	Severity linter.Severity
+	Linter   string
+	Message  string
+	Field    string
+	Kind     string
+	Name     string
+}
+
+// ParseObjects decodes a multi-document YAML string into unstructured objects
+func ParseObjects(t testing.TB, content string) []unstructured.Unstructured {
+	t.Helper()
+
+	decoder := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
+	objects, err := util.DecodeYAML(decoder, []byte(content))
+	if err != nil {
+		t.Fatalf("failed to decode objects: %v", err)
+	}
+
+	return objects
+}
+
+// LoadObjects reads a YAML fixture file into unstructured objects
+func LoadObjects(t testing.TB, path string) []unstructured.Unstructured {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read fixture %q: %v", path, err)
+	}
+
+	return ParseObjects(t, string(content))
+}
+
+// Configure applies settings to the linter, failing the test on error
+func Configure(t testing.TB, l linter.Linter, settings map[string]interface{}) {
+	t.Helper()
+
+	if err := l.Configure(settings); err != nil {
+		t.Fatalf("failed to configure linter %q: %v", l.Name(), err)
+	}
+}
+
+// Run lints every object with the given linter, exposing the whole set to
+// cross-object linters, and returns all the reported issues. Set linters are
+// run once against all the objects, as the runner does.
+func Run(t testing.TB, l linter.Linter, objects ...unstructured.Unstructured) []linter.Issue {
+	t.Helper()
+
+	ctx := linter.WithAllObjects(context.Background(), objects)
+
+	if sl, ok := l.(linter.SetLinter); ok {
+		issues, err := sl.LintSet(ctx, objects)
+		if err != nil {
+			t.Fatalf("linter %q failed: %v", l.Name(), err)
+		}
+		return issues
+	}
+
+	var issues []linter.Issue
+	for i, obj := range objects {
+		objIssues, err := l.Lint(linter.WithObjectIndex(ctx, i), obj)
+		if err != nil {
+			t.Fatalf("linter %q failed on %s/%s: %v", l.Name(), obj.GetKind(), obj.GetName(), err)
+		}
+		issues = append(issues, objIssues...)
+	}
+
+	return issues
+}
+
+// RunFile loads a fixture file and lints all the objects it contains
+func RunFile(t testing.TB, l linter.Linter, path string) []linter.Issue {
+	t.Helper()
+
+	return Run(t, l, LoadObjects(t, path)...)
+}
+
+// AssertNoIssues fails the test if any issue has been reported
+func AssertNoIssues(t testing.TB, issues []linter.Issue) {
+	t.Helper()
+
+	for _, issue := range issues {
+		t.Errorf("unexpected issue: %s", describe(issue))
+	}
+}
+
+// AssertIssues checks that the issues match the expectations, in order
+func AssertIssues(t testing.TB, issues []linter.Issue, expected ...Expectation) {
+	t.Helper()
+
+	if len(issues) != len(expected) {
+		for _, issue := range issues {
+			t.Logf("reported: %s", describe(issue))
+		}
+		t.Fatalf("expected %d issue(s), got %d", len(expected), len(issues))
+	}
+
+	for i, e := range expected {
+		if !e.Matches(issues[i]) {
+			t.Errorf("issue %d: %s does not match %+v", i, describe(issues[i]), e)
+		}
+	}
+}
+
+// AssertContains checks that at least one issue matches each expectation
+func AssertContains(t testing.TB, issues []linter.Issue, expected ...Expectation) {
+	t.Helper()
+
+	for _, e := range expected {
+		found := false
+		for _, issue := range issues {
+			if e.Matches(issue) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no issue matches %+v", e)
+		}
+	}
+}
+
+// AssertGolden compares the JSON encoding of the issues with a golden file.
+// Run the tests with UPDATE_GOLDEN=1 to create or refresh golden files.
+func AssertGolden(t testing.TB, issues []linter.Issue, goldenPath string) {
+	t.Helper()
+
+	if issues == nil {
+		issues = []linter.Issue{}
+	}
+
+	actual, err := json.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode issues: %v", err)
+	}
+	actual = append(actual, '\n')
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+			t.Fatalf("failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(goldenPath, actual, 0o644); err != nil {
+			t.Fatalf("failed to write golden file %q: %v", goldenPath, err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden file %q (set %s=1 to create it): %v", goldenPath, UpdateGoldenEnv, err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("issues do not match golden file %q\n--- expected\n%s\n--- actual\n%s", goldenPath, expected, actual)
+	}
+}
+
+// Matches reports whether the issue satisfies the expectation
+func (e Expectation) Matches(issue linter.Issue) bool {
+	if e.Severity != "" && e.Severity != issue.Severity {
+		return false
+	}
+	if e.Linter != "" && e.Linter != issue.Linter {
+		return false
+	}
+	if e.Message != "" && !strings.Contains(issue.Message, e.Message) {
+		return false
+	}
+	if e.Field != "" && e.Field != issue.Field {
+		return false
+	}
+	if e.Kind != "" && e.Kind != issue.Resource.Kind {
+		return false
+	}
+	if e.Name != "" && e.Name != issue.Resource.Name {
+		return false
+	}
+	return true
+}
+
+func describe(issue linter.Issue) string {
+	return "[" + string(issue.Severity) + "] " + issue.Resource.Kind + "/" + issue.Resource.Name +
+		": " + issue.Message + " (" + issue.Linter + ") field=" + issue.Field
+}
@@ -0,0 +1,187 @@
+package linter
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+)
+
+// SettingAnnotationPrefix prefixes the annotations overriding a linter
+// setting for the annotated object, the annotation name is the linter name
+// and the setting separated by a dot, as in
+// k8s-manifests-lint/resource-limits.require-cpu-limit: "false"
+const SettingAnnotationPrefix = "k8s-manifests-lint/"
+
+// ObjectSettings returns the settings of the linter overridden by the
+// annotations of the object, keyed by setting name
+func ObjectSettings(obj unstructured.Unstructured, name string) map[string]string {
+	prefix := SettingAnnotationPrefix + name + "."
+
+	var settings map[string]string
+	for key, value := range obj.GetAnnotations() {
+		setting, ok := strings.CutPrefix(key, prefix)
+		if !ok || setting == "" {
+			continue
+		}
+
+		if settings == nil {
+			settings = make(map[string]string)
+		}
+		settings[setting] = value
+	}
+
+	return settings
+}
+
+// Overridable is implemented by the linters reading the settings overridden
+// for an object with ObjectConfig. The runner reports the annotations
+// overriding the settings of the other linters, which would be silently
+// ignored otherwise.
+type Overridable interface {
+	Linter
+	// SupportsOverrides tells whether the settings can be overridden
+	SupportsOverrides() bool
+}
+
+// SupportsOverrides tells whether the settings of the linter can be
+// overridden for a single object
+func SupportsOverrides(l Linter) bool {
+	o, ok := l.(Overridable)
+	return ok && o.SupportsOverrides()
+}
+
+// ObjectConfig returns the linter configuration with the settings overridden
+// by the annotations of the object decoded on top of it, and whether any
+// setting has been overridden. Values are strings converted to the type of
+// the setting, lists are comma separated. Invalid overrides, such as unknown
+// settings or values of the wrong type, are ignored and returned as issues
+// on the object, so that a misspelled annotation does not abort the run.
+func ObjectConfig[T any](obj unstructured.Unstructured, name string, config T) (T, bool, []Issue) {
+	overrides := ObjectSettings(obj, name)
+	if len(overrides) == 0 {
+		return config, false, nil
+	}
+
+	base := make(map[string]interface{})
+	if err := mapstructure.Decode(config, &base); err != nil {
+		return config, false, []Issue{
+			InvalidOverride(obj, name, "", fmt.Sprintf("Failed to read %s settings: %v", name, err)),
+		}
+	}
+
+	settings := maps.Clone(base)
+
+	keys := make([]string, 0, len(overrides))
+	for setting := range overrides {
+		keys = append(keys, setting)
+	}
+	sort.Strings(keys)
+
+	var issues []Issue
+	for _, setting := range keys {
+		if _, ok := base[setting]; !ok {
+			issues = append(issues, InvalidOverride(obj, name, setting,
+				fmt.Sprintf("Unknown %s setting %q, the annotation is ignored", name, setting)))
+			continue
+		}
+
+		// every override is checked on its own, so that an invalid one does
+		// not discard the others
+		candidate := maps.Clone(base)
+		candidate[setting] = overrides[setting]
+
+		if _, err := decodeConfig[T](candidate); err != nil {
+			issues = append(issues, InvalidOverride(obj, name, setting,
+				fmt.Sprintf("Invalid value %q for %s setting %q, the annotation is ignored: %s", overrides[setting], name, setting, errorText(err))))
+			continue
+		}
+
+		settings[setting] = overrides[setting]
+	}
+
+	if len(issues) == len(keys) {
+		return config, false, issues
+	}
+
+	result, err := decodeConfig[T](settings)
+	if err != nil {
+		return config, false, append(issues, InvalidOverride(obj, name, "",
+			fmt.Sprintf("Invalid %s annotations, they are ignored: %s", name, errorText(err))))
+	}
+
+	return result, true, issues
+}
+
+// InvalidOverride returns the issue reporting an invalid override of the
+// setting of the linter, or of all its settings when setting is empty
+func InvalidOverride(obj unstructured.Unstructured, name string, setting string, message string) Issue {
+	issue := Issue{
+		Severity: SeverityWarning,
+		Linter:   name,
+		Message:  message,
+		Resource: resourceRef(obj),
+	}
+
+	if setting != "" {
+		issue.Field = fieldpath.Path("metadata", "annotations", SettingAnnotationPrefix+name+"."+setting)
+	}
+
+	return issue
+}
+
+// decodeConfig decodes the settings into a new configuration, so that the
+// slices of the linter configuration are never modified
+func decodeConfig[T any](settings map[string]interface{}) (T, error) {
+	var result T
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       stringToTrimmedSlice,
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+		Result:           &result,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = decoder.Decode(settings)
+
+	return result, err
+}
+
+// errorText returns the message of a decoding error on a single line
+func errorText(err error) string {
+	var merr *mapstructure.Error
+	if errors.As(err, &merr) {
+		return strings.Join(merr.Errors, ", ")
+	}
+
+	return err.Error()
+}
+
+// stringToTrimmedSlice splits comma separated strings decoded into slices
+func stringToTrimmedSlice(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to.Kind() != reflect.Slice {
+		return data, nil
+	}
+
+	value := data.(string)
+	if value == "" {
+		return []string{}, nil
+	}
+
+	parts := strings.Split(value, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	return parts, nil
+}
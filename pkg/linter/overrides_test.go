@@ -0,0 +1,103 @@
+package linter_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters"
+)
+
+type overridesConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Limit   int      `mapstructure:"limit"`
+	Names   []string `mapstructure:"names"`
+}
+
+func TestObjectConfig(t *testing.T) {
+	obj := lintertest.ParseObjects(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  annotations:
+    k8s-manifests-lint/test.enabled: "false"
+    k8s-manifests-lint/test.names: "a, b"
+    k8s-manifests-lint/test.limit: "many"
+    k8s-manifests-lint/test.unknown: "true"
+`)[0]
+
+	base := overridesConfig{Enabled: true, Limit: 1, Names: []string{"c"}}
+
+	config, overridden, issues := linter.ObjectConfig(obj, "test", base)
+	if !overridden {
+		t.Fatal("expected the valid settings to be overridden")
+	}
+
+	if config.Enabled || config.Limit != 1 || strings.Join(config.Names, ",") != "a,b" {
+		t.Errorf("unexpected configuration %+v", config)
+	}
+
+	if strings.Join(base.Names, ",") != "c" {
+		t.Errorf("the linter configuration has been modified: %+v", base)
+	}
+
+	lintertest.AssertIssues(t, issues,
+		lintertest.Expectation{
+			Severity: linter.SeverityWarning,
+			Message:  `Invalid value "many" for test setting "limit"`,
+			Field:    "$.metadata.annotations['k8s-manifests-lint/test.limit']",
+		},
+		lintertest.Expectation{
+			Severity: linter.SeverityWarning,
+			Message:  `Unknown test setting "unknown"`,
+			Field:    "$.metadata.annotations['k8s-manifests-lint/test.unknown']",
+		},
+	)
+}
+
+// TestRunnerOverrides keeps linting objects with invalid overrides and
+// reports the overrides of linters not supporting them
+func TestRunnerOverrides(t *testing.T) {
+	objects := lintertest.ParseObjects(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    k8s-manifests-lint/required-labels.lables: "team"
+    k8s-manifests-lint/pod-complexity.max-containers: "10"
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.27
+`)
+
+	runner, err := linter.NewRunner(&linter.RunnerConfig{
+		EnabledLinters: []string{"required-labels", "pod-complexity"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := runner.Run(context.Background(), objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lintertest.AssertIssues(t, issues,
+		lintertest.Expectation{
+			Linter:  "pod-complexity",
+			Message: "cannot be overridden per object",
+		},
+		lintertest.Expectation{
+			Linter:  "required-labels",
+			Message: `Unknown required-labels setting "lables"`,
+		},
+	)
+}
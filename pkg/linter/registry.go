@@ -2,7 +2,10 @@ package linter
 
 import (
 	"fmt"
+	"path"
+	"regexp"
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -23,6 +26,13 @@ func Register(linter Linter) {
 	registry.linters[linter.Name()] = linter
 }
 
+// Unregister removes a linter, e.g. a custom linter registered by a run
+func Unregister(name string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.linters, name)
+}
+
 func Get(name string) (Linter, error) {
 	registry.mu.RLock()
 	defer registry.mu.RUnlock()
@@ -63,6 +73,74 @@ func Names() []string {
 	return names
 }
 
+// IsPattern reports whether the given linter reference is a glob pattern
+// (image-*) or a regular expression enclosed in slashes (/^image-.*$/)
+// rather than a plain linter name
+func IsPattern(ref string) bool {
+	if isRegexp(ref) {
+		return true
+	}
+	return strings.ContainsAny(ref, "*?[")
+}
+
+// Match reports whether the linter name matches the given reference, which
+// can either be a plain name, a glob pattern or a regular expression
+func Match(ref string, name string) (bool, error) {
+	if isRegexp(ref) {
+		re, err := regexp.Compile(ref[1 : len(ref)-1])
+		if err != nil {
+			return false, fmt.Errorf("invalid linter pattern %q: %w", ref, err)
+		}
+		return re.MatchString(name), nil
+	}
+
+	matched, err := path.Match(ref, name)
+	if err != nil {
+		return false, fmt.Errorf("invalid linter pattern %q: %w", ref, err)
+	}
+	return matched, nil
+}
+
+// Resolve expands the given linter references against the registered linters
+// and returns the sorted list of matching names. An error is returned if a
+// reference does not match any linter.
+func Resolve(refs []string) ([]string, error) {
+	names := Names()
+	resolved := make(map[string]bool)
+
+	for _, ref := range refs {
+		found := false
+		for _, name := range names {
+			matched, err := Match(ref, name)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				resolved[name] = true
+				found = true
+			}
+		}
+
+		if !found {
+			if IsPattern(ref) {
+				return nil, fmt.Errorf("linter pattern %q does not match any linter", ref)
+			}
+			return nil, fmt.Errorf("linter %q not found", ref)
+		}
+	}
+
+	result := make([]string, 0, len(resolved))
+	for name := range resolved {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func isRegexp(ref string) bool {
+	return len(ref) > 2 && strings.HasPrefix(ref, "/") && strings.HasSuffix(ref, "/")
+}
+
 type Factory interface {
 	Create(name string, description string) Linter
 }
@@ -73,6 +151,13 @@ func RegisterFactory(linterType string, factory Factory) {
 	factories[linterType] = factory
 }
 
+// IsType reports whether the name is a custom linter type, such as jq,
+// registered with a factory
+func IsType(name string) bool {
+	_, ok := factories[name]
+	return ok
+}
+
 func CreateLinter(linterType string, name string, description string) (Linter, error) {
 	factory, ok := factories[linterType]
 	if !ok {
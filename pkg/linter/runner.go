@@ -2,11 +2,20 @@ package linter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/jq"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
 )
 
 type RunnerConfig struct {
@@ -14,14 +23,177 @@ type RunnerConfig struct {
 	DisabledLinters []string
 	Settings        map[string]map[string]interface{}
 	CustomLinters   []config.CustomLinter
+	// Stability, when set to StabilityStable, skips experimental linters
+	// unless they are enabled by name
+	Stability Stability
+	// Timeout is the maximum time a linter can spend on a single object,
+	// zero means no limit
+	Timeout time.Duration
+	// Timeouts overrides Timeout for specific linters, keyed by linter name
+	// or pattern as the settings are
+	Timeouts map[string]time.Duration
+	// Concurrency is the number of objects linted in parallel, zero means
+	// as many as GOMAXPROCS
+	Concurrency int
+	// OnObjectLinted, if set, is invoked after all the linters have been
+	// run against an object with the issues reported for it. It may be
+	// invoked concurrently when Concurrency is greater than one.
+	OnObjectLinted func(obj unstructured.Unstructured, issues []Issue)
+	// Exclude, if set, drops the issues for which it returns true, path is
+	// the file the object has been loaded from if known
+	Exclude func(issue Issue, path string) bool
+	// Scopes are the linters and exclusions of the objects attributed to a
+	// source, keyed by the source name held by SourceNameAnnotation
+	Scopes map[string]Scope
+}
+
+// Scope selects the linters run against the objects of a source and the
+// issues excluded for them
+type Scope struct {
+	// EnabledLinters and DisabledLinters replace the ones of the runner when
+	// set
+	EnabledLinters  []string
+	DisabledLinters []string
+	// Exclude, if set, drops the issues for which it returns true on top of
+	// the ones dropped by the Exclude of the runner
+	Exclude func(issue Issue, path string) bool
+}
+
+// objectMeta holds what the runner needs to know about an object from its
+// internal annotations
+type objectMeta struct {
+	disabled []string
+	path     string
+	scope    string
+	source   *SourceRef
+	file     string
+	position *sourcePosition
+}
+
+// selection holds the linters resolved from the enabled and disabled
+// references of a configuration
+type selection struct {
+	enabled   map[string]bool
+	explicit  map[string]bool
+	disabled  map[string]bool
+	stability Stability
+}
+
+func newSelection(enabledLinters []string, disabledLinters []string, stability Stability) (*selection, error) {
+	enabled, err := Resolve(enabledLinters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enabled linters: %w", err)
+	}
+
+	s := &selection{
+		enabled:   make(map[string]bool),
+		explicit:  make(map[string]bool),
+		disabled:  make(map[string]bool),
+		stability: stability,
+	}
+
+	for _, name := range enabled {
+		s.enabled[name] = true
+	}
+
+	for _, ref := range enabledLinters {
+		if !IsPattern(ref) {
+			s.explicit[ref] = true
+		}
+	}
+
+	disabled, err := Resolve(disabledLinters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid disabled linters: %w", err)
+	}
+
+	for _, name := range disabled {
+		s.disabled[name] = true
+	}
+
+	return s, nil
+}
+
+// selects tells whether the linter is selected
+func (s *selection) selects(l Linter) bool {
+	name := l.Name()
+
+	if len(s.enabled) > 0 && !s.enabled[name] {
+		return false
+	}
+
+	if s.disabled[name] {
+		return false
+	}
+
+	if s.stability == StabilityStable && StabilityOf(l) == StabilityExperimental && !s.explicit[name] {
+		return false
+	}
+
+	return true
+}
+
+// scope holds the linters selected for the objects of a source
+type scope struct {
+	linters map[string]bool
+	exclude func(issue Issue, path string) bool
+}
+
+// checkFilter holds the enable-checks and disable-checks settings of a
+// linter
+type checkFilter struct {
+	enabled  map[string]bool
+	disabled map[string]bool
+}
+
+// skip tells whether the issues of the check are dropped, issues not
+// reported by a specific check are always kept
+func (f *checkFilter) skip(check string) bool {
+	if f == nil || check == "" {
+		return false
+	}
+	if len(f.enabled) > 0 && !f.enabled[check] {
+		return true
+	}
+	return f.disabled[check]
 }
 
 type Runner struct {
 	linters []Linter
 	config  *RunnerConfig
+	docURLs map[string]string
+	checks  map[string]*checkFilter
+	// selected are the linters of the objects without a scope
+	selected map[string]bool
+	scopes   map[string]*scope
+
+	// timeouts are the Timeouts of the configuration resolved per linter
+	timeouts map[string]time.Duration
+
+	// overridable are the registered linters whose settings can be
+	// overridden per object
+	overridable []string
+
+	mu         sync.Mutex
+	suppressed map[string]int
+	stats      map[string]*LinterStats
+}
+
+// LinterStats holds the time spent in a linter during a run and the number
+// of issues it reported
+type LinterStats struct {
+	Name string `json:"name"`
+	// Duration is the time spent in the linter, summed across the
+	// concurrent workers
+	Duration time.Duration `json:"duration"`
+	// Calls is the number of objects linted, or 1 for set linters
+	Calls  int `json:"calls"`
+	Issues int `json:"issues"`
 }
 
 func NewRunner(config *RunnerConfig) (*Runner, error) {
+	docURLs := make(map[string]string)
+
 	for _, customLinter := range config.CustomLinters {
 		if customLinter.Name == "" {
 			return nil, fmt.Errorf("custom linter name is required")
@@ -41,64 +213,669 @@ func NewRunner(config *RunnerConfig) (*Runner, error) {
 			}
 		}
 
+		if customLinter.DocURL != "" {
+			docURLs[customLinter.Name] = customLinter.DocURL
+		}
+
 		Register(l)
 	}
 
-	enabledMap := make(map[string]bool)
-	for _, name := range config.EnabledLinters {
-		enabledMap[name] = true
+	selected, err := newSelection(config.EnabledLinters, config.DisabledLinters, config.Stability)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make(map[string]*scope, len(config.Scopes))
+	scopeSelections := make(map[string]*selection, len(config.Scopes))
+
+	for name, sc := range config.Scopes {
+		enabledLinters := config.EnabledLinters
+		if len(sc.EnabledLinters) > 0 {
+			enabledLinters = sc.EnabledLinters
+		}
+
+		disabledLinters := config.DisabledLinters
+		if len(sc.DisabledLinters) > 0 {
+			disabledLinters = sc.DisabledLinters
+		}
+
+		sel, err := newSelection(enabledLinters, disabledLinters, config.Stability)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", name, err)
+		}
+
+		scopes[name] = &scope{linters: make(map[string]bool), exclude: sc.Exclude}
+		scopeSelections[name] = sel
+	}
+
+	settings, err := resolveSettings(config.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid linter settings: %w", err)
 	}
 
-	disabledMap := make(map[string]bool)
-	for _, name := range config.DisabledLinters {
-		disabledMap[name] = true
+	timeouts, err := resolveTimeouts(config.Timeouts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid linter timeouts: %w", err)
 	}
 
+	checks := make(map[string]*checkFilter)
+
 	var linters []Linter
+	var overridable []string
+	topLevel := make(map[string]bool)
+
+	// the runner holds the linters selected by the top level configuration
+	// or by any scope, each object is linted by the ones of its scope
 	for _, l := range All() {
 		name := l.Name()
 
-		if len(enabledMap) > 0 && !enabledMap[name] {
-			continue
+		if SupportsOverrides(l) {
+			overridable = append(overridable, name)
+		}
+
+		in := selected.selects(l)
+		if in {
+			topLevel[name] = true
+		}
+
+		for scopeName, sel := range scopeSelections {
+			if sel.selects(l) {
+				scopes[scopeName].linters[name] = true
+				in = true
+			}
 		}
 
-		if disabledMap[name] {
+		if !in {
 			continue
 		}
 
-		if settings, ok := config.Settings[name]; ok {
-			if err := l.Configure(settings); err != nil {
+		// linters are registered once per process and configured by every
+		// runner, they start from their defaults
+		if d, ok := l.(Defaulted); ok {
+			if err := d.Reset(); err != nil {
+				return nil, fmt.Errorf("failed to reset linter %q: %w", name, err)
+			}
+		}
+
+		for _, s := range settings[name] {
+			filter, rest, err := extractChecks(l, s, checks[name])
+			if err != nil {
+				return nil, fmt.Errorf("failed to configure linter %q: %w", name, err)
+			}
+			if filter != nil {
+				checks[name] = filter
+			}
+
+			if err := l.Configure(rest); err != nil {
 				return nil, fmt.Errorf("failed to configure linter %q: %w", name, err)
 			}
 		}
 
+		if d, ok := l.(Documented); ok && docURLs[name] == "" {
+			docURLs[name] = d.DocURL()
+		}
+
 		linters = append(linters, l)
 	}
 
 	return &Runner{
-		linters: linters,
-		config:  config,
+		linters:     linters,
+		config:      config,
+		docURLs:     docURLs,
+		checks:      checks,
+		timeouts:    timeouts,
+		selected:    topLevel,
+		scopes:      scopes,
+		overridable: overridable,
+		suppressed:  make(map[string]int),
+		stats:       make(map[string]*LinterStats),
 	}, nil
 }
 
+// extractChecks reads the enable-checks and disable-checks settings, merged
+// into the filter of previous settings, and returns the other settings to
+// configure the linter with. Check names are validated for linters
+// implementing Checker.
+func extractChecks(l Linter, settings map[string]interface{}, filter *checkFilter) (*checkFilter, map[string]interface{}, error) {
+	_, hasEnable := settings["enable-checks"]
+	_, hasDisable := settings["disable-checks"]
+	if !hasEnable && !hasDisable {
+		return filter, settings, nil
+	}
+
+	var known map[string]bool
+	if c, ok := l.(Checker); ok {
+		known = make(map[string]bool)
+		for _, check := range c.Checks() {
+			known[check] = true
+		}
+	}
+
+	if filter == nil {
+		filter = &checkFilter{}
+	}
+
+	rest := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		var target *map[string]bool
+		switch key {
+		case "enable-checks":
+			target = &filter.enabled
+		case "disable-checks":
+			target = &filter.disabled
+		default:
+			rest[key] = value
+			continue
+		}
+
+		var names []string
+		if err := mapstructure.Decode(value, &names); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+
+		*target = make(map[string]bool, len(names))
+		for _, check := range names {
+			if known != nil && !known[check] {
+				return nil, nil, fmt.Errorf("invalid %s: unknown check %q (supported: %s)", key, check, strings.Join(l.(Checker).Checks(), ", "))
+			}
+			(*target)[check] = true
+		}
+	}
+
+	return filter, rest, nil
+}
+
+// resolveTimeouts maps every linter to its timeout. Unlike settings, unknown
+// linter names are rejected, a misspelled name would otherwise leave the
+// linter without the intended limit. Timeouts keyed by the exact linter name
+// take precedence over the ones keyed by a pattern.
+func resolveTimeouts(timeouts map[string]time.Duration) (map[string]time.Duration, error) {
+	keys := make([]string, 0, len(timeouts))
+	for key := range timeouts {
+		keys = append(keys, key)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		pi, pj := IsPattern(keys[i]), IsPattern(keys[j])
+		if pi != pj {
+			return pi
+		}
+		return keys[i] < keys[j]
+	})
+
+	result := make(map[string]time.Duration)
+	for _, key := range keys {
+		names, err := Resolve([]string{key})
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			result[name] = timeouts[key]
+		}
+	}
+
+	return result, nil
+}
+
+// resolveSettings maps every linter to the list of settings that apply to it.
+// Settings keyed by a pattern are applied first, in key order, so that the
+// settings keyed by the exact linter name always take precedence.
+func resolveSettings(settings map[string]map[string]interface{}) (map[string][]map[string]interface{}, error) {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		pi, pj := IsPattern(keys[i]), IsPattern(keys[j])
+		if pi != pj {
+			return pi
+		}
+		return keys[i] < keys[j]
+	})
+
+	result := make(map[string][]map[string]interface{})
+	for _, key := range keys {
+		if !IsPattern(key) {
+			if _, err := Get(key); err != nil {
+				return nil, err
+			}
+		}
+
+		names, err := Resolve([]string{key})
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			result[name] = append(result[name], settings[key])
+		}
+	}
+
+	return result, nil
+}
+
 func (r *Runner) Run(ctx context.Context, objects []unstructured.Unstructured) ([]Issue, error) {
-	var issues []Issue
+	// suppressions are read before internal annotations are stripped, so
+	// linters only see the objects as they are rendered
+	meta := make([]objectMeta, len(objects))
+	for i := range objects {
+		meta[i] = objectMeta{
+			disabled: DisabledFor(objects[i]),
+			path:     objects[i].GetAnnotations()[SourcePathAnnotation],
+			scope:    objects[i].GetAnnotations()[SourceNameAnnotation],
+			source:   newSourceRef(objects[i]),
+			file:     sourceFile(objects[i]),
+			position: newSourcePosition(objects[i]),
+		}
+	}
 
+	objects = StripAllInternalAnnotations(objects)
 	ctx = WithAllObjects(ctx, objects)
 
-	for _, obj := range objects {
-		for _, linter := range r.linters {
-			objIssues, err := linter.Lint(ctx, obj)
-			if err != nil {
-				return issues, fmt.Errorf("linter %q failed on %s/%s: %w",
-					linter.Name(), obj.GetKind(), obj.GetName(), err)
+	// the typed conversions and the normalized jq inputs are shared by the
+	// linters of this run only, the caches are dropped together with the
+	// context once Run returns
+	ctx = typed.WithCache(ctx)
+	ctx = jq.WithInputs(ctx)
+
+	// set linters run first so that their issues are reported together with
+	// the ones of the object they are attributed to; the extra slot holds
+	// the issues not matching any object
+	setIssues, err := r.lintSet(ctx, objects, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := r.config.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	if concurrency > len(objects) {
+		concurrency = len(objects)
+	}
+
+	// issues are collected per object and concatenated in object order once
+	// all the workers are done, so the result does not depend on scheduling
+	results := make([][]Issue, len(objects))
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the first error is recorded before the other workers are canceled, so
+	// that it is not hidden by the cancellation errors they return
+	var failure error
+	var once sync.Once
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				var err error
+				results[i], err = r.lintObject(WithObjectIndex(ctx, i), objects[i], meta[i], setIssues[i])
+				if err != nil {
+					once.Do(func() { failure = err })
+					cancel()
+				}
 			}
+		}()
+	}
+
+dispatch:
+	for i := range objects {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	// objects are left unlinted when the run is canceled
+	if failure == nil {
+		failure = parent.Err()
+	}
+	if failure != nil {
+		return nil, failure
+	}
+
+	var issues []Issue
+	for i := range objects {
+		issues = append(issues, results[i]...)
+	}
+
+	return append(issues, setIssues[len(objects)]...), nil
+}
+
+// lintSet runs the set linters and returns their issues grouped by the index
+// of the object they are attributed to, issues for unknown resources are
+// grouped at index len(objects)
+func (r *Runner) lintSet(ctx context.Context, objects []unstructured.Unstructured, meta []objectMeta) ([][]Issue, error) {
+	result := make([][]Issue, len(objects)+1)
+
+	var setLinters []SetLinter
+	for _, l := range r.linters {
+		if sl, ok := l.(SetLinter); ok {
+			setLinters = append(setLinters, sl)
+		}
+	}
+
+	if len(setLinters) == 0 {
+		return result, nil
+	}
+
+	indexes := make(map[ResourceRef]int, len(objects))
+	for i := range objects {
+		ref := resourceRef(objects[i])
+		if _, ok := indexes[ref]; !ok {
+			indexes[ref] = i
+		}
+	}
+
+	for _, l := range setLinters {
+		start := time.Now()
+		issues, err := r.guard(ctx, l, ResourceRef{}, func(ctx context.Context) ([]Issue, error) {
+			return l.LintSet(ctx, objects)
+		})
+		elapsed := time.Since(start)
+		if err != nil {
+			return nil, fmt.Errorf("linter %q failed: %w", l.Name(), err)
+		}
+
+		reported := 0
+		for _, issue := range issues {
+			i, ok := indexes[issue.Resource]
+			if !ok {
+				i = len(objects)
+			}
+
+			var m objectMeta
+			if ok {
+				m = meta[i]
+			}
+
+			if !r.selects(m.scope, l.Name()) {
+				continue
+			}
+
+			if isSuppressed(m.disabled, l.Name()) {
+				r.suppress(l.Name())
+				continue
+			}
+
+			if issue, ok := r.finalize(l, issue, m); ok {
+				result[i] = append(result[i], issue)
+				reported++
+			}
+		}
+
+		r.record(l.Name(), elapsed, reported)
+	}
+
+	return result, nil
+}
+
+// lintObject runs all the per object linters, but the disabled ones, against
+// a single object. setIssues are the issues of the set linters attributed to
+// the object.
+func (r *Runner) lintObject(ctx context.Context, obj unstructured.Unstructured, meta objectMeta, setIssues []Issue) ([]Issue, error) {
+	var objectIssues []Issue
+
+	for _, linter := range r.linters {
+		if SupportsOverrides(linter) || !r.selects(meta.scope, linter.Name()) || isSuppressed(meta.disabled, linter.Name()) {
+			continue
+		}
+
+		for _, issue := range r.unsupportedOverrides(obj, linter) {
+			if issue, ok := r.finalize(linter, issue, meta); ok {
+				objectIssues = append(objectIssues, issue)
+			}
+		}
+	}
+
+	for _, linter := range r.linters {
+		if _, ok := linter.(SetLinter); ok {
+			continue
+		}
+
+		if !r.selects(meta.scope, linter.Name()) {
+			continue
+		}
+
+		if isSuppressed(meta.disabled, linter.Name()) {
+			r.suppress(linter.Name())
+			continue
+		}
 
-			issues = append(issues, objIssues...)
+		start := time.Now()
+		objIssues, err := r.guard(ctx, linter, resourceRef(obj), func(ctx context.Context) ([]Issue, error) {
+			return linter.Lint(ctx, obj)
+		})
+		elapsed := time.Since(start)
+		if err != nil {
+			return nil, fmt.Errorf("linter %q failed on %s/%s: %w",
+				linter.Name(), obj.GetKind(), obj.GetName(), err)
+		}
+
+		reported := 0
+		for _, issue := range objIssues {
+			if issue, ok := r.finalize(linter, issue, meta); ok {
+				objectIssues = append(objectIssues, issue)
+				reported++
+			}
 		}
+
+		r.record(linter.Name(), elapsed, reported)
+	}
+
+	objectIssues = append(objectIssues, setIssues...)
+
+	if r.config.OnObjectLinted != nil {
+		r.config.OnObjectLinted(obj, objectIssues)
+	}
+
+	return objectIssues, nil
+}
+
+// unsupportedOverrides returns the issues reporting the annotations of the
+// object overriding the settings of a linter not supporting it
+func (r *Runner) unsupportedOverrides(obj unstructured.Unstructured, l Linter) []Issue {
+	overrides := ObjectSettings(obj, l.Name())
+	if len(overrides) == 0 {
+		return nil
 	}
 
-	return issues, nil
+	settings := make([]string, 0, len(overrides))
+	for setting := range overrides {
+		settings = append(settings, setting)
+	}
+	sort.Strings(settings)
+
+	issues := make([]Issue, 0, len(settings))
+	for _, setting := range settings {
+		issues = append(issues, InvalidOverride(obj, l.Name(), setting,
+			fmt.Sprintf("The settings of %s cannot be overridden per object, the annotation is ignored (supported by: %s)",
+				l.Name(), strings.Join(r.overridable, ", "))))
+	}
+
+	return issues
+}
+
+// selects tells whether the linter runs against the objects of the scope,
+// objects without a scope are linted by the top level linters
+func (r *Runner) selects(scope string, name string) bool {
+	if s, ok := r.scopes[scope]; ok {
+		return s.linters[name]
+	}
+
+	return r.selected[name]
+}
+
+// finalize applies the check filters and exclusions to an issue and fills
+// its documentation link, source and source position, it returns false if
+// the issue is excluded
+func (r *Runner) finalize(l Linter, issue Issue, meta objectMeta) (Issue, bool) {
+	if r.checks[l.Name()].skip(issue.Check) {
+		return issue, false
+	}
+
+	if r.config.Exclude != nil && r.config.Exclude(issue, meta.path) {
+		return issue, false
+	}
+
+	if s, ok := r.scopes[meta.scope]; ok && s.exclude != nil && s.exclude(issue, meta.path) {
+		return issue, false
+	}
+
+	if issue.DocURL == "" {
+		issue.DocURL = r.docURLs[l.Name()]
+	}
+
+	if issue.Source == nil {
+		issue.Source = meta.source
+	}
+
+	if issue.File == "" {
+		issue.File = meta.file
+	}
+
+	if issue.Position == nil {
+		issue.Position = meta.position.resolve(issue.Field)
+	}
+
+	return issue, true
+}
+
+// guard runs a linter, turning panics and timeouts into fatal issues for the
+// given resource so a misbehaving linter does not abort the whole run
+func (r *Runner) guard(ctx context.Context, l Linter, ref ResourceRef, fn func(context.Context) ([]Issue, error)) ([]Issue, error) {
+	timeout := r.config.Timeout
+	if t, ok := r.timeouts[l.Name()]; ok {
+		timeout = t
+	}
+
+	if timeout <= 0 {
+		return safeLint(ctx, l, ref, fn)
+	}
+
+	lintCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		issues []Issue
+		err    error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		issues, err := safeLint(lintCtx, l, ref, fn)
+		ch <- result{issues: issues, err: err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.issues, res.err
+	case <-lintCtx.Done():
+		if ctx.Err() != nil || !errors.Is(lintCtx.Err(), context.DeadlineExceeded) {
+			return nil, ctx.Err()
+		}
+		return []Issue{
+			fatalIssue(l, ref, fmt.Sprintf("Linter timed out after %s", timeout)),
+		}, nil
+	}
+}
+
+func safeLint(ctx context.Context, l Linter, ref ResourceRef, fn func(context.Context) ([]Issue, error)) (issues []Issue, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			issues = []Issue{
+				fatalIssue(l, ref, fmt.Sprintf("Linter panicked: %v", rec)),
+			}
+			err = nil
+		}
+	}()
+
+	return fn(ctx)
+}
+
+func fatalIssue(l Linter, ref ResourceRef, message string) Issue {
+	return Issue{
+		Severity: SeverityFatal,
+		Linter:   l.Name(),
+		Message:  message,
+		Resource: ref,
+	}
+}
+
+func resourceRef(obj unstructured.Unstructured) ResourceRef {
+	return ResourceRef{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+}
+
+func (r *Runner) suppress(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.suppressed[name]++
+}
+
+// Suppressed returns, per linter, the number of objects the linter has been
+// skipped for because of the disable annotation
+func (r *Runner) Suppressed() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make(map[string]int, len(r.suppressed))
+	for name, count := range r.suppressed {
+		result[name] = count
+	}
+
+	return result
+}
+
+func (r *Runner) record(name string, d time.Duration, issues int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.stats[name]
+	if !ok {
+		s = &LinterStats{Name: name}
+		r.stats[name] = s
+	}
+
+	s.Duration += d
+	s.Calls++
+	s.Issues += issues
+}
+
+// Stats returns the time spent in every linter which has run and the number
+// of issues it reported, the slowest linters first
+func (r *Runner) Stats() []LinterStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]LinterStats, 0, len(r.stats))
+	for _, s := range r.stats {
+		result = append(result, *s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Duration != result[j].Duration {
+			return result[i].Duration > result[j].Duration
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result
 }
 
 func (r *Runner) Linters() []Linter {
@@ -0,0 +1,152 @@
+package linter_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+)
+
+const latest = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+    - name: web
+      image: nginx:latest
+`
+
+func TestRunnerSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]map[string]interface{}
+		issues   int
+	}{
+		{
+			name:   "defaults",
+			issues: 1,
+		},
+		{
+			name:     "name",
+			settings: map[string]map[string]interface{}{"image-tags": {"disallow-latest": false}},
+		},
+		{
+			name:     "glob",
+			settings: map[string]map[string]interface{}{"image-*": {"disallow-latest": false}},
+		},
+		{
+			name:     "regexp",
+			settings: map[string]map[string]interface{}{`/^(?i:IMAGE-TAG.)$/`: {"disallow-latest": false}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, err := linter.NewRunner(&linter.RunnerConfig{
+				EnabledLinters: []string{"image-tags"},
+				Settings:       tt.settings,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			issues, err := runner.Run(t.Context(), lintertest.ParseObjects(t, latest))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(issues) != tt.issues {
+				t.Errorf("expected %d issue(s), got %d: %v", tt.issues, len(issues), issues)
+			}
+		})
+	}
+}
+
+func TestRunnerInvalidSettings(t *testing.T) {
+	for name, key := range map[string]string{
+		"unknown linter":  "image-tag",
+		"unmatched glob":  "imagetags-*",
+		"invalid regexp":  "/^image-(tags$/",
+		"unmatched regex": `/^IMAGE-TAGS\.io$/`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := linter.NewRunner(&linter.RunnerConfig{
+				EnabledLinters: []string{"image-tags"},
+				Settings:       map[string]map[string]interface{}{key: {"disallow-latest": false}},
+			})
+			if err == nil {
+				t.Errorf("expected an error for the settings of %s", key)
+			}
+		})
+	}
+}
+
+// testLinter fails on the object named fail and blocks on the other ones
+// until the run is canceled
+type testLinter struct {
+	name string
+	fail string
+}
+
+func (l *testLinter) Name() string        { return l.name }
+func (l *testLinter) Description() string { return "test linter" }
+
+func (l *testLinter) Configure(map[string]interface{}) error { return nil }
+
+func (l *testLinter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	if l.fail != "" {
+		if obj.GetName() == l.fail {
+			return nil, errors.New("boom")
+		}
+		return nil, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(10 * time.Second):
+		return nil, nil
+	}
+}
+
+// TestRunnerFirstError reports the error of the failing linter, not the
+// cancellation errors of the linters still running on earlier objects
+func TestRunnerFirstError(t *testing.T) {
+	for _, l := range []*testLinter{
+		{name: "runner-test-failing", fail: "fail"},
+		{name: "runner-test-slow"},
+	} {
+		linter.Register(l)
+		t.Cleanup(func() { linter.Unregister(l.name) })
+	}
+
+	var manifests []string
+	for _, name := range []string{"a", "b", "c", "fail"} {
+		manifests = append(manifests, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: "+name+"\n")
+	}
+	objects := lintertest.ParseObjects(t, strings.Join(manifests, "---\n"))
+
+	runner, err := linter.NewRunner(&linter.RunnerConfig{
+		EnabledLinters: []string{"runner-test-*"},
+		Concurrency:    len(objects),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = runner.Run(t.Context(), objects)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected the error of the failing linter, got %v", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("expected no cancellation error, got %v", err)
+	}
+}
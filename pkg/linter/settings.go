@@ -0,0 +1,48 @@
+package linter
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// DecodeSettings decodes the settings of a linter on top of its
+// configuration. The settings left out keep their configured value, while
+// lists and maps which are set replace the configured ones rather than being
+// merged with them element by element.
+func DecodeSettings(settings map[string]interface{}, config interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ZeroFields: true,
+		Result:     config,
+	})
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(settings)
+}
+
+// EncodeSettings returns the settings of a typed linter configuration, every
+// field of the configuration is set, so that decoding them replaces the
+// whole configuration of the linter
+func EncodeSettings(config interface{}) (map[string]interface{}, error) {
+	settings := make(map[string]interface{})
+	if err := mapstructure.Decode(config, &settings); err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+
+	// nil lists and maps are not decoded, empty ones replace the configured
+	// ones
+	for key, value := range settings {
+		v := reflect.ValueOf(value)
+		switch {
+		case v.Kind() == reflect.Slice && v.IsNil():
+			settings[key] = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+		case v.Kind() == reflect.Map && v.IsNil():
+			settings[key] = reflect.MakeMap(v.Type()).Interface()
+		}
+	}
+
+	return settings, nil
+}
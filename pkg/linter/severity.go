@@ -0,0 +1,166 @@
+package linter
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// SeverityLevel is a severity issues can be reported with, the built-in
+// ones and the custom ones defined in the configuration, such as blocker or
+// nit
+type SeverityLevel struct {
+	Name Severity
+	// Like is the built-in severity the level is reported as by formats with
+	// a fixed set of levels, such as SARIF, and which sets the exit code
+	Like Severity
+	// Color is the color of the level in the text output, as in red or
+	// bold-magenta
+	Color string
+}
+
+// builtinSeverityLevels are the built-in levels, the most severe first
+var builtinSeverityLevels = []SeverityLevel{
+	{Name: SeverityFatal, Like: SeverityFatal, Color: "bold-red"},
+	{Name: SeverityError, Like: SeverityError, Color: "red"},
+	{Name: SeverityWarning, Like: SeverityWarning, Color: "yellow"},
+	{Name: SeverityInfo, Like: SeverityInfo, Color: "cyan"},
+}
+
+var (
+	severityMu     sync.RWMutex
+	severityLevels = builtinSeverityLevels
+)
+
+// SetSeverityLevels replaces the severity levels, given the most severe
+// first. The built-in levels must be listed, custom levels must be like one
+// of them and levels without a color get the one of the level they are like.
+func SetSeverityLevels(levels []SeverityLevel) error {
+	builtin := make(map[Severity]SeverityLevel, len(builtinSeverityLevels))
+	for _, l := range builtinSeverityLevels {
+		builtin[l.Name] = l
+	}
+
+	seen := make(map[Severity]bool, len(levels))
+	result := make([]SeverityLevel, 0, len(levels))
+
+	for _, l := range levels {
+		if l.Name == "" {
+			return fmt.Errorf("severity level name is required")
+		}
+		if seen[l.Name] {
+			return fmt.Errorf("duplicate severity level %q", l.Name)
+		}
+		seen[l.Name] = true
+
+		if b, ok := builtin[l.Name]; ok {
+			if l.Like != "" && l.Like != l.Name {
+				return fmt.Errorf("built-in severity level %q cannot be like %q", l.Name, l.Like)
+			}
+			l.Like = l.Name
+			if l.Color == "" {
+				l.Color = b.Color
+			}
+		} else {
+			b, ok := builtin[l.Like]
+			if !ok {
+				return fmt.Errorf("severity level %q must be like one of fatal, error, warning or info", l.Name)
+			}
+			if l.Color == "" {
+				l.Color = b.Color
+			}
+		}
+
+		if _, ok := colorCodes[l.Color]; !ok {
+			return fmt.Errorf("severity level %q: unsupported color %q", l.Name, l.Color)
+		}
+
+		result = append(result, l)
+	}
+
+	for _, l := range builtinSeverityLevels {
+		if !seen[l.Name] {
+			return fmt.Errorf("built-in severity level %q is missing", l.Name)
+		}
+	}
+
+	severityMu.Lock()
+	defer severityMu.Unlock()
+
+	severityLevels = result
+
+	return nil
+}
+
+// Severities returns the severity levels, the most severe first
+func Severities() []Severity {
+	severityMu.RLock()
+	defer severityMu.RUnlock()
+
+	result := make([]Severity, len(severityLevels))
+	for i, l := range severityLevels {
+		result[i] = l.Name
+	}
+
+	return result
+}
+
+func level(s Severity) (SeverityLevel, int, bool) {
+	severityMu.RLock()
+	defer severityMu.RUnlock()
+
+	i := slices.IndexFunc(severityLevels, func(l SeverityLevel) bool { return l.Name == s })
+	if i < 0 {
+		return SeverityLevel{}, len(severityLevels), false
+	}
+
+	return severityLevels[i], i, true
+}
+
+// Rank returns the position of the severity among the levels, lower is more
+// severe, unknown severities rank last
+func (s Severity) Rank() int {
+	_, rank, _ := level(s)
+	return rank
+}
+
+// Builtin returns the built-in severity the severity is reported as, unknown
+// severities are returned as they are
+func (s Severity) Builtin() Severity {
+	if l, _, ok := level(s); ok {
+		return l.Like
+	}
+	return s
+}
+
+// ANSI returns the severity wrapped in the ANSI escape codes of its color
+func (s Severity) ANSI() string {
+	l, _, ok := level(s)
+	if !ok {
+		return string(s)
+	}
+
+	return "\033[" + colorCodes[l.Color] + "m" + string(s) + "\033[0m"
+}
+
+// colorCodes maps the supported severity colors to their ANSI codes
+var colorCodes = map[string]string{
+	"black":        "30",
+	"red":          "31",
+	"green":        "32",
+	"yellow":       "33",
+	"blue":         "34",
+	"magenta":      "35",
+	"cyan":         "36",
+	"white":        "37",
+	"gray":         "90",
+	"bold-black":   "30;1",
+	"bold-red":     "31;1",
+	"bold-green":   "32;1",
+	"bold-yellow":  "33;1",
+	"bold-blue":    "34;1",
+	"bold-magenta": "35;1",
+	"bold-cyan":    "36;1",
+	"bold-white":   "37;1",
+	"bold-gray":    "90;1",
+}
@@ -0,0 +1,184 @@
+package linter
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+)
+
+// InternalAnnotationPrefix is the prefix of the annotations renderers use to
+// pass metadata about the origin of an object to the runner. They are removed
+// before objects are handed to linters.
+const InternalAnnotationPrefix = "internal.k8s-manifests-lint/"
+
+const (
+	// SourcePathAnnotation holds the file an object has been loaded from
+	SourcePathAnnotation = InternalAnnotationPrefix + "path"
+	// SourceIndexAnnotation holds the index of the YAML document within the file
+	SourceIndexAnnotation = InternalAnnotationPrefix + "index"
+	// SourceItemAnnotation holds the index of the object within the items of
+	// a List document
+	SourceItemAnnotation = InternalAnnotationPrefix + "item"
+	// SourceLineAnnotation holds the line, and column, of the object in the
+	// file in the line:column form
+	SourceLineAnnotation = InternalAnnotationPrefix + "line"
+	// SourceFieldsAnnotation holds the JSON encoded map of the field paths of
+	// the object to their line:column in the file
+	SourceFieldsAnnotation = InternalAnnotationPrefix + "fields"
+	// SourceNameAnnotation holds the name of the configured source an
+	// object has been rendered from
+	SourceNameAnnotation = InternalAnnotationPrefix + "source"
+	// SourceTypeAnnotation holds the type of the source an object has been
+	// rendered from
+	SourceTypeAnnotation = InternalAnnotationPrefix + "source-type"
+	// SourceFileAnnotation holds the file an object has been rendered from
+	// when it cannot be positioned in it, such as a Helm template, the
+	// objects loaded as they are use SourcePathAnnotation
+	SourceFileAnnotation = InternalAnnotationPrefix + "file"
+	// NolintAnnotation holds, comma separated, the linters disabled by
+	// # nolint comments in the source document
+	NolintAnnotation = InternalAnnotationPrefix + "nolint"
+)
+
+// HasInternalAnnotations reports whether the object carries any internal
+// annotation
+func HasInternalAnnotations(obj unstructured.Unstructured) bool {
+	for key := range obj.GetAnnotations() {
+		if strings.HasPrefix(key, InternalAnnotationPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StripInternalAnnotations returns the object without internal annotations,
+// the object is copied only when it has some
+func StripInternalAnnotations(obj unstructured.Unstructured) unstructured.Unstructured {
+	if !HasInternalAnnotations(obj) {
+		return obj
+	}
+
+	stripped := *obj.DeepCopy()
+
+	annotations := stripped.GetAnnotations()
+	for key := range annotations {
+		if strings.HasPrefix(key, InternalAnnotationPrefix) {
+			delete(annotations, key)
+		}
+	}
+
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	stripped.SetAnnotations(annotations)
+
+	return stripped
+}
+
+// StripAllInternalAnnotations strips the internal annotations of all the
+// objects
+func StripAllInternalAnnotations(objects []unstructured.Unstructured) []unstructured.Unstructured {
+	result := make([]unstructured.Unstructured, len(objects))
+	for i := range objects {
+		result[i] = StripInternalAnnotations(objects[i])
+	}
+
+	return result
+}
+
+// sourcePosition holds the location of an object in its source file and
+// resolves the location of its fields
+type sourcePosition struct {
+	base      Position
+	rawFields string
+
+	once   sync.Once
+	fields map[string]string
+}
+
+// newSourceRef returns the source the object has been rendered from, if
+// known
+func newSourceRef(obj unstructured.Unstructured) *SourceRef {
+	annotations := obj.GetAnnotations()
+
+	sourceType, ok := annotations[SourceTypeAnnotation]
+	if !ok {
+		return nil
+	}
+
+	return &SourceRef{Name: annotations[SourceNameAnnotation], Type: sourceType}
+}
+
+// sourceFile returns the file the object has been loaded, or rendered, from
+func sourceFile(obj unstructured.Unstructured) string {
+	annotations := obj.GetAnnotations()
+
+	if file, ok := annotations[SourceFileAnnotation]; ok {
+		return file
+	}
+
+	return annotations[SourcePathAnnotation]
+}
+
+func newSourcePosition(obj unstructured.Unstructured) *sourcePosition {
+	annotations := obj.GetAnnotations()
+
+	file, ok := annotations[SourcePathAnnotation]
+	if !ok {
+		return nil
+	}
+
+	p := sourcePosition{
+		base:      Position{File: file},
+		rawFields: annotations[SourceFieldsAnnotation],
+	}
+
+	p.base.Document, _ = strconv.Atoi(annotations[SourceIndexAnnotation])
+	if v, ok := annotations[SourceItemAnnotation]; ok {
+		if item, err := strconv.Atoi(v); err == nil {
+			p.base.Item = &item
+		}
+	}
+	p.base.Line, p.base.Column = parseLineColumn(annotations[SourceLineAnnotation])
+
+	return &p
+}
+
+// resolve returns the position of the field, or of its closest parent
+// present in the source when the field is missing, falling back to the
+// position of the object
+func (p *sourcePosition) resolve(field string) *Position {
+	if p == nil {
+		return nil
+	}
+
+	pos := p.base
+
+	p.once.Do(func() {
+		if p.rawFields != "" {
+			_ = json.Unmarshal([]byte(p.rawFields), &p.fields)
+		}
+	})
+
+	for f := field; f != "" && f != fieldpath.Root; f = fieldpath.Parent(f) {
+		if lc, ok := p.fields[f]; ok {
+			pos.Line, pos.Column = parseLineColumn(lc)
+			break
+		}
+	}
+
+	return &pos
+}
+
+func parseLineColumn(value string) (int, int) {
+	l, c, _ := strings.Cut(value, ":")
+	line, _ := strconv.Atoi(l)
+	column, _ := strconv.Atoi(c)
+	return line, column
+}
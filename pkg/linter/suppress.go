@@ -0,0 +1,40 @@
+package linter
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DisableAnnotation lists, comma separated, the linters to skip for the
+// annotated resource. Glob patterns and /regexp/ are supported.
+const DisableAnnotation = "k8s-manifests-lint/disable"
+
+// DisabledFor returns the linter references listed in the disable annotation
+// of the object and in the # nolint comments of its source document
+func DisabledFor(obj unstructured.Unstructured) []string {
+	annotations := obj.GetAnnotations()
+
+	var refs []string
+	for _, key := range []string{DisableAnnotation, NolintAnnotation} {
+		for _, ref := range strings.Split(annotations[key], ",") {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	return refs
+}
+
+// isSuppressed reports whether the linter matches any of the references,
+// invalid references never match
+func isSuppressed(refs []string, name string) bool {
+	for _, ref := range refs {
+		if matched, err := Match(ref, name); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
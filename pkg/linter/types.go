@@ -2,14 +2,20 @@ package linter
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
 )
 
 type contextKey int
 
 const (
 	allObjectsKey contextKey = iota
+	objectIndexKey
 )
 
 type Severity string
@@ -29,14 +35,141 @@ type ResourceRef struct {
 }
 
 type Issue struct {
-	Severity   Severity    `json:"severity" yaml:"severity"`
-	Linter     string      `json:"linter" yaml:"linter"`
-	Message    string      `json:"message" yaml:"message"`
-	Resource   ResourceRef `json:"resource" yaml:"resource"`
-	Field      string      `json:"field,omitempty" yaml:"field,omitempty"`
+	Severity Severity `json:"severity" yaml:"severity"`
+	Linter   string   `json:"linter" yaml:"linter"`
+	// Check identifies the rule of the linter which reported the issue, for
+	// linters made of several checks
+	Check    string      `json:"check,omitempty" yaml:"check,omitempty"`
+	Message  string      `json:"message" yaml:"message"`
+	Resource ResourceRef `json:"resource" yaml:"resource"`
+	// Field is the JSONPath, relative to the object, of the offending field
+	Field string `json:"field,omitempty" yaml:"field,omitempty"`
+	// Value is the offending value, if the field is set
+	Value      interface{} `json:"value,omitempty" yaml:"value,omitempty"`
 	Suggestion string      `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
+	DocURL     string      `json:"docURL,omitempty" yaml:"docURL,omitempty"`
+	// Related lists the other resources involved in the issue
+	Related []ResourceRef `json:"related,omitempty" yaml:"related,omitempty"`
+	// Position is the location of the field, or of the resource, in the
+	// source file when known
+	Position *Position `json:"position,omitempty" yaml:"position,omitempty"`
+	// Fix is a machine-applicable change resolving the issue, as JSON patch
+	// operations on the object
+	Fix []PatchOperation `json:"fix,omitempty" yaml:"fix,omitempty"`
+	// Profile is the profile of run --matrix-config which reported the issue
+	Profile string `json:"profile,omitempty" yaml:"profile,omitempty"`
+	// Source is the source the resource has been rendered from
+	Source *SourceRef `json:"source,omitempty" yaml:"source,omitempty"`
+	// File is the file the resource has been loaded, or rendered, from. It
+	// is set even when the resource cannot be positioned in the file, such
+	// as for the templates of a Helm chart.
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+}
+
+// SourceRef identifies the configured source, or command line path, an
+// issue comes from, Name is only set for named sources
+type SourceRef struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	Type string `json:"type" yaml:"type"`
+}
+
+// String returns the source in the name (type) form, or its type alone when
+// the source has no name
+func (s SourceRef) String() string {
+	if s.Name == "" {
+		return s.Type
+	}
+	return s.Name + " (" + s.Type + ")"
+}
+
+// PatchOperation is a JSON patch (RFC 6902) operation, Path is a JSON
+// Pointer relative to the object
+type PatchOperation struct {
+	Op    string      `json:"op" yaml:"op"`
+	Path  string      `json:"path" yaml:"path"`
+	Value interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// SetField returns the patch operation setting the field, given as a
+// JSONPath expression, to the value
+func SetField(field string, value interface{}) []PatchOperation {
+	pointer, err := fieldpath.Pointer(field)
+	if err != nil {
+		return nil
+	}
+
+	return []PatchOperation{{Op: "add", Path: pointer, Value: value}}
 }
 
+// AppendField returns the patch operation appending the value to the array
+// at the field, given as a JSONPath expression
+func AppendField(field string, value interface{}) []PatchOperation {
+	pointer, err := fieldpath.Pointer(field)
+	if err != nil {
+		return nil
+	}
+
+	return []PatchOperation{{Op: "add", Path: pointer + "/-", Value: value}}
+}
+
+// Position is a location within a source file, lines and columns are 1-based
+type Position struct {
+	File     string `json:"file" yaml:"file"`
+	Document int    `json:"document" yaml:"document"`
+	// Item is the index of the object within the items of the document when
+	// it is a List, nil otherwise
+	Item   *int `json:"item,omitempty" yaml:"item,omitempty"`
+	Line   int  `json:"line,omitempty" yaml:"line,omitempty"`
+	Column int  `json:"column,omitempty" yaml:"column,omitempty"`
+}
+
+// String returns the position in the file:line:column form
+func (p Position) String() string {
+	switch {
+	case p.Line == 0:
+		return p.File
+	case p.Column == 0:
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+}
+
+// Location returns the position of the issue in the file:line:column form,
+// or the file the resource comes from when it cannot be positioned
+func (i Issue) Location() string {
+	if i.Position != nil {
+		return i.Position.String()
+	}
+	return i.File
+}
+
+// Fingerprint returns a stable identifier for the issue which does not depend
+// on the order issues are reported in, so it can be used to match issues
+// across runs
+func (i Issue) Fingerprint() string {
+	h := sha256.New()
+	for _, s := range []string{
+		i.Linter,
+		i.Resource.APIVersion,
+		i.Resource.Kind,
+		i.Resource.Namespace,
+		i.Resource.Name,
+		i.Field,
+		i.Message,
+	} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Linter checks objects and reports the issues it finds.
+//
+// The runner lints the objects concurrently and hands the same objects, and
+// the same maps, to every linter: linters must never modify them, nor the
+// objects returned by AllObjectsFromContext. Linters deriving values from
+// the objects must work on copies, as pkg/utils/jq does.
 type Linter interface {
 	Name() string
 	Description() string
@@ -44,6 +177,81 @@ type Linter interface {
 	Configure(settings map[string]interface{}) error
 }
 
+// SetLinter is implemented by linters checking the whole set of objects at
+// once, such as cross-resource invariants. The runner calls LintSet once per
+// run instead of calling Lint for every object, issues are attributed to the
+// object matching their Resource.
+type SetLinter interface {
+	Linter
+	LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]Issue, error)
+}
+
+// Defaulted is implemented by linters with a default configuration. The
+// runner resets them to it before applying the settings of the run, so that
+// the settings of a run never leak into the next one.
+type Defaulted interface {
+	// Reset restores the default configuration
+	Reset() error
+}
+
+// Checker is implemented by linters made of several checks, which can be
+// turned on and off individually with the enable-checks and disable-checks
+// settings
+type Checker interface {
+	// Checks returns the identifiers of the checks, as set in Issue.Check
+	Checks() []string
+}
+
+// Stability tells whether the checks of a linter are settled or may still
+// change between releases
+type Stability string
+
+const (
+	StabilityStable       Stability = "stable"
+	StabilityExperimental Stability = "experimental"
+)
+
+// Released is implemented by linters declaring the release they have been
+// introduced in and their stability, linters not implementing it are
+// considered stable
+type Released interface {
+	// Since returns the version the linter has been introduced in, such as
+	// v0.1.0
+	Since() string
+	Stability() Stability
+}
+
+// StabilityOf returns the stability of the given linter
+func StabilityOf(l Linter) Stability {
+	if r, ok := l.(Released); ok && r.Stability() != "" {
+		return r.Stability()
+	}
+	return StabilityStable
+}
+
+// SinceOf returns the version the given linter has been introduced in, or an
+// empty string if unknown
+func SinceOf(l Linter) string {
+	if r, ok := l.(Released); ok {
+		return r.Since()
+	}
+	return ""
+}
+
+// DocsBaseURL is the location of the built-in linters documentation
+const DocsBaseURL = "https://github.com/lburgazzoli/k8s-manifests-lint/blob/main/docs/linters.md"
+
+// Documented is implemented by linters providing a link to the rationale and
+// remediation guide of the issues they report
+type Documented interface {
+	DocURL() string
+}
+
+// DocURL returns the documentation URL of a built-in linter
+func DocURL(name string) string {
+	return DocsBaseURL + "#" + name
+}
+
 // WithAllObjects adds all objects to the context
 func WithAllObjects(ctx context.Context, objects []unstructured.Unstructured) context.Context {
 	return context.WithValue(ctx, allObjectsKey, objects)
@@ -54,3 +262,15 @@ func AllObjectsFromContext(ctx context.Context) ([]unstructured.Unstructured, bo
 	objects, ok := ctx.Value(allObjectsKey).([]unstructured.Unstructured)
 	return objects, ok
 }
+
+// WithObjectIndex adds the position of the object being linted within the
+// set of all objects, which reflects the apply order, to the context
+func WithObjectIndex(ctx context.Context, index int) context.Context {
+	return context.WithValue(ctx, objectIndexKey, index)
+}
+
+// ObjectIndexFromContext retrieves the position of the object being linted
+func ObjectIndexFromContext(ctx context.Context) (int, bool) {
+	index, ok := ctx.Value(objectIndexKey).(int)
+	return index, ok
+}
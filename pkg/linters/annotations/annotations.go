@@ -0,0 +1,228 @@
+package annotations
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+)
+
+const (
+	Name        = "annotations"
+	Description = "Ensures resources have the required annotations, with valid values, and none of the forbidden ones"
+	Since       = "v0.2.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckRequired  = "required"
+	CheckValue     = "value"
+	CheckForbidden = "forbidden"
+)
+
+// RequiredAnnotation is an annotation the resources of Kinds, all of them when
+// empty, must have
+type RequiredAnnotation struct {
+	Key string `mapstructure:"key"`
+	// Value is a regular expression the whole value must match, any value is
+	// accepted when empty
+	Value string   `mapstructure:"value"`
+	Kinds []string `mapstructure:"kinds"`
+}
+
+type Config struct {
+	Required []RequiredAnnotation `mapstructure:"required"`
+	// Forbidden are the keys, glob patterns are supported, no resource may
+	// have
+	Forbidden    []string `mapstructure:"forbidden"`
+	ExcludeKinds []string `mapstructure:"exclude-kinds"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		// written by kubectl apply, a copy of it committed to the manifests
+		// is stale and leaks the previous state of the resource
+		Forbidden: []string{"kubectl.kubernetes.io/last-applied-configuration"},
+	}
+}
+
+func init() {
+	l := &Linter{
+		config: DefaultConfig(),
+	}
+
+	if err := l.compile(); err != nil {
+		panic(err)
+	}
+
+	linter.Register(l)
+}
+
+type Linter struct {
+	config Config
+	values []*regexp.Regexp
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckRequired, CheckValue, CheckForbidden}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	// configured keys replace the defaults rather than being merged into
+	// them element by element
+	if _, ok := settings["forbidden"]; ok {
+		l.config.Forbidden = nil
+	}
+
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	return l.compile()
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) compile() error {
+	l.values = make([]*regexp.Regexp, len(l.config.Required))
+
+	for i, required := range l.config.Required {
+		if required.Key == "" {
+			return fmt.Errorf("required[%d]: key is required", i)
+		}
+
+		if required.Value == "" {
+			continue
+		}
+
+		re, err := regexp.Compile("^(?:" + required.Value + ")$")
+		if err != nil {
+			return fmt.Errorf("required[%d]: invalid value %q: %w", i, required.Value, err)
+		}
+
+		l.values[i] = re
+	}
+
+	for _, pattern := range l.config.Forbidden {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid forbidden key %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	kind := obj.GetKind()
+	if slices.Contains(l.config.ExcludeKinds, kind) {
+		return nil, nil
+	}
+
+	var issues []linter.Issue
+	annotations := obj.GetAnnotations()
+
+	for i, required := range l.config.Required {
+		if len(required.Kinds) > 0 && !slices.Contains(required.Kinds, kind) {
+			continue
+		}
+
+		field := fieldpath.Path("metadata", "annotations", required.Key)
+
+		value, ok := annotations[required.Key]
+		if !ok {
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityWarning,
+				Linter:     l.Name(),
+				Check:      CheckRequired,
+				Message:    fmt.Sprintf("Missing required annotation %q", required.Key),
+				Resource:   common.ResourceRef(obj),
+				Field:      field,
+				Suggestion: fmt.Sprintf("Add annotation: %s: <value>", required.Key),
+			})
+			continue
+		}
+
+		if re := l.values[i]; re != nil && !re.MatchString(value) {
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityWarning,
+				Linter:     l.Name(),
+				Check:      CheckValue,
+				Message:    fmt.Sprintf("Annotation %q has value %q, which does not match %q", required.Key, value, required.Value),
+				Resource:   common.ResourceRef(obj),
+				Field:      field,
+				Value:      value,
+				Suggestion: fmt.Sprintf("Set the annotation to a value matching %q", required.Value),
+			})
+		}
+	}
+
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		pattern, ok := l.forbidden(key)
+		if !ok {
+			continue
+		}
+
+		issues = append(issues, linter.Issue{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Check:      CheckForbidden,
+			Message:    fmt.Sprintf("Annotation %q is forbidden (matches %q)", key, pattern),
+			Resource:   common.ResourceRef(obj),
+			Field:      fieldpath.Path("metadata", "annotations", key),
+			Suggestion: fmt.Sprintf("Remove the annotation %s", key),
+		})
+	}
+
+	return issues, nil
+}
+
+// forbidden returns the first forbidden pattern matching the key
+func (l *Linter) forbidden(key string) (string, bool) {
+	for _, pattern := range l.config.Forbidden {
+		if matched, _ := path.Match(pattern, key); matched {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
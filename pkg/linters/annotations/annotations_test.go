@@ -0,0 +1,116 @@
+package annotations_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/annotations"
+)
+
+const manifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    owner: team-a
+    tier: gold
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  annotations:
+    checksum/config: abc
+`
+
+func TestAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		expected []lintertest.Expectation
+	}{
+		{
+			name: "default",
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityWarning,
+				Linter:   annotations.Name,
+				Message:  `Annotation "kubectl.kubernetes.io/last-applied-configuration" is forbidden`,
+				Field:    "$.metadata.annotations['kubectl.kubernetes.io/last-applied-configuration']",
+				Kind:     "Deployment",
+				Name:     "web",
+			}},
+		},
+		{
+			name: "required",
+			settings: map[string]interface{}{
+				"required": []interface{}{
+					map[string]interface{}{"key": "owner"},
+					map[string]interface{}{"key": "tier", "value": "silver|bronze", "kinds": []interface{}{"Deployment"}},
+				},
+				"forbidden": []interface{}{},
+			},
+			expected: []lintertest.Expectation{
+				{
+					Severity: linter.SeverityWarning,
+					Message:  `Annotation "tier" has value "gold", which does not match "silver|bronze"`,
+					Field:    "$.metadata.annotations.tier",
+					Kind:     "Deployment",
+				},
+				{
+					Severity: linter.SeverityWarning,
+					Message:  `Missing required annotation "owner"`,
+					Field:    "$.metadata.annotations.owner",
+					Kind:     "ConfigMap",
+				},
+			},
+		},
+		{
+			name: "forbidden replaces the defaults",
+			settings: map[string]interface{}{
+				"forbidden": []interface{}{"checksum/*"},
+			},
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityWarning,
+				Message:  `Annotation "checksum/config" is forbidden (matches "checksum/*")`,
+				Field:    "$.metadata.annotations['checksum/config']",
+				Kind:     "ConfigMap",
+			}},
+		},
+		{
+			name: "exclude kinds",
+			settings: map[string]interface{}{
+				"required":      []interface{}{map[string]interface{}{"key": "owner"}},
+				"exclude-kinds": []interface{}{"ConfigMap", "Deployment"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &annotations.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			lintertest.Configure(t, l, tt.settings)
+
+			issues := lintertest.Run(t, l, lintertest.ParseObjects(t, manifests)...)
+			lintertest.AssertIssues(t, issues, tt.expected...)
+		})
+	}
+}
+
+func TestAnnotationsInvalidSettings(t *testing.T) {
+	for name, settings := range map[string]map[string]interface{}{
+		"missing key":       {"required": []interface{}{map[string]interface{}{"value": "x"}}},
+		"invalid value":     {"required": []interface{}{map[string]interface{}{"key": "owner", "value": "("}}},
+		"invalid forbidden": {"forbidden": []interface{}{"["}},
+	} {
+		l := &annotations.Linter{}
+		if err := l.Configure(settings); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package applyorder
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+)
+
+const (
+	Name        = "apply-order"
+	Description = "Ensures Namespaces, CRDs and webhook backends are defined before the resources depending on them"
+	Since       = "v0.2.0"
+
+	SyncWaveAnnotation = "argocd.argoproj.io/sync-wave"
+	HelmHookAnnotation = "helm.sh/hook"
+)
+
+type Config struct {
+	CheckNamespaces     bool     `mapstructure:"check-namespaces"`
+	CheckCRDs           bool     `mapstructure:"check-crds"`
+	CheckWebhooks       bool     `mapstructure:"check-webhooks"`
+	OrderingAnnotations []string `mapstructure:"ordering-annotations"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		CheckNamespaces: true,
+		CheckCRDs:       true,
+		CheckWebhooks:   true,
+		OrderingAnnotations: []string{
+			SyncWaveAnnotation,
+			HelmHookAnnotation,
+		},
+	}
+}
+
+func init() {
+	linter.Register(&Linter{
+		config: DefaultConfig(),
+	})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// Lint checks a single object, the runner uses LintSet instead which indexes
+// the objects once for the whole run
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, ok := linter.AllObjectsFromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	index, ok := linter.ObjectIndexFromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	return l.check(obj, index, newObjectIndex(allObjects)), nil
+}
+
+// LintSet checks all the objects against an index of the Namespaces, CRDs
+// and Services of the set, which keeps the check linear in the number of
+// objects
+func (l *Linter) LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]linter.Issue, error) {
+	idx := newObjectIndex(objects)
+
+	var issues []linter.Issue
+	for i, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, l.check(obj, i, idx)...)
+	}
+
+	return issues, nil
+}
+
+func (l *Linter) check(obj unstructured.Unstructured, index int, idx *objectIndex) []linter.Issue {
+	var issues []linter.Issue
+
+	if l.config.CheckNamespaces {
+		issues = append(issues, l.checkNamespace(obj, index, idx)...)
+	}
+
+	if l.config.CheckCRDs {
+		issues = append(issues, l.checkCRD(obj, index, idx)...)
+	}
+
+	if l.config.CheckWebhooks && gvk.IsAnyGVK(obj, gvk.ValidatingWebhookConfiguration, gvk.MutatingWebhookConfiguration) {
+		issues = append(issues, l.checkWebhook(obj, index, idx)...)
+	}
+
+	return issues
+}
+
+// position is the position of an object within the set
+type position struct {
+	index int
+	obj   unstructured.Unstructured
+}
+
+// objectIndex maps the objects other resources depend on to their first
+// position within the set
+type objectIndex struct {
+	namespaces map[string]position
+	crds       map[schema.GroupKind]position
+	services   map[types.NamespacedName]position
+}
+
+func newObjectIndex(objects []unstructured.Unstructured) *objectIndex {
+	idx := objectIndex{
+		namespaces: make(map[string]position),
+		crds:       make(map[schema.GroupKind]position),
+		services:   make(map[types.NamespacedName]position),
+	}
+
+	for i, o := range objects {
+		p := position{index: i, obj: o}
+
+		switch {
+		case gvk.IsGVK(o, gvk.Namespace):
+			if _, ok := idx.namespaces[o.GetName()]; !ok {
+				idx.namespaces[o.GetName()] = p
+			}
+		case gvk.IsGVK(o, gvk.CustomResourceDefinition):
+			group, _, _ := unstructured.NestedString(o.Object, "spec", "group")
+			kind, _, _ := unstructured.NestedString(o.Object, "spec", "names", "kind")
+			gk := schema.GroupKind{Group: group, Kind: kind}
+			if _, ok := idx.crds[gk]; !ok {
+				idx.crds[gk] = p
+			}
+		case gvk.IsGVK(o, gvk.Service):
+			nn := types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}
+			if _, ok := idx.services[nn]; !ok {
+				idx.services[nn] = p
+			}
+		}
+	}
+
+	return &idx
+}
+
+func (l *Linter) checkNamespace(obj unstructured.Unstructured, index int, idx *objectIndex) []linter.Issue {
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		return nil
+	}
+
+	ns, ok := idx.namespaces[namespace]
+	if !ok || ns.index < index {
+		return nil
+	}
+
+	return []linter.Issue{{
+		Severity:   linter.SeverityError,
+		Linter:     l.Name(),
+		Message:    fmt.Sprintf("Namespace %q is defined after the resources it contains", namespace),
+		Resource:   common.ResourceRef(obj),
+		Field:      fieldpath.Path("metadata", "namespace"),
+		Value:      namespace,
+		Suggestion: "Move the Namespace before the resources it contains",
+		Related:    []linter.ResourceRef{common.ResourceRef(ns.obj)},
+	}}
+}
+
+func (l *Linter) checkCRD(obj unstructured.Unstructured, index int, idx *objectIndex) []linter.Issue {
+	objGVK := obj.GroupVersionKind()
+	if objGVK.Group == "" {
+		return nil
+	}
+
+	crd, ok := idx.crds[objGVK.GroupKind()]
+	if !ok {
+		return nil
+	}
+
+	related := []linter.ResourceRef{common.ResourceRef(crd.obj)}
+
+	if crd.index > index {
+		return []linter.Issue{{
+			Severity:   linter.SeverityError,
+			Linter:     l.Name(),
+			Message:    fmt.Sprintf("CustomResourceDefinition %q is defined after this custom resource", crd.obj.GetName()),
+			Resource:   common.ResourceRef(obj),
+			Suggestion: "Move the CustomResourceDefinition before its custom resources",
+			Related:    related,
+		}}
+	}
+
+	if !l.hasOrdering(obj) {
+		return []linter.Issue{{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Message:    fmt.Sprintf("Custom resource is shipped together with its CustomResourceDefinition %q without ordering annotations", crd.obj.GetName()),
+			Resource:   common.ResourceRef(obj),
+			Field:      fieldpath.Path("metadata", "annotations"),
+			Suggestion: fmt.Sprintf("Add one of %v so the CRD is established before the resource is applied", l.config.OrderingAnnotations),
+			Related:    related,
+		}}
+	}
+
+	crWave, crOK := syncWave(obj)
+	crdWave, crdOK := syncWave(crd.obj)
+	if crOK && crdOK && crWave <= crdWave {
+		return []linter.Issue{{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Message:    fmt.Sprintf("Custom resource sync-wave %d is not after its CustomResourceDefinition sync-wave %d", crWave, crdWave),
+			Resource:   common.ResourceRef(obj),
+			Field:      fieldpath.Path("metadata", "annotations", SyncWaveAnnotation),
+			Value:      obj.GetAnnotations()[SyncWaveAnnotation],
+			Suggestion: "Use a sync-wave greater than the one of the CustomResourceDefinition",
+			Related:    related,
+		}}
+	}
+
+	return nil
+}
+
+func (l *Linter) checkWebhook(obj unstructured.Unstructured, index int, idx *objectIndex) []linter.Issue {
+	webhooks, _, _ := unstructured.NestedSlice(obj.Object, "webhooks")
+
+	var issues []linter.Issue
+	for w, webhook := range webhooks {
+		webhookMap, ok := webhook.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		namespace, _, _ := unstructured.NestedString(webhookMap, "clientConfig", "service", "namespace")
+		name, _, _ := unstructured.NestedString(webhookMap, "clientConfig", "service", "name")
+		if name == "" {
+			continue
+		}
+
+		svc, ok := idx.services[types.NamespacedName{Namespace: namespace, Name: name}]
+		if !ok || svc.index < index {
+			continue
+		}
+
+		issues = append(issues, linter.Issue{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Message:    fmt.Sprintf("Webhook backend Service %s/%s is defined after the webhook configuration", namespace, name),
+			Resource:   common.ResourceRef(obj),
+			Field:      fieldpath.Path("webhooks", w, "clientConfig", "service"),
+			Suggestion: "Move the webhook configuration after the Service and workload serving it",
+			Related:    []linter.ResourceRef{common.ResourceRef(svc.obj)},
+		})
+	}
+
+	return issues
+}
+
+func (l *Linter) hasOrdering(obj unstructured.Unstructured) bool {
+	annotations := obj.GetAnnotations()
+	for _, a := range l.config.OrderingAnnotations {
+		if _, ok := annotations[a]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func syncWave(obj unstructured.Unstructured) (int, bool) {
+	value, ok := obj.GetAnnotations()[SyncWaveAnnotation]
+	if !ok {
+		return 0, false
+	}
+
+	wave, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return wave, true
+}
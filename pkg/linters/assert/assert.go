@@ -0,0 +1,375 @@
+package assert
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+)
+
+// Operators of the assertions
+const (
+	OperatorExists       = "exists"
+	OperatorNotExists    = "not-exists"
+	OperatorEquals       = "equals"
+	OperatorNotEquals    = "not-equals"
+	OperatorIn           = "in"
+	OperatorNotIn        = "not-in"
+	OperatorMatchesRegex = "matches-regex"
+	OperatorGte          = "gte"
+	OperatorGt           = "gt"
+	OperatorLte          = "lte"
+	OperatorLt           = "lt"
+)
+
+// wildcard matches every element of an array in a rule field
+const wildcard = "[*]"
+
+// Rule asserts that the values at Field satisfy the operator, an issue is
+// reported for every value that does not
+type Rule struct {
+	Field      string          `mapstructure:"field"`
+	Operator   string          `mapstructure:"operator"`
+	Value      interface{}     `mapstructure:"value"`
+	Message    string          `mapstructure:"message"`
+	Severity   linter.Severity `mapstructure:"severity"`
+	Suggestion string          `mapstructure:"suggestion"`
+	DocURL     string          `mapstructure:"doc-url"`
+	// Kinds restricts the rule to objects of the listed kinds
+	Kinds []string `mapstructure:"kinds"`
+
+	// parts are the segments of the field between wildcards
+	parts   [][]interface{}
+	regexp  *regexp.Regexp
+	values  []interface{}
+	compare *resource.Quantity
+}
+
+type Linter struct {
+	name        string
+	description string
+	rules       []Rule
+}
+
+type Factory struct{}
+
+func (f *Factory) Create(name string, description string) linter.Linter {
+	return &Linter{
+		name:        name,
+		description: description,
+	}
+}
+
+func init() {
+	linter.Register(&Linter{
+		name:        "assert",
+		description: "Asserts the values of fields of Kubernetes resources",
+	})
+	linter.RegisterFactory("assert", &Factory{})
+}
+
+func New(name string, description string) *Linter {
+	return &Linter{
+		name:        name,
+		description: description,
+	}
+}
+
+func (l *Linter) Name() string {
+	return l.name
+}
+
+func (l *Linter) Description() string {
+	return l.description
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	rulesData, ok := settings["rules"].([]interface{})
+	if !ok {
+		return fmt.Errorf("rules must be an array")
+	}
+
+	l.rules = make([]Rule, 0, len(rulesData))
+	for i, ruleData := range rulesData {
+		rule := Rule{
+			Severity: linter.SeverityError,
+		}
+
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			ErrorUnused: true,
+			Result:      &rule,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := decoder.Decode(ruleData); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+
+		if err := rule.compile(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+
+		l.rules = append(l.rules, rule)
+	}
+
+	return nil
+}
+
+func (l *Linter) Lint(_ context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	var issues []linter.Issue
+
+	ref := common.ResourceRef(obj)
+
+	for _, rule := range l.rules {
+		if len(rule.Kinds) > 0 && !slices.Contains(rule.Kinds, obj.GetKind()) {
+			continue
+		}
+
+		for _, m := range rule.resolve(obj.Object) {
+			ok, actual := rule.check(m)
+			if ok {
+				continue
+			}
+
+			message := rule.Message
+			if message == "" {
+				message = fmt.Sprintf("%s %s, expected %s", m.path, actual, rule.expectation())
+			}
+
+			issues = append(issues, linter.Issue{
+				Severity:   rule.Severity,
+				Linter:     l.Name(),
+				Message:    message,
+				Resource:   ref,
+				Field:      m.path,
+				Suggestion: rule.Suggestion,
+				DocURL:     rule.DocURL,
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// compile parses the field and the expected value of the rule, so that
+// rules are validated at configuration time
+func (r *Rule) compile() error {
+	if r.Field == "" {
+		return fmt.Errorf("field is required")
+	}
+
+	field := fieldpath.Normalize(r.Field)
+
+	for i, part := range strings.Split(field, wildcard) {
+		if i > 0 {
+			part = fieldpath.Root + part
+		}
+
+		segments, err := fieldpath.Segments(part)
+		if err != nil {
+			return err
+		}
+
+		r.parts = append(r.parts, segments)
+	}
+
+	switch r.Operator {
+	case OperatorExists, OperatorNotExists:
+		return nil
+	case OperatorEquals, OperatorNotEquals:
+		if r.Value == nil {
+			return fmt.Errorf("value is required by %s", r.Operator)
+		}
+	case OperatorIn, OperatorNotIn:
+		values, ok := r.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("value must be a list for %s", r.Operator)
+		}
+		r.values = values
+	case OperatorMatchesRegex:
+		expr, ok := r.Value.(string)
+		if !ok {
+			return fmt.Errorf("value must be a string for %s", r.Operator)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid regexp %q: %w", expr, err)
+		}
+		r.regexp = re
+	case OperatorGte, OperatorGt, OperatorLte, OperatorLt:
+		q, err := resource.ParseQuantity(fmt.Sprint(r.Value))
+		if err != nil {
+			return fmt.Errorf("value %v must be a number or a quantity for %s", r.Value, r.Operator)
+		}
+		r.compare = &q
+	case "":
+		return fmt.Errorf("operator is required")
+	default:
+		return fmt.Errorf("unsupported operator %q", r.Operator)
+	}
+
+	return nil
+}
+
+// match is a value found at the rule field, or the first missing field of
+// the path when the value is not set
+type match struct {
+	path   string
+	value  interface{}
+	exists bool
+}
+
+// resolve returns the values at the rule field, every element of the
+// arrays matched by a wildcard is visited
+func (r *Rule) resolve(object map[string]interface{}) []match {
+	var result []match
+
+	var walk func(value interface{}, path string, part int)
+	walk = func(value interface{}, path string, part int) {
+		for _, segment := range r.parts[part] {
+			next, ok := child(value, segment)
+			if !ok {
+				result = append(result, match{path: fieldpath.Join(path, segment)})
+				return
+			}
+
+			value = next
+			path = fieldpath.Join(path, segment)
+		}
+
+		if part == len(r.parts)-1 {
+			result = append(result, match{path: path, value: value, exists: true})
+			return
+		}
+
+		items, ok := value.([]interface{})
+		if !ok {
+			result = append(result, match{path: path})
+			return
+		}
+
+		for i, item := range items {
+			walk(item, fieldpath.Join(path, i), part+1)
+		}
+	}
+
+	walk(object, fieldpath.Root, 0)
+
+	return result
+}
+
+func child(value interface{}, segment interface{}) (interface{}, bool) {
+	switch s := segment.(type) {
+	case string:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		v, ok := m[s]
+		return v, ok
+	case int:
+		items, ok := value.([]interface{})
+		if !ok || s < 0 || s >= len(items) {
+			return nil, false
+		}
+		return items[s], true
+	}
+
+	return nil, false
+}
+
+// check reports whether the value satisfies the rule and describes the
+// actual value. Missing values only fail exists, the other operators only
+// assert the values that are set.
+func (r *Rule) check(m match) (bool, string) {
+	switch r.Operator {
+	case OperatorExists:
+		return m.exists, "is not set"
+	case OperatorNotExists:
+		return !m.exists, "is set"
+	}
+
+	if !m.exists {
+		return true, ""
+	}
+
+	actual := fmt.Sprintf("is %v", m.value)
+
+	switch r.Operator {
+	case OperatorEquals:
+		return equal(m.value, r.Value), actual
+	case OperatorNotEquals:
+		return !equal(m.value, r.Value), actual
+	case OperatorIn, OperatorNotIn:
+		found := slices.ContainsFunc(r.values, func(v interface{}) bool {
+			return equal(m.value, v)
+		})
+		return found == (r.Operator == OperatorIn), actual
+	case OperatorMatchesRegex:
+		return r.regexp.MatchString(fmt.Sprint(m.value)), actual
+	}
+
+	q, err := resource.ParseQuantity(fmt.Sprint(m.value))
+	if err != nil {
+		return false, fmt.Sprintf("is %v, not a quantity", m.value)
+	}
+
+	c := q.Cmp(*r.compare)
+
+	switch r.Operator {
+	case OperatorGte:
+		return c >= 0, actual
+	case OperatorGt:
+		return c > 0, actual
+	case OperatorLte:
+		return c <= 0, actual
+	default:
+		return c < 0, actual
+	}
+}
+
+// expectation describes the assertion for the default message
+func (r *Rule) expectation() string {
+	switch r.Operator {
+	case OperatorExists:
+		return "it to be set"
+	case OperatorNotExists:
+		return "it not to be set"
+	case OperatorEquals:
+		return fmt.Sprintf("%v", r.Value)
+	case OperatorNotEquals:
+		return fmt.Sprintf("anything but %v", r.Value)
+	case OperatorIn:
+		return fmt.Sprintf("one of %v", r.Value)
+	case OperatorNotIn:
+		return fmt.Sprintf("none of %v", r.Value)
+	case OperatorMatchesRegex:
+		return fmt.Sprintf("a match of %v", r.Value)
+	case OperatorGte:
+		return fmt.Sprintf(">= %v", r.Value)
+	case OperatorGt:
+		return fmt.Sprintf("> %v", r.Value)
+	case OperatorLte:
+		return fmt.Sprintf("<= %v", r.Value)
+	default:
+		return fmt.Sprintf("< %v", r.Value)
+	}
+}
+
+// equal compares values by their string form, so that a number or boolean
+// written as a string in the rule matches the typed value of the object
+func equal(a interface{}, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
@@ -5,9 +5,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/jq"
-	"github.com/mitchellh/mapstructure"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
@@ -17,6 +17,7 @@ import (
 const (
 	Name        = "cluster-role-binding-security"
 	Description = "Validates ClusterRoleBindings for overly permissive group assignments"
+	Since       = "v0.1.0"
 )
 
 type Config struct {
@@ -26,16 +27,22 @@ type Config struct {
 	CriticalRoles              []string `mapstructure:"critical-roles"`
 }
 
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		DisallowedGroups: []string{
+			"system:authenticated",
+			"system:unauthenticated",
+			"system:serviceaccounts",
+		},
+		WarnNamespaceGroups: true,
+	}
+}
+
 func init() {
 	linter.Register(&Linter{
-		config: Config{
-			DisallowedGroups: []string{
-				"system:authenticated",
-				"system:unauthenticated",
-				"system:serviceaccounts",
-			},
-			WarnNamespaceGroups: true,
-		},
+		config: DefaultConfig(),
 	})
 }
 
@@ -51,33 +58,82 @@ func (l *Linter) Description() string {
 	return Description
 }
 
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityStable
+}
+
 func (l *Linter) Configure(settings map[string]interface{}) error {
-	return mapstructure.Decode(settings, &l.config)
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// SupportsOverrides tells that the settings can be overridden per object
+func (l *Linter) SupportsOverrides() bool {
+	return true
 }
 
 func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	// settings overridden by the object annotations apply to this object only
+	config, overridden, invalid := linter.ObjectConfig(obj, Name, l.config)
+	if overridden {
+		l = &Linter{config: config}
+	}
+
+	issues, err := l.lint(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(invalid, issues...), nil
+}
+
+func (l *Linter) lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
 	if !gvk.IsGVK(obj, gvk.ClusterRoleBinding) {
 		return nil, nil
 	}
 
 	var issues []linter.Issue
 
-	roleName, _, err := jq.QueryString(obj, ".roleRef.name")
+	// the group subjects are returned as [index, name] pairs, the index is
+	// used to report the path of the subject
+	results, err := jq.QueryMany(ctx, obj,
+		`.roleRef.name?`,
+		`[.subjects | arrays | to_entries[] | select(.value | type == "object" and .kind == "Group") | [.key, .value.name]]`,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	groupSubjects, err := jq.QueryArray(obj, `.subjects[] | select(.kind == "Group") | .name`)
-	if err != nil {
-		return nil, err
-	}
+	roleName, _ := results[0].(string)
+	groupSubjects, _ := results[1].([]interface{})
 
 	for _, subject := range groupSubjects {
-		name, ok := subject.(string)
+		pair, _ := subject.([]interface{})
+		if len(pair) != 2 {
+			continue
+		}
+
+		index, _ := pair[0].(int)
+		name, ok := pair[1].(string)
 		if !ok {
 			continue
 		}
 
+		field := fieldpath.Path("subjects", index, "name")
+
 		for _, disallowedGroup := range l.config.DisallowedGroups {
 			if name == disallowedGroup {
 				severity := linter.SeverityError
@@ -90,7 +146,8 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 					Linter:     l.Name(),
 					Message:    fmt.Sprintf("Binds to dangerous group %q (role: %s)", name, roleName),
 					Resource:   common.ResourceRef(obj),
-					Field:      "subjects",
+					Field:      field,
+					Value:      name,
 					Suggestion: "Use specific ServiceAccounts or Users instead of broad groups",
 				})
 			}
@@ -108,7 +165,8 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				Linter:     l.Name(),
 				Message:    fmt.Sprintf("Binds to all ServiceAccounts in namespace %q (role: %s)", namespace, roleName),
 				Resource:   common.ResourceRef(obj),
-				Field:      "subjects",
+				Field:      field,
+				Value:      name,
 				Suggestion: "Use specific ServiceAccount instead of namespace-wide group",
 			})
 		}
@@ -0,0 +1,359 @@
+package configreferences
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "config-references"
+	Description = "Ensures the ConfigMaps and Secrets referenced by workloads, and their keys, are defined in the manifests"
+	Since       = "v0.2.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckMissingObject = "missing-object"
+	CheckMissingKey    = "missing-key"
+)
+
+type Config struct {
+	// ExternalConfigMaps are the names, glob patterns are supported, of the
+	// ConfigMaps created outside the manifests
+	ExternalConfigMaps []string `mapstructure:"external-config-maps"`
+	// ExternalSecrets are the names, glob patterns are supported, of the
+	// Secrets created outside the manifests, e.g. by an operator
+	ExternalSecrets []string `mapstructure:"external-secrets"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		// published by Kubernetes in every namespace
+		ExternalConfigMaps: []string{"kube-root-ca.crt"},
+	}
+}
+
+func init() {
+	linter.Register(&Linter{
+		config: DefaultConfig(),
+	})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckMissingObject, CheckMissingKey}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	// configured names replace the defaults rather than being merged into
+	// them element by element
+	if _, ok := settings["external-config-maps"]; ok {
+		l.config.ExternalConfigMaps = nil
+	}
+
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	for _, pattern := range slices.Concat(l.config.ExternalConfigMaps, l.config.ExternalSecrets) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid external name pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// Lint checks a single object, the runner uses LintSet instead which indexes
+// the ConfigMaps and Secrets once for the whole run
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, _ := linter.AllObjectsFromContext(ctx)
+
+	return l.check(ctx, obj, newIndex(allObjects)), nil
+}
+
+func (l *Linter) LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]linter.Issue, error) {
+	idx := newIndex(objects)
+
+	var issues []linter.Issue
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, l.check(ctx, obj, idx)...)
+	}
+
+	return issues, nil
+}
+
+// reference is a ConfigMap or Secret, and optionally one of its keys, used by
+// a pod
+type reference struct {
+	kind     string
+	name     string
+	key      string
+	optional *bool
+	// field is the JSONPath of the name, keyField the one of the key
+	field    string
+	keyField string
+}
+
+func (l *Linter) check(ctx context.Context, obj unstructured.Unstructured, idx index) []linter.Issue {
+	if !gvk.IsWorkloadOrPod(obj) {
+		return nil
+	}
+
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil
+	}
+
+	specPath, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil
+	}
+
+	var issues []linter.Issue
+
+	// the items of a volume share the field of its object, which is only
+	// reported once
+	reported := make(map[string]bool)
+
+	for _, ref := range references(spec, specPath) {
+		if ref.name == "" || ref.optional != nil && *ref.optional || l.external(ref.kind, ref.name) {
+			continue
+		}
+
+		keys, ok := idx[indexKey(ref.kind, obj.GetNamespace(), ref.name)]
+		if !ok {
+			if reported[ref.field] {
+				continue
+			}
+			reported[ref.field] = true
+
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityError,
+				Linter:     l.Name(),
+				Check:      CheckMissingObject,
+				Message:    fmt.Sprintf("%s %q uses %s %q, which is not defined in the manifests", obj.GetKind(), obj.GetName(), ref.kind, ref.name),
+				Resource:   common.ResourceRef(obj),
+				Field:      ref.field,
+				Value:      ref.name,
+				Suggestion: fmt.Sprintf("Add the %s to the manifests, fix the name, set optional: true, or list it as external when it is created elsewhere", ref.kind),
+			})
+			continue
+		}
+
+		if ref.key != "" && !keys[ref.key] {
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityError,
+				Linter:     l.Name(),
+				Check:      CheckMissingKey,
+				Message:    fmt.Sprintf("%s %q uses key %q of %s %q, which does not define it", obj.GetKind(), obj.GetName(), ref.key, ref.kind, ref.name),
+				Resource:   common.ResourceRef(obj),
+				Field:      ref.keyField,
+				Value:      ref.key,
+				Suggestion: fmt.Sprintf("Add the key to the %s, fix the key, or set optional: true", ref.kind),
+				Related:    []linter.ResourceRef{{APIVersion: "v1", Kind: ref.kind, Namespace: obj.GetNamespace(), Name: ref.name}},
+			})
+		}
+	}
+
+	return issues
+}
+
+func (l *Linter) external(kind string, name string) bool {
+	patterns := l.config.ExternalConfigMaps
+	if kind == gvk.Secret.Kind {
+		patterns = l.config.ExternalSecrets
+	}
+
+	return glob.MatchAny(patterns, name)
+}
+
+// references returns the ConfigMaps and Secrets used by the environment
+// variables and volumes of the pod
+func references(spec *corev1.PodSpec, specPath string) []reference {
+	var refs []reference
+
+	for _, group := range []struct {
+		field      string
+		containers []corev1.Container
+	}{
+		{field: "initContainers", containers: spec.InitContainers},
+		{field: "containers", containers: spec.Containers},
+	} {
+		for i, c := range group.containers {
+			containerPath := fieldpath.Join(specPath, group.field, i)
+
+			for j, e := range c.Env {
+				if e.ValueFrom == nil {
+					continue
+				}
+
+				base := fieldpath.Join(containerPath, "env", j, "valueFrom")
+
+				if r := e.ValueFrom.ConfigMapKeyRef; r != nil {
+					field := fieldpath.Join(base, "configMapKeyRef")
+					refs = append(refs, reference{
+						kind: gvk.ConfigMap.Kind, name: r.Name, key: r.Key, optional: r.Optional,
+						field: fieldpath.Join(field, "name"), keyField: fieldpath.Join(field, "key"),
+					})
+				}
+
+				if r := e.ValueFrom.SecretKeyRef; r != nil {
+					field := fieldpath.Join(base, "secretKeyRef")
+					refs = append(refs, reference{
+						kind: gvk.Secret.Kind, name: r.Name, key: r.Key, optional: r.Optional,
+						field: fieldpath.Join(field, "name"), keyField: fieldpath.Join(field, "key"),
+					})
+				}
+			}
+
+			for j, e := range c.EnvFrom {
+				base := fieldpath.Join(containerPath, "envFrom", j)
+
+				if r := e.ConfigMapRef; r != nil {
+					refs = append(refs, reference{
+						kind: gvk.ConfigMap.Kind, name: r.Name, optional: r.Optional,
+						field: fieldpath.Join(base, "configMapRef", "name"),
+					})
+				}
+
+				if r := e.SecretRef; r != nil {
+					refs = append(refs, reference{
+						kind: gvk.Secret.Kind, name: r.Name, optional: r.Optional,
+						field: fieldpath.Join(base, "secretRef", "name"),
+					})
+				}
+			}
+		}
+	}
+
+	for i, v := range spec.Volumes {
+		base := fieldpath.Join(specPath, "volumes", i)
+
+		if s := v.ConfigMap; s != nil {
+			refs = append(refs, volumeReferences(gvk.ConfigMap.Kind, s.Name, s.Items, s.Optional, fieldpath.Join(base, "configMap"), "name")...)
+		}
+
+		if s := v.Secret; s != nil {
+			refs = append(refs, volumeReferences(gvk.Secret.Kind, s.SecretName, s.Items, s.Optional, fieldpath.Join(base, "secret"), "secretName")...)
+		}
+
+		if v.Projected == nil {
+			continue
+		}
+
+		for j, source := range v.Projected.Sources {
+			sourcePath := fieldpath.Join(base, "projected", "sources", j)
+
+			if s := source.ConfigMap; s != nil {
+				refs = append(refs, volumeReferences(gvk.ConfigMap.Kind, s.Name, s.Items, s.Optional, fieldpath.Join(sourcePath, "configMap"), "name")...)
+			}
+
+			if s := source.Secret; s != nil {
+				refs = append(refs, volumeReferences(gvk.Secret.Kind, s.Name, s.Items, s.Optional, fieldpath.Join(sourcePath, "secret"), "name")...)
+			}
+		}
+	}
+
+	return refs
+}
+
+// volumeReferences returns the reference of a volume source to the object,
+// and one per key its items project
+func volumeReferences(kind string, name string, items []corev1.KeyToPath, optional *bool, base string, nameField string) []reference {
+	refs := []reference{{kind: kind, name: name, optional: optional, field: fieldpath.Join(base, nameField)}}
+
+	for i, item := range items {
+		refs = append(refs, reference{
+			kind: kind, name: name, key: item.Key, optional: optional,
+			field: fieldpath.Join(base, nameField), keyField: fieldpath.Join(base, "items", i, "key"),
+		})
+	}
+
+	return refs
+}
+
+// index holds the keys of the ConfigMaps and Secrets of the manifests
+type index map[string]map[string]bool
+
+func newIndex(objects []unstructured.Unstructured) index {
+	idx := make(index)
+
+	for _, obj := range objects {
+		var fields []string
+
+		switch {
+		case gvk.IsGVK(obj, gvk.ConfigMap):
+			fields = []string{"data", "binaryData"}
+		case gvk.IsGVK(obj, gvk.Secret):
+			fields = []string{"data", "stringData"}
+		default:
+			continue
+		}
+
+		keys := make(map[string]bool)
+		for _, field := range fields {
+			values, _, _ := unstructured.NestedMap(obj.Object, field)
+			for key := range values {
+				keys[key] = true
+			}
+		}
+
+		idx[indexKey(obj.GetKind(), obj.GetNamespace(), obj.GetName())] = keys
+	}
+
+	return idx
+}
+
+func indexKey(kind string, namespace string, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
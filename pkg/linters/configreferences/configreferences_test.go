@@ -0,0 +1,160 @@
+package configreferences_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/configreferences"
+)
+
+const manifests = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+data:
+  level: debug
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: web:1.0.0
+          env:
+            - name: LEVEL
+              valueFrom:
+                configMapKeyRef:
+                  name: settings
+                  key: level
+            - name: FORMAT
+              valueFrom:
+                configMapKeyRef:
+                  name: settings
+                  key: format
+            - name: PASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: credentials
+                  key: password
+            - name: TOKEN
+              valueFrom:
+                secretKeyRef:
+                  name: token
+                  key: token
+                  optional: true
+      volumes:
+        - name: ca
+          configMap:
+            name: kube-root-ca.crt
+`
+
+func TestConfigReferences(t *testing.T) {
+	missingKey := lintertest.Expectation{
+		Severity: linter.SeverityError,
+		Linter:   configreferences.Name,
+		Message:  `uses key "format" of ConfigMap "settings", which does not define it`,
+		Field:    "$.spec.template.spec.containers[0].env[1].valueFrom.configMapKeyRef.key",
+		Kind:     "Deployment",
+		Name:     "web",
+	}
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		expected []lintertest.Expectation
+	}{
+		{
+			name: "default",
+			expected: []lintertest.Expectation{
+				missingKey,
+				{
+					Severity: linter.SeverityError,
+					Linter:   configreferences.Name,
+					Message:  `uses Secret "credentials", which is not defined in the manifests`,
+					Field:    "$.spec.template.spec.containers[0].env[2].valueFrom.secretKeyRef.name",
+					Kind:     "Deployment",
+					Name:     "web",
+				},
+			},
+		},
+		{
+			name: "external secrets",
+			settings: map[string]interface{}{
+				"external-secrets": []interface{}{"cred*"},
+			},
+			expected: []lintertest.Expectation{missingKey},
+		},
+		{
+			name: "external config maps replace the defaults",
+			settings: map[string]interface{}{
+				"external-config-maps": []interface{}{"other"},
+				"external-secrets":     []interface{}{"credentials"},
+			},
+			expected: []lintertest.Expectation{
+				missingKey,
+				{
+					Severity: linter.SeverityError,
+					Message:  `uses ConfigMap "kube-root-ca.crt", which is not defined in the manifests`,
+					Field:    "$.spec.template.spec.volumes[0].configMap.name",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &configreferences.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			lintertest.Configure(t, l, tt.settings)
+
+			issues := lintertest.Run(t, l, lintertest.ParseObjects(t, manifests)...)
+			lintertest.AssertIssues(t, issues, tt.expected...)
+		})
+	}
+}
+
+// TestConfigReferencesNamespace does not resolve references to the objects
+// of another namespace
+func TestConfigReferencesNamespace(t *testing.T) {
+	l := &configreferences.Linter{}
+	if err := l.Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	objects := lintertest.ParseObjects(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: other
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+  namespace: default
+spec:
+  containers:
+    - name: app
+      image: app:1.0.0
+      envFrom:
+        - configMapRef:
+            name: settings
+`)
+
+	lintertest.AssertIssues(t, lintertest.Run(t, l, objects...), lintertest.Expectation{
+		Severity: linter.SeverityError,
+		Message:  `Pod "app" uses ConfigMap "settings", which is not defined in the manifests`,
+		Field:    "$.spec.containers[0].envFrom[0].configMapRef.name",
+	})
+}
@@ -0,0 +1,303 @@
+package egresspolicies
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+)
+
+const (
+	Name        = "egress-policies"
+	Description = "Ensures workloads using external services have egress policies and egress policies select workloads"
+	Since       = "v0.2.0"
+
+	// serviceEntry is the kind of the Istio resources registering external
+	// services, exported to every namespace unless they set exportTo
+	serviceEntry = "ServiceEntry"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckMissingPolicy = "missing-policy"
+	CheckOrphanPolicy  = "orphan-policy"
+)
+
+type Config struct {
+	// Annotation marks the workloads, or their pod templates, requiring
+	// external access, any value but "false" counts
+	Annotation string `mapstructure:"annotation"`
+	// EgressKinds are the kinds of the resources, besides NetworkPolicies
+	// allowing egress outside of the cluster, granting external access to
+	// the workloads of their namespace, such as Istio ServiceEntries
+	EgressKinds []string `mapstructure:"egress-kinds"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		Annotation:  "k8s-manifests-lint/external-access",
+		EgressKinds: []string{serviceEntry},
+	}
+}
+
+func init() {
+	linter.Register(&Linter{
+		config: DefaultConfig(),
+	})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckMissingPolicy, CheckOrphanPolicy}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	// configured kinds replace the defaults rather than being merged into
+	// them element by element
+	if _, ok := settings["egress-kinds"]; ok {
+		l.config.EgressKinds = nil
+	}
+
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	if l.config.Annotation == "" {
+		return fmt.Errorf("annotation is required")
+	}
+
+	return nil
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, _ := linter.AllObjectsFromContext(ctx)
+
+	switch {
+	case gvk.IsGVK(obj, gvk.NetworkPolicy):
+		return l.lintPolicy(obj, allObjects), nil
+	case gvk.IsWorkloadOrPod(obj):
+		return l.lintWorkload(obj, allObjects), nil
+	default:
+		return nil, nil
+	}
+}
+
+// lintWorkload reports the workloads requiring external access which are
+// neither selected by a NetworkPolicy allowing egress outside of the cluster
+// nor covered by an egress resource
+func (l *Linter) lintWorkload(obj unstructured.Unstructured, allObjects []unstructured.Unstructured) []linter.Issue {
+	metadata, podLabels, ok := podMetadata(obj)
+	if !ok {
+		return nil
+	}
+
+	field := fieldpath.Path("metadata", "annotations", l.config.Annotation)
+
+	value, ok := obj.GetAnnotations()[l.config.Annotation]
+	if !ok {
+		value, ok = podAnnotations(obj, metadata)[l.config.Annotation]
+		field = fieldpath.Join(fieldpath.Path(metadata...), "annotations", l.config.Annotation)
+	}
+
+	if !ok || value == "false" {
+		return nil
+	}
+
+	for _, other := range allObjects {
+		if gvk.IsGVK(other, gvk.NetworkPolicy) {
+			policy, err := networkPolicy(other)
+			if err != nil || policy.Namespace != obj.GetNamespace() || !allowsExternal(policy) {
+				continue
+			}
+
+			if selects(policy, podLabels) {
+				return nil
+			}
+
+			continue
+		}
+
+		if slices.Contains(l.config.EgressKinds, other.GetKind()) && visible(other, obj.GetNamespace()) {
+			return nil
+		}
+	}
+
+	return []linter.Issue{{
+		Severity: linter.SeverityWarning,
+		Linter:   l.Name(),
+		Check:    CheckMissingPolicy,
+		Message: fmt.Sprintf("%s %q requires external access but no NetworkPolicy allows its egress outside of the cluster",
+			obj.GetKind(), obj.GetName()),
+		Resource:   common.ResourceRef(obj),
+		Field:      field,
+		Suggestion: "Add a NetworkPolicy selecting the pods with an egress rule to the ipBlock of the external service, or an egress resource such as a ServiceEntry",
+	}}
+}
+
+// lintPolicy reports the egress NetworkPolicies selecting no workload of
+// their namespace
+func (l *Linter) lintPolicy(obj unstructured.Unstructured, allObjects []unstructured.Unstructured) []linter.Issue {
+	policy, err := networkPolicy(obj)
+	if err != nil || !isEgress(policy) {
+		return nil
+	}
+
+	for _, other := range allObjects {
+		if !gvk.IsWorkloadOrPod(other) || other.GetNamespace() != obj.GetNamespace() {
+			continue
+		}
+
+		if _, podLabels, ok := podMetadata(other); ok && selects(policy, podLabels) {
+			return nil
+		}
+	}
+
+	return []linter.Issue{{
+		Severity:   linter.SeverityWarning,
+		Linter:     l.Name(),
+		Check:      CheckOrphanPolicy,
+		Message:    fmt.Sprintf("Egress NetworkPolicy %q selects no workload in the manifests", obj.GetName()),
+		Resource:   common.ResourceRef(obj),
+		Field:      fieldpath.Path("spec", "podSelector"),
+		Suggestion: "Fix the podSelector to match the labels of the pod templates, or remove the stale policy",
+	}}
+}
+
+// podMetadata returns the path of the pod metadata of a workload and the
+// labels of its pods
+func podMetadata(obj unstructured.Unstructured) ([]interface{}, map[string]string, bool) {
+	var metadata []interface{}
+
+	switch {
+	case gvk.IsGVK(obj, gvk.Pod):
+		metadata = []interface{}{"metadata"}
+	case gvk.IsGVK(obj, gvk.CronJob):
+		metadata = []interface{}{"spec", "jobTemplate", "spec", "template", "metadata"}
+	case gvk.IsWorkload(obj):
+		metadata = []interface{}{"spec", "template", "metadata"}
+	default:
+		return nil, nil, false
+	}
+
+	podLabels, _, _ := unstructured.NestedStringMap(obj.Object, fields(metadata, "labels")...)
+
+	return metadata, podLabels, true
+}
+
+func podAnnotations(obj unstructured.Unstructured, metadata []interface{}) map[string]string {
+	annotations, _, _ := unstructured.NestedStringMap(obj.Object, fields(metadata, "annotations")...)
+	return annotations
+}
+
+func fields(segments []interface{}, field string) []string {
+	result := make([]string, 0, len(segments)+1)
+	for _, s := range segments {
+		result = append(result, s.(string))
+	}
+	return append(result, field)
+}
+
+func networkPolicy(obj unstructured.Unstructured) (*networkingv1.NetworkPolicy, error) {
+	var policy networkingv1.NetworkPolicy
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// isEgress reports whether the policy restricts egress, policies without
+// policyTypes do when they have egress rules
+func isEgress(policy *networkingv1.NetworkPolicy) bool {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return len(policy.Spec.Egress) > 0
+	}
+	return slices.Contains(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+}
+
+// allowsExternal reports whether an egress rule of the policy allows
+// traffic outside of the cluster: to any destination or to an ipBlock
+func allowsExternal(policy *networkingv1.NetworkPolicy) bool {
+	if !isEgress(policy) {
+		return false
+	}
+
+	for _, rule := range policy.Spec.Egress {
+		if len(rule.To) == 0 {
+			return true
+		}
+
+		for _, peer := range rule.To {
+			if peer.IPBlock != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func selects(policy *networkingv1.NetworkPolicy, podLabels map[string]string) bool {
+	selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(podLabels))
+}
+
+// visible reports whether an egress resource applies to the namespace:
+// resources of the same namespace, or without one, do and the ones
+// setting exportTo, like ServiceEntries, apply to the listed namespaces
+func visible(obj unstructured.Unstructured, namespace string) bool {
+	if obj.GetNamespace() == namespace || obj.GetNamespace() == "" {
+		return true
+	}
+
+	exportTo, found, _ := unstructured.NestedStringSlice(obj.Object, "spec", "exportTo")
+	if !found {
+		return obj.GetKind() == serviceEntry
+	}
+
+	return slices.Contains(exportTo, "*") || slices.Contains(exportTo, namespace)
+}
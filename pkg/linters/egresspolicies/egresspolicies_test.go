@@ -0,0 +1,187 @@
+package egresspolicies_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/egresspolicies"
+)
+
+const workloads = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: billing
+  namespace: apps
+  annotations:
+    k8s-manifests-lint/external-access: "true"
+spec:
+  template:
+    metadata:
+      labels:
+        app: billing
+    spec:
+      containers:
+        - name: billing
+          image: billing:1.0.0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: sync
+  namespace: apps
+spec:
+  jobTemplate:
+    spec:
+      template:
+        metadata:
+          labels:
+            app: sync
+          annotations:
+            example.com/egress: "yes"
+        spec:
+          containers:
+            - name: sync
+              image: sync:1.0.0
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: internal
+  namespace: apps
+  annotations:
+    k8s-manifests-lint/external-access: "false"
+spec:
+  containers:
+    - name: internal
+      image: internal:1.0.0
+`
+
+const billingPolicy = `
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: billing-egress
+  namespace: apps
+spec:
+  podSelector:
+    matchLabels:
+      app: billing
+  policyTypes: [Egress]
+  egress:
+    - to:
+        - ipBlock:
+            cidr: 203.0.113.0/24
+`
+
+const stalePolicy = `
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: payments-egress
+  namespace: apps
+spec:
+  podSelector:
+    matchLabels:
+      app: payments
+  egress:
+    - to:
+        - namespaceSelector: {}
+`
+
+const serviceEntry = `
+---
+apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: payments-api
+  namespace: mesh
+spec:
+  hosts: [api.payments.example.com]
+`
+
+func TestEgressPolicies(t *testing.T) {
+	billingIssue := lintertest.Expectation{
+		Severity: linter.SeverityWarning,
+		Linter:   egresspolicies.Name,
+		Message:  `Deployment "billing" requires external access but no NetworkPolicy allows its egress outside of the cluster`,
+		Field:    "$.metadata.annotations['k8s-manifests-lint/external-access']",
+		Kind:     "Deployment",
+	}
+
+	tests := []struct {
+		name      string
+		manifests string
+		settings  map[string]interface{}
+		expected  []lintertest.Expectation
+	}{
+		{
+			name:      "missing policy",
+			manifests: workloads,
+			expected:  []lintertest.Expectation{billingIssue},
+		},
+		{
+			name:      "policy allowing external egress",
+			manifests: workloads + billingPolicy,
+		},
+		{
+			name:      "orphan policy",
+			manifests: workloads + billingPolicy + stalePolicy,
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityWarning,
+				Linter:   egresspolicies.Name,
+				Message:  `Egress NetworkPolicy "payments-egress" selects no workload in the manifests`,
+				Field:    "$.spec.podSelector",
+				Kind:     "NetworkPolicy",
+			}},
+		},
+		{
+			name:      "ServiceEntry exported to every namespace",
+			manifests: workloads + serviceEntry,
+		},
+		{
+			name:      "egress kinds replace the defaults",
+			manifests: workloads + serviceEntry,
+			settings: map[string]interface{}{
+				"egress-kinds": []interface{}{"Sidecar"},
+			},
+			expected: []lintertest.Expectation{billingIssue},
+		},
+		{
+			name:      "annotation of the pod template",
+			manifests: workloads + billingPolicy,
+			settings: map[string]interface{}{
+				"annotation": "example.com/egress",
+			},
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityWarning,
+				Message:  `CronJob "sync" requires external access`,
+				Field:    "$.spec.jobTemplate.spec.template.metadata.annotations['example.com/egress']",
+				Kind:     "CronJob",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &egresspolicies.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			lintertest.Configure(t, l, tt.settings)
+
+			issues := lintertest.Run(t, l, lintertest.ParseObjects(t, tt.manifests)...)
+			lintertest.AssertIssues(t, issues, tt.expected...)
+		})
+	}
+}
+
+func TestEgressPoliciesInvalidSettings(t *testing.T) {
+	l := &egresspolicies.Linter{}
+	if err := l.Configure(map[string]interface{}{"annotation": ""}); err == nil {
+		t.Error("expected an error for an empty annotation")
+	}
+}
@@ -0,0 +1,203 @@
+package forbiddenresources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+)
+
+const (
+	Name        = "forbidden-resources"
+	Description = "Denies resources by API group, kind, namespace or field value"
+	Since       = "v0.2.0"
+)
+
+// Rule denies the resources matching all of its criteria. API groups, kinds
+// and namespaces are glob patterns, the core API group can be written as ""
+// or core, and an empty list matches everything. When Field is set, only
+// resources where the field holds one of Values are denied, or where the
+// field is set at all if Values is empty.
+type Rule struct {
+	APIGroups  []string        `mapstructure:"api-groups"`
+	Kinds      []string        `mapstructure:"kinds"`
+	Namespaces []string        `mapstructure:"namespaces"`
+	Field      string          `mapstructure:"field"`
+	Values     []string        `mapstructure:"values"`
+	Message    string          `mapstructure:"message"`
+	Suggestion string          `mapstructure:"suggestion"`
+	Severity   linter.Severity `mapstructure:"severity"`
+}
+
+type Config struct {
+	Rules []Rule `mapstructure:"rules"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{}
+}
+
+func init() {
+	linter.Register(&Linter{config: DefaultConfig()})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	for i := range l.config.Rules {
+		rule := &l.config.Rules[i]
+
+		if len(rule.APIGroups) == 0 && len(rule.Kinds) == 0 {
+			return fmt.Errorf("rules[%d]: api-groups or kinds is required", i)
+		}
+
+		switch rule.Severity {
+		case "":
+			rule.Severity = linter.SeverityError
+		case linter.SeverityFatal, linter.SeverityError, linter.SeverityWarning, linter.SeverityInfo:
+		default:
+			return fmt.Errorf("rules[%d]: invalid severity %q", i, rule.Severity)
+		}
+
+		rule.Field = fieldpath.Normalize(rule.Field)
+		if rule.Field != "" {
+			if _, err := fieldpath.Segments(rule.Field); err != nil {
+				return fmt.Errorf("rules[%d]: %w", i, err)
+			}
+		}
+
+		for j, group := range rule.APIGroups {
+			if group == "core" {
+				rule.APIGroups[j] = ""
+			}
+		}
+	}
+
+	return nil
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	var issues []linter.Issue
+
+	gvk := obj.GroupVersionKind()
+
+	for _, rule := range l.config.Rules {
+		if !selects(rule.APIGroups, gvk.Group) || !selects(rule.Kinds, gvk.Kind) || !selects(rule.Namespaces, obj.GetNamespace()) {
+			continue
+		}
+
+		var value interface{}
+		if rule.Field != "" {
+			v, ok := lookup(obj.Object, rule.Field)
+			if !ok || len(rule.Values) > 0 && !contains(rule.Values, fmt.Sprint(v)) {
+				continue
+			}
+			value = v
+		}
+
+		message := rule.Message
+		if message == "" {
+			message = fmt.Sprintf("%s resources are forbidden", gvk.Kind)
+			if rule.Field != "" {
+				message = fmt.Sprintf("%s resources with %s set to %v are forbidden", gvk.Kind, strings.TrimPrefix(rule.Field, fieldpath.Root+"."), value)
+			}
+		}
+
+		issues = append(issues, linter.Issue{
+			Severity:   rule.Severity,
+			Linter:     l.Name(),
+			Message:    message,
+			Resource:   common.ResourceRef(obj),
+			Field:      rule.Field,
+			Value:      value,
+			Suggestion: rule.Suggestion,
+		})
+	}
+
+	return issues, nil
+}
+
+// lookup returns the value found at the JSONPath in the object
+func lookup(obj map[string]interface{}, field string) (interface{}, bool) {
+	segments, err := fieldpath.Segments(field)
+	if err != nil {
+		return nil, false
+	}
+
+	var current interface{} = obj
+	for _, segment := range segments {
+		switch s := segment.(type) {
+		case string:
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = m[s]; !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := current.([]interface{})
+			if !ok || s < 0 || s >= len(a) {
+				return nil, false
+			}
+			current = a[s]
+		}
+	}
+
+	return current, true
+}
+
+// selects reports whether s matches one of the patterns, an empty list
+// matches everything
+func selects(patterns []string, s string) bool {
+	return len(patterns) == 0 || glob.MatchAny(patterns, s)
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
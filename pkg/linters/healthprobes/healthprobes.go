@@ -3,10 +3,13 @@ package healthprobes
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
-	"github.com/mitchellh/mapstructure"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
@@ -16,20 +19,53 @@ import (
 const (
 	Name        = "health-probes"
 	Description = "Ensures pods have liveness and readiness probes"
+	Since       = "v0.1.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckLivenessProbe            = "liveness-probe"
+	CheckReadinessProbe           = "readiness-probe"
+	CheckLivenessDependency       = "liveness-dependency"
+	CheckLivenessTimeout          = "liveness-timeout"
+	CheckLivenessFailureThreshold = "liveness-failure-threshold"
+	CheckLivenessSameAsReadiness  = "liveness-same-as-readiness"
+)
+
+// Kubernetes defaults of the probe settings left unset
+const (
+	defaultTimeoutSeconds = 1
+	defaultPeriodSeconds  = 10
 )
 
 type Config struct {
 	RequireLiveness  bool     `mapstructure:"require-liveness"`
 	RequireReadiness bool     `mapstructure:"require-readiness"`
 	ExcludeKinds     []string `mapstructure:"exclude-kinds"`
+
+	// CheckLivenessSafety reports liveness probes turning failures of
+	// dependencies or slow responses into restart storms
+	CheckLivenessSafety bool `mapstructure:"check-liveness-safety"`
+	// DependencyPaths are the substrings of liveness probe HTTP paths
+	// hinting at an endpoint checking external services
+	DependencyPaths []string `mapstructure:"dependency-paths"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		RequireLiveness:     true,
+		RequireReadiness:    true,
+		CheckLivenessSafety: true,
+		DependencyPaths:     []string{"deep", "depend", "database", "/db", "upstream", "external"},
+	}
 }
 
 func init() {
 	linter.Register(&Linter{
-		config: Config{
-			RequireLiveness:  true,
-			RequireReadiness: true,
-		},
+		config: DefaultConfig(),
 	})
 }
 
@@ -45,11 +81,60 @@ func (l *Linter) Description() string {
 	return Description
 }
 
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityStable
+}
+
+func (l *Linter) Checks() []string {
+	return []string{
+		CheckLivenessProbe,
+		CheckReadinessProbe,
+		CheckLivenessDependency,
+		CheckLivenessTimeout,
+		CheckLivenessFailureThreshold,
+		CheckLivenessSameAsReadiness,
+	}
+}
+
 func (l *Linter) Configure(settings map[string]interface{}) error {
-	return mapstructure.Decode(settings, &l.config)
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// SupportsOverrides tells that the settings can be overridden per object
+func (l *Linter) SupportsOverrides() bool {
+	return true
 }
 
 func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	// settings overridden by the object annotations apply to this object only
+	config, overridden, invalid := linter.ObjectConfig(obj, Name, l.config)
+	if overridden {
+		l = &Linter{config: config}
+	}
+
+	issues, err := l.lint(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(invalid, issues...), nil
+}
+
+func (l *Linter) lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
 	kind := obj.GetKind()
 
 	for _, excludeKind := range l.config.ExcludeKinds {
@@ -64,45 +149,133 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 
 	var issues []linter.Issue
 
-	containers, err := k8s.GetContainers(obj)
+	// malformed objects are treated as having no containers, consistently
+	// with k8s.GetContainers
+	spec, err := typed.PodSpec(ctx, obj)
 	if err != nil {
-		return nil, err
+		return nil, nil
 	}
 
-	for i, container := range containers {
-		containerMap, ok := container.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		name, _ := containerMap["name"].(string)
+	for i, container := range spec.Containers {
+		name := container.Name
 
 		if l.config.RequireLiveness {
-			if _, ok := containerMap["livenessProbe"]; !ok {
+			if container.LivenessProbe == nil {
 				issues = append(issues, linter.Issue{
 					Severity:   linter.SeverityWarning,
 					Linter:     l.Name(),
+					Check:      CheckLivenessProbe,
 					Message:    fmt.Sprintf("Container %q missing livenessProbe", name),
 					Resource:   common.ResourceRef(obj),
-					Field:      fmt.Sprintf("spec.template.spec.containers[%d].livenessProbe", i),
+					Field:      k8s.ContainerPath(obj, i, "livenessProbe"),
 					Suggestion: "Add a livenessProbe to detect and recover from failures",
 				})
 			}
 		}
 
 		if l.config.RequireReadiness {
-			if _, ok := containerMap["readinessProbe"]; !ok {
+			if container.ReadinessProbe == nil {
 				issues = append(issues, linter.Issue{
 					Severity:   linter.SeverityWarning,
 					Linter:     l.Name(),
+					Check:      CheckReadinessProbe,
 					Message:    fmt.Sprintf("Container %q missing readinessProbe", name),
 					Resource:   common.ResourceRef(obj),
-					Field:      fmt.Sprintf("spec.template.spec.containers[%d].readinessProbe", i),
+					Field:      k8s.ContainerPath(obj, i, "readinessProbe"),
 					Suggestion: "Add a readinessProbe to control traffic routing",
 				})
 			}
 		}
+
+		if l.config.CheckLivenessSafety && container.LivenessProbe != nil {
+			issues = append(issues, l.livenessSafety(obj, i, container)...)
+		}
 	}
 
 	return issues, nil
 }
+
+// livenessSafety reports the liveness probe patterns restarting containers
+// when a dependency blips or the container is just slow: restarts do not fix
+// either, and restarting every replica at once makes the outage worse
+func (l *Linter) livenessSafety(obj unstructured.Unstructured, index int, container corev1.Container) []linter.Issue {
+	var issues []linter.Issue
+
+	probe := container.LivenessProbe
+	name := container.Name
+
+	issue := func(check string, message string, field string, suggestion string) {
+		issues = append(issues, linter.Issue{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Check:      check,
+			Message:    message,
+			Resource:   common.ResourceRef(obj),
+			Field:      field,
+			Suggestion: suggestion,
+		})
+	}
+
+	if probe.HTTPGet != nil {
+		path := strings.ToLower(probe.HTTPGet.Path)
+		for _, hint := range l.config.DependencyPaths {
+			if hint != "" && strings.Contains(path, strings.ToLower(hint)) {
+				issue(CheckLivenessDependency,
+					fmt.Sprintf("Container %q livenessProbe path %q looks like it checks external services", name, probe.HTTPGet.Path),
+					k8s.ContainerPath(obj, index, "livenessProbe", "httpGet", "path"),
+					"Check only the process itself in the livenessProbe, dependencies belong to the readinessProbe")
+				break
+			}
+		}
+	}
+
+	timeout := probe.TimeoutSeconds
+	if timeout == 0 {
+		timeout = defaultTimeoutSeconds
+	}
+	period := probe.PeriodSeconds
+	if period == 0 {
+		period = defaultPeriodSeconds
+	}
+
+	if timeout >= period {
+		issue(CheckLivenessTimeout,
+			fmt.Sprintf("Container %q livenessProbe timeoutSeconds (%d) is not lower than periodSeconds (%d)", name, timeout, period),
+			k8s.ContainerPath(obj, index, "livenessProbe", "timeoutSeconds"),
+			"Set timeoutSeconds lower than periodSeconds, so that probes do not overlap")
+	}
+
+	if probe.FailureThreshold == 1 {
+		issue(CheckLivenessFailureThreshold,
+			fmt.Sprintf("Container %q livenessProbe restarts the container on the first failure", name),
+			k8s.ContainerPath(obj, index, "livenessProbe", "failureThreshold"),
+			"Set failureThreshold to at least 3 to tolerate transient failures")
+	}
+
+	if sameHandler(probe, container.ReadinessProbe) {
+		issue(CheckLivenessSameAsReadiness,
+			fmt.Sprintf("Container %q livenessProbe is identical to its readinessProbe", name),
+			k8s.ContainerPath(obj, index, "livenessProbe"),
+			"Use a lighter livenessProbe than the readinessProbe, so that an unready container is not restarted")
+	}
+
+	return issues
+}
+
+// sameHandler reports whether both probes run the same command or request
+// the same HTTP endpoint
+func sameHandler(liveness *corev1.Probe, readiness *corev1.Probe) bool {
+	if readiness == nil {
+		return false
+	}
+
+	switch {
+	case liveness.Exec != nil && readiness.Exec != nil:
+		return reflect.DeepEqual(liveness.Exec.Command, readiness.Exec.Command)
+	case liveness.HTTPGet != nil && readiness.HTTPGet != nil:
+		return liveness.HTTPGet.Path == readiness.HTTPGet.Path &&
+			liveness.HTTPGet.Port == readiness.HTTPGet.Port
+	default:
+		return false
+	}
+}
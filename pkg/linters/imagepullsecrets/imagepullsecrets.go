@@ -0,0 +1,511 @@
+package imagepullsecrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "image-pull-secrets"
+	Description = "Ensures imagePullSecrets exist and hold credentials for the registries of the images"
+	Since       = "v0.2.0"
+
+	// defaultServiceAccount is the ServiceAccount of pods not setting one
+	defaultServiceAccount = "default"
+
+	// dockerHub is the registry of images without a registry host
+	dockerHub = "docker.io"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckMissingSecret    = "missing-secret"
+	CheckSecretType       = "secret-type"
+	CheckDockerConfig     = "docker-config"
+	CheckRegistryCoverage = "registry-coverage"
+)
+
+type Config struct {
+	// ExternalSecrets are the names, glob patterns are supported, of pull
+	// Secrets created outside the manifests, e.g. by an operator
+	ExternalSecrets []string `mapstructure:"external-secrets"`
+	// PublicRegistries are the registries images are pulled from without
+	// credentials, with the syntax of the docker config keys
+	PublicRegistries []string `mapstructure:"public-registries"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		PublicRegistries: []string{"registry.k8s.io"},
+	}
+}
+
+func init() {
+	linter.Register(&Linter{
+		config: DefaultConfig(),
+	})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckMissingSecret, CheckSecretType, CheckDockerConfig, CheckRegistryCoverage}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// Lint checks a single object, the runner uses LintSet instead which indexes
+// the Secrets and ServiceAccounts once for the whole run
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, _ := linter.AllObjectsFromContext(ctx)
+
+	return l.check(ctx, obj, newIndex(allObjects)), nil
+}
+
+func (l *Linter) LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]linter.Issue, error) {
+	idx := newIndex(objects)
+
+	var issues []linter.Issue
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, l.check(ctx, obj, idx)...)
+	}
+
+	return issues, nil
+}
+
+// key identifies an object of the manifests
+type key struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+	name      string
+}
+
+// index holds the Secrets and ServiceAccounts of the run, the first object
+// of the manifests wins when several share a name
+type index map[key]unstructured.Unstructured
+
+func newIndex(objects []unstructured.Unstructured) index {
+	idx := make(index)
+
+	for _, obj := range objects {
+		if !gvk.IsAnyGVK(obj, gvk.Secret, gvk.ServiceAccount) {
+			continue
+		}
+
+		k := key{gvk: obj.GroupVersionKind(), namespace: obj.GetNamespace(), name: obj.GetName()}
+		if _, ok := idx[k]; !ok {
+			idx[k] = obj
+		}
+	}
+
+	return idx
+}
+
+// find returns the object of the manifests with the given kind, namespace
+// and name
+func (idx index) find(kind schema.GroupVersionKind, namespace string, name string) (unstructured.Unstructured, bool) {
+	obj, ok := idx[key{gvk: kind, namespace: namespace, name: name}]
+	return obj, ok
+}
+
+func (l *Linter) check(ctx context.Context, obj unstructured.Unstructured, idx index) []linter.Issue {
+	switch {
+	case gvk.IsGVK(obj, gvk.Secret):
+		return l.lintSecret(obj)
+	case gvk.IsGVK(obj, gvk.ServiceAccount):
+		secrets, _, _ := unstructured.NestedSlice(obj.Object, "imagePullSecrets")
+		return l.lintReferences(obj, names(secrets), fieldpath.Path("imagePullSecrets"), idx)
+	case gvk.IsWorkloadOrPod(obj):
+		return l.lintWorkload(ctx, obj, idx)
+	default:
+		return nil
+	}
+}
+
+// lintSecret reports the dockerconfigjson Secrets whose content is not a
+// docker config, kubelet ignores them
+func (l *Linter) lintSecret(obj unstructured.Unstructured) []linter.Issue {
+	if secretType(obj) != corev1.SecretTypeDockerConfigJson {
+		return nil
+	}
+
+	if _, field, err := dockerConfig(obj); err != nil {
+		return []linter.Issue{{
+			Severity:   linter.SeverityError,
+			Linter:     l.Name(),
+			Check:      CheckDockerConfig,
+			Message:    fmt.Sprintf("Secret %q is not a valid docker config: %v", obj.GetName(), err),
+			Resource:   common.ResourceRef(obj),
+			Field:      field,
+			Suggestion: "Create the Secret with kubectl create secret docker-registry, its .dockerconfigjson must hold an auths object",
+		}}
+	}
+
+	return nil
+}
+
+// lintReferences reports the pull Secrets, listed at field, which are not
+// in the manifests or are not docker configs
+func (l *Linter) lintReferences(obj unstructured.Unstructured, secrets []string, field string, idx index) []linter.Issue {
+	var issues []linter.Issue
+
+	for i, name := range secrets {
+		if name == "" {
+			continue
+		}
+
+		secret, ok := idx.find(gvk.Secret, obj.GetNamespace(), name)
+		if !ok {
+			if l.external(name) {
+				continue
+			}
+
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityWarning,
+				Linter:     l.Name(),
+				Check:      CheckMissingSecret,
+				Message:    fmt.Sprintf("%s %q pulls images with Secret %q, which is not in the manifests", obj.GetKind(), obj.GetName(), name),
+				Resource:   common.ResourceRef(obj),
+				Field:      fieldpath.Join(field, i, "name"),
+				Suggestion: "Add the Secret to the manifests, or list it in external-secrets when it is created by other means",
+			})
+			continue
+		}
+
+		if t := secretType(secret); t != corev1.SecretTypeDockerConfigJson {
+			suggestion := fmt.Sprintf("Use a Secret of type %s", corev1.SecretTypeDockerConfigJson)
+			if t == corev1.SecretTypeDockercfg {
+				suggestion = fmt.Sprintf("Migrate the legacy %s Secret to %s", corev1.SecretTypeDockercfg, corev1.SecretTypeDockerConfigJson)
+			}
+
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityError,
+				Linter:     l.Name(),
+				Check:      CheckSecretType,
+				Message:    fmt.Sprintf("%s %q pulls images with Secret %q of type %q", obj.GetKind(), obj.GetName(), name, t),
+				Resource:   common.ResourceRef(obj),
+				Field:      fieldpath.Join(field, i, "name"),
+				Suggestion: suggestion,
+			})
+		}
+	}
+
+	return issues
+}
+
+// lintWorkload checks the pull Secrets of the pod and reports the images of
+// registries none of the Secrets of the pod, or of its ServiceAccount, hold
+// credentials for
+func (l *Linter) lintWorkload(ctx context.Context, obj unstructured.Unstructured, idx index) []linter.Issue {
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil
+	}
+
+	specPath, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil
+	}
+
+	secrets := make([]string, 0, len(spec.ImagePullSecrets))
+	for _, s := range spec.ImagePullSecrets {
+		secrets = append(secrets, s.Name)
+	}
+
+	issues := l.lintReferences(obj, secrets, fieldpath.Join(specPath, "imagePullSecrets"), idx)
+
+	// kubelet uses the pull Secrets of the ServiceAccount when the pod has
+	// none, as they are added to the pod at admission
+	if len(secrets) == 0 {
+		serviceAccount := spec.ServiceAccountName
+		if serviceAccount == "" {
+			serviceAccount = defaultServiceAccount
+		}
+
+		if sa, ok := idx.find(gvk.ServiceAccount, obj.GetNamespace(), serviceAccount); ok {
+			refs, _, _ := unstructured.NestedSlice(sa.Object, "imagePullSecrets")
+			secrets = names(refs)
+		}
+	}
+
+	if len(secrets) == 0 {
+		return issues
+	}
+
+	// the registries are only known when every Secret is in the manifests,
+	// otherwise the coverage is not checked
+	var registries []string
+	for _, name := range secrets {
+		secret, ok := idx.find(gvk.Secret, obj.GetNamespace(), name)
+		if !ok {
+			return issues
+		}
+
+		keys, _, err := dockerConfig(secret)
+		if err != nil {
+			return issues
+		}
+
+		registries = append(registries, keys...)
+	}
+	registries = append(registries, l.config.PublicRegistries...)
+
+	for _, group := range []struct {
+		field      string
+		containers []corev1.Container
+	}{
+		{field: "initContainers", containers: spec.InitContainers},
+		{field: "containers", containers: spec.Containers},
+	} {
+		for i, c := range group.containers {
+			if c.Image == "" || covered(registries, c.Image) {
+				continue
+			}
+
+			host, _ := parseImage(c.Image)
+
+			issues = append(issues, linter.Issue{
+				Severity: linter.SeverityWarning,
+				Linter:   l.Name(),
+				Check:    CheckRegistryCoverage,
+				Message: fmt.Sprintf("Container %q pulls from registry %q, none of the pull Secrets %v holds credentials for it",
+					c.Name, host, secrets),
+				Resource:   common.ResourceRef(obj),
+				Field:      fieldpath.Join(specPath, group.field, i, "image"),
+				Value:      c.Image,
+				Suggestion: "Add the registry to a pull Secret, or list it in public-registries when it needs no credentials",
+			})
+		}
+	}
+
+	return issues
+}
+
+func (l *Linter) external(name string) bool {
+	return glob.MatchAny(l.config.ExternalSecrets, name)
+}
+
+// names returns the names of a list of local object references
+func names(refs []interface{}) []string {
+	result := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		m, _ := ref.(map[string]interface{})
+		name, _ := m["name"].(string)
+		result = append(result, name)
+	}
+
+	return result
+}
+
+func secretType(obj unstructured.Unstructured) corev1.SecretType {
+	t, _, _ := unstructured.NestedString(obj.Object, "type")
+	if t == "" {
+		return corev1.SecretTypeOpaque
+	}
+
+	return corev1.SecretType(t)
+}
+
+// dockerConfig returns the registries of the docker config of a pull
+// Secret, read from data or stringData, and the field holding it
+func dockerConfig(obj unstructured.Unstructured) ([]string, string, error) {
+	key := corev1.DockerConfigJsonKey
+	if secretType(obj) == corev1.SecretTypeDockercfg {
+		key = corev1.DockerConfigKey
+	}
+
+	var content []byte
+	var field string
+
+	if value, ok, _ := unstructured.NestedString(obj.Object, "stringData", key); ok {
+		content, field = []byte(value), fieldpath.Path("stringData", key)
+	} else if value, ok, _ := unstructured.NestedString(obj.Object, "data", key); ok {
+		field = fieldpath.Path("data", key)
+
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, field, fmt.Errorf("%s is not base64 encoded", key)
+		}
+		content = decoded
+	} else {
+		return nil, fieldpath.Path("data"), fmt.Errorf("%s is not set", key)
+	}
+
+	// the legacy .dockercfg format is the content of the auths object
+	var auths map[string]json.RawMessage
+	if key == corev1.DockerConfigKey {
+		if err := json.Unmarshal(content, &auths); err != nil {
+			return nil, field, fmt.Errorf("%s is not valid JSON: %w", key, err)
+		}
+	} else {
+		var config struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		if err := json.Unmarshal(content, &config); err != nil {
+			return nil, field, fmt.Errorf("%s is not valid JSON: %w", key, err)
+		}
+		if len(config.Auths) == 0 {
+			return nil, field, fmt.Errorf("%s has no auths", key)
+		}
+		auths = config.Auths
+	}
+
+	registries := make([]string, 0, len(auths))
+	for registry := range auths {
+		registries = append(registries, registry)
+	}
+
+	return registries, field, nil
+}
+
+// covered reports whether one of the registries, docker config keys, matches
+// the image the way kubelet does: the hosts match, with globs allowed in the
+// key, and the path of the key is a prefix of the repository
+func covered(registries []string, image string) bool {
+	host, repository := parseImage(image)
+
+	for _, registry := range registries {
+		keyHost, keyPath := parseKey(registry)
+
+		if !matchHost(keyHost, host) {
+			continue
+		}
+
+		if keyPath == "" || repository == keyPath || strings.HasPrefix(repository, keyPath+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseImage returns the registry host and the repository of an image,
+// images without a registry host are pulled from Docker Hub
+func parseImage(image string) (string, string) {
+	image, _, _ = strings.Cut(image, "@")
+
+	host, repository := dockerHub, image
+	if first, rest, ok := strings.Cut(image, "/"); ok &&
+		(strings.ContainsAny(first, ".:") || first == "localhost") {
+		host, repository = first, rest
+	}
+
+	// the tag follows the last path segment
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+
+	if host == dockerHub && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	return normalizeHost(host), repository
+}
+
+// parseKey returns the host and the path of a docker config key, which may
+// be a URL such as https://index.docker.io/v1/
+func parseKey(key string) (string, string) {
+	for _, scheme := range []string{"https://", "http://"} {
+		key = strings.TrimPrefix(key, scheme)
+	}
+
+	host, keyPath, _ := strings.Cut(key, "/")
+
+	// the API version of legacy keys is not part of the repository
+	for _, version := range []string{"v1", "v2"} {
+		if keyPath == version || strings.HasPrefix(keyPath, version+"/") {
+			keyPath = strings.TrimPrefix(keyPath, version)
+		}
+	}
+
+	return normalizeHost(host), strings.Trim(keyPath, "/")
+}
+
+// normalizeHost maps the aliases of Docker Hub to a single host
+func normalizeHost(host string) string {
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return dockerHub
+	}
+
+	return host
+}
+
+// matchHost matches the host of a key, whose labels may be globs as in
+// *.example.com, against the host of an image, ports must be the same
+func matchHost(pattern string, host string) bool {
+	patternName, patternPort, _ := strings.Cut(pattern, ":")
+	hostName, hostPort, _ := strings.Cut(host, ":")
+
+	if patternPort != hostPort {
+		return false
+	}
+
+	patternLabels := strings.Split(patternName, ".")
+	hostLabels := strings.Split(hostName, ".")
+
+	if len(patternLabels) != len(hostLabels) {
+		return false
+	}
+
+	for i := range patternLabels {
+		if matched, _ := path.Match(patternLabels[i], hostLabels[i]); !matched {
+			return false
+		}
+	}
+
+	return true
+}
@@ -8,7 +8,6 @@ import (
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
-	"github.com/mitchellh/mapstructure"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
@@ -18,6 +17,16 @@ import (
 const (
 	Name        = "image-tags"
 	Description = "Validates container image tags"
+	Since       = "v0.1.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckDigest     = "digest"
+	CheckRegistry   = "registry"
+	CheckLatestTag  = "latest-tag"
+	CheckTagPattern = "tag-pattern"
 )
 
 type Config struct {
@@ -27,11 +36,17 @@ type Config struct {
 	RequireVersionPattern string   `mapstructure:"require-version-pattern"`
 }
 
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		DisallowLatest: true,
+	}
+}
+
 func init() {
 	linter.Register(&Linter{
-		config: Config{
-			DisallowLatest: true,
-		},
+		config: DefaultConfig(),
 	})
 }
 
@@ -48,11 +63,39 @@ func (l *Linter) Description() string {
 	return Description
 }
 
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityStable
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckDigest, CheckRegistry, CheckLatestTag, CheckTagPattern}
+}
+
 func (l *Linter) Configure(settings map[string]interface{}) error {
-	if err := mapstructure.Decode(settings, &l.config); err != nil {
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
 		return err
 	}
 
+	return l.compile()
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) compile() error {
+	l.versionRegex = nil
+
 	if l.config.RequireVersionPattern != "" {
 		var err error
 		l.versionRegex, err = regexp.Compile(l.config.RequireVersionPattern)
@@ -64,14 +107,40 @@ func (l *Linter) Configure(settings map[string]interface{}) error {
 	return nil
 }
 
+// SupportsOverrides tells that the settings can be overridden per object
+func (l *Linter) SupportsOverrides() bool {
+	return true
+}
+
 func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	// settings overridden by the object annotations apply to this object only
+	config, overridden, invalid := linter.ObjectConfig(obj, Name, l.config)
+	if overridden {
+		o := &Linter{config: config}
+		if err := o.compile(); err != nil {
+			invalid = append(invalid, linter.InvalidOverride(obj, Name, "",
+				fmt.Sprintf("Invalid %s annotations, they are ignored: %v", Name, err)))
+		} else {
+			l = o
+		}
+	}
+
+	issues, err := l.lint(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(invalid, issues...), nil
+}
+
+func (l *Linter) lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
 	if !gvk.IsWorkloadOrPod(obj) {
 		return nil, nil
 	}
 
 	var issues []linter.Issue
 
-	containers, err := k8s.GetContainers(obj)
+	containers, err := k8s.GetContainers(ctx, obj)
 	if err != nil {
 		return nil, err
 	}
@@ -105,9 +174,11 @@ func (l *Linter) checkImage(obj unstructured.Unstructured, containerName string,
 		issues = append(issues, linter.Issue{
 			Severity:   linter.SeverityWarning,
 			Linter:     l.Name(),
+			Check:      CheckDigest,
 			Message:    fmt.Sprintf("Container %q image should use digest", containerName),
 			Resource:   common.ResourceRef(obj),
-			Field:      fmt.Sprintf("spec.template.spec.containers[%d].image", index),
+			Field:      k8s.ContainerPath(obj, index, "image"),
+			Value:      image,
 			Suggestion: "Use image with SHA256 digest: image@sha256:...",
 		})
 	}
@@ -144,9 +215,11 @@ func (l *Linter) checkImage(obj unstructured.Unstructured, containerName string,
 			issues = append(issues, linter.Issue{
 				Severity:   linter.SeverityError,
 				Linter:     l.Name(),
+				Check:      CheckRegistry,
 				Message:    fmt.Sprintf("Container %q uses disallowed registry %q", containerName, registry),
 				Resource:   common.ResourceRef(obj),
-				Field:      fmt.Sprintf("spec.template.spec.containers[%d].image", index),
+				Field:      k8s.ContainerPath(obj, index, "image"),
+				Value:      image,
 				Suggestion: fmt.Sprintf("Use one of the allowed registries: %v", l.config.AllowedRegistries),
 			})
 		}
@@ -157,9 +230,11 @@ func (l *Linter) checkImage(obj unstructured.Unstructured, containerName string,
 			issues = append(issues, linter.Issue{
 				Severity:   linter.SeverityError,
 				Linter:     l.Name(),
+				Check:      CheckLatestTag,
 				Message:    fmt.Sprintf("Container %q uses 'latest' tag", containerName),
 				Resource:   common.ResourceRef(obj),
-				Field:      fmt.Sprintf("spec.template.spec.containers[%d].image", index),
+				Field:      k8s.ContainerPath(obj, index, "image"),
+				Value:      image,
 				Suggestion: "Specify an explicit version tag",
 			})
 		}
@@ -168,9 +243,11 @@ func (l *Linter) checkImage(obj unstructured.Unstructured, containerName string,
 			issues = append(issues, linter.Issue{
 				Severity:   linter.SeverityWarning,
 				Linter:     l.Name(),
+				Check:      CheckTagPattern,
 				Message:    fmt.Sprintf("Container %q tag %q doesn't match required pattern", containerName, tag),
 				Resource:   common.ResourceRef(obj),
-				Field:      fmt.Sprintf("spec.template.spec.containers[%d].image", index),
+				Field:      k8s.ContainerPath(obj, index, "image"),
+				Value:      image,
 				Suggestion: fmt.Sprintf("Use tag matching pattern: %s", l.config.RequireVersionPattern),
 			})
 		}
@@ -0,0 +1,64 @@
+package imagetags_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/imagetags"
+)
+
+func TestImageTags(t *testing.T) {
+	l := &imagetags.Linter{}
+	lintertest.Configure(t, l, map[string]interface{}{"disallow-latest": true})
+
+	issues := lintertest.RunFile(t, l, "testdata/deployment.yaml")
+	lintertest.AssertIssues(t, issues, lintertest.Expectation{
+		Severity: linter.SeverityError,
+		Linter:   imagetags.Name,
+		Message:  "uses 'latest' tag",
+		Field:    "$.spec.template.spec.containers[0].image",
+		Kind:     "Deployment",
+		Name:     "web",
+	})
+
+	lintertest.AssertGolden(t, issues, "testdata/deployment.golden.json")
+}
+
+func TestImageTagsSettings(t *testing.T) {
+	l := &imagetags.Linter{}
+	lintertest.Configure(t, l, map[string]interface{}{
+		"allowed-registries":      []interface{}{"registry.example.com"},
+		"require-version-pattern": `^v?\d+\.\d+\.\d+$`,
+	})
+
+	issues := lintertest.RunFile(t, l, "testdata/deployment.yaml")
+	lintertest.AssertContains(t, issues,
+		lintertest.Expectation{Severity: linter.SeverityError, Message: `disallowed registry "ghcr.io"`, Name: "report"},
+		lintertest.Expectation{Severity: linter.SeverityWarning, Message: `tag "v2" doesn't match`, Name: "report"},
+	)
+
+	for _, issue := range issues {
+		if issue.Resource.Name == "web" && issue.Check != imagetags.CheckTagPattern {
+			t.Errorf("unexpected issue for web: %s", issue.Message)
+		}
+	}
+}
+
+func TestImageTagsPinned(t *testing.T) {
+	l := &imagetags.Linter{}
+	lintertest.Configure(t, l, map[string]interface{}{"disallow-latest": true})
+
+	objects := lintertest.ParseObjects(t, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pinned
+spec:
+  containers:
+    - name: app
+      image: nginx:1.27.0@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+`)
+
+	lintertest.AssertNoIssues(t, lintertest.Run(t, l, objects...))
+}
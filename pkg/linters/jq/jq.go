@@ -3,11 +3,26 @@ package jq
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"slices"
+	"sync"
 
-	"github.com/itchyny/gojq"
+	"github.com/mitchellh/mapstructure"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	jqutil "github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/jq"
+)
+
+const (
+	// ScopeObject rules are evaluated for every object, with the object
+	// as $object
+	ScopeObject = "object"
+	// ScopeSet rules are evaluated once per run and output the objects of
+	// $objects they report an issue for
+	ScopeSet = "set"
 )
 
 type Rule struct {
@@ -16,12 +31,43 @@ type Rule struct {
 	Severity   linter.Severity
 	Field      string
 	Suggestion string
+	DocURL     string
+	Scope      string
+	// Objects restricts the objects passed in $objects
+	Objects ObjectFilter
+
+	code *jqutil.Code
+}
+
+// ObjectFilter selects objects by kind and namespace, empty lists match
+// everything
+type ObjectFilter struct {
+	Kinds      []string
+	Namespaces []string
 }
 
 type Linter struct {
 	name        string
 	description string
 	rules       []Rule
+
+	mu  sync.Mutex
+	run *runState
+}
+
+// runState holds what is computed once per run: the normalized objects, the
+// $objects of every rule and the issues of the set scoped rules
+type runState struct {
+	key  *unstructured.Unstructured
+	size int
+	once sync.Once
+	// inputs are the objects of the run copied and normalized once, they
+	// are shared by every rule and every evaluation, which may run
+	// concurrently
+	inputs  []*jqutil.Input
+	objects []*jqutil.Input
+	issues  map[linter.ResourceRef][]linter.Issue
+	err     error
 }
 
 type Factory struct{}
@@ -62,6 +108,7 @@ func (l *Linter) Configure(settings map[string]interface{}) error {
 		return fmt.Errorf("rules must be an array")
 	}
 
+	l.run = nil
 	l.rules = make([]Rule, 0, len(rulesData))
 	for i, ruleData := range rulesData {
 		ruleMap, ok := ruleData.(map[string]interface{})
@@ -90,13 +137,37 @@ func (l *Linter) Configure(settings map[string]interface{}) error {
 		}
 
 		if field, ok := ruleMap["field"].(string); ok {
-			rule.Field = field
+			rule.Field = fieldpath.Normalize(field)
 		}
 
 		if sugg, ok := ruleMap["suggestion"].(string); ok {
 			rule.Suggestion = sugg
 		}
 
+		if docURL, ok := ruleMap["doc-url"].(string); ok {
+			rule.DocURL = docURL
+		}
+
+		rule.Scope = ScopeObject
+		if scope, ok := ruleMap["scope"].(string); ok {
+			if scope != ScopeObject && scope != ScopeSet {
+				return fmt.Errorf("rule %d: invalid scope %q (supported: %s, %s)", i, scope, ScopeObject, ScopeSet)
+			}
+			rule.Scope = scope
+		}
+
+		if objects, ok := ruleMap["objects"].(map[string]interface{}); ok {
+			if err := mapstructure.Decode(objects, &rule.Objects); err != nil {
+				return fmt.Errorf("rule %d: invalid objects filter: %w", i, err)
+			}
+		}
+
+		code, err := compile(rule.Expression)
+		if err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+		rule.code = code
+
 		l.rules = append(l.rules, rule)
 	}
 
@@ -106,25 +177,34 @@ func (l *Linter) Configure(settings map[string]interface{}) error {
 func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
 	var issues []linter.Issue
 
+	if len(l.rules) == 0 {
+		return nil, nil
+	}
+
 	allObjects, _ := linter.AllObjectsFromContext(ctx)
 
-	for _, rule := range l.rules {
-		query, err := gojq.Parse(rule.Expression)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse jq expression %q: %w", rule.Expression, err)
-		}
+	state := l.state(allObjects)
+	state.once.Do(func() {
+		state.err = l.prepare(ctx, state, allObjects)
+	})
+	if state.err != nil {
+		return nil, state.err
+	}
 
-		code, err := gojq.Compile(query, gojq.WithVariables([]string{"$objects", "$object"}))
-		if err != nil {
-			return nil, fmt.Errorf("failed to compile jq expression %q: %w", rule.Expression, err)
+	ref := common.ResourceRef(obj)
+
+	var object *jqutil.Input
+
+	for i, rule := range l.rules {
+		if rule.Scope == ScopeSet {
+			continue
 		}
 
-		allObjectsSlice := make([]interface{}, len(allObjects))
-		for i, o := range allObjects {
-			allObjectsSlice[i] = o.Object
+		if object == nil {
+			object = state.input(ctx, allObjects, obj)
 		}
 
-		iter := code.Run(nil, allObjectsSlice, obj.Object)
+		iter := rule.code.Run(ctx, nil, state.objects[i], object)
 		for {
 			result, ok := iter.Next()
 			if !ok {
@@ -139,24 +219,124 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				continue
 			}
 
-			issue := linter.Issue{
-				Severity: rule.Severity,
-				Linter:   l.Name(),
-				Message:  rule.Message,
-				Resource: linter.ResourceRef{
-					APIVersion: obj.GetAPIVersion(),
-					Kind:       obj.GetKind(),
-					Namespace:  obj.GetNamespace(),
-					Name:       obj.GetName(),
-				},
-				Field:      rule.Field,
-				Suggestion: rule.Suggestion,
+			issues = append(issues, l.issue(rule, ref))
+			break
+		}
+	}
+
+	return append(issues, state.issues[ref]...), nil
+}
+
+// state returns the state of the run the given objects belong to, runs are
+// told apart by the identity of the slice holding all the objects
+func (l *Linter) state(allObjects []unstructured.Unstructured) *runState {
+	var key *unstructured.Unstructured
+	if len(allObjects) > 0 {
+		key = &allObjects[0]
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.run == nil || l.run.key != key || l.run.size != len(allObjects) {
+		l.run = &runState{key: key, size: len(allObjects)}
+	}
+
+	return l.run
+}
+
+// input returns the normalized object being linted: the one prepared for the
+// run, or a copy when the object is not one of the run
+func (s *runState) input(ctx context.Context, allObjects []unstructured.Unstructured, obj unstructured.Unstructured) *jqutil.Input {
+	i, ok := linter.ObjectIndexFromContext(ctx)
+	if ok && i >= 0 && i < len(s.inputs) &&
+		reflect.ValueOf(allObjects[i].Object).Pointer() == reflect.ValueOf(obj.Object).Pointer() {
+		return s.inputs[i]
+	}
+
+	return jqutil.NewInput(obj.Object)
+}
+
+// prepare normalizes the objects, filters $objects for every rule and
+// evaluates the set scoped rules
+func (l *Linter) prepare(ctx context.Context, state *runState, allObjects []unstructured.Unstructured) error {
+	state.inputs = make([]*jqutil.Input, len(allObjects))
+	state.objects = make([]*jqutil.Input, len(l.rules))
+	state.issues = make(map[linter.ResourceRef][]linter.Issue)
+
+	// the objects are shared with the other linters, the rules are
+	// evaluated against copies of them
+	for i, o := range allObjects {
+		state.inputs[i] = jqutil.NewInput(o.Object)
+	}
+
+	for i, rule := range l.rules {
+		objects := make([]*jqutil.Input, 0, len(allObjects))
+		for j, o := range allObjects {
+			if rule.Objects.matches(o) {
+				objects = append(objects, state.inputs[j])
 			}
+		}
+		state.objects[i] = jqutil.Array(objects...)
 
-			issues = append(issues, issue)
-			break
+		if rule.Scope != ScopeSet {
+			continue
+		}
+
+		reported := make(map[linter.ResourceRef]bool)
+
+		iter := rule.code.Run(ctx, nil, state.objects[i], nil)
+		for {
+			result, ok := iter.Next()
+			if !ok {
+				break
+			}
+
+			if err, ok := result.(error); ok {
+				return fmt.Errorf("jq expression %q failed: %w", rule.Expression, err)
+			}
+
+			if result == nil || result == false {
+				continue
+			}
+
+			m, ok := result.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("jq expression %q: set scoped rules must output the offending objects, got %v", rule.Expression, result)
+			}
+
+			ref := common.ResourceRef(unstructured.Unstructured{Object: m})
+			if reported[ref] {
+				continue
+			}
+			reported[ref] = true
+
+			state.issues[ref] = append(state.issues[ref], l.issue(rule, ref))
 		}
 	}
 
-	return issues, nil
+	return nil
+}
+
+func (l *Linter) issue(rule Rule, ref linter.ResourceRef) linter.Issue {
+	return linter.Issue{
+		Severity:   rule.Severity,
+		Linter:     l.Name(),
+		Message:    rule.Message,
+		Resource:   ref,
+		Field:      rule.Field,
+		Suggestion: rule.Suggestion,
+		DocURL:     rule.DocURL,
+	}
+}
+
+func (f ObjectFilter) matches(obj unstructured.Unstructured) bool {
+	return (len(f.Kinds) == 0 || slices.Contains(f.Kinds, obj.GetKind())) &&
+		(len(f.Namespaces) == 0 || slices.Contains(f.Namespaces, obj.GetNamespace()))
+}
+
+// compile parses and compiles a rule expression, rules are compiled once at
+// configuration time and the compiled code is reused for every object
+func compile(expression string) (*jqutil.Code, error) {
+	return jqutil.Compile(expression, "$objects", "$object")
 }
@@ -0,0 +1,191 @@
+package jq_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest/generate"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/jq"
+	jqutil "github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/jq"
+)
+
+var benchRules = []interface{}{
+	map[string]interface{}{
+		"expression": `$object.kind == "Deployment" and ($object.spec.replicas // 1) < 2`,
+		"message":    "Deployments must run at least 2 replicas",
+	},
+	map[string]interface{}{
+		"expression": `[$object.spec.template.spec.containers[]? | select(.image // "" | endswith(":latest"))] | length > 0`,
+		"message":    "Images must not use the latest tag",
+	},
+	map[string]interface{}{
+		"expression": `$object.metadata.labels["app.kubernetes.io/name"] == null`,
+		"message":    "Missing app.kubernetes.io/name label",
+	},
+	map[string]interface{}{
+		"expression": `$object.kind == "Service" and ([$objects[] | select(.kind == "Deployment" and .metadata.namespace == $object.metadata.namespace)] | length == 0)`,
+		"message":    "Service without Deployment in its namespace",
+	},
+}
+
+// BenchmarkLint lints a corpus with a set of rules, each operation lints
+// every object of the corpus
+func BenchmarkLint(b *testing.B) {
+	l := jq.New("bench", "benchmark rules")
+	if err := l.Configure(map[string]interface{}{"rules": benchRules}); err != nil {
+		b.Fatal(err)
+	}
+
+	objects := generate.New(2252, generate.WithOddTypeRate(0)).Objects(500)
+	ctx := linter.WithAllObjects(context.Background(), objects)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		for i, obj := range objects {
+			if _, err := l.Lint(linter.WithObjectIndex(ctx, i), obj); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkCompile measures what compiling the rules for every object, as
+// the linter did before compiling them once in Configure, costs per object
+func BenchmarkCompile(b *testing.B) {
+	b.ReportAllocs()
+
+	for b.Loop() {
+		for _, rule := range benchRules {
+			expression := rule.(map[string]interface{})["expression"].(string)
+			if _, err := jqutil.Compile(expression, "$objects", "$object"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	l := jq.New("jq-test", "test rules")
+	lintertest.Configure(t, l, map[string]interface{}{"rules": benchRules})
+
+	objects := lintertest.ParseObjects(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  labels:
+    app.kubernetes.io/name: app
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:latest
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: orphan
+  namespace: other
+  labels:
+    app.kubernetes.io/name: orphan
+`)
+
+	issues := lintertest.Run(t, l, objects...)
+	lintertest.AssertIssues(t, issues,
+		lintertest.Expectation{Kind: "Deployment", Name: "app", Message: "Deployments must run at least 2 replicas"},
+		lintertest.Expectation{Kind: "Deployment", Name: "app", Message: "Images must not use the latest tag"},
+		lintertest.Expectation{Kind: "Service", Name: "orphan", Message: "Service without Deployment in its namespace"},
+	)
+}
+
+// TestLintConcurrent lints, through the runner and concurrently, objects
+// holding numbers gojq normalizes with a rule reading $objects; run with
+// -race to check that the evaluations do not write to shared values
+func TestLintConcurrent(t *testing.T) {
+	const namespaces = 4
+
+	objects := make([]unstructured.Unstructured, 200)
+	for i := range objects {
+		objects[i] = unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      fmt.Sprintf("app-%d", i),
+				"namespace": fmt.Sprintf("ns-%d", i%namespaces),
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(i % 7),
+			},
+		}}
+	}
+
+	runner, err := linter.NewRunner(&linter.RunnerConfig{
+		Concurrency: 8,
+		CustomLinters: []config.CustomLinter{{
+			Name: "jq-concurrent",
+			Type: "jq",
+			Settings: map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{
+						"expression": `$object.spec.replicas > ([$objects[] | select(.metadata.namespace == $object.metadata.namespace) | .spec.replicas] | max)`,
+						"message":    "Deployment has more replicas than the others of its namespace",
+					},
+					map[string]interface{}{
+						"expression": `$object.spec.replicas == ([$objects[] | select(.metadata.namespace == $object.metadata.namespace) | .spec.replicas] | max)`,
+						"message":    "Deployment has the most replicas of its namespace",
+					},
+				},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := runner.Run(context.Background(), objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// i%7 == 6 is the most replicas of every namespace
+	want := 0
+	for i := range objects {
+		if i%7 == 6 {
+			want++
+		}
+	}
+
+	got := 0
+	for _, issue := range issues {
+		if issue.Linter != "jq-concurrent" {
+			continue
+		}
+
+		if issue.Message != "Deployment has the most replicas of its namespace" {
+			t.Errorf("unexpected issue on %s: %s", issue.Resource.Name, issue.Message)
+		}
+		got++
+	}
+
+	if got != want {
+		t.Errorf("got %d issue(s), want %d", got, want)
+	}
+
+	// the numbers of the objects are left as decoded
+	for _, obj := range objects {
+		replicas := obj.Object["spec"].(map[string]interface{})["replicas"]
+		if _, ok := replicas.(int64); !ok {
+			t.Fatalf("object %s modified: replicas is a %T", obj.GetName(), replicas)
+		}
+	}
+}
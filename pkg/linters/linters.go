@@ -1,11 +1,29 @@
 package linters
 
 import (
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/annotations"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/applyorder"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/assert"
 	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/clusterrolebindingsecurity"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/configreferences"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/egresspolicies"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/forbiddenresources"
 	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/healthprobes"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/imagepullsecrets"
 	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/imagetags"
 	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/jq"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/namespacelabels"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/openapischema"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podcomplexity"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/poddisruptionbudgets"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podsecurity"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podtemplatemetadata"
 	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/requiredlabels"
 	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/resourcelimits"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/rolloutsafety"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/secretleak"
 	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/securitycontext"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceaccountreferences"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceaccounttokens"
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceselectors"
 )
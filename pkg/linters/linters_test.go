@@ -0,0 +1,89 @@
+package linters_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest/generate"
+
+	_ "github.com/lburgazzoli/k8s-manifests-lint/pkg/linters"
+)
+
+// fuzzObjects is the number of objects generated per input, enough for the
+// cross-object linters to find related objects
+const fuzzObjects = 8
+
+func fuzzSeeds(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{1})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	f.Add([]byte("k8s-manifests-lint"))
+}
+
+// generator returns a generator producing malformed objects more often than
+// the defaults, as the fuzz targets are about linters not choking on them
+func generator(data []byte) *generate.Generator {
+	return generate.FromBytes(data,
+		generate.WithMissingFieldRate(0.2),
+		generate.WithOddTypeRate(0.2),
+	)
+}
+
+// FuzzLinters runs every built-in linter against generated objects, the
+// linters must neither panic nor fail
+func FuzzLinters(f *testing.F) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		objects := generator(data).Objects(fuzzObjects)
+
+		for _, l := range linter.All() {
+			t.Run(l.Name(), func(t *testing.T) {
+				lintertest.Run(t, l, objects...)
+			})
+		}
+	})
+}
+
+// FuzzRunner lints generated objects with all the built-in linters through
+// the runner, concurrently, which turns panics into fatal issues
+func FuzzRunner(f *testing.F) {
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		objects := generator(data).Objects(fuzzObjects)
+
+		runner, err := linter.NewRunner(&linter.RunnerConfig{Concurrency: 4})
+		if err != nil {
+			t.Fatalf("failed to create runner: %v", err)
+		}
+
+		issues, err := runner.Run(context.Background(), objects)
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+
+		for _, issue := range issues {
+			if issue.Severity == linter.SeverityFatal {
+				t.Errorf("%s on %s/%s: %s", issue.Linter, issue.Resource.Kind, issue.Resource.Name, issue.Message)
+			}
+		}
+	})
+}
+
+// TestReset restores the default configuration of every built-in linter,
+// which the runner does before configuring them
+func TestReset(t *testing.T) {
+	for _, l := range linter.All() {
+		d, ok := l.(linter.Defaulted)
+		if !ok {
+			continue
+		}
+
+		if err := d.Reset(); err != nil {
+			t.Errorf("%s: %v", l.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package namespacelabels
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/pss"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "namespace-labels"
+	Description = "Ensures Namespaces carry the required labels and their workloads comply with the enforced Pod Security level"
+	Since       = "v0.2.0"
+)
+
+// LabelRule requires the label Key on every Namespace, when Pattern is set
+// the value must match it
+type LabelRule struct {
+	Key     string `mapstructure:"key"`
+	Pattern string `mapstructure:"pattern"`
+}
+
+type Config struct {
+	Labels []LabelRule `mapstructure:"labels"`
+	// CheckWorkloads verifies that the workloads of the Namespaces defined
+	// in the same set are allowed by their pod-security.kubernetes.io/enforce
+	// level
+	CheckWorkloads bool `mapstructure:"check-workloads"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		Labels: []LabelRule{
+			{Key: pss.EnforceLabel, Pattern: "^(privileged|baseline|restricted)$"},
+		},
+		CheckWorkloads: true,
+	}
+}
+
+func init() {
+	l := &Linter{
+		config: DefaultConfig(),
+	}
+
+	if err := l.compile(); err != nil {
+		panic(err)
+	}
+
+	linter.Register(l)
+}
+
+type Linter struct {
+	config   Config
+	patterns []*regexp.Regexp
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	// configured labels replace the defaults rather than being merged into
+	// them element by element
+	if _, ok := settings["labels"]; ok {
+		l.config.Labels = nil
+	}
+
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	return l.compile()
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) compile() error {
+	l.patterns = make([]*regexp.Regexp, len(l.config.Labels))
+
+	for i, rule := range l.config.Labels {
+		if rule.Key == "" {
+			return fmt.Errorf("labels[%d]: key is required", i)
+		}
+
+		if rule.Pattern == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("labels[%d]: invalid pattern %q: %w", i, rule.Pattern, err)
+		}
+
+		l.patterns[i] = re
+	}
+
+	return nil
+}
+
+// Lint checks a single object, the runner uses LintSet instead which indexes
+// the Namespaces once for the whole run
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, _ := linter.AllObjectsFromContext(ctx)
+
+	return l.check(ctx, obj, namespaces(allObjects)), nil
+}
+
+func (l *Linter) LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]linter.Issue, error) {
+	ns := namespaces(objects)
+
+	var issues []linter.Issue
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, l.check(ctx, obj, ns)...)
+	}
+
+	return issues, nil
+}
+
+func (l *Linter) check(ctx context.Context, obj unstructured.Unstructured, ns map[string]unstructured.Unstructured) []linter.Issue {
+	switch {
+	case gvk.IsGVK(obj, gvk.Namespace):
+		return l.checkLabels(obj)
+	case l.config.CheckWorkloads && gvk.IsWorkloadOrPod(obj):
+		return l.checkWorkload(ctx, obj, ns)
+	default:
+		return nil
+	}
+}
+
+func (l *Linter) checkLabels(obj unstructured.Unstructured) []linter.Issue {
+	var issues []linter.Issue
+
+	labels := obj.GetLabels()
+
+	for i, rule := range l.config.Labels {
+		field := fieldpath.Path("metadata", "labels", rule.Key)
+
+		value, ok := labels[rule.Key]
+		if !ok {
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityWarning,
+				Linter:     l.Name(),
+				Message:    fmt.Sprintf("Namespace is missing required label %q", rule.Key),
+				Resource:   common.ResourceRef(obj),
+				Field:      field,
+				Suggestion: fmt.Sprintf("Add label: %s: <value>", rule.Key),
+			})
+			continue
+		}
+
+		if re := l.patterns[i]; re != nil && !re.MatchString(value) {
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityWarning,
+				Linter:     l.Name(),
+				Message:    fmt.Sprintf("Namespace label %q value %q does not match %q", rule.Key, value, rule.Pattern),
+				Resource:   common.ResourceRef(obj),
+				Field:      field,
+				Value:      value,
+				Suggestion: fmt.Sprintf("Set label %s to a value matching %s", rule.Key, rule.Pattern),
+			})
+		}
+	}
+
+	return issues
+}
+
+func (l *Linter) checkWorkload(ctx context.Context, obj unstructured.Unstructured, ns map[string]unstructured.Unstructured) []linter.Issue {
+	namespace, ok := ns[obj.GetNamespace()]
+	if !ok {
+		return nil
+	}
+
+	level, err := pss.ParseLevel(namespace.GetLabels()[pss.EnforceLabel])
+	if err != nil || level == pss.Privileged {
+		return nil
+	}
+
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil
+	}
+
+	base, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil
+	}
+
+	var issues []linter.Issue
+	for _, v := range pss.Check(spec, base, level) {
+		issues = append(issues, linter.Issue{
+			Severity: linter.SeverityError,
+			Linter:   l.Name(),
+			Message: fmt.Sprintf("Namespace %q enforces the %s Pod Security level: %s",
+				namespace.GetName(), level, v.Message),
+			Resource:   common.ResourceRef(obj),
+			Field:      v.Field,
+			Suggestion: fmt.Sprintf("Comply with the %s level or relax the %s label of Namespace %q", level, pss.EnforceLabel, namespace.GetName()),
+			Related:    []linter.ResourceRef{common.ResourceRef(namespace)},
+		})
+	}
+
+	return issues
+}
+
+func namespaces(objects []unstructured.Unstructured) map[string]unstructured.Unstructured {
+	ns := make(map[string]unstructured.Unstructured)
+	for _, obj := range objects {
+		if gvk.IsGVK(obj, gvk.Namespace) {
+			ns[obj.GetName()] = obj
+		}
+	}
+	return ns
+}
@@ -0,0 +1,146 @@
+package openapischema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// builtin derives the schemas of the built-in kinds from their Go types, as
+// registered in the client-go scheme, so that they are validated without
+// downloading anything. They follow the Kubernetes version of the linter
+// dependencies.
+type builtin struct {
+	mu      sync.Mutex
+	strict  bool
+	schemas map[reflect.Type]*Schema
+}
+
+func newBuiltin(strict bool) *builtin {
+	return &builtin{
+		strict:  strict,
+		schemas: make(map[reflect.Type]*Schema),
+	}
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// schema returns the schema of the kind, nil for kinds the scheme does not
+// know about
+func (b *builtin) schema(gvk schema.GroupVersionKind) *Schema {
+	obj, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.of(reflect.TypeOf(obj))
+}
+
+// of returns the schema of the values of type t, schemas are registered
+// before being filled so that recursive types terminate
+func (b *builtin) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if s, ok := b.schemas[t]; ok {
+		return s
+	}
+
+	s := &Schema{}
+	b.schemas[t] = s
+
+	switch t {
+	case reflect.TypeOf(intstr.IntOrString{}):
+		s.IntOrString = true
+		return s
+	case reflect.TypeOf(resource.Quantity{}):
+		s.Types = Types{"string", "number"}
+		return s
+	case reflect.TypeOf(metav1.Time{}), reflect.TypeOf(metav1.MicroTime{}), reflect.TypeOf(metav1.Duration{}):
+		s.Types = Types{"string"}
+		return s
+	case reflect.TypeOf(runtime.RawExtension{}):
+		return s
+	}
+
+	// other types decoding themselves, such as JSON fields of CRDs, accept
+	// any value
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s.Types = Types{"object"}
+		s.Properties = make(map[string]*Schema)
+		s.Closed = b.strict
+		b.fields(t, s)
+	case reflect.Map:
+		s.Types = Types{"object"}
+		s.AdditionalProperties = b.of(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// byte slices are base64 encoded strings
+			s.Types = Types{"string"}
+		} else {
+			s.Types = Types{"array"}
+			s.Items = b.of(t.Elem())
+		}
+	case reflect.String:
+		s.Types = Types{"string"}
+	case reflect.Bool:
+		s.Types = Types{"boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s.Types = Types{"integer"}
+	case reflect.Float32, reflect.Float64:
+		s.Types = Types{"number"}
+	}
+
+	return s
+}
+
+// fields adds the JSON fields of the struct to the properties of s, the
+// inlined and embedded structs are flattened
+func (b *builtin) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if (name == "" && f.Anonymous) || strings.Contains(opts, "inline") {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.fields(ft, s)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = b.of(f.Type)
+	}
+}
@@ -0,0 +1,118 @@
+package openapischema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lburgazzoli/k8s-manifests-lib/pkg/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+)
+
+// crdSchemas returns the schemas of the versions of the
+// CustomResourceDefinitions, keyed by the kind they define
+func crdSchemas(objects []unstructured.Unstructured, strict bool) map[schema.GroupVersionKind]*Schema {
+	schemas := make(map[schema.GroupVersionKind]*Schema)
+
+	for _, obj := range objects {
+		if obj.GetKind() != "CustomResourceDefinition" || !strings.HasPrefix(obj.GetAPIVersion(), "apiextensions.k8s.io/") {
+			continue
+		}
+
+		group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+		versions, _, _ := unstructured.NestedSlice(obj.Object, "spec", "versions")
+
+		for _, v := range versions {
+			version, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name, _, _ := unstructured.NestedString(version, "name")
+			raw, found, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+			if !found {
+				continue
+			}
+
+			data, err := json.Marshal(raw)
+			if err != nil {
+				continue
+			}
+
+			var s Schema
+			if err := json.Unmarshal(data, &s); err != nil {
+				continue
+			}
+
+			// the schemas of most CRDs leave the object metadata out, the
+			// API server validates it on its own
+			if s.Properties == nil {
+				s.Properties = make(map[string]*Schema)
+			}
+			for name, types := range map[string]Types{"apiVersion": {"string"}, "kind": {"string"}, "metadata": {"object"}} {
+				if _, ok := s.Properties[name]; !ok {
+					s.Properties[name] = &Schema{Types: types, PreserveUnknownFields: name == "metadata"}
+				}
+			}
+
+			if strict {
+				s.close(make(map[*Schema]bool))
+			}
+
+			schemas[schema.GroupVersionKind{Group: group, Version: name, Kind: kind}] = &s
+		}
+	}
+
+	return schemas
+}
+
+// loadCRDs reads the CustomResourceDefinitions of the YAML and JSON files
+// found in the directories
+func loadCRDs(dirs []string) ([]unstructured.Unstructured, error) {
+	decoder := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
+
+	var objects []unstructured.Unstructured
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".yaml", ".yml", ".json":
+			default:
+				return nil
+			}
+
+			if d.IsDir() {
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			decoded, err := util.DecodeYAML(decoder, data)
+			if err != nil {
+				return fmt.Errorf("failed to decode %s: %w", path, err)
+			}
+
+			objects = append(objects, decoded...)
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CRD schemas from %s: %w", dir, err)
+		}
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,206 @@
+package openapischema
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/cachedir"
+)
+
+const (
+	// LocationBuiltin stands for the schemas derived from the Go types of the
+	// built-in kinds
+	LocationBuiltin = "builtin"
+	// LocationDefault stands for DefaultLocation
+	LocationDefault = "default"
+
+	// DefaultLocation is the kubernetes-json-schema repository kubeconform
+	// downloads its schemas from
+	DefaultLocation = "https://raw.githubusercontent.com/yannh/kubernetes-json-schema/master/{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+
+	// dirLayout is the layout of the schemas in a local directory given
+	// without a template, the one of kubernetes-json-schema
+	dirLayout = "{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+)
+
+// errNotFound is returned for schemas missing from a location
+var errNotFound = errors.New("schema not found")
+
+// templateData are the variables of the location templates, named after the
+// kubeconform ones so that the same locations can be used
+type templateData struct {
+	NormalizedKubernetesVersion string
+	StrictSuffix                string
+	ResourceKind                string
+	ResourceAPIVersion          string
+	Group                       string
+	KindSuffix                  string
+}
+
+// location is a template of URLs or paths of JSON schemas, one per kind
+type location struct {
+	raw  string
+	tmpl *template.Template
+}
+
+func newLocation(raw string) (*location, error) {
+	text := raw
+	if raw == LocationDefault {
+		text = DefaultLocation
+	} else if !strings.Contains(raw, "{{") && !isURL(raw) {
+		text = filepath.ToSlash(filepath.Join(raw, dirLayout))
+	}
+
+	tmpl, err := template.New(raw).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema location %q: %w", raw, err)
+	}
+
+	return &location{raw: raw, tmpl: tmpl}, nil
+}
+
+// target returns the URL or path of the schema of the kind
+func (l *location) target(version string, strict bool, gvk schema.GroupVersionKind) (string, error) {
+	data := templateData{
+		NormalizedKubernetesVersion: normalizeVersion(version),
+		ResourceKind:                strings.ToLower(gvk.Kind),
+		ResourceAPIVersion:          gvk.Version,
+		Group:                       gvk.Group,
+		KindSuffix:                  "-" + strings.ToLower(gvk.Version),
+	}
+
+	if strict {
+		data.StrictSuffix = "-strict"
+	}
+
+	if gvk.Group != "" {
+		data.KindSuffix = "-" + strings.ToLower(strings.Split(gvk.Group, ".")[0]) + data.KindSuffix
+	}
+
+	var out bytes.Buffer
+	if err := l.tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("invalid schema location %q: %w", l.raw, err)
+	}
+
+	return out.String(), nil
+}
+
+// normalizeVersion returns the version in the v1.30.0 form, master is kept
+// as it is
+func normalizeVersion(version string) string {
+	if version == "" || version == "master" {
+		return "master"
+	}
+
+	version = "v" + strings.TrimPrefix(version, "v")
+	if strings.Count(version, ".") == 1 {
+		version += ".0"
+	}
+
+	return version
+}
+
+func isURL(target string) bool {
+	return strings.HasPrefix(target, "https://") || strings.HasPrefix(target, "http://")
+}
+
+// fetcher loads JSON schemas from local files or URLs, the downloaded ones
+// are cached in the schemas subdirectory of the cache directory
+type fetcher struct {
+	cacheDir string
+	offline  bool
+}
+
+func (f *fetcher) load(ctx context.Context, target string) (*Schema, error) {
+	var data []byte
+	var err error
+
+	if isURL(target) {
+		data, err = f.download(ctx, target)
+	} else {
+		data, err = os.ReadFile(target)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = errNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", target, err)
+	}
+
+	return &s, nil
+}
+
+func (f *fetcher) download(ctx context.Context, target string) ([]byte, error) {
+	dir, err := cachedir.Dir(f.cacheDir, "schemas")
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(target))
+	cached := filepath.Join(dir, hex.EncodeToString(sum[:16])+".json")
+
+	if data, err := os.ReadFile(cached); err == nil {
+		return data, nil
+	}
+
+	if f.offline {
+		return nil, errNotFound
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download schema: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errNotFound
+	default:
+		return nil, fmt.Errorf("failed to download schema: GET %s: %s", target, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download schema: %w", err)
+	}
+
+	// the schema is only cached once it is known to be valid JSON
+	if json.Valid(data) {
+		tmp, err := os.CreateTemp(dir, ".schema-*")
+		if err == nil {
+			_, werr := tmp.Write(data)
+			cerr := tmp.Close()
+			if werr != nil || cerr != nil || os.Rename(tmp.Name(), cached) != nil {
+				_ = os.Remove(tmp.Name())
+			}
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,311 @@
+package openapischema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+)
+
+const (
+	Name        = "openapi-schema"
+	Description = "Validates objects against the JSON schemas of their kind, reporting unknown fields and type mismatches"
+	Since       = "v0.2.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckUnknownField  = "unknown-field"
+	CheckType          = "type"
+	CheckRequired      = "required"
+	CheckEnum          = "enum"
+	CheckMissingSchema = "missing-schema"
+)
+
+type Config struct {
+	// KubernetesVersion selects the schemas of the locations, e.g. 1.30.0
+	KubernetesVersion string `mapstructure:"kubernetes-version"`
+	// Strict reports the fields the schemas do not declare
+	Strict bool `mapstructure:"strict"`
+	// SchemaLocations are looked up in order for the schema of each kind:
+	// builtin, default, a local directory or a URL template using the
+	// kubeconform variables
+	SchemaLocations []string `mapstructure:"schema-locations"`
+	// CRDSchemaDirs hold CustomResourceDefinitions validating the custom
+	// resources, along with the ones of the linted manifests
+	CRDSchemaDirs []string `mapstructure:"crd-schema-dirs"`
+	// IgnoreMissingSchemas skips the kinds no schema is found for instead of
+	// reporting them
+	IgnoreMissingSchemas bool `mapstructure:"ignore-missing-schemas"`
+	// CacheDir is where the downloaded schemas are cached
+	CacheDir string `mapstructure:"cache-dir"`
+	// Offline only uses the local and cached schemas
+	Offline bool `mapstructure:"offline"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		KubernetesVersion:    "master",
+		Strict:               true,
+		SchemaLocations:      []string{LocationBuiltin},
+		IgnoreMissingSchemas: true,
+	}
+}
+
+func init() {
+	l := &Linter{
+		config: DefaultConfig(),
+	}
+
+	if err := l.compile(); err != nil {
+		panic(err)
+	}
+
+	linter.Register(l)
+}
+
+type Linter struct {
+	config    Config
+	locations []*location
+	builtin   *builtin
+	fetcher   *fetcher
+	crds      []unstructured.Unstructured
+
+	mu sync.Mutex
+	// loaded memoizes the schemas by location target, nil for the missing
+	// ones
+	loaded map[string]*Schema
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckUnknownField, CheckType, CheckRequired, CheckEnum, CheckMissingSchema}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	// configured locations replace the defaults rather than being merged
+	// into them element by element
+	if _, ok := settings["schema-locations"]; ok {
+		l.config.SchemaLocations = nil
+	}
+
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	return l.compile()
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) compile() error {
+	l.locations = nil
+	l.builtin = nil
+	l.crds = nil
+
+	for _, raw := range l.config.SchemaLocations {
+		if raw == LocationBuiltin {
+			l.builtin = newBuiltin(l.config.Strict)
+			l.locations = append(l.locations, nil)
+			continue
+		}
+
+		loc, err := newLocation(raw)
+		if err != nil {
+			return err
+		}
+
+		l.locations = append(l.locations, loc)
+	}
+
+	crds, err := loadCRDs(l.config.CRDSchemaDirs)
+	if err != nil {
+		return err
+	}
+
+	l.crds = crds
+	l.fetcher = &fetcher{cacheDir: l.config.CacheDir, offline: l.config.Offline}
+
+	l.mu.Lock()
+	l.loaded = make(map[string]*Schema)
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Lint checks a single object, the runner uses LintSet instead which builds
+// the schemas of the CustomResourceDefinitions once for the whole run
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, _ := linter.AllObjectsFromContext(ctx)
+
+	return l.check(ctx, obj, l.crdSchemas(allObjects))
+}
+
+func (l *Linter) LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]linter.Issue, error) {
+	crds := l.crdSchemas(objects)
+
+	var issues []linter.Issue
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		objIssues, err := l.check(ctx, obj, crds)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, objIssues...)
+	}
+
+	return issues, nil
+}
+
+// crdSchemas returns the schemas of the CustomResourceDefinitions of the
+// configured directories and of the objects, the latter taking precedence
+func (l *Linter) crdSchemas(objects []unstructured.Unstructured) map[schema.GroupVersionKind]*Schema {
+	schemas := crdSchemas(l.crds, l.config.Strict)
+	for gvk, s := range crdSchemas(objects, l.config.Strict) {
+		schemas[gvk] = s
+	}
+
+	return schemas
+}
+
+func (l *Linter) check(ctx context.Context, obj unstructured.Unstructured, crds map[schema.GroupVersionKind]*Schema) ([]linter.Issue, error) {
+	gvk := obj.GroupVersionKind()
+	if gvk.Kind == "" || gvk.Version == "" {
+		return nil, nil
+	}
+
+	s := crds[gvk]
+	if s == nil {
+		var err error
+		if s, err = l.lookup(ctx, gvk); err != nil {
+			return nil, err
+		}
+	}
+
+	if s == nil {
+		if l.config.IgnoreMissingSchemas {
+			return nil, nil
+		}
+
+		return []linter.Issue{{
+			Severity:   linter.SeverityInfo,
+			Linter:     l.Name(),
+			Check:      CheckMissingSchema,
+			Message:    fmt.Sprintf("No schema found for %s %s, the object is not validated", gvk.GroupVersion(), gvk.Kind),
+			Resource:   common.ResourceRef(obj),
+			Suggestion: "Add a location providing the schema to schema-locations, or the CustomResourceDefinition of custom resources to crd-schema-dirs",
+		}}, nil
+	}
+
+	v := &validator{root: s}
+
+	var issues []linter.Issue
+	for _, violation := range v.validate(s, obj.Object, fieldpath.Root) {
+		issue := linter.Issue{
+			Severity: linter.SeverityError,
+			Linter:   l.Name(),
+			Check:    violation.check,
+			Message:  violation.message,
+			Resource: common.ResourceRef(obj),
+			Field:    violation.field,
+		}
+
+		if violation.suggestion != "" {
+			issue.Suggestion = fmt.Sprintf("Did you mean %q?", violation.suggestion)
+		}
+
+		issues = append(issues, issue)
+	}
+
+	return issues, nil
+}
+
+// lookup returns the schema of the kind from the first location providing
+// it, nil when none does
+func (l *Linter) lookup(ctx context.Context, gvk schema.GroupVersionKind) (*Schema, error) {
+	for _, loc := range l.locations {
+		if loc == nil {
+			if s := l.builtin.schema(gvk); s != nil {
+				return s, nil
+			}
+			continue
+		}
+
+		target, err := loc.target(l.config.KubernetesVersion, l.config.Strict, gvk)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := l.load(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			return s, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// load fetches the schema at target once per run, nil when it does not exist
+func (l *Linter) load(ctx context.Context, target string) (*Schema, error) {
+	l.mu.Lock()
+	s, ok := l.loaded[target]
+	l.mu.Unlock()
+
+	if ok {
+		return s, nil
+	}
+
+	s, err := l.fetcher.load(ctx, target)
+	switch {
+	case errors.Is(err, errNotFound):
+		s = nil
+	case err != nil:
+		return nil, err
+	}
+
+	l.mu.Lock()
+	l.loaded[target] = s
+	l.mu.Unlock()
+
+	return s, nil
+}
@@ -0,0 +1,212 @@
+package openapischema_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/openapischema"
+)
+
+const deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: "3"
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: web:1.0.0
+          imagePullPolcy: Always
+`
+
+const crd = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              required: [size]
+              properties:
+                size:
+                  type: string
+                  enum: [small, large]
+`
+
+const widget = `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+spec:
+  color: blue
+`
+
+func TestOpenAPISchema(t *testing.T) {
+	typeIssue := lintertest.Expectation{
+		Severity: linter.SeverityError,
+		Linter:   openapischema.Name,
+		Message:  "Expected integer, got string",
+		Field:    "$.spec.replicas",
+		Kind:     "Deployment",
+	}
+	unknownIssue := lintertest.Expectation{
+		Severity: linter.SeverityError,
+		Linter:   openapischema.Name,
+		Message:  `Unknown field "imagePullPolcy"`,
+		Field:    "$.spec.template.spec.containers[0].imagePullPolcy",
+		Kind:     "Deployment",
+	}
+
+	tests := []struct {
+		name      string
+		manifests string
+		settings  map[string]interface{}
+		expected  []lintertest.Expectation
+	}{
+		{
+			name:      "default",
+			manifests: deployment,
+			expected:  []lintertest.Expectation{typeIssue, unknownIssue},
+		},
+		{
+			name:      "not strict",
+			manifests: deployment,
+			settings:  map[string]interface{}{"strict": false},
+			expected:  []lintertest.Expectation{typeIssue},
+		},
+		{
+			name:      "missing schemas ignored",
+			manifests: widget,
+		},
+		{
+			name:      "missing schemas reported",
+			manifests: widget,
+			settings:  map[string]interface{}{"ignore-missing-schemas": false},
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityInfo,
+				Message:  "No schema found for example.com/v1 Widget",
+				Kind:     "Widget",
+			}},
+		},
+		{
+			name:      "CustomResourceDefinition of the manifests",
+			manifests: crd + "---" + widget,
+			expected: []lintertest.Expectation{
+				{Severity: linter.SeverityError, Message: `Missing required field "size"`, Kind: "Widget"},
+				{Severity: linter.SeverityError, Message: `Unknown field "color"`, Field: "$.spec.color", Kind: "Widget"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &openapischema.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			lintertest.Configure(t, l, tt.settings)
+
+			issues := lintertest.Run(t, l, lintertest.ParseObjects(t, tt.manifests)...)
+			lintertest.AssertIssues(t, issues, tt.expected...)
+		})
+	}
+}
+
+// TestOpenAPISchemaDirectory looks up the schemas in a local directory laid
+// out as kubernetes-json-schema
+func TestOpenAPISchemaDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	schema := `{
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "properties": {
+        "size": {"type": "integer"}
+      }
+    }
+  }
+}`
+
+	path := filepath.Join(dir, "v1.30.0-standalone", "widget-example-v1.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(schema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l := &openapischema.Linter{}
+	if err := l.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	lintertest.Configure(t, l, map[string]interface{}{
+		"kubernetes-version": "1.30",
+		"strict":             false,
+		"schema-locations":   []interface{}{dir},
+		"offline":            true,
+	})
+
+	issues := lintertest.Run(t, l, lintertest.ParseObjects(t, `
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+spec:
+  size: large
+`)...)
+
+	lintertest.AssertIssues(t, issues, lintertest.Expectation{
+		Severity: linter.SeverityError,
+		Linter:   openapischema.Name,
+		Message:  "Expected integer, got string",
+		Field:    "$.spec.size",
+		Kind:     "Widget",
+	})
+}
+
+func TestOpenAPISchemaInvalidSettings(t *testing.T) {
+	for name, settings := range map[string]map[string]interface{}{
+		"invalid template": {"schema-locations": []interface{}{"https://example.com/{{ .Kind"}},
+		"invalid strict":   {"strict": "sometimes"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			l := &openapischema.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := l.Configure(settings); err == nil {
+				t.Errorf("expected an error configuring %v", settings)
+			}
+		})
+	}
+}
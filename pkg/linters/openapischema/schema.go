@@ -0,0 +1,110 @@
+package openapischema
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Schema is the subset of JSON schema, as used by the Kubernetes OpenAPI
+// schemas and the CRD structural schemas, the objects are validated against
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Types       Types              `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty"`
+	Nullable    bool               `json:"nullable,omitempty"`
+	AllOf       []*Schema          `json:"allOf,omitempty"`
+	AnyOf       []*Schema          `json:"anyOf,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty"`
+	Definitions map[string]*Schema `json:"definitions,omitempty"`
+	// AdditionalProperties is the schema of the fields not listed in
+	// Properties, Closed is set when they are not allowed at all
+	AdditionalProperties *Schema `json:"-"`
+	Closed               bool    `json:"-"`
+	// IntOrString and PreserveUnknownFields are the Kubernetes extensions
+	// accepting either an integer or a string and any field
+	IntOrString           bool `json:"x-kubernetes-int-or-string,omitempty"`
+	PreserveUnknownFields bool `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+}
+
+// Types are the JSON types a value can have, a schema sets a single type or
+// a list of them
+type Types []string
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*t = list
+
+	return nil
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+
+	var raw struct {
+		plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = Schema(raw.plain)
+
+	// additionalProperties is either a boolean or a schema
+	switch strings.TrimSpace(string(raw.AdditionalProperties)) {
+	case "":
+	case "true":
+		s.AdditionalProperties = &Schema{}
+	case "false":
+		s.Closed = true
+	default:
+		s.AdditionalProperties = &Schema{}
+		if err := json.Unmarshal(raw.AdditionalProperties, s.AdditionalProperties); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// close marks the objects declaring their properties as not accepting any
+// other field, unless they preserve unknown fields or set
+// additionalProperties. CRD schemas leave them open and the API server
+// prunes the unknown fields, which hides typos.
+func (s *Schema) close(seen map[*Schema]bool) {
+	if s == nil || seen[s] {
+		return
+	}
+	seen[s] = true
+
+	if len(s.Properties) > 0 && s.AdditionalProperties == nil && !s.PreserveUnknownFields {
+		s.Closed = true
+	}
+
+	for _, p := range s.Properties {
+		p.close(seen)
+	}
+
+	for _, list := range [][]*Schema{s.AllOf, s.AnyOf, s.OneOf} {
+		for _, sub := range list {
+			sub.close(seen)
+		}
+	}
+
+	s.Items.close(seen)
+	s.AdditionalProperties.close(seen)
+}
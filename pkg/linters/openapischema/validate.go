@@ -0,0 +1,292 @@
+package openapischema
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+)
+
+// violation is a value not matching its schema
+type violation struct {
+	check   string
+	field   string
+	message string
+	// suggestion is the closest known field of an unknown one
+	suggestion string
+}
+
+// validator validates values against a schema, root holds the definitions
+// local references point to
+type validator struct {
+	root *Schema
+}
+
+func (v *validator) validate(s *Schema, value interface{}, path string) []violation {
+	s = v.resolve(s)
+	if s == nil {
+		return nil
+	}
+
+	var violations []violation
+
+	for _, sub := range s.AllOf {
+		violations = append(violations, v.validate(sub, value, path)...)
+	}
+
+	for _, alternatives := range [][]*Schema{s.AnyOf, s.OneOf} {
+		if len(alternatives) > 0 {
+			violations = append(violations, v.alternatives(alternatives, value, path)...)
+		}
+	}
+
+	if value == nil {
+		// explicit nulls are dropped by the API server
+		return violations
+	}
+
+	if s.IntOrString {
+		switch value.(type) {
+		case string, int64, int, float64:
+			return violations
+		}
+		return append(violations, typeViolation(path, []string{"integer", "string"}, value))
+	}
+
+	if len(s.Types) > 0 && !slices.ContainsFunc(s.Types, func(t string) bool { return hasType(value, t) }) {
+		return append(violations, typeViolation(path, s.Types, value))
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e interface{}) bool { return equal(e, value) }) {
+		allowed := make([]string, 0, len(s.Enum))
+		for _, e := range s.Enum {
+			allowed = append(allowed, fmt.Sprint(e))
+		}
+
+		violations = append(violations, violation{
+			check:   CheckEnum,
+			field:   path,
+			message: fmt.Sprintf("Value %v is not one of %s", value, strings.Join(allowed, ", ")),
+		})
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		violations = append(violations, v.object(s, val, path)...)
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range val {
+				violations = append(violations, v.validate(s.Items, item, fieldpath.Join(path, i))...)
+			}
+		}
+	}
+
+	return violations
+}
+
+func (v *validator) object(s *Schema, value map[string]interface{}, path string) []violation {
+	var violations []violation
+
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			violations = append(violations, violation{
+				check:   CheckRequired,
+				field:   fieldpath.Join(path, name),
+				message: fmt.Sprintf("Missing required field %q", name),
+			})
+		}
+	}
+
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := fieldpath.Join(path, key)
+
+		if p, ok := s.Properties[key]; ok {
+			violations = append(violations, v.validate(p, value[key], field)...)
+			continue
+		}
+
+		switch {
+		case s.AdditionalProperties != nil:
+			violations = append(violations, v.validate(s.AdditionalProperties, value[key], field)...)
+		case s.Closed && !s.PreserveUnknownFields:
+			violations = append(violations, violation{
+				check:      CheckUnknownField,
+				field:      field,
+				message:    fmt.Sprintf("Unknown field %q", key),
+				suggestion: closest(key, s.Properties),
+			})
+		}
+	}
+
+	return violations
+}
+
+// alternatives validates a value matching any of the schemas, the violations
+// of the closest one are reported when none matches
+func (v *validator) alternatives(schemas []*Schema, value interface{}, path string) []violation {
+	var best []violation
+
+	for i, sub := range schemas {
+		violations := v.validate(sub, value, path)
+		if len(violations) == 0 {
+			return nil
+		}
+
+		if i == 0 || len(violations) < len(best) {
+			best = violations
+		}
+	}
+
+	return best
+}
+
+// resolve follows the local references, such as
+// #/definitions/io.k8s.api.core.v1.PodSpec, references which cannot be
+// resolved accept any value
+func (v *validator) resolve(s *Schema) *Schema {
+	for depth := 0; s != nil && s.Ref != ""; depth++ {
+		name, ok := strings.CutPrefix(s.Ref, "#/definitions/")
+		if !ok || v.root == nil || depth > 32 {
+			return nil
+		}
+
+		s = v.root.Definitions[name]
+	}
+
+	return s
+}
+
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "integer":
+		switch n := value.(type) {
+		case int64, int:
+			return true
+		case float64:
+			return n == math.Trunc(n)
+		}
+		return false
+	case "number":
+		switch value.(type) {
+		case int64, int, float64:
+			return true
+		}
+		return false
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int64, int:
+		return "integer"
+	case float64:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func typeViolation(path string, types []string, value interface{}) violation {
+	return violation{
+		check:   CheckType,
+		field:   path,
+		message: fmt.Sprintf("Expected %s, got %s", strings.Join(types, " or "), typeName(value)),
+	}
+}
+
+func equal(a interface{}, b interface{}) bool {
+	// numbers decoded from YAML and JSON have different types
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// closest returns the known field the name is most likely a typo of, empty
+// when none is close enough
+func closest(name string, properties map[string]*Schema) string {
+	best := ""
+	bestDistance := len(name)/3 + 1
+
+	for candidate := range properties {
+		d := distance(strings.ToLower(name), strings.ToLower(candidate))
+		if d < bestDistance || d == bestDistance && best != "" && candidate < best {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	return best
+}
+
+// distance is the Levenshtein distance between a and b
+func distance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,184 @@
+package podcomplexity
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "pod-complexity"
+	Description = "Flags pods exceeding complexity thresholds, a sign the workload should be split"
+	Since       = "v0.2.0"
+)
+
+// Config holds the thresholds, zero disables a check
+type Config struct {
+	MaxContainers int `mapstructure:"max-containers"`
+	MaxVolumes    int `mapstructure:"max-volumes"`
+	MaxEnvVars    int `mapstructure:"max-env-vars"`
+	// MaxSpecSize is the size, as a quantity such as 32Ki, of the pod spec
+	// serialized as YAML
+	MaxSpecSize string `mapstructure:"max-spec-size"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		MaxContainers: 5,
+		MaxVolumes:    20,
+		MaxEnvVars:    50,
+		MaxSpecSize:   "32Ki",
+	}
+}
+
+func init() {
+	l := &Linter{
+		config: DefaultConfig(),
+	}
+
+	if err := l.parse(); err != nil {
+		panic(err)
+	}
+
+	linter.Register(l)
+}
+
+type Linter struct {
+	config      Config
+	maxSpecSize int64
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	return l.parse()
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) parse() error {
+	l.maxSpecSize = 0
+
+	if l.config.MaxSpecSize != "" {
+		q, err := resource.ParseQuantity(l.config.MaxSpecSize)
+		if err != nil {
+			return fmt.Errorf("invalid max-spec-size %q: %w", l.config.MaxSpecSize, err)
+		}
+		l.maxSpecSize = q.Value()
+	}
+
+	return nil
+}
+
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	if !gvk.IsWorkloadOrPod(obj) {
+		return nil, nil
+	}
+
+	specPath, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil, nil
+	}
+
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil, nil
+	}
+
+	var issues []linter.Issue
+
+	issue := func(field string, format string, args ...interface{}) linter.Issue {
+		return linter.Issue{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Message:    fmt.Sprintf(format, args...),
+			Resource:   common.ResourceRef(obj),
+			Field:      field,
+			Suggestion: "Consider splitting the workload into smaller, independently deployable units",
+		}
+	}
+
+	if containers := len(spec.Containers) + len(spec.InitContainers); l.config.MaxContainers > 0 && containers > l.config.MaxContainers {
+		issues = append(issues, issue(fieldpath.Join(specPath, "containers"),
+			"Pod has %d containers, more than the maximum of %d", containers, l.config.MaxContainers))
+	}
+
+	if volumes := len(spec.Volumes); l.config.MaxVolumes > 0 && volumes > l.config.MaxVolumes {
+		issues = append(issues, issue(fieldpath.Join(specPath, "volumes"),
+			"Pod has %d volumes, more than the maximum of %d", volumes, l.config.MaxVolumes))
+	}
+
+	if l.config.MaxEnvVars > 0 {
+		for i, c := range spec.Containers {
+			if n := len(c.Env); n > l.config.MaxEnvVars {
+				issues = append(issues, issue(k8s.ContainerPath(obj, i, "env"),
+					"Container %q has %d environment variables, more than the maximum of %d", c.Name, n, l.config.MaxEnvVars))
+			}
+		}
+	}
+
+	if l.maxSpecSize > 0 {
+		segments, err := fieldpath.Segments(specPath)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make([]string, 0, len(segments))
+		for _, s := range segments {
+			fields = append(fields, s.(string))
+		}
+
+		if podSpec, ok, _ := unstructured.NestedFieldNoCopy(obj.Object, fields...); ok {
+			data, err := yaml.Marshal(podSpec)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode pod spec: %w", err)
+			}
+
+			if size := int64(len(data)); size > l.maxSpecSize {
+				issues = append(issues, issue(specPath,
+					"Pod spec is %s, larger than the maximum of %s",
+					resource.NewQuantity(size, resource.BinarySI), l.config.MaxSpecSize))
+			}
+		}
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,303 @@
+package poddisruptionbudgets
+
+import (
+	"context"
+	"fmt"
+
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+)
+
+const (
+	Name        = "pod-disruption-budgets"
+	Description = "Ensures PodDisruptionBudgets select workloads and allow pods to be evicted"
+	Since       = "v0.2.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckSelector       = "selector"
+	CheckMinAvailable   = "min-available"
+	CheckMaxUnavailable = "max-unavailable"
+)
+
+const horizontalPodAutoscaler = "HorizontalPodAutoscaler"
+
+type Config struct{}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{}
+}
+
+func init() {
+	linter.Register(&Linter{
+		config: DefaultConfig(),
+	})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckSelector, CheckMinAvailable, CheckMaxUnavailable}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// Lint checks a single object, the runner uses LintSet instead which indexes
+// the workloads once for the whole run
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, _ := linter.AllObjectsFromContext(ctx)
+
+	return l.check(obj, newIndex(allObjects)), nil
+}
+
+func (l *Linter) LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]linter.Issue, error) {
+	idx := newIndex(objects)
+
+	var issues []linter.Issue
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, l.check(obj, idx)...)
+	}
+
+	return issues, nil
+}
+
+// workload is a workload, or a Pod, that PodDisruptionBudgets may select
+type workload struct {
+	ref    linter.ResourceRef
+	labels labels.Set
+	// replicas is the number of pods of the workload, when known
+	replicas int32
+	known    bool
+}
+
+// target identifies the workload scaled by a HorizontalPodAutoscaler
+type target struct {
+	namespace string
+	kind      string
+	name      string
+}
+
+// scale is the minimum number of replicas set by a HorizontalPodAutoscaler
+type scale struct {
+	replicas int32
+	known    bool
+}
+
+// index holds the workloads of every namespace, along with the number of
+// pods they run
+type index map[string][]workload
+
+func newIndex(objects []unstructured.Unstructured) index {
+	scales := make(map[target]scale)
+
+	for _, obj := range objects {
+		if obj.GetKind() != horizontalPodAutoscaler {
+			continue
+		}
+
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "name")
+
+		t := target{namespace: obj.GetNamespace(), kind: kind, name: name}
+		if _, ok := scales[t]; ok {
+			continue
+		}
+
+		minReplicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "minReplicas")
+		if !found {
+			minReplicas = 1
+		}
+
+		scales[t] = scale{replicas: int32(minReplicas), known: err == nil}
+	}
+
+	idx := make(index)
+
+	for _, obj := range objects {
+		if !gvk.IsWorkloadOrPod(obj) {
+			continue
+		}
+
+		podLabels, ok := k8s.PodLabels(obj)
+		if !ok {
+			continue
+		}
+
+		replicas, known := replicaCount(obj, scales)
+
+		idx[obj.GetNamespace()] = append(idx[obj.GetNamespace()], workload{
+			ref:      common.ResourceRef(obj),
+			labels:   labels.Set(podLabels),
+			replicas: replicas,
+			known:    known,
+		})
+	}
+
+	return idx
+}
+
+func (l *Linter) check(obj unstructured.Unstructured, idx index) []linter.Issue {
+	if !gvk.IsGVK(obj, gvk.PodDisruptionBudget) {
+		return nil
+	}
+
+	var pdb policyv1.PodDisruptionBudget
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pdb); err != nil {
+		return nil
+	}
+
+	var issues []linter.Issue
+
+	if maxUnavailable := pdb.Spec.MaxUnavailable; maxUnavailable != nil && isZero(*maxUnavailable) {
+		issues = append(issues, linter.Issue{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Check:      CheckMaxUnavailable,
+			Message:    fmt.Sprintf("PodDisruptionBudget %q sets maxUnavailable to %s, no pod can ever be evicted and node drains block", pdb.Name, maxUnavailable),
+			Resource:   common.ResourceRef(obj),
+			Field:      fieldpath.Path("spec", "maxUnavailable"),
+			Value:      maxUnavailable.String(),
+			Suggestion: "Allow at least one pod to be unavailable, e.g. maxUnavailable: 1",
+		})
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil || pdb.Spec.Selector == nil {
+		// a PodDisruptionBudget without selector selects no pod
+		selector = labels.Nothing()
+	}
+
+	var selected []linter.ResourceRef
+	replicas := int32(0)
+	known := true
+
+	for _, w := range idx[obj.GetNamespace()] {
+		if !selector.Matches(w.labels) {
+			continue
+		}
+
+		selected = append(selected, w.ref)
+
+		replicas += w.replicas
+		known = known && w.known
+	}
+
+	if len(selected) == 0 {
+		issues = append(issues, linter.Issue{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Check:      CheckSelector,
+			Message:    fmt.Sprintf("PodDisruptionBudget %q selects no workload in the manifests", pdb.Name),
+			Resource:   common.ResourceRef(obj),
+			Field:      fieldpath.Path("spec", "selector"),
+			Suggestion: "Fix the selector to match the labels of the pod templates, or remove the stale PodDisruptionBudget",
+		})
+
+		return issues
+	}
+
+	if minAvailable := pdb.Spec.MinAvailable; minAvailable != nil && known && replicas > 0 {
+		required, err := intstr.GetScaledValueFromIntOrPercent(minAvailable, int(replicas), true)
+		if err == nil && required >= int(replicas) {
+			issues = append(issues, linter.Issue{
+				Severity: linter.SeverityWarning,
+				Linter:   l.Name(),
+				Check:    CheckMinAvailable,
+				Message: fmt.Sprintf("PodDisruptionBudget %q requires %d available pods out of %d replicas, no pod can be evicted and node drains block",
+					pdb.Name, required, replicas),
+				Resource:   common.ResourceRef(obj),
+				Field:      fieldpath.Path("spec", "minAvailable"),
+				Value:      minAvailable.String(),
+				Suggestion: "Lower minAvailable below the number of replicas, or scale the workloads up",
+				Related:    selected,
+			})
+		}
+	}
+
+	return issues
+}
+
+// isZero reports whether maxUnavailable allows no disruption whatever the
+// number of replicas
+func isZero(v intstr.IntOrString) bool {
+	if v.Type == intstr.Int {
+		return v.IntVal == 0
+	}
+
+	scaled, err := intstr.GetScaledValueFromIntOrPercent(&v, 100, true)
+	return err == nil && scaled == 0
+}
+
+// replicaCount returns the number of pods a workload runs, the minimum
+// replicas of its HorizontalPodAutoscaler when it is scaled by one. The count
+// is unknown for DaemonSets, Jobs and CronJobs.
+func replicaCount(obj unstructured.Unstructured, scales map[target]scale) (int32, bool) {
+	switch {
+	case gvk.IsGVK(obj, gvk.Pod):
+		return 1, true
+	case !gvk.IsAnyGVK(obj, gvk.Deployment, gvk.StatefulSet):
+		return 0, false
+	}
+
+	if s, ok := scales[target{namespace: obj.GetNamespace(), kind: obj.GetKind(), name: obj.GetName()}]; ok {
+		return s.replicas, s.known
+	}
+
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return 0, false
+	}
+	if !found {
+		replicas = 1
+	}
+
+	return int32(replicas), true
+}
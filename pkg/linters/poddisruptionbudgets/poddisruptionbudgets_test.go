@@ -0,0 +1,176 @@
+package poddisruptionbudgets_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/poddisruptionbudgets"
+)
+
+const deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: apps
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: web:1.0.0
+`
+
+func pdb(spec string) string {
+	return `
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+  namespace: apps
+spec:
+` + spec
+}
+
+func TestPodDisruptionBudgets(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifests string
+		expected  []lintertest.Expectation
+	}{
+		{
+			name: "sane budget",
+			manifests: deployment + pdb(`
+  minAvailable: 2
+  selector:
+    matchLabels:
+      app: web
+`),
+		},
+		{
+			name: "minAvailable equal to the replicas",
+			manifests: deployment + pdb(`
+  minAvailable: 100%
+  selector:
+    matchLabels:
+      app: web
+`),
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityWarning,
+				Linter:   poddisruptionbudgets.Name,
+				Message:  `PodDisruptionBudget "web" requires 3 available pods out of 3 replicas`,
+				Field:    "$.spec.minAvailable",
+				Kind:     "PodDisruptionBudget",
+			}},
+		},
+		{
+			name: "minReplicas of the HorizontalPodAutoscaler",
+			manifests: deployment + pdb(`
+  minAvailable: 2
+  selector:
+    matchLabels:
+      app: web
+`) + `
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+  namespace: apps
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: web
+  minReplicas: 2
+  maxReplicas: 10
+`,
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityWarning,
+				Message:  `requires 2 available pods out of 2 replicas`,
+				Field:    "$.spec.minAvailable",
+			}},
+		},
+		{
+			name: "maxUnavailable zero",
+			manifests: deployment + pdb(`
+  maxUnavailable: 0%
+  selector:
+    matchLabels:
+      app: web
+`),
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityWarning,
+				Message:  `PodDisruptionBudget "web" sets maxUnavailable to 0%`,
+				Field:    "$.spec.maxUnavailable",
+			}},
+		},
+		{
+			name: "selector matching no workload",
+			manifests: deployment + pdb(`
+  maxUnavailable: 1
+  selector:
+    matchLabels:
+      app: frontend
+`),
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityWarning,
+				Message:  `PodDisruptionBudget "web" selects no workload in the manifests`,
+				Field:    "$.spec.selector",
+			}},
+		},
+		{
+			name: "missing selector",
+			manifests: deployment + pdb(`
+  maxUnavailable: 1
+`),
+			expected: []lintertest.Expectation{{
+				Severity: linter.SeverityWarning,
+				Message:  `selects no workload in the manifests`,
+				Field:    "$.spec.selector",
+			}},
+		},
+		{
+			name: "unknown replicas of a DaemonSet",
+			manifests: `
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: web
+  namespace: apps
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: web:1.0.0
+` + pdb(`
+  minAvailable: 100%
+  selector:
+    matchLabels:
+      app: web
+`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &poddisruptionbudgets.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+
+			issues := lintertest.Run(t, l, lintertest.ParseObjects(t, tt.manifests)...)
+			lintertest.AssertIssues(t, issues, tt.expected...)
+		})
+	}
+}
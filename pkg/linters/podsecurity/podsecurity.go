@@ -0,0 +1,179 @@
+package podsecurity
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/pss"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "pod-security"
+	Description = "Evaluates workloads against the baseline or restricted Pod Security Standards profile"
+	Since       = "v0.2.0"
+)
+
+// NamespaceLevel sets the level of the workloads of the namespaces matching
+// any of the Namespaces globs
+type NamespaceLevel struct {
+	Namespaces []string `mapstructure:"namespaces"`
+	Level      string   `mapstructure:"level"`
+}
+
+type Config struct {
+	// Level is the profile of the workloads of the namespaces matching no
+	// NamespaceLevels entry
+	Level string `mapstructure:"level"`
+	// NamespaceLevels are matched in order, the first match wins
+	NamespaceLevels []NamespaceLevel `mapstructure:"namespace-levels"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		Level: string(pss.Baseline),
+	}
+}
+
+func init() {
+	l := &Linter{
+		config: DefaultConfig(),
+	}
+
+	if err := l.compile(); err != nil {
+		panic(err)
+	}
+
+	linter.Register(l)
+}
+
+type Linter struct {
+	config Config
+	level  pss.Level
+	levels []pss.Level
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+// Checks are the controls of the Pod Security Standards
+func (l *Linter) Checks() []string {
+	return pss.Controls()
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	return l.compile()
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) compile() error {
+	level, err := pss.ParseLevel(l.config.Level)
+	if err != nil {
+		return fmt.Errorf("invalid level: %w", err)
+	}
+
+	l.level = level
+	l.levels = make([]pss.Level, len(l.config.NamespaceLevels))
+
+	for i, entry := range l.config.NamespaceLevels {
+		if len(entry.Namespaces) == 0 {
+			return fmt.Errorf("namespace-levels[%d]: namespaces is required", i)
+		}
+
+		for _, pattern := range entry.Namespaces {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("namespace-levels[%d]: invalid namespace pattern %q: %w", i, pattern, err)
+			}
+		}
+
+		if l.levels[i], err = pss.ParseLevel(entry.Level); err != nil {
+			return fmt.Errorf("namespace-levels[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	if !gvk.IsWorkloadOrPod(obj) {
+		return nil, nil
+	}
+
+	level := l.levelOf(obj.GetNamespace())
+	if level == pss.Privileged {
+		return nil, nil
+	}
+
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil, nil
+	}
+
+	base, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil, nil
+	}
+
+	var issues []linter.Issue
+	for _, v := range pss.Check(spec, base, level) {
+		issues = append(issues, linter.Issue{
+			Severity:   linter.SeverityError,
+			Linter:     l.Name(),
+			Check:      v.Control,
+			Message:    fmt.Sprintf("Violates the %s Pod Security Standard: %s", v.Level, v.Message),
+			Resource:   common.ResourceRef(obj),
+			Field:      v.Field,
+			Suggestion: fmt.Sprintf("Comply with the %q control of the %s level", v.Control, v.Level),
+		})
+	}
+
+	return issues, nil
+}
+
+// levelOf returns the level of the workloads of the namespace
+func (l *Linter) levelOf(namespace string) pss.Level {
+	for i, entry := range l.config.NamespaceLevels {
+		for _, pattern := range entry.Namespaces {
+			if matched, _ := path.Match(pattern, namespace); matched {
+				return l.levels[i]
+			}
+		}
+	}
+
+	return l.level
+}
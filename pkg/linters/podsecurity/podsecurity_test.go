@@ -0,0 +1,159 @@
+package podsecurity_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/podsecurity"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/pss"
+)
+
+const manifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: node-agent
+  namespace: monitoring
+spec:
+  template:
+    spec:
+      hostNetwork: true
+      containers:
+        - name: agent
+          image: agent:1.0.0
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: apps
+spec:
+  containers:
+    - name: web
+      image: web:1.0.0
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: hardened
+  namespace: apps
+spec:
+  securityContext:
+    runAsNonRoot: true
+    seccompProfile:
+      type: RuntimeDefault
+  containers:
+    - name: app
+      image: app:1.0.0
+      securityContext:
+        allowPrivilegeEscalation: false
+        capabilities:
+          drop: [ALL]
+`
+
+// checks returns the controls violated per object name
+func checks(issues []linter.Issue) map[string][]string {
+	result := make(map[string][]string)
+	for _, issue := range issues {
+		if !slices.Contains(result[issue.Resource.Name], issue.Check) {
+			result[issue.Resource.Name] = append(result[issue.Resource.Name], issue.Check)
+		}
+	}
+	return result
+}
+
+func TestPodSecurity(t *testing.T) {
+	restricted := []string{pss.ControlPrivilegeEscalation, pss.ControlRunningAsNonRoot, pss.ControlSeccompRestricted, pss.ControlCapabilitiesRestricted}
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		expected map[string][]string
+	}{
+		{
+			name: "default",
+			expected: map[string][]string{
+				"node-agent": {pss.ControlHostNamespaces},
+			},
+		},
+		{
+			name:     "restricted",
+			settings: map[string]interface{}{"level": "restricted"},
+			expected: map[string][]string{
+				"node-agent": append([]string{pss.ControlHostNamespaces}, restricted...),
+				"web":        restricted,
+			},
+		},
+		{
+			name: "namespace levels",
+			settings: map[string]interface{}{
+				"level": "restricted",
+				"namespace-levels": []interface{}{
+					map[string]interface{}{"namespaces": []interface{}{"monitor*"}, "level": "privileged"},
+					map[string]interface{}{"namespaces": []interface{}{"apps"}, "level": "baseline"},
+				},
+			},
+			expected: map[string][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &podsecurity.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			lintertest.Configure(t, l, tt.settings)
+
+			issues := lintertest.Run(t, l, lintertest.ParseObjects(t, manifests)...)
+			for _, issue := range issues {
+				if issue.Severity != linter.SeverityError || issue.Linter != podsecurity.Name {
+					t.Errorf("unexpected issue: %s %s %s", issue.Severity, issue.Linter, issue.Message)
+				}
+			}
+
+			got := checks(issues)
+			for _, name := range []string{"node-agent", "web", "hardened"} {
+				want := tt.expected[name]
+				slices.Sort(want)
+				slices.Sort(got[name])
+				if !slices.Equal(got[name], want) {
+					t.Errorf("%s: got violations %v, want %v", name, got[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPodSecurityField(t *testing.T) {
+	l := &podsecurity.Linter{}
+	if err := l.Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	issues := lintertest.Run(t, l, lintertest.ParseObjects(t, manifests)...)
+	lintertest.AssertIssues(t, issues, lintertest.Expectation{
+		Severity: linter.SeverityError,
+		Linter:   podsecurity.Name,
+		Message:  "Violates the baseline Pod Security Standard",
+		Field:    "$.spec.template.spec.hostNetwork",
+		Kind:     "Deployment",
+		Name:     "node-agent",
+	})
+}
+
+func TestPodSecurityInvalidSettings(t *testing.T) {
+	for name, settings := range map[string]map[string]interface{}{
+		"invalid level":           {"level": "strict"},
+		"missing namespaces":      {"namespace-levels": []interface{}{map[string]interface{}{"level": "baseline"}}},
+		"invalid namespace":       {"namespace-levels": []interface{}{map[string]interface{}{"namespaces": []interface{}{"["}, "level": "baseline"}}},
+		"invalid namespace level": {"namespace-levels": []interface{}{map[string]interface{}{"namespaces": []interface{}{"apps"}, "level": "strict"}}},
+	} {
+		l := &podsecurity.Linter{}
+		if err := l.Configure(settings); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
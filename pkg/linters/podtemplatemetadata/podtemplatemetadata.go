@@ -0,0 +1,152 @@
+package podtemplatemetadata
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+)
+
+const (
+	Name        = "pod-template-metadata"
+	Description = "Ensures annotations and labels meant for pods are set on the pod template"
+	Since       = "v0.2.0"
+)
+
+// Config lists the annotation and label keys which only take effect on pods,
+// glob patterns such as checksum/* are supported
+type Config struct {
+	Annotations []string `mapstructure:"annotations"`
+	Labels      []string `mapstructure:"labels"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		Annotations: []string{
+			"prometheus.io/*",
+			"sidecar.istio.io/*",
+			"linkerd.io/inject",
+			"checksum/*",
+		},
+		Labels: []string{
+			"sidecar.istio.io/inject",
+		},
+	}
+}
+
+func init() {
+	linter.Register(&Linter{
+		config: DefaultConfig(),
+	})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	if !gvk.IsWorkload(obj) {
+		return nil, nil
+	}
+
+	specPath, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil, nil
+	}
+
+	templatePath := fieldpath.Parent(specPath)
+
+	segments, err := fieldpath.Segments(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(segments))
+	for _, s := range segments {
+		fields = append(fields, s.(string))
+	}
+
+	var issues []linter.Issue
+
+	for _, m := range []struct {
+		kind     string
+		field    string
+		patterns []string
+		workload map[string]string
+	}{
+		{kind: "Annotation", field: "annotations", patterns: l.config.Annotations, workload: obj.GetAnnotations()},
+		{kind: "Label", field: "labels", patterns: l.config.Labels, workload: obj.GetLabels()},
+	} {
+		template, _, _ := unstructured.NestedStringMap(obj.Object, append(fields, "metadata", m.field)...)
+
+		keys := make([]string, 0, len(m.workload))
+		for key := range m.workload {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if !glob.MatchAny(m.patterns, key) {
+				continue
+			}
+
+			if _, ok := template[key]; ok {
+				continue
+			}
+
+			field := fieldpath.Join(templatePath, "metadata", m.field, key)
+
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityWarning,
+				Linter:     l.Name(),
+				Message:    fmt.Sprintf("%s %q is set on the %s but not on its pod template, it has no effect on the pods", m.kind, key, obj.GetKind()),
+				Resource:   common.ResourceRef(obj),
+				Field:      field,
+				Suggestion: fmt.Sprintf("Add %q to spec.template.metadata.%s", key, m.field),
+				Fix:        linter.SetField(field, m.workload[key]),
+			})
+		}
+	}
+
+	return issues, nil
+}
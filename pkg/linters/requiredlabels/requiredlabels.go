@@ -4,16 +4,17 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/mitchellh/mapstructure"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
 )
 
 const (
 	Name        = "required-labels"
 	Description = "Ensures resources have required labels"
+	Since       = "v0.1.0"
 )
 
 type Config struct {
@@ -21,8 +22,14 @@ type Config struct {
 	ExcludeKinds []string `mapstructure:"exclude-kinds"`
 }
 
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{}
+}
+
 func init() {
-	linter.Register(&Linter{})
+	linter.Register(&Linter{config: DefaultConfig()})
 }
 
 type Linter struct {
@@ -37,11 +44,49 @@ func (l *Linter) Description() string {
 	return Description
 }
 
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityStable
+}
+
 func (l *Linter) Configure(settings map[string]interface{}) error {
-	return mapstructure.Decode(settings, &l.config)
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// SupportsOverrides tells that the settings can be overridden per object
+func (l *Linter) SupportsOverrides() bool {
+	return true
 }
 
 func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	// settings overridden by the object annotations apply to this object only
+	config, overridden, invalid := linter.ObjectConfig(obj, Name, l.config)
+	if overridden {
+		l = &Linter{config: config}
+	}
+
+	issues, err := l.lint(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(invalid, issues...), nil
+}
+
+func (l *Linter) lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
 	kind := obj.GetKind()
 	for _, excludeKind := range l.config.ExcludeKinds {
 		if kind == excludeKind {
@@ -59,7 +104,7 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				Linter:     l.Name(),
 				Message:    fmt.Sprintf("Missing required label %q", requiredLabel),
 				Resource:   common.ResourceRef(obj),
-				Field:      "metadata.labels",
+				Field:      fieldpath.Path("metadata", "labels", requiredLabel),
 				Suggestion: fmt.Sprintf("Add label: %s: <value>", requiredLabel),
 			})
 		}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
-	"github.com/mitchellh/mapstructure"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
@@ -16,6 +15,17 @@ import (
 const (
 	Name        = "resource-limits"
 	Description = "Ensures containers have resource requests and limits defined"
+	Since       = "v0.1.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckResources     = "resources"
+	CheckCPULimit      = "cpu-limit"
+	CheckMemoryLimit   = "memory-limit"
+	CheckCPURequest    = "cpu-request"
+	CheckMemoryRequest = "memory-request"
 )
 
 type Config struct {
@@ -26,14 +36,20 @@ type Config struct {
 	ExcludeNamespaces    []string `mapstructure:"exclude-namespaces"`
 }
 
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		RequireCPULimit:      true,
+		RequireMemoryLimit:   true,
+		RequireCPURequest:    true,
+		RequireMemoryRequest: true,
+	}
+}
+
 func init() {
 	linter.Register(&Linter{
-		config: Config{
-			RequireCPULimit:      true,
-			RequireMemoryLimit:   true,
-			RequireCPURequest:    true,
-			RequireMemoryRequest: true,
-		},
+		config: DefaultConfig(),
 	})
 }
 
@@ -49,11 +65,53 @@ func (l *Linter) Description() string {
 	return Description
 }
 
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityStable
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckResources, CheckCPULimit, CheckMemoryLimit, CheckCPURequest, CheckMemoryRequest}
+}
+
 func (l *Linter) Configure(settings map[string]interface{}) error {
-	return mapstructure.Decode(settings, &l.config)
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// SupportsOverrides tells that the settings can be overridden per object
+func (l *Linter) SupportsOverrides() bool {
+	return true
 }
 
 func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	// settings overridden by the object annotations apply to this object only
+	config, overridden, invalid := linter.ObjectConfig(obj, Name, l.config)
+	if overridden {
+		l = &Linter{config: config}
+	}
+
+	issues, err := l.lint(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(invalid, issues...), nil
+}
+
+func (l *Linter) lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
 	if !gvk.IsWorkload(obj) {
 		return nil, nil
 	}
@@ -67,7 +125,7 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 
 	var issues []linter.Issue
 
-	containers, err := k8s.GetContainers(obj)
+	containers, err := k8s.GetContainers(ctx, obj)
 	if err != nil {
 		return nil, err
 	}
@@ -85,9 +143,11 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 			issues = append(issues, linter.Issue{
 				Severity:   linter.SeverityError,
 				Linter:     l.Name(),
+				Check:      CheckResources,
 				Message:    fmt.Sprintf("Container %q has no resource requirements", name),
 				Resource:   common.ResourceRef(obj),
-				Field:      fmt.Sprintf("spec.template.spec.containers[%d].resources", i),
+				Field:      k8s.ContainerPath(obj, i, "resources"),
+				Value:      containerMap["resources"],
 				Suggestion: "Add resources.requests and resources.limits",
 			})
 			continue
@@ -101,9 +161,10 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				issues = append(issues, linter.Issue{
 					Severity:   linter.SeverityError,
 					Linter:     l.Name(),
+					Check:      CheckCPULimit,
 					Message:    fmt.Sprintf("Container %q missing CPU limit", name),
 					Resource:   common.ResourceRef(obj),
-					Field:      fmt.Sprintf("spec.template.spec.containers[%d].resources.limits.cpu", i),
+					Field:      k8s.ContainerPath(obj, i, "resources", "limits", "cpu"),
 					Suggestion: "Add: resources.limits.cpu: \"1000m\"",
 				})
 			}
@@ -114,9 +175,10 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				issues = append(issues, linter.Issue{
 					Severity:   linter.SeverityError,
 					Linter:     l.Name(),
+					Check:      CheckMemoryLimit,
 					Message:    fmt.Sprintf("Container %q missing memory limit", name),
 					Resource:   common.ResourceRef(obj),
-					Field:      fmt.Sprintf("spec.template.spec.containers[%d].resources.limits.memory", i),
+					Field:      k8s.ContainerPath(obj, i, "resources", "limits", "memory"),
 					Suggestion: "Add: resources.limits.memory: \"512Mi\"",
 				})
 			}
@@ -127,9 +189,10 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				issues = append(issues, linter.Issue{
 					Severity:   linter.SeverityError,
 					Linter:     l.Name(),
+					Check:      CheckCPURequest,
 					Message:    fmt.Sprintf("Container %q missing CPU request", name),
 					Resource:   common.ResourceRef(obj),
-					Field:      fmt.Sprintf("spec.template.spec.containers[%d].resources.requests.cpu", i),
+					Field:      k8s.ContainerPath(obj, i, "resources", "requests", "cpu"),
 					Suggestion: "Add: resources.requests.cpu: \"100m\"",
 				})
 			}
@@ -140,9 +203,10 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				issues = append(issues, linter.Issue{
 					Severity:   linter.SeverityError,
 					Linter:     l.Name(),
+					Check:      CheckMemoryRequest,
 					Message:    fmt.Sprintf("Container %q missing memory request", name),
 					Resource:   common.ResourceRef(obj),
-					Field:      fmt.Sprintf("spec.template.spec.containers[%d].resources.requests.memory", i),
+					Field:      k8s.ContainerPath(obj, i, "resources", "requests", "memory"),
 					Suggestion: "Add: resources.requests.memory: \"256Mi\"",
 				})
 			}
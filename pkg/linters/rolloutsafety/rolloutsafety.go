@@ -0,0 +1,251 @@
+package rolloutsafety
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "rollout-safety"
+	Description = "Ensures workloads using ConfigMaps or Secrets are rolled out when they change"
+	Since       = "v0.2.0"
+
+	// kubeRootCA is the ConfigMap Kubernetes publishes in every namespace,
+	// commonly referenced by projected service account volumes
+	kubeRootCA = "kube-root-ca.crt"
+)
+
+// hashSuffixRe matches the suffix kustomize generators append to ConfigMap
+// and Secret names, a content change renames the object and so triggers a
+// rollout by itself
+var hashSuffixRe = regexp.MustCompile(`-[a-z0-9]{10}$`)
+
+type Config struct {
+	// TemplateAnnotations are pod template annotations, glob patterns are
+	// supported, whose value changes with the configuration (e.g. checksums)
+	TemplateAnnotations []string `mapstructure:"template-annotations"`
+	// WorkloadAnnotations are workload annotations of controllers restarting
+	// pods on configuration changes (e.g. Reloader)
+	WorkloadAnnotations []string `mapstructure:"workload-annotations"`
+	// IgnoreHashedNames skips ConfigMaps and Secrets with a generated hash
+	// suffix
+	IgnoreHashedNames bool     `mapstructure:"ignore-hashed-names"`
+	Namespaces        []string `mapstructure:"namespaces"`
+	ExcludeNamespaces []string `mapstructure:"exclude-namespaces"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		TemplateAnnotations: []string{
+			"checksum/*",
+		},
+		WorkloadAnnotations: []string{
+			"reloader.stakater.com/auto",
+			"reloader.stakater.com/search",
+			"configmap.reloader.stakater.com/reload",
+			"secret.reloader.stakater.com/reload",
+			"wave.pusher.com/update-on-config-change",
+		},
+		IgnoreHashedNames: true,
+	}
+}
+
+func init() {
+	linter.Register(&Linter{
+		config: DefaultConfig(),
+	})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	// Jobs and bare Pods are not rolled out
+	if !gvk.IsAnyGVK(obj, gvk.Deployment, gvk.StatefulSet, gvk.DaemonSet) {
+		return nil, nil
+	}
+
+	if !l.inScope(obj.GetNamespace()) {
+		return nil, nil
+	}
+
+	if annotated(l.config.WorkloadAnnotations, obj.GetAnnotations()) {
+		return nil, nil
+	}
+
+	specPath, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil, nil
+	}
+
+	templatePath := fieldpath.Parent(specPath)
+
+	templateAnnotations, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "annotations")
+	if annotated(l.config.TemplateAnnotations, templateAnnotations) {
+		return nil, nil
+	}
+
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil, nil
+	}
+
+	refs := l.references(spec)
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	return []linter.Issue{{
+		Severity: linter.SeverityWarning,
+		Linter:   l.Name(),
+		Message: fmt.Sprintf("Changes to %s will not trigger a rollout of the %s",
+			strings.Join(refs, ", "), obj.GetKind()),
+		Resource:   common.ResourceRef(obj),
+		Field:      fieldpath.Join(templatePath, "metadata", "annotations"),
+		Suggestion: "Add a checksum/config pod template annotation computed from the configuration, or use a controller such as Reloader",
+	}}, nil
+}
+
+func (l *Linter) inScope(namespace string) bool {
+	for _, ns := range l.config.ExcludeNamespaces {
+		if ns == namespace {
+			return false
+		}
+	}
+
+	if len(l.config.Namespaces) == 0 {
+		return true
+	}
+
+	for _, ns := range l.config.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// references returns the sorted ConfigMaps and Secrets the pod spec mounts
+// or reads environment variables from
+func (l *Linter) references(spec *corev1.PodSpec) []string {
+	seen := make(map[string]bool)
+
+	add := func(kind string, name string) {
+		if name == "" || name == kubeRootCA && kind == "ConfigMap" {
+			return
+		}
+		if l.config.IgnoreHashedNames && hashSuffixRe.MatchString(name) {
+			return
+		}
+		seen[fmt.Sprintf("%s %q", kind, name)] = true
+	}
+
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			add("ConfigMap", v.ConfigMap.Name)
+		}
+		if v.Secret != nil {
+			add("Secret", v.Secret.SecretName)
+		}
+		if v.Projected != nil {
+			for _, s := range v.Projected.Sources {
+				if s.ConfigMap != nil {
+					add("ConfigMap", s.ConfigMap.Name)
+				}
+				if s.Secret != nil {
+					add("Secret", s.Secret.Name)
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, e := range c.EnvFrom {
+			if e.ConfigMapRef != nil {
+				add("ConfigMap", e.ConfigMapRef.Name)
+			}
+			if e.SecretRef != nil {
+				add("Secret", e.SecretRef.Name)
+			}
+		}
+
+		for _, e := range c.Env {
+			if e.ValueFrom == nil {
+				continue
+			}
+			if e.ValueFrom.ConfigMapKeyRef != nil {
+				add("ConfigMap", e.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if e.ValueFrom.SecretKeyRef != nil {
+				add("Secret", e.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+
+	refs := make([]string, 0, len(seen))
+	for r := range seen {
+		refs = append(refs, r)
+	}
+	sort.Strings(refs)
+
+	return refs
+}
+
+// annotated reports whether one of the annotation keys matches the patterns
+func annotated(patterns []string, annotations map[string]string) bool {
+	for key := range annotations {
+		if glob.MatchAny(patterns, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package secretleak
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Pattern is a named regular expression matching a kind of credential
+type Pattern struct {
+	Name  string `mapstructure:"name"`
+	Regex string `mapstructure:"regex"`
+}
+
+// highEntropy is the kind of the findings of the entropy check, less
+// certain than the ones of the patterns
+const highEntropy = "high entropy string"
+
+// builtinPatterns match credentials with a well known format
+var builtinPatterns = []Pattern{
+	{Name: "AWS access key", Regex: `\b(AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "private key", Regex: `-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`},
+	{Name: "bearer token", Regex: `(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{20,}=*`},
+	{Name: "GitHub token", Regex: `\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`},
+	{Name: "Slack token", Regex: `\bxox[abposr]-[A-Za-z0-9-]{10,}\b`},
+	{Name: "JSON Web Token", Regex: `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`},
+	{Name: "URL with credentials", Regex: `\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:[^/\s:@]+@`},
+}
+
+type compiledPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// finding is a value looking like a credential, match is the part of the
+// value which does
+type finding struct {
+	kind  string
+	match string
+}
+
+// detector looks for credentials in values, with the patterns first and the
+// entropy of their tokens then
+type detector struct {
+	patterns         []compiledPattern
+	allowed          []*regexp.Regexp
+	entropyThreshold float64
+	minLength        int
+}
+
+func (d *detector) detect(value string) []finding {
+	if d.allowedValue(value) {
+		return nil
+	}
+
+	var findings []finding
+
+	for _, p := range d.patterns {
+		for _, match := range p.re.FindAllString(value, -1) {
+			if !d.allowedValue(match) {
+				findings = append(findings, finding{kind: p.name, match: match})
+			}
+		}
+	}
+
+	if len(findings) > 0 || d.entropyThreshold <= 0 {
+		return findings
+	}
+
+	for _, token := range tokens(value) {
+		if len(token) < d.minLength || !mixed(token) || d.allowedValue(token) {
+			continue
+		}
+
+		if entropy(token) >= d.entropyThreshold {
+			findings = append(findings, finding{kind: highEntropy, match: token})
+		}
+	}
+
+	return findings
+}
+
+func (d *detector) allowedValue(value string) bool {
+	for _, re := range d.allowed {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tokens splits a value on the characters which do not appear in encoded
+// credentials, such as spaces, quotes and the separators of key=value pairs
+func tokens(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("+/=_-.", r))
+	})
+}
+
+// mixed reports whether the token mixes letters and digits, words and
+// numbers are not credentials however long they are
+func mixed(token string) bool {
+	return strings.ContainsFunc(token, unicode.IsLetter) && strings.ContainsFunc(token, unicode.IsDigit)
+}
+
+// entropy is the Shannon entropy of the token in bits per character
+func entropy(token string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range token {
+		counts[r]++
+	}
+
+	n := float64(len([]rune(token)))
+
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+
+	return h
+}
+
+// redact keeps the first characters of a match, so that the reported issue
+// identifies the value without leaking it
+func redact(match string) string {
+	const visible = 4
+
+	runes := []rune(match)
+	if len(runes) <= visible*2 {
+		return strings.Repeat("*", len(runes))
+	}
+
+	return string(runes[:visible]) + strings.Repeat("*", 8)
+}
@@ -0,0 +1,277 @@
+package secretleak
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "secret-leak"
+	Description = "Detects credentials written in plain text in environment variables, container arguments and ConfigMaps"
+	Since       = "v0.2.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckEnv       = "env"
+	CheckArgs      = "args"
+	CheckConfigMap = "config-map"
+)
+
+type Config struct {
+	// Patterns are matched along with the built-in ones
+	Patterns []Pattern `mapstructure:"patterns"`
+	// EntropyThreshold is the Shannon entropy, in bits per character, from
+	// which a token is reported as a likely credential, 0 disables the check
+	EntropyThreshold float64 `mapstructure:"entropy-threshold"`
+	// MinEntropyLength is the length from which the entropy of a token is
+	// checked
+	MinEntropyLength int `mapstructure:"min-entropy-length"`
+	// AllowedKeys are the names of environment variables and ConfigMap keys
+	// not scanned, glob patterns are supported
+	AllowedKeys []string `mapstructure:"allowed-keys"`
+	// AllowedValues are regular expressions of the values, or matches, not
+	// reported, such as placeholders
+	AllowedValues []string `mapstructure:"allowed-values"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		EntropyThreshold: 4.5,
+		MinEntropyLength: 20,
+		AllowedValues: []string{
+			`^\$\{[^}]+\}$`,
+			`^\$\([^)]+\)$`,
+			`^\{\{.*\}\}$`,
+		},
+	}
+}
+
+func init() {
+	l := &Linter{
+		config: DefaultConfig(),
+	}
+
+	if err := l.compile(); err != nil {
+		panic(err)
+	}
+
+	linter.Register(l)
+}
+
+type Linter struct {
+	config   Config
+	detector *detector
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckEnv, CheckArgs, CheckConfigMap}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	// configured values replace the defaults rather than being merged into
+	// them element by element
+	if _, ok := settings["allowed-values"]; ok {
+		l.config.AllowedValues = nil
+	}
+
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	return l.compile()
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+func (l *Linter) compile() error {
+	d := &detector{
+		entropyThreshold: l.config.EntropyThreshold,
+		minLength:        l.config.MinEntropyLength,
+	}
+
+	for i, p := range append(builtinPatterns, l.config.Patterns...) {
+		if p.Name == "" || p.Regex == "" {
+			return fmt.Errorf("patterns[%d]: name and regex are required", i-len(builtinPatterns))
+		}
+
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p.Name, err)
+		}
+
+		d.patterns = append(d.patterns, compiledPattern{name: p.Name, re: re})
+	}
+
+	for _, value := range l.config.AllowedValues {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return fmt.Errorf("invalid allowed value %q: %w", value, err)
+		}
+
+		d.allowed = append(d.allowed, re)
+	}
+
+	for _, pattern := range l.config.AllowedKeys {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid allowed key %q: %w", pattern, err)
+		}
+	}
+
+	l.detector = d
+
+	return nil
+}
+
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	switch {
+	case gvk.IsGVK(obj, gvk.ConfigMap):
+		return l.lintConfigMap(obj), nil
+	case gvk.IsWorkloadOrPod(obj):
+		return l.lintWorkload(ctx, obj), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (l *Linter) lintConfigMap(obj unstructured.Unstructured) []linter.Issue {
+	data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var issues []linter.Issue
+	for _, key := range keys {
+		if l.allowedKey(key) {
+			continue
+		}
+
+		where := fmt.Sprintf("ConfigMap key %q", key)
+		issues = append(issues, l.scan(obj, CheckConfigMap, where, fieldpath.Path("data", key), data[key])...)
+	}
+
+	return issues
+}
+
+func (l *Linter) lintWorkload(ctx context.Context, obj unstructured.Unstructured) []linter.Issue {
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil
+	}
+
+	specPath, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil
+	}
+
+	var issues []linter.Issue
+
+	for _, group := range []struct {
+		field      string
+		containers []corev1.Container
+	}{
+		{field: "initContainers", containers: spec.InitContainers},
+		{field: "containers", containers: spec.Containers},
+	} {
+		for i, c := range group.containers {
+			containerPath := fieldpath.Join(specPath, group.field, i)
+
+			for j, e := range c.Env {
+				if e.Value == "" || l.allowedKey(e.Name) {
+					continue
+				}
+
+				where := fmt.Sprintf("Environment variable %q of container %q", e.Name, c.Name)
+				issues = append(issues, l.scan(obj, CheckEnv, where, fieldpath.Join(containerPath, "env", j, "value"), e.Value)...)
+			}
+
+			for _, arguments := range []struct {
+				field  string
+				values []string
+			}{
+				{field: "command", values: c.Command},
+				{field: "args", values: c.Args},
+			} {
+				for j, value := range arguments.values {
+					where := fmt.Sprintf("Argument %d of the %s of container %q", j, arguments.field, c.Name)
+					issues = append(issues, l.scan(obj, CheckArgs, where, fieldpath.Join(containerPath, arguments.field, j), value)...)
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+// scan reports the credentials found in the value, the issues never carry
+// the value itself
+func (l *Linter) scan(obj unstructured.Unstructured, check string, where string, field string, value string) []linter.Issue {
+	var issues []linter.Issue
+
+	for _, f := range l.detector.detect(value) {
+		severity := linter.SeverityError
+		if f.kind == highEntropy {
+			severity = linter.SeverityWarning
+		}
+
+		issues = append(issues, linter.Issue{
+			Severity:   severity,
+			Linter:     l.Name(),
+			Check:      check,
+			Message:    fmt.Sprintf("%s looks like it holds a credential in plain text (%s %s)", where, f.kind, redact(f.match)),
+			Resource:   common.ResourceRef(obj),
+			Field:      field,
+			Suggestion: "Move the credential to a Secret and reference it with secretKeyRef or a volume, then rotate it as it is in the repository history",
+		})
+	}
+
+	return issues
+}
+
+func (l *Linter) allowedKey(key string) bool {
+	return glob.MatchAny(l.config.AllowedKeys, key)
+}
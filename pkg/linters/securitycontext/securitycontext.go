@@ -3,10 +3,10 @@ package securitycontext
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
-	"github.com/mitchellh/mapstructure"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
@@ -16,6 +16,17 @@ import (
 const (
 	Name        = "security-context"
 	Description = "Validates pod and container security contexts"
+	Since       = "v0.1.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckRunAsNonRoot           = "run-as-non-root"
+	CheckReadOnlyRootFilesystem = "read-only-root-filesystem"
+	CheckPrivilegeEscalation    = "privilege-escalation"
+	CheckDroppedCapabilities    = "dropped-capabilities"
+	CheckAddedCapabilities      = "added-capabilities"
 )
 
 type Config struct {
@@ -23,14 +34,23 @@ type Config struct {
 	RequireReadOnlyRootFilesystem bool     `mapstructure:"require-read-only-root-filesystem"`
 	DisallowPrivilegeEscalation   bool     `mapstructure:"disallow-privilege-escalation"`
 	RequiredDroppedCapabilities   []string `mapstructure:"required-dropped-capabilities"`
+	// DisallowedAddedCapabilities must not be listed in capabilities.add
+	DisallowedAddedCapabilities []string `mapstructure:"disallowed-added-capabilities"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		RequireRunAsNonRoot:         true,
+		DisallowPrivilegeEscalation: true,
+		DisallowedAddedCapabilities: []string{"ALL", "SYS_ADMIN", "NET_ADMIN", "NET_RAW", "SYS_PTRACE", "SYS_MODULE"},
+	}
 }
 
 func init() {
 	linter.Register(&Linter{
-		config: Config{
-			RequireRunAsNonRoot:         true,
-			DisallowPrivilegeEscalation: true,
-		},
+		config: DefaultConfig(),
 	})
 }
 
@@ -46,18 +66,60 @@ func (l *Linter) Description() string {
 	return Description
 }
 
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityStable
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckRunAsNonRoot, CheckReadOnlyRootFilesystem, CheckPrivilegeEscalation, CheckDroppedCapabilities, CheckAddedCapabilities}
+}
+
 func (l *Linter) Configure(settings map[string]interface{}) error {
-	return mapstructure.Decode(settings, &l.config)
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// SupportsOverrides tells that the settings can be overridden per object
+func (l *Linter) SupportsOverrides() bool {
+	return true
 }
 
 func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	// settings overridden by the object annotations apply to this object only
+	config, overridden, invalid := linter.ObjectConfig(obj, Name, l.config)
+	if overridden {
+		l = &Linter{config: config}
+	}
+
+	issues, err := l.lint(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(invalid, issues...), nil
+}
+
+func (l *Linter) lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
 	if !gvk.IsWorkloadOrPod(obj) {
 		return nil, nil
 	}
 
 	var issues []linter.Issue
 
-	containers, err := k8s.GetContainers(obj)
+	containers, err := k8s.GetContainers(ctx, obj)
 	if err != nil {
 		return nil, err
 	}
@@ -77,10 +139,13 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				issues = append(issues, linter.Issue{
 					Severity:   linter.SeverityError,
 					Linter:     l.Name(),
+					Check:      CheckRunAsNonRoot,
 					Message:    fmt.Sprintf("Container %q must set runAsNonRoot to true", name),
 					Resource:   common.ResourceRef(obj),
-					Field:      fmt.Sprintf("spec.template.spec.containers[%d].securityContext.runAsNonRoot", i),
+					Field:      k8s.ContainerPath(obj, i, "securityContext", "runAsNonRoot"),
+					Value:      securityContext["runAsNonRoot"],
 					Suggestion: "Add: securityContext.runAsNonRoot: true",
+					Fix:        linter.SetField(k8s.ContainerPath(obj, i, "securityContext", "runAsNonRoot"), true),
 				})
 			}
 		}
@@ -91,10 +156,13 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				issues = append(issues, linter.Issue{
 					Severity:   linter.SeverityWarning,
 					Linter:     l.Name(),
+					Check:      CheckReadOnlyRootFilesystem,
 					Message:    fmt.Sprintf("Container %q should set readOnlyRootFilesystem to true", name),
 					Resource:   common.ResourceRef(obj),
-					Field:      fmt.Sprintf("spec.template.spec.containers[%d].securityContext.readOnlyRootFilesystem", i),
+					Field:      k8s.ContainerPath(obj, i, "securityContext", "readOnlyRootFilesystem"),
+					Value:      securityContext["readOnlyRootFilesystem"],
 					Suggestion: "Add: securityContext.readOnlyRootFilesystem: true",
+					Fix:        linter.SetField(k8s.ContainerPath(obj, i, "securityContext", "readOnlyRootFilesystem"), true),
 				})
 			}
 		}
@@ -105,39 +173,119 @@ func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]lin
 				issues = append(issues, linter.Issue{
 					Severity:   linter.SeverityError,
 					Linter:     l.Name(),
+					Check:      CheckPrivilegeEscalation,
 					Message:    fmt.Sprintf("Container %q must set allowPrivilegeEscalation to false", name),
 					Resource:   common.ResourceRef(obj),
-					Field:      fmt.Sprintf("spec.template.spec.containers[%d].securityContext.allowPrivilegeEscalation", i),
+					Field:      k8s.ContainerPath(obj, i, "securityContext", "allowPrivilegeEscalation"),
+					Value:      securityContext["allowPrivilegeEscalation"],
 					Suggestion: "Add: securityContext.allowPrivilegeEscalation: false",
+					Fix:        linter.SetField(k8s.ContainerPath(obj, i, "securityContext", "allowPrivilegeEscalation"), false),
 				})
 			}
 		}
 
-		if len(l.config.RequiredDroppedCapabilities) > 0 {
-			capabilities, _ := securityContext["capabilities"].(map[string]interface{})
-			drop, _ := capabilities["drop"].([]interface{})
+		issues = append(issues, l.lintCapabilities(obj, "Container", name, securityContext, func(segments ...interface{}) string {
+			return k8s.ContainerPath(obj, i, segments...)
+		})...)
+	}
 
-			droppedCaps := make(map[string]bool)
-			for _, d := range drop {
-				if capStr, ok := d.(string); ok {
-					droppedCaps[capStr] = true
-				}
+	// init containers run with the capabilities they add too
+	initContainers, err := k8s.GetInitContainers(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, container := range initContainers {
+		containerMap, ok := container.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _ := containerMap["name"].(string)
+		securityContext, _ := containerMap["securityContext"].(map[string]interface{})
+
+		issues = append(issues, l.lintCapabilities(obj, "Init container", name, securityContext, func(segments ...interface{}) string {
+			return k8s.InitContainerPath(obj, i, segments...)
+		})...)
+	}
+
+	return issues, nil
+}
+
+// lintCapabilities checks the capabilities dropped and added by the security
+// context of a container, path returns the JSONPath of its fields
+func (l *Linter) lintCapabilities(obj unstructured.Unstructured, kind string, name string, securityContext map[string]interface{}, path func(segments ...interface{}) string) []linter.Issue {
+	var issues []linter.Issue
+
+	capabilities, _ := securityContext["capabilities"].(map[string]interface{})
+
+	if len(l.config.RequiredDroppedCapabilities) > 0 {
+		drop, _ := capabilities["drop"].([]interface{})
+
+		droppedCaps := make(map[string]bool)
+		for _, d := range drop {
+			if capStr, ok := d.(string); ok {
+				droppedCaps[capStr] = true
+			}
+		}
+
+		for _, requiredCap := range l.config.RequiredDroppedCapabilities {
+			if !droppedCaps[requiredCap] {
+				issues = append(issues, linter.Issue{
+					Severity:   linter.SeverityWarning,
+					Linter:     l.Name(),
+					Check:      CheckDroppedCapabilities,
+					Message:    fmt.Sprintf("%s %q should drop capability %q", kind, name, requiredCap),
+					Resource:   common.ResourceRef(obj),
+					Field:      path("securityContext", "capabilities", "drop"),
+					Value:      capabilities["drop"],
+					Suggestion: fmt.Sprintf("Add %q to capabilities.drop", requiredCap),
+					Fix:        linter.AppendField(path("securityContext", "capabilities", "drop"), requiredCap),
+				})
 			}
+		}
+	}
+
+	if len(l.config.DisallowedAddedCapabilities) > 0 {
+		add, _ := capabilities["add"].([]interface{})
 
-			for _, requiredCap := range l.config.RequiredDroppedCapabilities {
-				if !droppedCaps[requiredCap] {
-					issues = append(issues, linter.Issue{
-						Severity:   linter.SeverityWarning,
-						Linter:     l.Name(),
-						Message:    fmt.Sprintf("Container %q should drop capability %q", name, requiredCap),
-						Resource:   common.ResourceRef(obj),
-						Field:      fmt.Sprintf("spec.template.spec.containers[%d].securityContext.capabilities.drop", i),
-						Suggestion: fmt.Sprintf("Add %q to capabilities.drop", requiredCap),
-					})
-				}
+		for j, a := range add {
+			added, ok := a.(string)
+			if !ok || !l.disallowedCapability(added) {
+				continue
 			}
+
+			issues = append(issues, linter.Issue{
+				Severity:   linter.SeverityError,
+				Linter:     l.Name(),
+				Check:      CheckAddedCapabilities,
+				Message:    fmt.Sprintf("%s %q must not add capability %q", kind, name, added),
+				Resource:   common.ResourceRef(obj),
+				Field:      path("securityContext", "capabilities", "add", j),
+				Value:      added,
+				Suggestion: fmt.Sprintf("Remove %q from capabilities.add, or grant a narrower capability", added),
+			})
 		}
 	}
 
-	return issues, nil
+	return issues
+}
+
+// disallowedCapability reports whether the added capability is denied,
+// capabilities are compared ignoring case and the CAP_ prefix
+func (l *Linter) disallowedCapability(capability string) bool {
+	normalize := func(c string) string {
+		c = strings.ToUpper(c)
+		return strings.TrimPrefix(c, "CAP_")
+	}
+
+	capability = normalize(capability)
+
+	for _, disallowed := range l.config.DisallowedAddedCapabilities {
+		if normalize(disallowed) == capability {
+			return true
+		}
+	}
+
+	return false
 }
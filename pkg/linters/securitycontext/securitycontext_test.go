@@ -0,0 +1,117 @@
+package securitycontext_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/securitycontext"
+)
+
+const pod = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  initContainers:
+    - name: setup
+      image: busybox:1.36
+      securityContext:
+        runAsNonRoot: true
+        allowPrivilegeEscalation: false
+        capabilities:
+          add: [NET_ADMIN]
+  containers:
+    - name: app
+      image: app:1.0.0
+      securityContext:
+        runAsNonRoot: true
+        allowPrivilegeEscalation: false
+        capabilities:
+          add: [cap_sys_admin, CHOWN]
+`
+
+func TestSecurityContextAddedCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		expected []lintertest.Expectation
+	}{
+		{
+			name: "default",
+			expected: []lintertest.Expectation{
+				{
+					Severity: linter.SeverityError,
+					Linter:   securitycontext.Name,
+					Message:  `Container "app" must not add capability "cap_sys_admin"`,
+					Field:    "$.spec.containers[0].securityContext.capabilities.add[0]",
+				},
+				{
+					Severity: linter.SeverityError,
+					Linter:   securitycontext.Name,
+					Message:  `Init container "setup" must not add capability "NET_ADMIN"`,
+					Field:    "$.spec.initContainers[0].securityContext.capabilities.add[0]",
+				},
+			},
+		},
+		{
+			name: "configured list replaces the defaults",
+			settings: map[string]interface{}{
+				"disallowed-added-capabilities": []interface{}{"CHOWN"},
+			},
+			expected: []lintertest.Expectation{
+				{
+					Severity: linter.SeverityError,
+					Linter:   securitycontext.Name,
+					Message:  `Container "app" must not add capability "CHOWN"`,
+					Field:    "$.spec.containers[0].securityContext.capabilities.add[1]",
+				},
+			},
+		},
+		{
+			name: "empty list disables the check",
+			settings: map[string]interface{}{
+				"disallowed-added-capabilities": []interface{}{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &securitycontext.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			lintertest.Configure(t, l, tt.settings)
+
+			issues := lintertest.Run(t, l, lintertest.ParseObjects(t, pod)...)
+			lintertest.AssertIssues(t, issues, tt.expected...)
+		})
+	}
+}
+
+func TestSecurityContextDroppedCapabilities(t *testing.T) {
+	l := &securitycontext.Linter{}
+	if err := l.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	lintertest.Configure(t, l, map[string]interface{}{
+		"required-dropped-capabilities": []interface{}{"ALL"},
+		"disallowed-added-capabilities": []interface{}{},
+	})
+
+	issues := lintertest.Run(t, l, lintertest.ParseObjects(t, pod)...)
+	lintertest.AssertIssues(t, issues,
+		lintertest.Expectation{
+			Severity: linter.SeverityWarning,
+			Message:  `Container "app" should drop capability "ALL"`,
+			Field:    "$.spec.containers[0].securityContext.capabilities.drop",
+		},
+		lintertest.Expectation{
+			Severity: linter.SeverityWarning,
+			Message:  `Init container "setup" should drop capability "ALL"`,
+			Field:    "$.spec.initContainers[0].securityContext.capabilities.drop",
+		},
+	)
+}
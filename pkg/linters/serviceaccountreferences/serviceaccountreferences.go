@@ -0,0 +1,238 @@
+package serviceaccountreferences
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "service-account-references"
+	Description = "Ensures the ServiceAccounts of workloads and RBAC bindings are defined in the manifests"
+	Since       = "v0.2.0"
+
+	// defaultServiceAccount is created by Kubernetes in every namespace
+	defaultServiceAccount = "default"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckWorkload = "workload"
+	CheckBinding  = "binding"
+)
+
+type Config struct {
+	// ExternalServiceAccounts are the ServiceAccounts created outside the
+	// manifests, as namespace/name, glob patterns are supported
+	ExternalServiceAccounts []string `mapstructure:"external-service-accounts"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{
+		ExternalServiceAccounts: []string{"kube-system/*"},
+	}
+}
+
+func init() {
+	linter.Register(&Linter{
+		config: DefaultConfig(),
+	})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckWorkload, CheckBinding}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	// configured ServiceAccounts replace the defaults rather than being
+	// merged into them element by element
+	if _, ok := settings["external-service-accounts"]; ok {
+		l.config.ExternalServiceAccounts = nil
+	}
+
+	if err := linter.DecodeSettings(settings, &l.config); err != nil {
+		return err
+	}
+
+	for _, pattern := range l.config.ExternalServiceAccounts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid external-service-accounts pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// Lint checks a single object, the runner uses LintSet instead which indexes
+// the ServiceAccounts once for the whole run
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, _ := linter.AllObjectsFromContext(ctx)
+
+	return l.check(ctx, obj, serviceAccounts(allObjects)), nil
+}
+
+func (l *Linter) LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]linter.Issue, error) {
+	accounts := serviceAccounts(objects)
+
+	var issues []linter.Issue
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, l.check(ctx, obj, accounts)...)
+	}
+
+	return issues, nil
+}
+
+func (l *Linter) check(ctx context.Context, obj unstructured.Unstructured, accounts map[string]bool) []linter.Issue {
+	switch {
+	case gvk.IsWorkloadOrPod(obj):
+		return l.checkWorkload(ctx, obj, accounts)
+	case obj.GetAPIVersion() == rbacv1.SchemeGroupVersion.String() && (obj.GetKind() == "RoleBinding" || obj.GetKind() == "ClusterRoleBinding"):
+		return l.checkBinding(obj, accounts)
+	default:
+		return nil
+	}
+}
+
+func (l *Linter) checkWorkload(ctx context.Context, obj unstructured.Unstructured, accounts map[string]bool) []linter.Issue {
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil
+	}
+
+	specPath, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil
+	}
+
+	// serviceAccount is the deprecated alias of serviceAccountName
+	name, field := spec.ServiceAccountName, "serviceAccountName"
+	if name == "" {
+		name, field = spec.DeprecatedServiceAccount, "serviceAccount"
+	}
+
+	if l.resolved(obj.GetNamespace(), name, accounts) {
+		return nil
+	}
+
+	return []linter.Issue{{
+		Severity:   linter.SeverityError,
+		Linter:     l.Name(),
+		Check:      CheckWorkload,
+		Message:    fmt.Sprintf("%s %q runs as ServiceAccount %q, which is not defined in the manifests", obj.GetKind(), obj.GetName(), name),
+		Resource:   common.ResourceRef(obj),
+		Field:      fieldpath.Join(specPath, field),
+		Value:      name,
+		Suggestion: "Add the ServiceAccount to the manifests, fix the name, or list it in external-service-accounts when it is created elsewhere",
+	}}
+}
+
+func (l *Linter) checkBinding(obj unstructured.Unstructured, accounts map[string]bool) []linter.Issue {
+	subjects, _, _ := unstructured.NestedSlice(obj.Object, "subjects")
+
+	var issues []linter.Issue
+	for i, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok || subject["kind"] != rbacv1.ServiceAccountKind {
+			continue
+		}
+
+		name, _ := subject["name"].(string)
+		namespace, _ := subject["namespace"].(string)
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+
+		if l.resolved(namespace, name, accounts) {
+			continue
+		}
+
+		issues = append(issues, linter.Issue{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Check:      CheckBinding,
+			Message:    fmt.Sprintf("%s %q binds ServiceAccount %s/%s, which is not defined in the manifests", obj.GetKind(), obj.GetName(), namespace, name),
+			Resource:   common.ResourceRef(obj),
+			Field:      fieldpath.Path("subjects", i),
+			Suggestion: "Add the ServiceAccount to the manifests, fix the subject, or list it in external-service-accounts when it is created elsewhere",
+		})
+	}
+
+	return issues
+}
+
+// resolved reports whether the ServiceAccount is defined in the manifests,
+// is the default one of its namespace or is external
+func (l *Linter) resolved(namespace string, name string, accounts map[string]bool) bool {
+	if name == "" || name == defaultServiceAccount || accounts[key(namespace, name)] {
+		return true
+	}
+
+	for _, pattern := range l.config.ExternalServiceAccounts {
+		if matched, _ := path.Match(pattern, key(namespace, name)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func serviceAccounts(objects []unstructured.Unstructured) map[string]bool {
+	accounts := make(map[string]bool)
+	for _, obj := range objects {
+		if gvk.IsGVK(obj, gvk.ServiceAccount) {
+			accounts[key(obj.GetNamespace(), obj.GetName())] = true
+		}
+	}
+	return accounts
+}
+
+func key(namespace string, name string) string {
+	return namespace + "/" + name
+}
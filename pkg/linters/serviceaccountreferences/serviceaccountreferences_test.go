@@ -0,0 +1,145 @@
+package serviceaccountreferences_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceaccountreferences"
+)
+
+const manifests = `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+  namespace: apps
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: apps
+spec:
+  template:
+    spec:
+      serviceAccountName: web
+      containers:
+        - name: web
+          image: web:1.0.0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: report
+  namespace: apps
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          serviceAccount: reporter
+          containers:
+            - name: report
+              image: report:1.0.0
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+  namespace: apps
+spec:
+  containers:
+    - name: debug
+      image: busybox:1.36
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: readers
+  namespace: apps
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: reader
+subjects:
+  - kind: ServiceAccount
+    name: web
+  - kind: Group
+    name: readers
+  - kind: ServiceAccount
+    name: metrics
+    namespace: monitoring
+  - kind: ServiceAccount
+    name: coredns
+    namespace: kube-system
+`
+
+func TestServiceAccountReferences(t *testing.T) {
+	cronJobIssue := lintertest.Expectation{
+		Severity: linter.SeverityError,
+		Linter:   serviceaccountreferences.Name,
+		Message:  `CronJob "report" runs as ServiceAccount "reporter", which is not defined in the manifests`,
+		Field:    "$.spec.jobTemplate.spec.template.spec.serviceAccount",
+		Kind:     "CronJob",
+	}
+	metricsIssue := lintertest.Expectation{
+		Severity: linter.SeverityWarning,
+		Linter:   serviceaccountreferences.Name,
+		Message:  `RoleBinding "readers" binds ServiceAccount monitoring/metrics, which is not defined in the manifests`,
+		Field:    "$.subjects[2]",
+		Kind:     "RoleBinding",
+	}
+
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		expected []lintertest.Expectation
+	}{
+		{
+			name:     "default",
+			expected: []lintertest.Expectation{cronJobIssue, metricsIssue},
+		},
+		{
+			name: "external service accounts",
+			settings: map[string]interface{}{
+				"external-service-accounts": []interface{}{"kube-system/*", "monitoring/*"},
+			},
+			expected: []lintertest.Expectation{cronJobIssue},
+		},
+		{
+			name: "external service accounts replace the defaults",
+			settings: map[string]interface{}{
+				"external-service-accounts": []interface{}{"apps/report*"},
+			},
+			expected: []lintertest.Expectation{
+				metricsIssue,
+				{
+					Severity: linter.SeverityWarning,
+					Message:  `binds ServiceAccount kube-system/coredns`,
+					Field:    "$.subjects[3]",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &serviceaccountreferences.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+			lintertest.Configure(t, l, tt.settings)
+
+			issues := lintertest.Run(t, l, lintertest.ParseObjects(t, manifests)...)
+			lintertest.AssertIssues(t, issues, tt.expected...)
+		})
+	}
+}
+
+func TestServiceAccountReferencesInvalidSettings(t *testing.T) {
+	l := &serviceaccountreferences.Linter{}
+	if err := l.Configure(map[string]interface{}{"external-service-accounts": []interface{}{"["}}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
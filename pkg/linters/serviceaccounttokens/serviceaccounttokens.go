@@ -0,0 +1,317 @@
+package serviceaccounttokens
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "service-account-tokens"
+	Description = "Flags long-lived ServiceAccount token Secrets and their use"
+	Since       = "v0.2.0"
+
+	// enforceMountableSecrets restricts the Secrets pods of the
+	// ServiceAccount can mount to the ones listed in its secrets
+	enforceMountableSecrets = "kubernetes.io/enforce-mountable-secrets"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckTokenSecret          = "token-secret"
+	CheckDuplicateTokenSecret = "duplicate-token-secret"
+	CheckServiceAccountSecret = "service-account-secrets"
+	CheckMountedTokenSecret   = "mounted-token-secret"
+)
+
+type Config struct {
+	// AllowedSecrets are the names, glob patterns are supported, of token
+	// Secrets created on purpose, e.g. for systems outside the cluster which
+	// cannot request tokens
+	AllowedSecrets []string `mapstructure:"allowed-secrets"`
+}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{}
+}
+
+func init() {
+	linter.Register(&Linter{config: DefaultConfig()})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckTokenSecret, CheckDuplicateTokenSecret, CheckServiceAccountSecret, CheckMountedTokenSecret}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// Lint checks a single object, the runner uses LintSet instead which indexes
+// the token Secrets once for the whole run
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, _ := linter.AllObjectsFromContext(ctx)
+
+	return l.check(ctx, obj, newIndex(allObjects)), nil
+}
+
+func (l *Linter) LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]linter.Issue, error) {
+	idx := newIndex(objects)
+
+	var issues []linter.Issue
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, l.check(ctx, obj, idx)...)
+	}
+
+	return issues, nil
+}
+
+// account identifies a ServiceAccount
+type account struct {
+	namespace string
+	name      string
+}
+
+// index holds the token Secrets of the run
+type index struct {
+	// accounts holds the sorted names of the token Secrets of every
+	// ServiceAccount
+	accounts map[account][]string
+	// secrets holds the names of the token Secrets of every namespace
+	secrets map[string]map[string]bool
+}
+
+func newIndex(objects []unstructured.Unstructured) index {
+	idx := index{
+		accounts: make(map[account][]string),
+		secrets:  make(map[string]map[string]bool),
+	}
+
+	for _, obj := range objects {
+		if !isTokenSecret(obj) {
+			continue
+		}
+
+		a := account{namespace: obj.GetNamespace(), name: obj.GetAnnotations()[corev1.ServiceAccountNameKey]}
+		idx.accounts[a] = append(idx.accounts[a], obj.GetName())
+
+		if idx.secrets[obj.GetNamespace()] == nil {
+			idx.secrets[obj.GetNamespace()] = make(map[string]bool)
+		}
+		idx.secrets[obj.GetNamespace()][obj.GetName()] = true
+	}
+
+	for _, names := range idx.accounts {
+		sort.Strings(names)
+	}
+
+	return idx
+}
+
+func (l *Linter) check(ctx context.Context, obj unstructured.Unstructured, idx index) []linter.Issue {
+	switch {
+	case gvk.IsGVK(obj, gvk.Secret):
+		return l.lintSecret(obj, idx)
+	case gvk.IsGVK(obj, gvk.ServiceAccount):
+		return l.lintServiceAccount(obj)
+	case gvk.IsWorkloadOrPod(obj):
+		return l.lintWorkload(ctx, obj, idx)
+	default:
+		return nil
+	}
+}
+
+func (l *Linter) lintSecret(obj unstructured.Unstructured, idx index) []linter.Issue {
+	if !isTokenSecret(obj) || l.allowed(obj.GetName()) {
+		return nil
+	}
+
+	serviceAccount := obj.GetAnnotations()[corev1.ServiceAccountNameKey]
+
+	issues := []linter.Issue{{
+		Severity:   linter.SeverityWarning,
+		Linter:     l.Name(),
+		Check:      CheckTokenSecret,
+		Message:    fmt.Sprintf("Secret %q holds a long-lived token of ServiceAccount %q, which never expires", obj.GetName(), serviceAccount),
+		Resource:   common.ResourceRef(obj),
+		Field:      fieldpath.Path("type"),
+		Suggestion: "Use short-lived tokens from the TokenRequest API, or projected serviceAccountToken volumes in pods",
+	}}
+
+	// the first token Secret of a ServiceAccount, by name, is reported by
+	// token-secret only, the others are duplicates
+	names := idx.accounts[account{namespace: obj.GetNamespace(), name: serviceAccount}]
+
+	if len(names) > 1 && names[0] != obj.GetName() {
+		issues = append(issues, linter.Issue{
+			Severity: linter.SeverityWarning,
+			Linter:   l.Name(),
+			Check:    CheckDuplicateTokenSecret,
+			Message: fmt.Sprintf("ServiceAccount %q has %d token Secrets, %q duplicates %q",
+				serviceAccount, len(names), obj.GetName(), names[0]),
+			Resource:   common.ResourceRef(obj),
+			Field:      fieldpath.Path("metadata", "annotations", corev1.ServiceAccountNameKey),
+			Suggestion: "Remove the duplicate token Secrets, every one of them is a credential to rotate and revoke",
+		})
+	}
+
+	return issues
+}
+
+func (l *Linter) lintServiceAccount(obj unstructured.Unstructured) []linter.Issue {
+	if _, ok := obj.GetAnnotations()[enforceMountableSecrets]; ok {
+		return nil
+	}
+
+	secrets, _, _ := unstructured.NestedSlice(obj.Object, "secrets")
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	return []linter.Issue{{
+		Severity:   linter.SeverityInfo,
+		Linter:     l.Name(),
+		Check:      CheckServiceAccountSecret,
+		Message:    fmt.Sprintf("ServiceAccount %q lists secrets, a legacy pattern of long-lived tokens", obj.GetName()),
+		Resource:   common.ResourceRef(obj),
+		Field:      fieldpath.Path("secrets"),
+		Suggestion: "Remove secrets, pods get short-lived tokens through projected volumes since Kubernetes 1.22",
+	}}
+}
+
+// lintWorkload reports the pods mounting, or reading environment variables
+// from, token Secrets of the same namespace
+func (l *Linter) lintWorkload(ctx context.Context, obj unstructured.Unstructured, idx index) []linter.Issue {
+	secrets := idx.secrets[obj.GetNamespace()]
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	token := func(name string) bool {
+		return secrets[name] && !l.allowed(name)
+	}
+
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil
+	}
+
+	specPath, err := k8s.PodSpecPath(obj)
+	if err != nil {
+		return nil
+	}
+
+	var issues []linter.Issue
+
+	report := func(name string, field string) {
+		issues = append(issues, linter.Issue{
+			Severity:   linter.SeverityWarning,
+			Linter:     l.Name(),
+			Check:      CheckMountedTokenSecret,
+			Message:    fmt.Sprintf("%s %q uses the long-lived token of Secret %q", obj.GetKind(), obj.GetName(), name),
+			Resource:   common.ResourceRef(obj),
+			Field:      field,
+			Suggestion: "Use a projected serviceAccountToken volume, its tokens are bound to the pod and rotated",
+		})
+	}
+
+	for i, v := range spec.Volumes {
+		if v.Secret != nil && token(v.Secret.SecretName) {
+			report(v.Secret.SecretName, fieldpath.Join(specPath, "volumes", i, "secret", "secretName"))
+		}
+
+		if v.Projected != nil {
+			for j, s := range v.Projected.Sources {
+				if s.Secret != nil && token(s.Secret.Name) {
+					report(s.Secret.Name, fieldpath.Join(specPath, "volumes", i, "projected", "sources", j, "secret", "name"))
+				}
+			}
+		}
+	}
+
+	for _, group := range []struct {
+		field      string
+		containers []corev1.Container
+	}{
+		{field: "initContainers", containers: spec.InitContainers},
+		{field: "containers", containers: spec.Containers},
+	} {
+		for i, c := range group.containers {
+			for j, e := range c.Env {
+				if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil && token(e.ValueFrom.SecretKeyRef.Name) {
+					report(e.ValueFrom.SecretKeyRef.Name, fieldpath.Join(specPath, group.field, i, "env", j, "valueFrom", "secretKeyRef", "name"))
+				}
+			}
+
+			for j, e := range c.EnvFrom {
+				if e.SecretRef != nil && token(e.SecretRef.Name) {
+					report(e.SecretRef.Name, fieldpath.Join(specPath, group.field, i, "envFrom", j, "secretRef", "name"))
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+func (l *Linter) allowed(name string) bool {
+	return glob.MatchAny(l.config.AllowedSecrets, name)
+}
+
+func isTokenSecret(obj unstructured.Unstructured) bool {
+	if !gvk.IsGVK(obj, gvk.Secret) {
+		return false
+	}
+
+	secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+
+	return corev1.SecretType(secretType) == corev1.SecretTypeServiceAccountToken
+}
@@ -0,0 +1,236 @@
+package serviceselectors
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/common"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+const (
+	Name        = "service-selectors"
+	Description = "Ensures Services select workloads and workloads declaring container ports are exposed by a Service"
+	Since       = "v0.2.0"
+)
+
+// Checks of the linter, they can be turned on and off individually with
+// enable-checks and disable-checks
+const (
+	CheckOrphanService     = "orphan-service"
+	CheckUnexposedWorkload = "unexposed-workload"
+)
+
+type Config struct{}
+
+// DefaultConfig returns the configuration the linter starts from, settings
+// are decoded on top of it
+func DefaultConfig() Config {
+	return Config{}
+}
+
+func init() {
+	linter.Register(&Linter{
+		config: DefaultConfig(),
+	})
+}
+
+type Linter struct {
+	config Config
+}
+
+func (l *Linter) Name() string {
+	return Name
+}
+
+func (l *Linter) Description() string {
+	return Description
+}
+
+func (l *Linter) DocURL() string {
+	return linter.DocURL(Name)
+}
+
+func (l *Linter) Since() string {
+	return Since
+}
+
+func (l *Linter) Stability() linter.Stability {
+	return linter.StabilityExperimental
+}
+
+func (l *Linter) Checks() []string {
+	return []string{CheckOrphanService, CheckUnexposedWorkload}
+}
+
+func (l *Linter) Configure(settings map[string]interface{}) error {
+	return linter.DecodeSettings(settings, &l.config)
+}
+
+// Reset restores DefaultConfig
+func (l *Linter) Reset() error {
+	l.config = DefaultConfig()
+	return l.Configure(map[string]interface{}{})
+}
+
+// Lint checks a single object, the runner uses LintSet instead which indexes
+// the Services and the pod labels once for the whole run
+func (l *Linter) Lint(ctx context.Context, obj unstructured.Unstructured) ([]linter.Issue, error) {
+	allObjects, _ := linter.AllObjectsFromContext(ctx)
+
+	return l.check(ctx, obj, newIndex(allObjects)), nil
+}
+
+func (l *Linter) LintSet(ctx context.Context, objects []unstructured.Unstructured) ([]linter.Issue, error) {
+	idx := newIndex(objects)
+
+	var issues []linter.Issue
+	for _, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, l.check(ctx, obj, idx)...)
+	}
+
+	return issues, nil
+}
+
+// index holds, per namespace, the selectors of the Services routing through
+// them and the labels of the pods of the workloads
+type index struct {
+	selectors map[string][]labels.Selector
+	pods      map[string][]labels.Set
+}
+
+func newIndex(objects []unstructured.Unstructured) index {
+	idx := index{
+		selectors: make(map[string][]labels.Selector),
+		pods:      make(map[string][]labels.Set),
+	}
+
+	for _, obj := range objects {
+		switch {
+		case gvk.IsGVK(obj, gvk.Service):
+			service, err := toService(obj)
+			if err != nil || !selecting(service) {
+				continue
+			}
+
+			idx.selectors[obj.GetNamespace()] = append(idx.selectors[obj.GetNamespace()], labels.SelectorFromSet(service.Spec.Selector))
+		case gvk.IsWorkloadOrPod(obj):
+			if podLabels, ok := k8s.PodLabels(obj); ok {
+				idx.pods[obj.GetNamespace()] = append(idx.pods[obj.GetNamespace()], labels.Set(podLabels))
+			}
+		}
+	}
+
+	return idx
+}
+
+func (l *Linter) check(ctx context.Context, obj unstructured.Unstructured, idx index) []linter.Issue {
+	switch {
+	case gvk.IsGVK(obj, gvk.Service):
+		return l.lintService(obj, idx)
+	case gvk.IsAnyGVK(obj, gvk.Deployment, gvk.StatefulSet, gvk.DaemonSet, gvk.Pod):
+		// Jobs and CronJobs run to completion and are not meant to be
+		// reached through a Service
+		return l.lintWorkload(ctx, obj, idx)
+	default:
+		return nil
+	}
+}
+
+// lintService reports the Services whose selector matches the pods of no
+// workload of their namespace
+func (l *Linter) lintService(obj unstructured.Unstructured, idx index) []linter.Issue {
+	service, err := toService(obj)
+	if err != nil || !selecting(service) {
+		return nil
+	}
+
+	selector := labels.SelectorFromSet(service.Spec.Selector)
+
+	for _, podLabels := range idx.pods[obj.GetNamespace()] {
+		if selector.Matches(podLabels) {
+			return nil
+		}
+	}
+
+	return []linter.Issue{{
+		Severity:   linter.SeverityWarning,
+		Linter:     l.Name(),
+		Check:      CheckOrphanService,
+		Message:    fmt.Sprintf("Service %q selects no workload in the manifests, it has no endpoints", service.Name),
+		Resource:   common.ResourceRef(obj),
+		Field:      fieldpath.Path("spec", "selector"),
+		Value:      labels.Set(service.Spec.Selector).String(),
+		Suggestion: "Fix the selector to match the labels of the pod templates, or remove the stale Service",
+	}}
+}
+
+// lintWorkload reports the workloads declaring container ports whose pods no
+// Service of their namespace selects
+func (l *Linter) lintWorkload(ctx context.Context, obj unstructured.Unstructured, idx index) []linter.Issue {
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		return nil
+	}
+
+	field := ""
+	for i, c := range spec.Containers {
+		if len(c.Ports) > 0 {
+			field = k8s.ContainerPath(obj, i, "ports")
+			break
+		}
+	}
+
+	if field == "" {
+		return nil
+	}
+
+	podLabels, ok := k8s.PodLabels(obj)
+	if !ok {
+		return nil
+	}
+
+	for _, selector := range idx.selectors[obj.GetNamespace()] {
+		if selector.Matches(labels.Set(podLabels)) {
+			return nil
+		}
+	}
+
+	return []linter.Issue{{
+		Severity:   linter.SeverityWarning,
+		Linter:     l.Name(),
+		Check:      CheckUnexposedWorkload,
+		Message:    fmt.Sprintf("%s %q declares container ports but no Service in the manifests selects its pods", obj.GetKind(), obj.GetName()),
+		Resource:   common.ResourceRef(obj),
+		Field:      field,
+		Suggestion: "Add a Service selecting the labels of the pod template, fix the selector of the existing one, or disable the check for workloads not meant to be reached",
+	}}
+}
+
+func toService(obj unstructured.Unstructured) (*corev1.Service, error) {
+	var service corev1.Service
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// selecting reports whether the Service routes to pods through its selector:
+// Services without selector have their endpoints managed by hand and
+// ExternalName Services have none
+func selecting(service *corev1.Service) bool {
+	return len(service.Spec.Selector) > 0 && service.Spec.Type != corev1.ServiceTypeExternalName
+}
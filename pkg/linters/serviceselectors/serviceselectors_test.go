@@ -0,0 +1,232 @@
+package serviceselectors_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter/lintertest"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linters/serviceselectors"
+)
+
+func TestServiceSelectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifests string
+		expected  []lintertest.Expectation
+	}{
+		{
+			name: "matching Service and workload",
+			manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: apps
+spec:
+  selector:
+    app: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: apps
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+        tier: frontend
+    spec:
+      containers:
+        - name: web
+          image: web:1.0.0
+          ports:
+            - containerPort: 8080
+`,
+		},
+		{
+			name: "orphan Service and unexposed workload",
+			manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: apps
+spec:
+  selector:
+    app: frontend
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: web
+  namespace: apps
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: sidecar
+          image: sidecar:1.0.0
+        - name: web
+          image: web:1.0.0
+          ports:
+            - containerPort: 8080
+`,
+			expected: []lintertest.Expectation{
+				{
+					Severity: linter.SeverityWarning,
+					Linter:   serviceselectors.Name,
+					Message:  `Service "web" selects no workload in the manifests`,
+					Field:    "$.spec.selector",
+					Kind:     "Service",
+				},
+				{
+					Severity: linter.SeverityWarning,
+					Linter:   serviceselectors.Name,
+					Message:  `StatefulSet "web" declares container ports but no Service in the manifests selects its pods`,
+					Field:    "$.spec.template.spec.containers[1].ports",
+					Kind:     "StatefulSet",
+				},
+			},
+		},
+		{
+			name: "namespaces are not crossed",
+			manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: other
+spec:
+  selector:
+    app: web
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: apps
+  labels:
+    app: web
+spec:
+  containers:
+    - name: web
+      image: web:1.0.0
+      ports:
+        - containerPort: 8080
+`,
+			expected: []lintertest.Expectation{
+				{Message: `Service "web" selects no workload`, Kind: "Service"},
+				{Message: `Pod "web" declares container ports`, Field: "$.spec.containers[0].ports", Kind: "Pod"},
+			},
+		},
+		{
+			name: "Services without selector, ExternalName Services and Jobs are skipped",
+			manifests: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: external
+spec:
+  ports:
+    - port: 443
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: alias
+spec:
+  type: ExternalName
+  externalName: example.com
+  selector:
+    app: other
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    metadata:
+      labels:
+        app: migrate
+    spec:
+      containers:
+        - name: migrate
+          image: migrate:1.0.0
+          ports:
+            - containerPort: 9090
+`,
+		},
+		{
+			name: "workloads without ports are not expected to be exposed",
+			manifests: `
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    metadata:
+      labels:
+        app: agent
+    spec:
+      containers:
+        - name: agent
+          image: agent:1.0.0
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &serviceselectors.Linter{}
+			if err := l.Reset(); err != nil {
+				t.Fatal(err)
+			}
+
+			issues := lintertest.Run(t, l, lintertest.ParseObjects(t, tt.manifests)...)
+			lintertest.AssertIssues(t, issues, tt.expected...)
+		})
+	}
+}
+
+// TestServiceSelectorsLint checks a single object through Lint, as done
+// outside of the runner, with the objects of the run in the context
+func TestServiceSelectorsLint(t *testing.T) {
+	l := &serviceselectors.Linter{}
+
+	objects := lintertest.ParseObjects(t, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  containers:
+    - name: web
+      image: web:1.0.0
+`)
+
+	ctx := linter.WithAllObjects(t.Context(), objects)
+
+	issues, err := l.Lint(ctx, objects[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lintertest.AssertNoIssues(t, issues)
+}
@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Prefix is the prefix of every metric name
+const Prefix = "k8s_manifests_lint_"
+
+// Run holds the metrics of a lint run
+type Run struct {
+	Objects  int
+	Issues   []linter.Issue
+	Duration time.Duration
+	Time     time.Time
+}
+
+type sample struct {
+	labels string
+	value  float64
+}
+
+type family struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+// Write renders the metrics in the Prometheus text exposition format
+func (r *Run) Write(w io.Writer) error {
+	bySeverity := make(map[string]int)
+	byLinter := make(map[[2]string]int)
+	byNamespace := make(map[string]int)
+
+	// every severity is always reported, so that alerts on a severity going
+	// back to zero keep working
+	for _, s := range linter.Severities() {
+		bySeverity[string(s)] = 0
+	}
+
+	for _, issue := range r.Issues {
+		bySeverity[string(issue.Severity)]++
+		byLinter[[2]string{issue.Linter, string(issue.Severity)}]++
+		byNamespace[issue.Resource.Namespace]++
+	}
+
+	families := []family{
+		{
+			name: "issues",
+			help: "Number of issues reported by the last run, by severity.",
+		},
+		{
+			name: "linter_issues",
+			help: "Number of issues reported by the last run, by linter and severity.",
+		},
+		{
+			name: "namespace_issues",
+			help: "Number of issues reported by the last run, by namespace.",
+		},
+		{
+			name:    "objects",
+			help:    "Number of objects linted by the last run.",
+			samples: []sample{{value: float64(r.Objects)}},
+		},
+		{
+			name:    "run_duration_seconds",
+			help:    "Duration of the last run.",
+			samples: []sample{{value: r.Duration.Seconds()}},
+		},
+		{
+			name:    "last_run_timestamp_seconds",
+			help:    "Unix time the last run completed at.",
+			samples: []sample{{value: float64(r.Time.Unix())}},
+		},
+	}
+
+	for s, n := range bySeverity {
+		families[0].samples = append(families[0].samples, sample{labels: labels("severity", s), value: float64(n)})
+	}
+	for k, n := range byLinter {
+		families[1].samples = append(families[1].samples, sample{labels: labels("linter", k[0], "severity", k[1]), value: float64(n)})
+	}
+	for ns, n := range byNamespace {
+		families[2].samples = append(families[2].samples, sample{labels: labels("namespace", ns), value: float64(n)})
+	}
+
+	var buf bytes.Buffer
+	for _, f := range families {
+		sort.Slice(f.samples, func(i, j int) bool { return f.samples[i].labels < f.samples[j].labels })
+
+		fmt.Fprintf(&buf, "# HELP %s%s %s\n", Prefix, f.name, f.help)
+		fmt.Fprintf(&buf, "# TYPE %s%s gauge\n", Prefix, f.name)
+
+		for _, s := range f.samples {
+			fmt.Fprintf(&buf, "%s%s%s %s\n", Prefix, f.name, s.labels, strconv.FormatFloat(s.value, 'f', -1, 64))
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteTextfile writes the metrics to a file for the node exporter textfile
+// collector, the file is replaced atomically so that a partial file is never
+// scraped
+func (r *Run) WriteTextfile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := r.Write(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+
+	return nil
+}
+
+// Push replaces the metrics of the job on a Prometheus Pushgateway
+func (r *Run) Push(ctx context.Context, gateway string, job string) error {
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(gateway, "/") + "/metrics/job/" + url.PathEscape(job)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to push metrics: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// labels renders label name/value pairs
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(pairs[i+1])
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,69 @@
+package azuredevops
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Formatter emits Azure Pipelines logging commands, every issue is reported
+// with ##vso[task.logissue] so that it shows up on the pipeline run
+type Formatter struct{}
+
+func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
+	for _, issue := range issues {
+		resource := fmt.Sprintf("%s/%s", issue.Resource.Kind, issue.Resource.Name)
+		if issue.Resource.Namespace != "" {
+			resource = fmt.Sprintf("%s/%s", issue.Resource.Namespace, resource)
+		}
+
+		// logissue only supports errors and warnings
+		level := "error"
+		if s := issue.Severity.Builtin(); s == linter.SeverityWarning || s == linter.SeverityInfo {
+			level = "warning"
+		}
+
+		message := fmt.Sprintf("%s: %s", resource, issue.Message)
+		if issue.Suggestion != "" {
+			message = fmt.Sprintf("%s (Suggestion: %s)", message, issue.Suggestion)
+		}
+		if issue.DocURL != "" {
+			message = fmt.Sprintf("%s See: %s", message, issue.DocURL)
+		}
+
+		if issue.Source != nil {
+			message = fmt.Sprintf("%s (Source: %s)", message, issue.Source)
+		}
+
+		params := "type=" + level
+		if p := issue.Position; p != nil {
+			params += ";sourcepath=" + escapeProperty(p.File)
+			if p.Line > 0 {
+				params += fmt.Sprintf(";linenumber=%d", p.Line)
+			}
+			if p.Column > 0 {
+				params += fmt.Sprintf(";columnnumber=%d", p.Column)
+			}
+		} else if issue.File != "" {
+			params += ";sourcepath=" + escapeProperty(issue.File)
+		}
+		params += ";code=" + escapeProperty(issue.Linter)
+
+		fmt.Fprintf(w, "##vso[task.logissue %s]%s\n", params, escapeMessage(message))
+	}
+
+	return nil
+}
+
+// escapeMessage escapes the characters ending a logging command
+func escapeMessage(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes the characters separating logging command
+// properties
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A", "]", "%5D", ";", "%3B").Replace(s)
+}
@@ -0,0 +1,73 @@
+package azuredevops_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/azuredevops"
+)
+
+func TestFormat(t *testing.T) {
+	resource := linter.ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns", Name: "web"}
+
+	tests := []struct {
+		name     string
+		issue    linter.Issue
+		expected string
+	}{
+		{
+			name: "position",
+			issue: linter.Issue{
+				Severity: linter.SeverityError,
+				Message:  "uses 'latest' tag",
+				Position: &linter.Position{File: "deploy/web.yaml", Line: 12, Column: 3},
+			},
+			expected: "##vso[task.logissue type=error;sourcepath=deploy/web.yaml;linenumber=12;columnnumber=3;code=image-tags]ns/Deployment/web: uses 'latest' tag\n",
+		},
+		{
+			name: "file",
+			issue: linter.Issue{
+				Severity:   linter.SeverityWarning,
+				Message:    "uses 'latest' tag",
+				Suggestion: "Pin a version",
+				File:       "charts/web/templates/deployment.yaml",
+			},
+			expected: "##vso[task.logissue type=warning;sourcepath=charts/web/templates/deployment.yaml;code=image-tags]ns/Deployment/web: uses 'latest' tag (Suggestion: Pin a version)\n",
+		},
+		{
+			name: "info is a warning",
+			issue: linter.Issue{
+				Severity: linter.SeverityInfo,
+				Message:  "uses 'latest' tag",
+			},
+			expected: "##vso[task.logissue type=warning;code=image-tags]ns/Deployment/web: uses 'latest' tag\n",
+		},
+		{
+			name: "escaped",
+			issue: linter.Issue{
+				Severity: linter.SeverityError,
+				Message:  "100% of\nthe replicas",
+				File:     "a;b]c.yaml",
+			},
+			expected: "##vso[task.logissue type=error;sourcepath=a%3Bb%5Dc.yaml;code=image-tags]ns/Deployment/web: 100%AZP25 of%0Athe replicas\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue := tt.issue
+			issue.Linter = "image-tags"
+			issue.Resource = resource
+
+			var buf bytes.Buffer
+			if err := (&azuredevops.Formatter{}).Format(&buf, []linter.Issue{issue}); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
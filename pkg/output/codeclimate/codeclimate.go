@@ -0,0 +1,109 @@
+package codeclimate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Formatter emits the Code Climate JSON format, as consumed by the GitLab
+// Code Quality report to show findings inline in merge requests
+type Formatter struct{}
+
+type Issue struct {
+	Type        string   `json:"type"`
+	CheckName   string   `json:"check_name"`
+	Description string   `json:"description"`
+	Categories  []string `json:"categories"`
+	Fingerprint string   `json:"fingerprint"`
+	Severity    string   `json:"severity"`
+	Location    Location `json:"location"`
+}
+
+type Location struct {
+	Path  string `json:"path"`
+	Lines Lines  `json:"lines"`
+}
+
+type Lines struct {
+	Begin int `json:"begin"`
+}
+
+func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
+	result := make([]Issue, 0, len(issues))
+	seen := make(map[string]int)
+
+	for _, issue := range issues {
+		resource := fmt.Sprintf("%s/%s", issue.Resource.Kind, issue.Resource.Name)
+		if issue.Resource.Namespace != "" {
+			resource = fmt.Sprintf("%s/%s", issue.Resource.Namespace, resource)
+		}
+
+		description := fmt.Sprintf("%s: %s", resource, issue.Message)
+		if issue.Suggestion != "" {
+			description = fmt.Sprintf("%s (Suggestion: %s)", description, issue.Suggestion)
+		}
+
+		if issue.Source != nil {
+			description = fmt.Sprintf("%s (Source: %s)", description, issue.Source)
+		}
+
+		location := Location{Path: resource, Lines: Lines{Begin: 1}}
+		if p := issue.Position; p != nil {
+			location.Path = p.File
+			if p.Line > 0 {
+				location.Lines.Begin = p.Line
+			}
+		} else if issue.File != "" {
+			location.Path = issue.File
+		}
+
+		result = append(result, Issue{
+			Type:        "issue",
+			CheckName:   issue.Linter,
+			Description: description,
+			Categories:  []string{"Bug Risk"},
+			Fingerprint: fingerprint(issue, seen),
+			Severity:    severity(issue.Severity),
+			Location:    location,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// fingerprint returns the issue fingerprint, made unique when the same issue
+// is reported more than once (e.g. a resource defined in several files) as
+// GitLab drops issues sharing a fingerprint
+func fingerprint(issue linter.Issue, seen map[string]int) string {
+	fp := issue.Fingerprint()
+
+	n := seen[fp]
+	seen[fp]++
+
+	if n == 0 {
+		return fp
+	}
+
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", fp, n)))
+	return hex.EncodeToString(h[:])
+}
+
+func severity(s linter.Severity) string {
+	switch s.Builtin() {
+	case linter.SeverityFatal:
+		return "blocker"
+	case linter.SeverityError:
+		return "major"
+	case linter.SeverityWarning:
+		return "minor"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,142 @@
+package codeclimate_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/codeclimate"
+)
+
+func format(t *testing.T, issues ...linter.Issue) []codeclimate.Issue {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := (&codeclimate.Formatter{}).Format(&buf, issues); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []codeclimate.Issue
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func TestFormat(t *testing.T) {
+	resource := linter.ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns", Name: "web"}
+
+	tests := []struct {
+		name        string
+		issue       linter.Issue
+		severity    string
+		description string
+		location    codeclimate.Location
+	}{
+		{
+			name: "position",
+			issue: linter.Issue{
+				Severity: linter.SeverityError,
+				Position: &linter.Position{File: "deploy/web.yaml", Line: 12},
+			},
+			severity:    "major",
+			description: "ns/Deployment/web: uses 'latest' tag",
+			location:    codeclimate.Location{Path: "deploy/web.yaml", Lines: codeclimate.Lines{Begin: 12}},
+		},
+		{
+			name: "file",
+			issue: linter.Issue{
+				Severity:   linter.SeverityWarning,
+				Suggestion: "Pin a version",
+				File:       "charts/web/templates/deployment.yaml",
+			},
+			severity:    "minor",
+			description: "ns/Deployment/web: uses 'latest' tag (Suggestion: Pin a version)",
+			location:    codeclimate.Location{Path: "charts/web/templates/deployment.yaml", Lines: codeclimate.Lines{Begin: 1}},
+		},
+		{
+			name:        "resource only",
+			issue:       linter.Issue{Severity: linter.SeverityFatal},
+			severity:    "blocker",
+			description: "ns/Deployment/web: uses 'latest' tag",
+			location:    codeclimate.Location{Path: "ns/Deployment/web", Lines: codeclimate.Lines{Begin: 1}},
+		},
+		{
+			name:        "info",
+			issue:       linter.Issue{Severity: linter.SeverityInfo},
+			severity:    "info",
+			description: "ns/Deployment/web: uses 'latest' tag",
+			location:    codeclimate.Location{Path: "ns/Deployment/web", Lines: codeclimate.Lines{Begin: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue := tt.issue
+			issue.Linter = "image-tags"
+			issue.Message = "uses 'latest' tag"
+			issue.Resource = resource
+
+			result := format(t, issue)
+			if len(result) != 1 {
+				t.Fatalf("expected 1 issue, got %d", len(result))
+			}
+
+			r := result[0]
+			if r.Type != "issue" || r.CheckName != "image-tags" {
+				t.Errorf("unexpected type %s and check name %s", r.Type, r.CheckName)
+			}
+			if r.Severity != tt.severity {
+				t.Errorf("expected severity %s, got %s", tt.severity, r.Severity)
+			}
+			if r.Description != tt.description {
+				t.Errorf("expected description %q, got %q", tt.description, r.Description)
+			}
+			if r.Location != tt.location {
+				t.Errorf("expected location %+v, got %+v", tt.location, r.Location)
+			}
+		})
+	}
+}
+
+// TestFormatFingerprints makes the fingerprints of duplicated issues unique,
+// GitLab drops issues sharing a fingerprint
+func TestFormatFingerprints(t *testing.T) {
+	issue := linter.Issue{
+		Severity: linter.SeverityError,
+		Linter:   "image-tags",
+		Message:  "uses 'latest' tag",
+		Resource: linter.ResourceRef{Kind: "Deployment", Name: "web"},
+	}
+
+	result := format(t, issue, issue, issue)
+
+	seen := make(map[string]bool)
+	for _, r := range result {
+		if seen[r.Fingerprint] {
+			t.Errorf("duplicated fingerprint %s", r.Fingerprint)
+		}
+		seen[r.Fingerprint] = true
+	}
+
+	if result[0].Fingerprint != issue.Fingerprint() {
+		t.Errorf("expected the first fingerprint to be the one of the issue")
+	}
+
+	if again := format(t, issue, issue, issue); again[2].Fingerprint != result[2].Fingerprint {
+		t.Errorf("expected stable fingerprints across runs")
+	}
+}
+
+func TestFormatEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&codeclimate.Formatter{}).Format(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "[]\n" {
+		t.Errorf("expected an empty array, got %q", buf.String())
+	}
+}
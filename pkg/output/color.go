@@ -0,0 +1,59 @@
+package output
+
+import (
+	"fmt"
+	"os"
+)
+
+// ColorMode selects when output is colored
+type ColorMode string
+
+const (
+	ColorAuto   ColorMode = "auto"
+	ColorAlways ColorMode = "always"
+	ColorNever  ColorMode = "never"
+)
+
+// ParseColorMode validates a color mode, an empty string means auto
+func ParseColorMode(s string) (ColorMode, error) {
+	switch m := ColorMode(s); m {
+	case "":
+		return ColorAuto, nil
+	case ColorAuto, ColorAlways, ColorNever:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid color mode %q, expected always, auto or never", s)
+	}
+}
+
+// UseColor reports whether output written to f should be colored. In auto
+// mode a non-empty NO_COLOR disables colors, CLICOLOR_FORCE set to anything
+// but 0 forces them, otherwise colors are used when f is a terminal which is
+// not dumb.
+func UseColor(mode ColorMode, f *os.File) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	if v, ok := os.LookupEnv("CLICOLOR_FORCE"); ok && v != "0" {
+		return true
+	}
+
+	return IsTerminal(f) && os.Getenv("TERM") != "dumb"
+}
+
+// IsTerminal reports whether the file is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
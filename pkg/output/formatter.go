@@ -5,29 +5,78 @@ import (
 	"io"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/azuredevops"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/codeclimate"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/githubactions"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/html"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/json"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/junit"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/markdown"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/sarif"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/text"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/yaml"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/provenance"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
 )
 
 type Formatter interface {
 	Format(w io.Writer, issues []linter.Issue) error
 }
 
-func NewFormatter(format string, useColor bool) (Formatter, error) {
+// Options tunes how formatters render issues, not every formatter honors
+// every option
+type Options struct {
+	UseColor   bool
+	ShowDocURL bool
+	GroupBy    string
+	SortBy     []string
+	// Stats are included in the text, json and yaml reports when set
+	Stats *report.Stats
+	// Reproducibility is included in the json, yaml and sarif reports when set
+	Reproducibility *provenance.Report
+}
+
+// EmbedsStats tells whether the format includes Options.Stats in the report
+func EmbedsStats(format string) bool {
+	return format == "text" || format == "json" || format == "yaml"
+}
+
+func NewFormatter(format string, opts Options) (Formatter, error) {
 	switch format {
 	case "text":
-		return &text.Formatter{UseColor: useColor}, nil
+		return &text.Formatter{
+			UseColor:   opts.UseColor,
+			ShowDocURL: opts.ShowDocURL,
+			GroupBy:    opts.GroupBy,
+			SortBy:     opts.SortBy,
+			Stats:      opts.Stats,
+		}, nil
 	case "json":
-		return &json.Formatter{}, nil
+		return &json.Formatter{
+			Stats:           opts.Stats,
+			Reproducibility: opts.Reproducibility,
+			ByNamespace:     opts.GroupBy == text.KeyNamespace,
+		}, nil
 	case "yaml":
-		return &yaml.Formatter{}, nil
+		return &yaml.Formatter{
+			Stats:           opts.Stats,
+			Reproducibility: opts.Reproducibility,
+			ByNamespace:     opts.GroupBy == text.KeyNamespace,
+		}, nil
 	case "github-actions":
 		return &githubactions.Formatter{}, nil
 	case "sarif":
-		return &sarif.Formatter{}, nil
+		return &sarif.Formatter{Reproducibility: opts.Reproducibility}, nil
+	case "html":
+		return &html.Formatter{ByNamespace: opts.GroupBy == text.KeyNamespace}, nil
+	case "markdown":
+		return &markdown.Formatter{}, nil
+	case "code-climate", "gitlab":
+		return &codeclimate.Formatter{}, nil
+	case "azure-devops":
+		return &azuredevops.Formatter{}, nil
+	case "junit", "circleci":
+		return &junit.Formatter{}, nil
 	default:
 		return nil, fmt.Errorf("unknown format: %s", format)
 	}
@@ -17,7 +17,7 @@ func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
 		}
 
 		level := "error"
-		switch issue.Severity {
+		switch issue.Severity.Builtin() {
 		case linter.SeverityFatal:
 			level = "error"
 		case linter.SeverityWarning:
@@ -31,8 +31,29 @@ func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
 		if issue.Suggestion != "" {
 			message = fmt.Sprintf("%s (Suggestion: %s)", message, issue.Suggestion)
 		}
+		if issue.DocURL != "" {
+			message = fmt.Sprintf("%s See: %s", message, issue.DocURL)
+		}
+
+		if issue.Source != nil {
+			message = fmt.Sprintf("%s (Source: %s)", message, issue.Source)
+		}
+
+		params := ""
+		if p := issue.Position; p != nil {
+			params = fmt.Sprintf("file=%s,", p.File)
+			if p.Line > 0 {
+				params = fmt.Sprintf("%sline=%d,", params, p.Line)
+			}
+			if p.Column > 0 {
+				params = fmt.Sprintf("%scol=%d,", params, p.Column)
+			}
+		} else if issue.File != "" {
+			params = fmt.Sprintf("file=%s,", issue.File)
+		}
+		params += "title=" + title
 
-		fmt.Fprintf(w, "::%s title=%s::%s\n", level, title, message)
+		fmt.Fprintf(w, "::%s %s::%s\n", level, params, message)
 	}
 
 	return nil
@@ -0,0 +1,69 @@
+package githubactions_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/githubactions"
+)
+
+func TestFormat(t *testing.T) {
+	resource := linter.ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns", Name: "web"}
+
+	tests := []struct {
+		name     string
+		issue    linter.Issue
+		expected string
+	}{
+		{
+			name: "position",
+			issue: linter.Issue{
+				Severity: linter.SeverityError,
+				Position: &linter.Position{File: "deploy/web.yaml", Line: 12, Column: 3},
+			},
+			expected: "::error file=deploy/web.yaml,line=12,col=3,title=[image-tags] ns/Deployment/web::uses 'latest' tag\n",
+		},
+		{
+			name: "file",
+			issue: linter.Issue{
+				Severity: linter.SeverityWarning,
+				File:     "charts/web/templates/deployment.yaml",
+				Source:   &linter.SourceRef{Name: "web", Type: "helm"},
+			},
+			expected: "::warning file=charts/web/templates/deployment.yaml,title=[image-tags] ns/Deployment/web::uses 'latest' tag (Source: web (helm))\n",
+		},
+		{
+			name: "resource only",
+			issue: linter.Issue{
+				Severity:   linter.SeverityInfo,
+				Suggestion: "Pin a version",
+				DocURL:     "https://example.com/image-tags",
+			},
+			expected: "::notice title=[image-tags] ns/Deployment/web::uses 'latest' tag (Suggestion: Pin a version) See: https://example.com/image-tags\n",
+		},
+		{
+			name:     "fatal is an error",
+			issue:    linter.Issue{Severity: linter.SeverityFatal},
+			expected: "::error title=[image-tags] ns/Deployment/web::uses 'latest' tag\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue := tt.issue
+			issue.Linter = "image-tags"
+			issue.Message = "uses 'latest' tag"
+			issue.Resource = resource
+
+			var buf bytes.Buffer
+			if err := (&githubactions.Formatter{}).Format(&buf, []linter.Issue{issue}); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
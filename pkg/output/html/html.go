@@ -0,0 +1,63 @@
+package html
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+)
+
+//go:embed report.html
+var reportTemplate string
+
+var tmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"count": func(counts map[linter.Severity]int, severity linter.Severity) int {
+		return counts[severity]
+	},
+	// custom severities are styled as the built-in one they are like
+	"class": func(severity linter.Severity) string {
+		return string(severity.Builtin())
+	},
+}).Parse(reportTemplate))
+
+// Formatter emits a self-contained HTML report, with no external assets,
+// which can be filtered by linter, severity, namespace and kind
+type Formatter struct {
+	// ByNamespace adds a per namespace summary table to the report
+	ByNamespace bool
+}
+
+func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
+	if issues == nil {
+		issues = []linter.Issue{}
+	}
+
+	var namespaces []report.NamespaceSummary
+	if f.ByNamespace {
+		namespaces = report.ByNamespace(issues, report.DefaultWorstOffenders)
+	}
+
+	severities := linter.Severities()
+
+	classes := make(map[linter.Severity]string, len(severities))
+	for _, s := range severities {
+		classes[s] = string(s.Builtin())
+	}
+
+	return tmpl.Execute(w, struct {
+		Generated  string
+		Issues     []linter.Issue
+		Namespaces []report.NamespaceSummary
+		Severities []linter.Severity
+		Classes    map[linter.Severity]string
+	}{
+		Generated:  time.Now().UTC().Format(time.RFC3339),
+		Issues:     issues,
+		Namespaces: namespaces,
+		Severities: severities,
+		Classes:    classes,
+	})
+}
@@ -0,0 +1,99 @@
+package html_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/html"
+)
+
+var issues = []linter.Issue{
+	{
+		Severity: linter.SeverityError,
+		Linter:   "annotations",
+		Message:  `Annotation "note" is set to "</script><script>alert(1)</script>"`,
+		Resource: linter.ResourceRef{Kind: "Deployment", Namespace: "ns", Name: "web"},
+	},
+	{
+		Severity: linter.SeverityWarning,
+		Linter:   "image-tags",
+		Message:  "uses 'latest' tag",
+		Resource: linter.ResourceRef{Kind: "ClusterRole", Name: "admin"},
+	},
+}
+
+// embedded returns the issues embedded in the script of the report
+func embedded(t *testing.T, report string) []linter.Issue {
+	t.Helper()
+
+	const prefix = "const issues = "
+
+	for _, line := range strings.Split(report, "\n") {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+
+		var result []linter.Issue
+		if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(line, prefix), ";")), &result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	t.Fatal("no issues embedded in the report")
+	return nil
+}
+
+func TestFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&html.Formatter{}).Format(&buf, issues); err != nil {
+		t.Fatal(err)
+	}
+
+	report := buf.String()
+
+	if strings.Count(report, "</script>") != 1 {
+		t.Error("expected the messages to be escaped in the script")
+	}
+
+	if strings.Contains(report, "<h2>Namespaces</h2>") {
+		t.Error("expected no namespace summary")
+	}
+
+	result := embedded(t, report)
+	if len(result) != len(issues) {
+		t.Fatalf("expected %d issues, got %d", len(issues), len(result))
+	}
+	if result[0].Message != issues[0].Message {
+		t.Errorf("expected message %q, got %q", issues[0].Message, result[0].Message)
+	}
+}
+
+func TestFormatByNamespace(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&html.Formatter{ByNamespace: true}).Format(&buf, issues); err != nil {
+		t.Fatal(err)
+	}
+
+	report := buf.String()
+
+	for _, s := range []string{"<h2>Namespaces</h2>", "<td>ns</td>", "<td>(cluster scoped)</td>", "Deployment/web (1)"} {
+		if !strings.Contains(report, s) {
+			t.Errorf("expected the report to contain %q", s)
+		}
+	}
+}
+
+func TestFormatEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&html.Formatter{}).Format(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := embedded(t, buf.String()); len(result) != 0 {
+		t.Errorf("expected no issues, got %d", len(result))
+	}
+}
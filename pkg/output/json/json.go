@@ -5,15 +5,37 @@ import (
 	"io"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/provenance"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
 )
 
-type Formatter struct{}
+type Formatter struct {
+	// Stats, when set, are included in the report
+	Stats *report.Stats
+	// Reproducibility, when set, is included in the report
+	Reproducibility *provenance.Report
+	// ByNamespace adds the per namespace summary to the report
+	ByNamespace bool
+}
 
 func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
+	var namespaces []report.NamespaceSummary
+	if f.ByNamespace {
+		namespaces = report.ByNamespace(issues, report.DefaultWorstOffenders)
+	}
+
+	return Encode(w, &report.Report{
+		Issues:          issues,
+		Count:           len(issues),
+		Stats:           f.Stats,
+		Reproducibility: f.Reproducibility,
+		Namespaces:      namespaces,
+	})
+}
+
+// Encode writes the given report as indented JSON
+func Encode(w io.Writer, r *report.Report) error {
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(map[string]interface{}{
-		"issues": issues,
-		"count":  len(issues),
-	})
+	return encoder.Encode(r)
 }
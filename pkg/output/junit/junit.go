@@ -0,0 +1,134 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Formatter emits a JUnit XML report, as consumed by CircleCI
+// store_test_results and most CI test report viewers. Every linter is a
+// test suite and every issue a failed test case.
+type Formatter struct{}
+
+type testSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Suites   []testSuite `xml:"testsuite"`
+}
+
+type testSuite struct {
+	Name     string     `xml:"name,attr"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Cases    []testCase `xml:"testcase"`
+}
+
+type testCase struct {
+	Name      string  `xml:"name,attr"`
+	ClassName string  `xml:"classname,attr"`
+	File      string  `xml:"file,attr,omitempty"`
+	Failure   failure `xml:"failure"`
+}
+
+type failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
+	byLinter := make(map[string][]testCase)
+
+	for _, issue := range issues {
+		resource := fmt.Sprintf("%s/%s", issue.Resource.Kind, issue.Resource.Name)
+		if issue.Resource.Namespace != "" {
+			resource = fmt.Sprintf("%s/%s", issue.Resource.Namespace, resource)
+		}
+
+		tc := testCase{
+			Name:      fmt.Sprintf("%s: %s", resource, issue.Message),
+			ClassName: resource,
+			Failure: failure{
+				Message: issue.Message,
+				Type:    string(issue.Severity),
+				Text:    details(issue),
+			},
+		}
+
+		if issue.Position != nil {
+			tc.File = issue.Position.File
+		} else {
+			tc.File = issue.File
+		}
+
+		byLinter[issue.Linter] = append(byLinter[issue.Linter], tc)
+	}
+
+	names := make([]string, 0, len(byLinter))
+	for name := range byLinter {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := testSuites{
+		Name:     "k8s-manifests-lint",
+		Tests:    len(issues),
+		Failures: len(issues),
+	}
+
+	for _, name := range names {
+		cases := byLinter[name]
+		report.Suites = append(report.Suites, testSuite{
+			Name:     name,
+			Tests:    len(cases),
+			Failures: len(cases),
+			Cases:    cases,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// details lists the issue properties shown in the failure body
+func details(issue linter.Issue) string {
+	var lines []string
+
+	if location := issue.Location(); location != "" {
+		lines = append(lines, "Location: "+location)
+	}
+	if issue.Source != nil {
+		lines = append(lines, "Source: "+issue.Source.String())
+	}
+	if issue.Field != "" {
+		lines = append(lines, "Field: "+issue.Field)
+	}
+	if issue.Value != nil {
+		lines = append(lines, fmt.Sprintf("Value: %v", issue.Value))
+	}
+	if issue.Suggestion != "" {
+		lines = append(lines, "Suggestion: "+issue.Suggestion)
+	}
+	if issue.DocURL != "" {
+		lines = append(lines, "Docs: "+issue.DocURL)
+	}
+
+	return strings.Join(lines, "\n")
+}
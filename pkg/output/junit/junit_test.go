@@ -0,0 +1,117 @@
+package junit_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/junit"
+)
+
+type report struct {
+	Tests    int `xml:"tests,attr"`
+	Failures int `xml:"failures,attr"`
+	Suites   []struct {
+		Name  string `xml:"name,attr"`
+		Tests int    `xml:"tests,attr"`
+		Cases []struct {
+			Name    string `xml:"name,attr"`
+			File    string `xml:"file,attr"`
+			Failure struct {
+				Message string `xml:"message,attr"`
+				Type    string `xml:"type,attr"`
+				Text    string `xml:",chardata"`
+			} `xml:"failure"`
+		} `xml:"testcase"`
+	} `xml:"testsuite"`
+}
+
+func TestFormat(t *testing.T) {
+	issues := []linter.Issue{
+		{
+			Severity: linter.SeverityError,
+			Linter:   "required-labels",
+			Message:  "Missing required label \"team\"",
+			Resource: linter.ResourceRef{Kind: "Deployment", Namespace: "ns", Name: "web"},
+			File:     "deploy/web.yaml",
+		},
+		{
+			Severity:   linter.SeverityWarning,
+			Linter:     "image-tags",
+			Message:    "uses 'latest' tag",
+			Resource:   linter.ResourceRef{Kind: "Deployment", Name: "api"},
+			Field:      "$.spec.template.spec.containers[0].image",
+			Suggestion: "Pin a version",
+			Position:   &linter.Position{File: "deploy/api.yaml", Line: 12},
+		},
+		{
+			Severity: linter.SeverityWarning,
+			Linter:   "image-tags",
+			Message:  "uses 'latest' tag",
+			Resource: linter.ResourceRef{Kind: "Deployment", Name: "web"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&junit.Formatter{}).Format(&buf, issues); err != nil {
+		t.Fatal(err)
+	}
+
+	var r report
+	if err := xml.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Tests != 3 || r.Failures != 3 {
+		t.Errorf("expected 3 tests and failures, got %d and %d", r.Tests, r.Failures)
+	}
+
+	if len(r.Suites) != 2 {
+		t.Fatalf("expected 2 test suites, got %d", len(r.Suites))
+	}
+
+	// suites are sorted by linter
+	if r.Suites[0].Name != "image-tags" || r.Suites[0].Tests != 2 {
+		t.Errorf("expected 2 image-tags test cases first, got %d %s", r.Suites[0].Tests, r.Suites[0].Name)
+	}
+	if r.Suites[1].Name != "required-labels" || r.Suites[1].Tests != 1 {
+		t.Errorf("expected 1 required-labels test case last, got %d %s", r.Suites[1].Tests, r.Suites[1].Name)
+	}
+
+	tc := r.Suites[0].Cases[0]
+	if tc.Name != "Deployment/api: uses 'latest' tag" {
+		t.Errorf("unexpected test case name %q", tc.Name)
+	}
+	if tc.File != "deploy/api.yaml" {
+		t.Errorf("expected file deploy/api.yaml, got %q", tc.File)
+	}
+	if tc.Failure.Type != "warning" || tc.Failure.Message != "uses 'latest' tag" {
+		t.Errorf("unexpected failure %s %q", tc.Failure.Type, tc.Failure.Message)
+	}
+
+	text := "Location: deploy/api.yaml:12\nField: $.spec.template.spec.containers[0].image\nSuggestion: Pin a version"
+	if tc.Failure.Text != text {
+		t.Errorf("expected failure text %q, got %q", text, tc.Failure.Text)
+	}
+
+	if tc := r.Suites[1].Cases[0]; tc.Name != "ns/Deployment/web: Missing required label \"team\"" || tc.File != "deploy/web.yaml" {
+		t.Errorf("unexpected test case %q in %q", tc.Name, tc.File)
+	}
+}
+
+func TestFormatEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&junit.Formatter{}).Format(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var r report
+	if err := xml.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Tests != 0 || len(r.Suites) != 0 {
+		t.Errorf("expected an empty report, got %d tests in %d suites", r.Tests, len(r.Suites))
+	}
+}
@@ -0,0 +1,156 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Formatter emits a Markdown summary, suitable for $GITHUB_STEP_SUMMARY: a
+// table of the issue counts by severity and linter followed by the issues
+// of every resource in a collapsible section, resources with the most
+// severe issues first
+type Formatter struct {
+	// MaxResources limits the number of resources detailed, zero means no
+	// limit
+	MaxResources int
+}
+
+type count struct {
+	severity linter.Severity
+	linter   string
+}
+
+func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
+	fmt.Fprintln(w, "## k8s-manifests-lint")
+	fmt.Fprintln(w)
+
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "No issues found.")
+		return nil
+	}
+
+	totals := make(map[linter.Severity]int)
+	counts := make(map[count]int)
+	byResource := make(map[string][]linter.Issue)
+
+	var resources []string
+	for _, issue := range issues {
+		totals[issue.Severity]++
+		counts[count{severity: issue.Severity, linter: issue.Linter}]++
+
+		r := resource(issue.Resource)
+		if _, ok := byResource[r]; !ok {
+			resources = append(resources, r)
+		}
+		byResource[r] = append(byResource[r], issue)
+	}
+
+	var parts []string
+	for _, s := range linter.Severities() {
+		if totals[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", totals[s], s))
+		}
+	}
+
+	fmt.Fprintf(w, "**%d issue(s)** in %d resource(s): %s\n\n", len(issues), len(resources), strings.Join(parts, ", "))
+
+	keys := make([]count, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].severity != keys[j].severity {
+			return keys[i].severity.Rank() < keys[j].severity.Rank()
+		}
+		return keys[i].linter < keys[j].linter
+	})
+
+	fmt.Fprintln(w, "| Severity | Linter | Issues |")
+	fmt.Fprintln(w, "|----------|--------|-------:|")
+	for _, k := range keys {
+		fmt.Fprintf(w, "| %s | %s | %d |\n", k.severity, escape(k.linter), counts[k])
+	}
+	fmt.Fprintln(w)
+
+	sort.Slice(resources, func(i, j int) bool {
+		a, b := byResource[resources[i]], byResource[resources[j]]
+		if worst(a) != worst(b) {
+			return worst(a) < worst(b)
+		}
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return resources[i] < resources[j]
+	})
+
+	omitted := 0
+	if f.MaxResources > 0 && len(resources) > f.MaxResources {
+		omitted = len(resources) - f.MaxResources
+		resources = resources[:f.MaxResources]
+	}
+
+	for _, r := range resources {
+		fmt.Fprintf(w, "<details>\n<summary>%s (%d)</summary>\n\n", escapeHTML(r), len(byResource[r]))
+		fmt.Fprintln(w, "| Severity | Linter | Message | Location |")
+		fmt.Fprintln(w, "|----------|--------|---------|----------|")
+
+		for _, issue := range byResource[r] {
+			location := issue.Field
+			if l := issue.Location(); l != "" {
+				location = l
+			}
+			if location != "" {
+				location = "`" + location + "`"
+			}
+			if issue.Source != nil {
+				location = strings.TrimSpace(location + " " + escape(issue.Source.String()))
+			}
+
+			message := escape(issue.Message)
+			if issue.DocURL != "" {
+				message = fmt.Sprintf("%s ([docs](%s))", message, issue.DocURL)
+			}
+
+			fmt.Fprintf(w, "| %s | %s | %s | %s |\n", issue.Severity, escape(issue.Linter), message, location)
+		}
+
+		fmt.Fprintln(w, "\n</details>")
+		fmt.Fprintln(w)
+	}
+
+	if omitted > 0 {
+		fmt.Fprintf(w, "_%d more resource(s) with issues not shown._\n", omitted)
+	}
+
+	return nil
+}
+
+// worst returns the rank of the most severe issue
+func worst(issues []linter.Issue) int {
+	r := len(linter.Severities())
+	for _, issue := range issues {
+		r = min(r, issue.Severity.Rank())
+	}
+	return r
+}
+
+func resource(ref linter.ResourceRef) string {
+	if ref.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s", ref.Namespace, ref.Kind, ref.Name)
+	}
+	return fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+}
+
+// escape makes text safe to use in a table cell
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func escapeHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
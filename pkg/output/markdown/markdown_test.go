@@ -0,0 +1,85 @@
+package markdown_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/markdown"
+)
+
+var issues = []linter.Issue{
+	{
+		Severity: linter.SeverityWarning,
+		Linter:   "image-tags",
+		Message:  "uses 'latest' tag",
+		Resource: linter.ResourceRef{Kind: "Deployment", Name: "api"},
+		Field:    "$.spec.template.spec.containers[0].image",
+	},
+	{
+		Severity: linter.SeverityWarning,
+		Linter:   "image-tags",
+		Message:  "uses 'latest' tag",
+		Resource: linter.ResourceRef{Kind: "Deployment", Namespace: "ns", Name: "web"},
+	},
+	{
+		Severity: linter.SeverityError,
+		Linter:   "required-labels",
+		Message:  "Missing required label \"team|owner\"",
+		Resource: linter.ResourceRef{Kind: "Deployment", Namespace: "ns", Name: "web"},
+		Position: &linter.Position{File: "deploy/web.yaml", Line: 4},
+		DocURL:   "https://example.com/required-labels",
+	},
+}
+
+func format(t *testing.T, f *markdown.Formatter, issues []linter.Issue) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := f.Format(&buf, issues); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestFormat(t *testing.T) {
+	summary := format(t, &markdown.Formatter{}, issues)
+
+	for _, s := range []string{
+		"**3 issue(s)** in 2 resource(s): 1 error, 2 warning\n",
+		"| error | required-labels | 1 |\n| warning | image-tags | 2 |\n",
+		"<summary>ns/Deployment/web (2)</summary>",
+		"<summary>Deployment/api (1)</summary>",
+		"| error | required-labels | Missing required label \"team\\|owner\" ([docs](https://example.com/required-labels)) | `deploy/web.yaml:4` |",
+		"| warning | image-tags | uses 'latest' tag | `$.spec.template.spec.containers[0].image` |",
+	} {
+		if !strings.Contains(summary, s) {
+			t.Errorf("expected the summary to contain %q, got:\n%s", s, summary)
+		}
+	}
+
+	// resources with the most severe issues come first
+	if strings.Index(summary, "ns/Deployment/web") > strings.Index(summary, "Deployment/api (1)") {
+		t.Errorf("expected ns/Deployment/web to be detailed first, got:\n%s", summary)
+	}
+}
+
+func TestFormatMaxResources(t *testing.T) {
+	summary := format(t, &markdown.Formatter{MaxResources: 1}, issues)
+
+	if strings.Contains(summary, "<summary>Deployment/api") {
+		t.Errorf("expected Deployment/api not to be detailed, got:\n%s", summary)
+	}
+
+	if !strings.Contains(summary, "_1 more resource(s) with issues not shown._") {
+		t.Errorf("expected the omitted resources to be counted, got:\n%s", summary)
+	}
+}
+
+func TestFormatEmpty(t *testing.T) {
+	if summary := format(t, &markdown.Formatter{}, nil); summary != "## k8s-manifests-lint\n\nNo issues found.\n" {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
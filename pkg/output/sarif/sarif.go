@@ -1,42 +1,50 @@
 package sarif
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/provenance"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/version"
 )
 
-type Formatter struct{}
+// fingerprintKey is the partialFingerprints key, versioned so that the
+// fingerprint computation can change without mixing up alerts
+const fingerprintKey = "k8sManifestsLint/v1"
+
+type Formatter struct {
+	// Reproducibility, when set, is added to the run properties and the
+	// source revisions to its versionControlProvenance
+	Reproducibility *provenance.Report
+}
 
 func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
 	rules := make(map[string]rule)
 	results := make([]result, 0, len(issues))
+	seen := make(map[string]int)
 
 	for _, issue := range issues {
 		ruleID := issue.Linter
 
-		if _, exists := rules[ruleID]; !exists {
-			rules[ruleID] = rule{
-				ID:   ruleID,
-				Name: ruleID,
-				ShortDescription: message{
-					Text: fmt.Sprintf("Linter: %s", ruleID),
-				},
-			}
-		}
+		level := sarifLevel(issue.Severity)
 
-		level := "error"
-		switch issue.Severity {
-		case linter.SeverityFatal:
-			level = "error"
-		case linter.SeverityWarning:
-			level = "warning"
-		case linter.SeverityInfo:
-			level = "note"
+		r, exists := rules[ruleID]
+		if !exists {
+			r = newRule(ruleID, issue.DocURL)
+		}
+		// the default level of a rule is the most severe level it reported
+		if levels[level] > levels[r.DefaultConfiguration.Level] {
+			r.DefaultConfiguration.Level = level
 		}
+		rules[ruleID] = r
 
 		resource := fmt.Sprintf("%s/%s", issue.Resource.Kind, issue.Resource.Name)
 		if issue.Resource.Namespace != "" {
@@ -48,20 +56,28 @@ func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
 			messageText = fmt.Sprintf("%s\nSuggestion: %s", messageText, issue.Suggestion)
 		}
 
+		// without a position nor a file the resource is only a logical
+		// location, a resource name is no artifact code scanning can open
+		var physical *physicalLocation
+		if p := issue.Position; p != nil {
+			physical = &physicalLocation{ArtifactLocation: newArtifactLocation(p.File)}
+			if p.Line > 0 {
+				physical.Region = &region{StartLine: p.Line, StartColumn: p.Column}
+			}
+		} else if issue.File != "" {
+			physical = &physicalLocation{ArtifactLocation: newArtifactLocation(issue.File)}
+		}
+
 		result := result{
 			RuleID:  ruleID,
 			Level:   level,
 			Message: message{Text: messageText},
+			PartialFingerprints: map[string]string{
+				fingerprintKey: fingerprint(issue, seen),
+			},
 			Locations: []location{
 				{
-					PhysicalLocation: physicalLocation{
-						ArtifactLocation: artifactLocation{
-							URI: resource,
-						},
-						Region: region{
-							StartLine: 1,
-						},
-					},
+					PhysicalLocation: physical,
 					LogicalLocations: []logicalLocation{
 						{
 							Name:               resource,
@@ -71,11 +87,23 @@ func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
 					},
 				},
 			},
+			Properties: &Properties{
+				Severity:   issue.Severity,
+				Resource:   issue.Resource,
+				Field:      issue.Field,
+				Value:      issue.Value,
+				Suggestion: issue.Suggestion,
+				DocURL:     issue.DocURL,
+				Related:    issue.Related,
+				Position:   issue.Position,
+				Source:     issue.Source,
+				File:       issue.File,
+			},
 		}
 
 		if issue.Field != "" {
-			result.Locations[0].LogicalLocations[0].FullyQualifiedName = fmt.Sprintf("%s.%s.%s",
-				issue.Resource.APIVersion, resource, issue.Field)
+			result.Locations[0].LogicalLocations[0].FullyQualifiedName = fmt.Sprintf("%s.%s%s",
+				issue.Resource.APIVersion, resource, strings.TrimPrefix(issue.Field, fieldpath.Root))
 		}
 
 		results = append(results, result)
@@ -99,7 +127,7 @@ func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
 					Driver: driver{
 						Name:           "k8s-manifests-lint",
 						InformationURI: "https://github.com/lburgazzoli/k8s-manifests-lint",
-						Version:        "0.1.0",
+						Version:        version.Version,
 						Rules:          rulesList,
 					},
 				},
@@ -108,11 +136,111 @@ func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
 		},
 	}
 
+	if f.Reproducibility != nil {
+		report.Runs[0].Properties = map[string]interface{}{
+			"reproducibility": f.Reproducibility,
+		}
+
+		for _, source := range f.Reproducibility.Sources {
+			if source.Repository == "" || source.Revision == "" {
+				continue
+			}
+			report.Runs[0].VersionControlProvenance = append(report.Runs[0].VersionControlProvenance, versionControlDetails{
+				RepositoryURI: source.Repository,
+				RevisionID:    source.Revision,
+			})
+		}
+	}
+
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(report)
 }
 
+// fingerprint identifies the issue across runs, it does not depend on the
+// line the resource is defined at so alerts are not reopened when the file
+// is edited. The file is part of it, and repeated issues are numbered, so
+// that the same resource defined in several places gets distinct alerts.
+func fingerprint(issue linter.Issue, seen map[string]int) string {
+	file := ""
+	if issue.Position != nil {
+		file = filepath.ToSlash(issue.Position.File)
+	}
+
+	key := issue.Fingerprint() + ":" + file
+
+	n := seen[key]
+	seen[key]++
+
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", key, n)))
+	return hex.EncodeToString(h[:])
+}
+
+// levels ranks the SARIF levels by severity
+var levels = map[string]int{
+	"note":    1,
+	"warning": 2,
+	"error":   3,
+}
+
+func sarifLevel(severity linter.Severity) string {
+	switch severity.Builtin() {
+	case linter.SeverityWarning:
+		return "warning"
+	case linter.SeverityInfo:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// newRule describes a rule using the metadata of the linter reporting it,
+// docURL is used when the linter is not known, e.g. when converting a
+// report produced by another version
+func newRule(id string, docURL string) rule {
+	r := rule{
+		ID:               id,
+		Name:             id,
+		ShortDescription: message{Text: fmt.Sprintf("Linter: %s", id)},
+		HelpURI:          docURL,
+	}
+
+	l, err := linter.Get(id)
+	if err != nil {
+		return r
+	}
+
+	description := l.Description()
+	if description == "" {
+		return r
+	}
+
+	r.ShortDescription = message{Text: description}
+
+	full := description + "."
+	if since := linter.SinceOf(l); since != "" {
+		full = fmt.Sprintf("%s Introduced in %s", full, since)
+		if linter.StabilityOf(l) == linter.StabilityExperimental {
+			full += ", experimental"
+		}
+		full += "."
+	}
+	r.FullDescription = &message{Text: full}
+
+	if d, ok := l.(linter.Documented); ok && d.DocURL() != "" {
+		r.HelpURI = d.DocURL()
+	}
+
+	help := &helpMessage{Text: description, Markdown: description}
+	if r.HelpURI != "" {
+		help.Text = fmt.Sprintf("%s. See %s for the rationale and how to fix the issues.", description, r.HelpURI)
+		help.Markdown = fmt.Sprintf("%s. See the [documentation](%s) for the rationale and how to fix the issues.", description, r.HelpURI)
+	}
+	r.Help = help
+
+	return r
+}
+
 type Report struct {
 	Version string `json:"version"`
 	Schema  string `json:"$schema"`
@@ -120,8 +248,15 @@ type Report struct {
 }
 
 type run struct {
-	Tool    tool     `json:"tool"`
-	Results []result `json:"results"`
+	Tool                     tool                    `json:"tool"`
+	Results                  []result                `json:"results"`
+	VersionControlProvenance []versionControlDetails `json:"versionControlProvenance,omitempty"`
+	Properties               map[string]interface{}  `json:"properties,omitempty"`
+}
+
+type versionControlDetails struct {
+	RepositoryURI string `json:"repositoryUri"`
+	RevisionID    string `json:"revisionId"`
 }
 
 type tool struct {
@@ -136,16 +271,46 @@ type driver struct {
 }
 
 type rule struct {
-	ID               string  `json:"id"`
-	Name             string  `json:"name"`
-	ShortDescription message `json:"shortDescription"`
+	ID                   string        `json:"id"`
+	Name                 string        `json:"name"`
+	ShortDescription     message       `json:"shortDescription"`
+	FullDescription      *message      `json:"fullDescription,omitempty"`
+	Help                 *helpMessage  `json:"help,omitempty"`
+	HelpURI              string        `json:"helpUri,omitempty"`
+	DefaultConfiguration configuration `json:"defaultConfiguration"`
+}
+
+type configuration struct {
+	Level string `json:"level,omitempty"`
+}
+
+type helpMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
 }
 
 type result struct {
-	RuleID    string     `json:"ruleId"`
-	Level     string     `json:"level"`
-	Message   message    `json:"message"`
-	Locations []location `json:"locations"`
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             message           `json:"message"`
+	Locations           []location        `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Properties          *Properties       `json:"properties,omitempty"`
+}
+
+// Properties holds the original issue details so a SARIF report can be
+// converted back into issues without loss
+type Properties struct {
+	Severity   linter.Severity      `json:"severity,omitempty"`
+	Resource   linter.ResourceRef   `json:"resource"`
+	Field      string               `json:"field,omitempty"`
+	Value      interface{}          `json:"value,omitempty"`
+	Suggestion string               `json:"suggestion,omitempty"`
+	DocURL     string               `json:"docURL,omitempty"`
+	Related    []linter.ResourceRef `json:"related,omitempty"`
+	Position   *linter.Position     `json:"position,omitempty"`
+	Source     *linter.SourceRef    `json:"source,omitempty"`
+	File       string               `json:"file,omitempty"`
 }
 
 type message struct {
@@ -153,21 +318,34 @@ type message struct {
 }
 
 type location struct {
-	PhysicalLocation physicalLocation  `json:"physicalLocation"`
+	PhysicalLocation *physicalLocation `json:"physicalLocation,omitempty"`
 	LogicalLocations []logicalLocation `json:"logicalLocations,omitempty"`
 }
 
+// newArtifactLocation returns the location of the file, relative paths are
+// relative to the source root
+func newArtifactLocation(file string) artifactLocation {
+	artifact := artifactLocation{URI: filepath.ToSlash(file)}
+	if !filepath.IsAbs(file) {
+		artifact.URIBaseID = "%SRCROOT%"
+	}
+
+	return artifact
+}
+
 type physicalLocation struct {
 	ArtifactLocation artifactLocation `json:"artifactLocation"`
-	Region           region           `json:"region,omitempty"`
+	Region           *region          `json:"region,omitempty"`
 }
 
 type artifactLocation struct {
-	URI string `json:"uri"`
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
 }
 
 type region struct {
-	StartLine int `json:"startLine,omitempty"`
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
 }
 
 type logicalLocation struct {
@@ -0,0 +1,113 @@
+package sarif_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/sarif"
+)
+
+type report struct {
+	Runs []struct {
+		Results []struct {
+			Locations []struct {
+				PhysicalLocation *struct {
+					ArtifactLocation struct {
+						URI       string `json:"uri"`
+						URIBaseID string `json:"uriBaseId"`
+					} `json:"artifactLocation"`
+					Region *struct {
+						StartLine int `json:"startLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+				LogicalLocations []struct {
+					Name string `json:"name"`
+				} `json:"logicalLocations"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+func TestFormatLocations(t *testing.T) {
+	resource := linter.ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ns", Name: "web"}
+
+	tests := []struct {
+		name   string
+		issue  linter.Issue
+		uri    string
+		baseID string
+		line   int
+	}{
+		{
+			name: "position",
+			issue: linter.Issue{
+				Position: &linter.Position{File: "deploy/web.yaml", Line: 12, Column: 3},
+			},
+			uri:    "deploy/web.yaml",
+			baseID: "%SRCROOT%",
+			line:   12,
+		},
+		{
+			name:   "file",
+			issue:  linter.Issue{File: "/charts/web/templates/deployment.yaml"},
+			uri:    "/charts/web/templates/deployment.yaml",
+			baseID: "",
+		},
+		{
+			name:  "resource only",
+			issue: linter.Issue{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue := tt.issue
+			issue.Severity = linter.SeverityError
+			issue.Linter = "image-tags"
+			issue.Message = "uses 'latest' tag"
+			issue.Resource = resource
+
+			var buf bytes.Buffer
+			if err := (&sarif.Formatter{}).Format(&buf, []linter.Issue{issue}); err != nil {
+				t.Fatal(err)
+			}
+
+			var r report
+			if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+				t.Fatal(err)
+			}
+
+			loc := r.Runs[0].Results[0].Locations[0]
+
+			if name := loc.LogicalLocations[0].Name; name != "ns/Deployment/web" {
+				t.Errorf("expected logical location ns/Deployment/web, got %s", name)
+			}
+
+			if tt.uri == "" {
+				if loc.PhysicalLocation != nil {
+					t.Errorf("expected no physical location, got %+v", loc.PhysicalLocation)
+				}
+				return
+			}
+
+			if loc.PhysicalLocation == nil {
+				t.Fatal("expected a physical location")
+			}
+
+			artifact := loc.PhysicalLocation.ArtifactLocation
+			if artifact.URI != tt.uri || artifact.URIBaseID != tt.baseID {
+				t.Errorf("expected %s (%s), got %s (%s)", tt.uri, tt.baseID, artifact.URI, artifact.URIBaseID)
+			}
+
+			line := 0
+			if loc.PhysicalLocation.Region != nil {
+				line = loc.PhysicalLocation.Region.StartLine
+			}
+			if line != tt.line {
+				t.Errorf("expected line %d, got %d", tt.line, line)
+			}
+		})
+	}
+}
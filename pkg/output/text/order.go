@@ -0,0 +1,127 @@
+package text
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Keys issues can be grouped and sorted by
+const (
+	KeyLinter    = "linter"
+	KeyResource  = "resource"
+	KeySeverity  = "severity"
+	KeyFile      = "file"
+	KeyNamespace = "namespace"
+)
+
+// Keys lists the valid group and sort keys
+var Keys = []string{KeyLinter, KeyResource, KeySeverity, KeyFile, KeyNamespace}
+
+// DefaultSortBy is the order issues are reported in when none is configured
+var DefaultSortBy = []string{KeyResource, KeyLinter}
+
+// ValidKey tells whether the key can be used to group or sort issues
+func ValidKey(key string) bool {
+	for _, k := range Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterScoped is the group of the issues of cluster scoped resources
+const clusterScoped = "(cluster scoped)"
+
+// compare orders two issues by the given key, the most severe issues come
+// first, issues without a file and cluster scoped resources come last
+func compare(key string, a, b linter.Issue) int {
+	switch key {
+	case KeyLinter:
+		return cmp.Compare(a.Linter, b.Linter)
+	case KeyResource:
+		return cmp.Or(
+			cmp.Compare(a.Resource.Kind, b.Resource.Kind),
+			cmp.Compare(a.Resource.Name, b.Resource.Name),
+			cmp.Compare(a.Resource.Namespace, b.Resource.Namespace),
+		)
+	case KeySeverity:
+		return cmp.Compare(a.Severity.Rank(), b.Severity.Rank())
+	case KeyFile:
+		fileA, fileB := issueFile(a), issueFile(b)
+		switch {
+		case fileA == "" && fileB == "":
+			return 0
+		case fileA == "":
+			return 1
+		case fileB == "":
+			return -1
+		}
+		var posA, posB linter.Position
+		if a.Position != nil {
+			posA = *a.Position
+		}
+		if b.Position != nil {
+			posB = *b.Position
+		}
+		return cmp.Or(
+			cmp.Compare(fileA, fileB),
+			cmp.Compare(posA.Line, posB.Line),
+			cmp.Compare(posA.Column, posB.Column),
+		)
+	case KeyNamespace:
+		switch {
+		case a.Resource.Namespace == b.Resource.Namespace:
+			return 0
+		case a.Resource.Namespace == "":
+			return 1
+		case b.Resource.Namespace == "":
+			return -1
+		}
+		return cmp.Compare(a.Resource.Namespace, b.Resource.Namespace)
+	default:
+		return 0
+	}
+}
+
+// groupName returns the heading of the group the issue belongs to
+func groupName(key string, issue linter.Issue) string {
+	switch key {
+	case KeyLinter:
+		return issue.Linter
+	case KeyResource:
+		return resourceName(issue.Resource)
+	case KeySeverity:
+		return string(issue.Severity)
+	case KeyFile:
+		if file := issueFile(issue); file != "" {
+			return file
+		}
+		return "(no file)"
+	case KeyNamespace:
+		if issue.Resource.Namespace == "" {
+			return clusterScoped
+		}
+		return issue.Resource.Namespace
+	default:
+		return ""
+	}
+}
+
+func resourceName(ref linter.ResourceRef) string {
+	if ref.Namespace != "" {
+		return fmt.Sprintf("%s/%s/%s", ref.Namespace, ref.Kind, ref.Name)
+	}
+	return fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+}
+
+// issueFile returns the file of the position of the issue, or the file the
+// resource comes from when it cannot be positioned
+func issueFile(issue linter.Issue) string {
+	if issue.Position != nil {
+		return issue.Position.File
+	}
+	return issue.File
+}
@@ -4,58 +4,157 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
 )
 
 type Formatter struct {
-	UseColor bool
+	UseColor   bool
+	ShowDocURL bool
+	// GroupBy is the key issues are grouped under a heading by, see Keys
+	GroupBy string
+	// SortBy are the keys issues are sorted by, DefaultSortBy when empty
+	SortBy []string
+	// Stats, when set, are printed after the issues
+	Stats *report.Stats
 }
 
 func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
-	sort.Slice(issues, func(i, j int) bool {
-		if issues[i].Resource.Kind != issues[j].Resource.Kind {
-			return issues[i].Resource.Kind < issues[j].Resource.Kind
-		}
-		if issues[i].Resource.Name != issues[j].Resource.Name {
-			return issues[i].Resource.Name < issues[j].Resource.Name
+	sortBy := f.SortBy
+	if len(sortBy) == 0 {
+		sortBy = DefaultSortBy
+	}
+
+	keys := sortBy
+	if f.GroupBy != "" {
+		keys = append([]string{f.GroupBy}, sortBy...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		for _, key := range keys {
+			if c := compare(key, issues[i], issues[j]); c != 0 {
+				return c < 0
+			}
 		}
-		return issues[i].Linter < issues[j].Linter
+		return false
 	})
 
-	for _, issue := range issues {
-		severity := issue.Severity
-		if f.UseColor {
-			switch issue.Severity {
-			case linter.SeverityFatal:
-				severity = "\033[31;1mfatal\033[0m"
-			case linter.SeverityError:
-				severity = "\033[31merror\033[0m"
-			case linter.SeverityWarning:
-				severity = "\033[33mwarning\033[0m"
-			case linter.SeverityInfo:
-				severity = "\033[36minfo\033[0m"
+	// namespace groups are headed by their severity breakdown and their
+	// worst offenders
+	var namespaces map[string]report.NamespaceSummary
+	if f.GroupBy == KeyNamespace {
+		namespaces = make(map[string]report.NamespaceSummary)
+		for _, summary := range report.ByNamespace(issues, report.DefaultWorstOffenders) {
+			namespaces[summary.Namespace] = summary
+		}
+	}
+
+	group := ""
+	for i, issue := range issues {
+		if f.GroupBy != "" {
+			if name := groupName(f.GroupBy, issue); i == 0 || name != group {
+				group = name
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+
+				if summary, ok := namespaces[issue.Resource.Namespace]; ok {
+					writeNamespaceHeading(w, group, summary)
+				} else {
+					fmt.Fprintf(w, "%s (%d)\n", group, countGroup(f.GroupBy, group, issues[i:]))
+				}
 			}
 		}
 
-		resource := fmt.Sprintf("%s/%s", issue.Resource.Kind, issue.Resource.Name)
-		if issue.Resource.Namespace != "" {
-			resource = fmt.Sprintf("%s/%s", issue.Resource.Namespace, resource)
+		severity := string(issue.Severity)
+		if f.UseColor {
+			severity = issue.Severity.ANSI()
 		}
 
-		fmt.Fprintf(w, "[%s] %s: %s (%s)\n", severity, resource, issue.Message, issue.Linter)
+		resource := resourceName(issue.Resource)
+
+		source := issue.Linter
+		if issue.Profile != "" {
+			source = issue.Profile + ": " + issue.Linter
+		}
 
+		fmt.Fprintf(w, "[%s] %s: %s (%s)\n", severity, resource, issue.Message, source)
+
+		if location := issue.Location(); location != "" {
+			fmt.Fprintf(w, "  Location: %s\n", location)
+		}
+		// the location tells where the issues of plain files come from, the
+		// source is shown for named sources and rendered resources
+		if issue.Source != nil && (issue.Source.Name != "" || issue.Position == nil) {
+			fmt.Fprintf(w, "  Source: %s\n", issue.Source)
+		}
 		if issue.Field != "" {
 			fmt.Fprintf(w, "  Field: %s\n", issue.Field)
 		}
+		if issue.Value != nil {
+			fmt.Fprintf(w, "  Value: %v\n", issue.Value)
+		}
 		if issue.Suggestion != "" {
 			fmt.Fprintf(w, "  Suggestion: %s\n", issue.Suggestion)
 		}
+		for _, related := range issue.Related {
+			ref := fmt.Sprintf("%s/%s", related.Kind, related.Name)
+			if related.Namespace != "" {
+				ref = fmt.Sprintf("%s/%s", related.Namespace, ref)
+			}
+			fmt.Fprintf(w, "  Related: %s\n", ref)
+		}
+		if f.ShowDocURL && issue.DocURL != "" {
+			fmt.Fprintf(w, "  Docs: %s\n", issue.DocURL)
+		}
 	}
 
 	if len(issues) > 0 {
 		fmt.Fprintf(w, "\nFound %d issue(s)\n", len(issues))
 	}
 
+	if f.Stats != nil {
+		fmt.Fprintln(w)
+		return f.Stats.Write(w)
+	}
+
 	return nil
 }
+
+// countGroup counts the leading issues belonging to the group
+func countGroup(key string, group string, issues []linter.Issue) int {
+	n := 0
+	for _, issue := range issues {
+		if groupName(key, issue) != group {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// writeNamespaceHeading writes the heading of a namespace group, such as
+// "team-a (5: 2 error, 3 warning)" followed by its worst offenders
+func writeNamespaceHeading(w io.Writer, name string, summary report.NamespaceSummary) {
+	fmt.Fprintf(w, "%s (%d: %s)\n", name, summary.Issues, severityBreakdown(summary.BySeverity))
+
+	offenders := make([]string, 0, len(summary.WorstOffenders))
+	for _, r := range summary.WorstOffenders {
+		offenders = append(offenders, fmt.Sprintf("%s/%s (%d)", r.Resource.Kind, r.Resource.Name, r.Issues))
+	}
+	fmt.Fprintf(w, "Worst offenders: %s\n", strings.Join(offenders, ", "))
+}
+
+// severityBreakdown renders the counts of each severity, the most severe
+// first
+func severityBreakdown(counts map[linter.Severity]int) string {
+	var parts []string
+	for _, s := range linter.Severities() {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,122 @@
+package text_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/text"
+)
+
+func issues() []linter.Issue {
+	return []linter.Issue{
+		{
+			Severity: linter.SeverityWarning,
+			Linter:   "image-tags",
+			Message:  "uses 'latest' tag",
+			Resource: linter.ResourceRef{Kind: "Deployment", Namespace: "ns", Name: "web"},
+			Position: &linter.Position{File: "web.yaml", Line: 12},
+		},
+		{
+			Severity: linter.SeverityError,
+			Linter:   "required-labels",
+			Message:  "Missing required label \"team\"",
+			Resource: linter.ResourceRef{Kind: "ClusterRole", Name: "admin"},
+		},
+		{
+			Severity: linter.SeverityError,
+			Linter:   "image-tags",
+			Message:  "uses no tag",
+			Resource: linter.ResourceRef{Kind: "Deployment", Namespace: "ns", Name: "api"},
+			Position: &linter.Position{File: "api.yaml", Line: 3},
+		},
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter text.Formatter
+		expected  string
+	}{
+		{
+			name: "default",
+			expected: `[error] ClusterRole/admin: Missing required label "team" (required-labels)
+[error] ns/Deployment/api: uses no tag (image-tags)
+  Location: api.yaml:3
+[warning] ns/Deployment/web: uses 'latest' tag (image-tags)
+  Location: web.yaml:12
+
+Found 3 issue(s)
+`,
+		},
+		{
+			name:      "sort by file",
+			formatter: text.Formatter{SortBy: []string{text.KeyFile}},
+			expected: `[error] ns/Deployment/api: uses no tag (image-tags)
+  Location: api.yaml:3
+[warning] ns/Deployment/web: uses 'latest' tag (image-tags)
+  Location: web.yaml:12
+[error] ClusterRole/admin: Missing required label "team" (required-labels)
+
+Found 3 issue(s)
+`,
+		},
+		{
+			name:      "group by linter",
+			formatter: text.Formatter{GroupBy: text.KeyLinter, SortBy: []string{text.KeySeverity}},
+			expected: `image-tags (2)
+[error] ns/Deployment/api: uses no tag (image-tags)
+  Location: api.yaml:3
+[warning] ns/Deployment/web: uses 'latest' tag (image-tags)
+  Location: web.yaml:12
+
+required-labels (1)
+[error] ClusterRole/admin: Missing required label "team" (required-labels)
+
+Found 3 issue(s)
+`,
+		},
+		{
+			name:      "group by namespace",
+			formatter: text.Formatter{GroupBy: text.KeyNamespace},
+			expected: `ns (2: 1 error, 1 warning)
+Worst offenders: Deployment/api (1), Deployment/web (1)
+[error] ns/Deployment/api: uses no tag (image-tags)
+  Location: api.yaml:3
+[warning] ns/Deployment/web: uses 'latest' tag (image-tags)
+  Location: web.yaml:12
+
+(cluster scoped) (1: 1 error)
+Worst offenders: ClusterRole/admin (1)
+[error] ClusterRole/admin: Missing required label "team" (required-labels)
+
+Found 3 issue(s)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.formatter.Format(&buf, issues()); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestFormatEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&text.Formatter{}).Format(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
@@ -6,15 +6,32 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/provenance"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
 )
 
-type Formatter struct{}
+type Formatter struct {
+	// Stats, when set, are included in the report
+	Stats *report.Stats
+	// Reproducibility, when set, is included in the report
+	Reproducibility *provenance.Report
+	// ByNamespace adds the per namespace summary to the report
+	ByNamespace bool
+}
 
 func (f *Formatter) Format(w io.Writer, issues []linter.Issue) error {
+	var namespaces []report.NamespaceSummary
+	if f.ByNamespace {
+		namespaces = report.ByNamespace(issues, report.DefaultWorstOffenders)
+	}
+
 	encoder := yaml.NewEncoder(w)
 	defer encoder.Close()
-	return encoder.Encode(map[string]interface{}{
-		"issues": issues,
-		"count":  len(issues),
+	return encoder.Encode(&report.Report{
+		Issues:          issues,
+		Count:           len(issues),
+		Stats:           f.Stats,
+		Reproducibility: f.Reproducibility,
+		Namespaces:      namespaces,
 	})
 }
@@ -0,0 +1,69 @@
+package yaml_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	output "github.com/lburgazzoli/k8s-manifests-lint/pkg/output/yaml"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+)
+
+var issues = []linter.Issue{
+	{
+		Severity: linter.SeverityError,
+		Linter:   "required-labels",
+		Message:  "Missing required label \"team\"",
+		Resource: linter.ResourceRef{Kind: "Deployment", Namespace: "ns", Name: "web"},
+	},
+	{
+		Severity: linter.SeverityWarning,
+		Linter:   "image-tags",
+		Message:  "uses 'latest' tag",
+		Resource: linter.ResourceRef{Kind: "ClusterRole", Name: "admin"},
+	},
+}
+
+func format(t *testing.T, f *output.Formatter) report.Report {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := f.Format(&buf, issues); err != nil {
+		t.Fatal(err)
+	}
+
+	var r report.Report
+	if err := yaml.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestFormat(t *testing.T) {
+	r := format(t, &output.Formatter{})
+
+	if r.Count != len(issues) || len(r.Issues) != len(issues) {
+		t.Errorf("expected %d issues, got %d (count %d)", len(issues), len(r.Issues), r.Count)
+	}
+
+	if r.Namespaces != nil || r.Stats != nil || r.Reproducibility != nil {
+		t.Errorf("expected no namespaces, stats nor reproducibility report")
+	}
+}
+
+func TestFormatByNamespace(t *testing.T) {
+	r := format(t, &output.Formatter{ByNamespace: true})
+
+	if len(r.Namespaces) != 2 {
+		t.Fatalf("expected 2 namespaces, got %d", len(r.Namespaces))
+	}
+
+	for _, ns := range r.Namespaces {
+		if ns.Issues != 1 || len(ns.WorstOffenders) != 1 {
+			t.Errorf("%q: expected 1 issue of 1 resource, got %d of %d", ns.Namespace, ns.Issues, len(ns.WorstOffenders))
+		}
+	}
+}
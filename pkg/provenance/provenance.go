@@ -0,0 +1,284 @@
+package provenance
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/url"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/vcs"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/version"
+)
+
+// ciVariables are the environment variables identifying a CI build which
+// are recorded, other variables are never recorded as they may hold secrets
+var ciVariables = []string{
+	"CI",
+	"GITHUB_REPOSITORY", "GITHUB_SHA", "GITHUB_REF", "GITHUB_RUN_ID", "GITHUB_RUN_ATTEMPT",
+	"CI_PROJECT_PATH", "CI_COMMIT_SHA", "CI_COMMIT_REF_NAME", "CI_PIPELINE_ID", "CI_JOB_ID",
+	"BUILD_REPOSITORY_URI", "BUILD_SOURCEVERSION", "BUILD_SOURCEBRANCH", "BUILD_BUILDID",
+	"CIRCLE_PROJECT_REPONAME", "CIRCLE_SHA1", "CIRCLE_BRANCH", "CIRCLE_BUILD_NUM",
+}
+
+// Report records what has been linted and with which rules, so that a run
+// can be reproduced later
+type Report struct {
+	Tool        Tool        `json:"tool" yaml:"tool"`
+	Config      Config      `json:"config" yaml:"config"`
+	RulePacks   []File      `json:"rulePacks,omitempty" yaml:"rulePacks,omitempty"`
+	Sources     []Source    `json:"sources" yaml:"sources"`
+	Linters     []Linter    `json:"linters" yaml:"linters"`
+	Environment Environment `json:"environment" yaml:"environment"`
+	Time        time.Time   `json:"time" yaml:"time"`
+}
+
+type Tool struct {
+	Name      string `json:"name" yaml:"name"`
+	Version   string `json:"version" yaml:"version"`
+	GoVersion string `json:"goVersion" yaml:"goVersion"`
+}
+
+// Config identifies the configuration: the file it has been read from and
+// the hash of the effective configuration, defaults included
+type Config struct {
+	File   string `json:"file,omitempty" yaml:"file,omitempty"`
+	SHA256 string `json:"sha256" yaml:"sha256"`
+}
+
+type File struct {
+	Path   string `json:"path" yaml:"path"`
+	SHA256 string `json:"sha256" yaml:"sha256"`
+}
+
+// Source is a rendered source with the revision of the git repository it
+// lives in and, for Helm charts, the chart version
+type Source struct {
+	Type         string `json:"type" yaml:"type"`
+	Path         string `json:"path,omitempty" yaml:"path,omitempty"`
+	Chart        string `json:"chart,omitempty" yaml:"chart,omitempty"`
+	ChartVersion string `json:"chartVersion,omitempty" yaml:"chartVersion,omitempty"`
+	Repository   string `json:"repository,omitempty" yaml:"repository,omitempty"`
+	URL          string `json:"url,omitempty" yaml:"url,omitempty"`
+	SHA256       string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	Context      string `json:"context,omitempty" yaml:"context,omitempty"`
+	Revision     string `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Dirty        bool   `json:"dirty,omitempty" yaml:"dirty,omitempty"`
+}
+
+type Linter struct {
+	Name  string `json:"name" yaml:"name"`
+	Since string `json:"since,omitempty" yaml:"since,omitempty"`
+}
+
+type Environment struct {
+	OS   string            `json:"os" yaml:"os"`
+	Arch string            `json:"arch" yaml:"arch"`
+	CI   map[string]string `json:"ci,omitempty" yaml:"ci,omitempty"`
+}
+
+// Collect builds the report of a run. paths are the command line paths
+// linted when the configuration has no sources.
+func Collect(ctx context.Context, cfg *config.Config, paths []string, linters []linter.Linter) (*Report, error) {
+	r := &Report{
+		Tool: Tool{
+			Name:      "k8s-manifests-lint",
+			Version:   version.Version,
+			GoVersion: runtime.Version(),
+		},
+		Environment: Environment{
+			OS:   runtime.GOOS,
+			Arch: runtime.GOARCH,
+		},
+		Time: time.Now().UTC(),
+	}
+
+	effective, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash configuration: %w", err)
+	}
+
+	sum := sha256.Sum256(effective)
+	r.Config = Config{File: cfg.File, SHA256: hex.EncodeToString(sum[:])}
+
+	baseDir := "."
+	if cfg.File != "" {
+		baseDir = filepath.Dir(cfg.File)
+	}
+
+	for _, include := range cfg.Linters.Include {
+		file := include
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(baseDir, file)
+		}
+
+		sum, err := hashFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		r.RulePacks = append(r.RulePacks, File{Path: include, SHA256: sum})
+	}
+
+	sources := cfg.Sources
+	if len(sources) == 0 {
+		for _, p := range paths {
+			if url.IsURL(p) {
+				sources = append(sources, config.Source{Type: config.SourceTypeURL, URL: p})
+				continue
+			}
+			sources = append(sources, config.Source{Type: config.SourceTypeYAML, Path: p})
+		}
+	}
+
+	for _, s := range sources {
+		r.Sources = append(r.Sources, source(ctx, s))
+	}
+
+	for _, l := range linters {
+		r.Linters = append(r.Linters, Linter{Name: l.Name(), Since: linter.SinceOf(l)})
+	}
+	sort.Slice(r.Linters, func(i, j int) bool { return r.Linters[i].Name < r.Linters[j].Name })
+
+	for _, name := range ciVariables {
+		if value, ok := os.LookupEnv(name); ok {
+			if r.Environment.CI == nil {
+				r.Environment.CI = make(map[string]string)
+			}
+			r.Environment.CI[name] = value
+		}
+	}
+
+	return r, nil
+}
+
+// source describes a source, the git details are left empty when the source
+// is not in a git working tree
+func source(ctx context.Context, s config.Source) Source {
+	result := Source{
+		Type:  s.Type.String(),
+		Path:  s.Path,
+		Chart: s.Chart,
+	}
+
+	if result.Type == "" {
+		result.Type = config.SourceTypeYAML.String()
+	}
+
+	dir := s.Path
+	if dir == "" {
+		dir = "."
+	}
+
+	// manifests fetched from a URL are identified by the URL and, when
+	// pinned, by their digest
+	if s.URL != "" {
+		result.URL = s.URL
+		result.SHA256 = s.SHA256
+		return result
+	}
+
+	// resources of a live cluster are identified by the kubeconfig context,
+	// empty for the current one
+	if s.Type == config.SourceTypeCluster {
+		result.Context = s.Cluster.Context
+		return result
+	}
+
+	// charts from repositories are identified by the repository and the
+	// requested version
+	if s.Repo != "" {
+		result.Repository = s.Repo
+		result.ChartVersion = s.Version
+		return result
+	}
+
+	if s.Chart != "" {
+		result.ChartVersion = chartVersion(s.Chart)
+		if result.ChartVersion == "" {
+			result.ChartVersion = s.Version
+		}
+		if isLocal(s.Chart) {
+			dir = s.Chart
+		} else {
+			return result
+		}
+	}
+
+	// glob patterns, as used by gotemplate sources, are resolved to the
+	// directory holding them
+	if strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	git := &vcs.Git{Dir: dir}
+
+	revision, err := git.Revision(ctx)
+	if err != nil {
+		return result
+	}
+
+	result.Revision = revision
+	result.Dirty, _ = git.Dirty(ctx)
+
+	if remote, err := git.RemoteURL(ctx, "origin"); err == nil {
+		result.Repository = remote
+	}
+
+	return result
+}
+
+// chartVersion returns the version of a chart: the tag of an OCI reference
+// or the version of a local chart
+func chartVersion(chart string) string {
+	if !isLocal(chart) {
+		ref := chart[strings.LastIndex(chart, "/")+1:]
+		if i := strings.LastIndex(ref, ":"); i >= 0 {
+			return ref[i+1:]
+		}
+		return ""
+	}
+
+	data, err := os.ReadFile(filepath.Join(chart, "Chart.yaml"))
+	if err != nil {
+		return ""
+	}
+
+	var meta struct {
+		Version string `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return ""
+	}
+
+	return meta.Version
+}
+
+func isLocal(chart string) bool {
+	return !strings.Contains(chart, "://")
+}
+
+func hashFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", file, err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
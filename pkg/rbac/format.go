@@ -0,0 +1,104 @@
+package rbac
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+//go:embed report.html
+var reportTemplate string
+
+var tmpl = template.Must(template.New("rbac").Funcs(template.FuncMap{
+	"join":     strings.Join,
+	"resource": resourceName,
+	"class": func(severity linter.Severity) string {
+		return string(severity.Builtin())
+	},
+}).Parse(reportTemplate))
+
+// WriteText writes the matrix as a table, one row per entry, followed by
+// the violations and the grants whose role is unknown
+func WriteText(w io.Writer, m *Matrix) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SUBJECT\tNAMESPACE\tRESOURCE\tVERBS\tGRANTED BY\t")
+	for _, e := range m.Entries {
+		namespace := e.Namespace
+		if namespace == "" {
+			namespace = "*"
+		}
+
+		resource := e.Resource
+		if len(e.ResourceNames) > 0 {
+			resource += "[" + strings.Join(e.ResourceNames, ",") + "]"
+		}
+
+		grants := make([]string, 0, len(e.Grants))
+		for _, g := range e.Grants {
+			grants = append(grants, resourceName(g.Binding))
+		}
+
+		marker := ""
+		if len(e.Violations) > 0 {
+			marker = "!"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Subject, namespace, resource, strings.Join(e.Verbs, ","), strings.Join(grants, ","), marker)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if violations := m.Violations(); violations > 0 {
+		fmt.Fprintf(w, "\n%d entry(ies) violating the RBAC policies:\n", violations)
+
+		seen := make(map[string]bool)
+		for _, e := range m.Entries {
+			for _, issue := range e.Violations {
+				if seen[issue.Fingerprint()] {
+					continue
+				}
+				seen[issue.Fingerprint()] = true
+
+				fmt.Fprintf(w, "! [%s] %s: %s (%s)\n", issue.Severity, resourceName(issue.Resource), issue.Message, issue.Linter)
+			}
+		}
+	}
+
+	if len(m.Unresolved) > 0 {
+		fmt.Fprintf(w, "\n%d binding(s) refer to roles not in the manifests:\n", len(m.Unresolved))
+		for _, g := range m.Unresolved {
+			fmt.Fprintf(w, "? %s -> %s\n", resourceName(g.Binding), resourceName(g.Role))
+		}
+	}
+
+	return nil
+}
+
+// WriteHTML writes the matrix as a self-contained HTML page, the entries
+// with violations are highlighted
+func WriteHTML(w io.Writer, m *Matrix) error {
+	return tmpl.Execute(w, struct {
+		Generated string
+		Matrix    *Matrix
+	}{
+		Generated: time.Now().UTC().Format(time.RFC3339),
+		Matrix:    m,
+	})
+}
+
+func resourceName(r linter.ResourceRef) string {
+	if r.Namespace != "" {
+		return r.Namespace + "/" + r.Kind + "/" + r.Name
+	}
+	return r.Kind + "/" + r.Name
+}
@@ -0,0 +1,256 @@
+package rbac
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Matrix holds the permissions granted by the Roles, ClusterRoles and their
+// bindings of a set of manifests, one entry per subject, namespace and
+// resource
+type Matrix struct {
+	Entries []Entry `json:"entries" yaml:"entries"`
+	// Unresolved are the grants whose role is not part of the manifests,
+	// such as the built-in ClusterRoles, their permissions are unknown
+	Unresolved []Grant `json:"unresolved,omitempty" yaml:"unresolved,omitempty"`
+}
+
+// Subject is a user, group or ServiceAccount permissions are granted to
+type Subject struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+func (s Subject) String() string {
+	if s.Namespace != "" {
+		return s.Kind + ":" + s.Namespace + "/" + s.Name
+	}
+	return s.Kind + ":" + s.Name
+}
+
+// Entry lists the verbs a subject is allowed on a resource, as resource.group
+// or as a non resource URL, in a namespace or cluster wide when the namespace
+// is empty
+type Entry struct {
+	Subject       Subject  `json:"subject" yaml:"subject"`
+	Namespace     string   `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Resource      string   `json:"resource" yaml:"resource"`
+	ResourceNames []string `json:"resourceNames,omitempty" yaml:"resourceNames,omitempty"`
+	Verbs         []string `json:"verbs" yaml:"verbs"`
+	// Grants are the bindings, and their role, granting the verbs
+	Grants []Grant `json:"grants" yaml:"grants"`
+	// Violations are the issues reported for the bindings and roles of the
+	// grants
+	Violations []linter.Issue `json:"violations,omitempty" yaml:"violations,omitempty"`
+}
+
+// Grant is a binding together with the role it refers to
+type Grant struct {
+	Binding linter.ResourceRef `json:"binding" yaml:"binding"`
+	Role    linter.ResourceRef `json:"role" yaml:"role"`
+}
+
+// Violations returns the number of entries with violations
+func (m *Matrix) Violations() int {
+	count := 0
+	for _, e := range m.Entries {
+		if len(e.Violations) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Build aggregates the permissions granted by the bindings of the objects,
+// the issues reported for the bindings and their roles are attached to the
+// entries as violations
+func Build(objects []unstructured.Unstructured, issues []linter.Issue) *Matrix {
+	roles := make(map[linter.ResourceRef]rbacv1.ClusterRole)
+
+	var bindings []rbacv1.RoleBinding
+
+	for _, obj := range objects {
+		if obj.GetAPIVersion() != rbacv1.SchemeGroupVersion.String() {
+			continue
+		}
+
+		switch obj.GetKind() {
+		case "Role", "ClusterRole":
+			// Roles and ClusterRoles share their fields, but the aggregation
+			// rule, so both are decoded as ClusterRoles
+			var role rbacv1.ClusterRole
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &role); err != nil {
+				continue
+			}
+			roles[ref(obj.GetKind(), obj.GetNamespace(), obj.GetName())] = role
+		case "RoleBinding", "ClusterRoleBinding":
+			var binding rbacv1.RoleBinding
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &binding); err != nil {
+				continue
+			}
+			binding.Kind = obj.GetKind()
+			bindings = append(bindings, binding)
+		}
+	}
+
+	violations := make(map[linter.ResourceRef][]linter.Issue)
+	for _, issue := range issues {
+		if issue.Resource.APIVersion == rbacv1.SchemeGroupVersion.String() {
+			violations[issue.Resource] = append(violations[issue.Resource], issue)
+		}
+	}
+
+	m := &Matrix{}
+	entries := make(map[string]*Entry)
+
+	for _, binding := range bindings {
+		roleNamespace := ""
+		if binding.RoleRef.Kind == "Role" {
+			roleNamespace = binding.Namespace
+		}
+
+		grant := Grant{
+			Binding: ref(binding.Kind, binding.Namespace, binding.Name),
+			Role:    ref(binding.RoleRef.Kind, roleNamespace, binding.RoleRef.Name),
+		}
+
+		role, ok := roles[grant.Role]
+		if !ok {
+			m.Unresolved = append(m.Unresolved, grant)
+			continue
+		}
+
+		rules := aggregatedRules(role, roles)
+
+		for _, s := range binding.Subjects {
+			subject := Subject{Kind: s.Kind, Name: s.Name, Namespace: s.Namespace}
+			if s.Kind == rbacv1.ServiceAccountKind && s.Namespace == "" {
+				subject.Namespace = binding.Namespace
+			}
+
+			for _, rule := range rules {
+				for _, resource := range resources(rule) {
+					key := strings.Join([]string{subject.String(), binding.Namespace, resource, strings.Join(rule.ResourceNames, ",")}, "|")
+
+					e, ok := entries[key]
+					if !ok {
+						e = &Entry{
+							Subject:       subject,
+							Namespace:     binding.Namespace,
+							Resource:      resource,
+							ResourceNames: rule.ResourceNames,
+						}
+						entries[key] = e
+					}
+
+					e.add(grant, rule.Verbs, violations)
+				}
+			}
+		}
+	}
+
+	for _, e := range entries {
+		m.Entries = append(m.Entries, *e)
+	}
+
+	slices.SortFunc(m.Entries, func(a, b Entry) int {
+		return cmp.Or(
+			cmp.Compare(a.Subject.Kind, b.Subject.Kind),
+			cmp.Compare(a.Subject.Namespace, b.Subject.Namespace),
+			cmp.Compare(a.Subject.Name, b.Subject.Name),
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Resource, b.Resource),
+			slices.Compare(a.ResourceNames, b.ResourceNames),
+		)
+	})
+
+	return m
+}
+
+// add records the verbs granted by the grant, along with the violations of
+// its binding and role
+func (e *Entry) add(grant Grant, verbs []string, violations map[linter.ResourceRef][]linter.Issue) {
+	for _, verb := range verbs {
+		if !slices.Contains(e.Verbs, verb) {
+			e.Verbs = append(e.Verbs, verb)
+		}
+	}
+	slices.Sort(e.Verbs)
+
+	if slices.Contains(e.Grants, grant) {
+		return
+	}
+	e.Grants = append(e.Grants, grant)
+
+	for _, r := range []linter.ResourceRef{grant.Binding, grant.Role} {
+		for _, issue := range violations[r] {
+			if !slices.ContainsFunc(e.Violations, func(other linter.Issue) bool {
+				return other.Fingerprint() == issue.Fingerprint()
+			}) {
+				e.Violations = append(e.Violations, issue)
+			}
+		}
+	}
+}
+
+// aggregatedRules returns the rules of the role and, for aggregated
+// ClusterRoles, the ones of the ClusterRoles matching the aggregation rule
+func aggregatedRules(role rbacv1.ClusterRole, roles map[linter.ResourceRef]rbacv1.ClusterRole) []rbacv1.PolicyRule {
+	rules := slices.Clone(role.Rules)
+
+	if role.AggregationRule == nil {
+		return rules
+	}
+
+	for _, s := range role.AggregationRule.ClusterRoleSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(&s)
+		if err != nil {
+			continue
+		}
+
+		for r, other := range roles {
+			if r.Kind == "ClusterRole" && other.Name != role.Name && selector.Matches(labels.Set(other.Labels)) {
+				rules = append(rules, other.Rules...)
+			}
+		}
+	}
+
+	return rules
+}
+
+// resources returns the resources of a rule as resource.group, as kubectl
+// auth can-i --list does, followed by its non resource URLs
+func resources(rule rbacv1.PolicyRule) []string {
+	var result []string
+
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			if group == "" {
+				result = append(result, resource)
+			} else {
+				result = append(result, resource+"."+group)
+			}
+		}
+	}
+
+	return append(result, rule.NonResourceURLs...)
+}
+
+func ref(kind string, namespace string, name string) linter.ResourceRef {
+	return linter.ResourceRef{
+		APIVersion: rbacv1.SchemeGroupVersion.String(),
+		Kind:       kind,
+		Namespace:  namespace,
+		Name:       name,
+	}
+}
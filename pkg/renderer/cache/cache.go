@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/cachedir"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/version"
+)
+
+// Dir is the subdirectory of the cache directory rendered objects are
+// stored in
+const Dir = "rendered"
+
+// Entry is the outcome of rendering a source
+type Entry struct {
+	Objects []unstructured.Unstructured `json:"objects"`
+	Issues  []linter.Issue              `json:"issues,omitempty"`
+}
+
+// Cache stores the objects rendered from sources, keyed by the hash of the
+// source configuration and of the files they are rendered from, so that
+// sources whose inputs have not changed are not rendered again
+type Cache struct {
+	dir string
+}
+
+// New returns the cache stored in the rendered subdirectory of the base
+// cache directory, base defaults to the user cache directory
+func New(base string) (*Cache, error) {
+	dir, err := cachedir.Dir(base, Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{dir: dir}, nil
+}
+
+// Key returns the key of the objects rendered from the source out of the
+// inputs, files or directories walked recursively. The key covers the
+// version of the tool, the source configuration and the location and
+// content of every input.
+func Key(source config.Source, inputs []string) (string, error) {
+	// the cache location and the network access do not change the objects
+	source.CacheDir = ""
+	source.Offline = false
+
+	h := sha256.New()
+
+	fmt.Fprintf(h, "version %s\n", version.Version)
+
+	encoded, err := json.Marshal(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode source: %w", err)
+	}
+	fmt.Fprintf(h, "source %s\n", encoded)
+
+	for _, input := range inputs {
+		if err := hashInput(h, input); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashInput writes the absolute path of the input, and the relative path and
+// content of every file below it for directories, to h
+func hashInput(h io.Writer, input string) error {
+	abs, err := filepath.Abs(input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(h, "input %s\n", filepath.ToSlash(abs))
+
+	return filepath.WalkDir(abs, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(abs, path)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+
+		fmt.Fprintf(h, "file %s\n", filepath.ToSlash(rel))
+		_, err = io.Copy(h, f)
+
+		return err
+	})
+}
+
+// Load returns the entry stored under key, false when there is none or it
+// cannot be read
+func (c *Cache) Load(key string) (*Entry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+// Store writes the entry under key, the file is replaced atomically so that
+// sources rendered in parallel never read a partial entry
+func (c *Cache) Store(key string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode rendered objects: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to store rendered objects: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store rendered objects: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store rendered objects: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store rendered objects: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
@@ -0,0 +1,220 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/kube"
+)
+
+// AllNamespaces lists the resources of every namespace
+const AllNamespaces = "*"
+
+// pageSize is the number of resources fetched per list request
+const pageSize = 500
+
+// lastAppliedAnnotation holds the configuration last applied by kubectl
+// apply, a copy of the object rather than part of it
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// DefaultKinds are the resources listed when a source sets none: the
+// workloads and the resources linters check them against. Pods and Jobs are
+// left out as the ones created by controllers are linted through their
+// owner.
+var DefaultKinds = []string{
+	"deployments.apps",
+	"statefulsets.apps",
+	"daemonsets.apps",
+	"cronjobs.batch",
+	"services",
+	"serviceaccounts",
+	"networkpolicies.networking.k8s.io",
+	"poddisruptionbudgets.policy",
+}
+
+// Renderer lists the resources deployed in a live cluster, so that what is
+// actually running is audited with the same linters as what is in git.
+// Resources created by a controller, such as the Pods of a ReplicaSet, are
+// skipped in favor of their owner, and the fields set by the API server are
+// dropped.
+type Renderer struct {
+	source config.Source
+}
+
+func New(source config.Source) *Renderer {
+	return &Renderer{source: source}
+}
+
+func (r *Renderer) Render(ctx context.Context, _ string) ([]unstructured.Unstructured, error) {
+	restConfig, err := kube.RESTConfig(r.source.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster client: %w", err)
+	}
+
+	mapper := restmapper.NewShortcutExpander(
+		restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)),
+		discoveryClient,
+		nil,
+	)
+
+	namespaces, err := r.namespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := r.source.Kinds
+	if len(kinds) == 0 {
+		kinds = DefaultKinds
+	}
+
+	var objects []unstructured.Unstructured
+
+	for _, kind := range kinds {
+		mapping, err := resolve(mapper, kind)
+		if err != nil {
+			return nil, fmt.Errorf("unknown kind %q: %w", kind, err)
+		}
+
+		resource := client.Resource(mapping.Resource)
+
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+			items, err := r.list(ctx, resource)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", mapping.Resource.GroupResource(), err)
+			}
+			objects = append(objects, items...)
+			continue
+		}
+
+		for _, namespace := range namespaces {
+			items, err := r.list(ctx, resource.Namespace(namespace))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s in %s: %w", mapping.Resource.GroupResource(), namespaceName(namespace), err)
+			}
+			objects = append(objects, items...)
+		}
+	}
+
+	progress.Notify(ctx, progress.Event{
+		Source:  config.SourceTypeCluster.String(),
+		Path:    r.source.Cluster.Context,
+		Objects: len(objects),
+	})
+
+	return objects, nil
+}
+
+// namespaces returns the namespaces to list resources from, the empty
+// namespace standing for all of them
+func (r *Renderer) namespaces() ([]string, error) {
+	if slices.Contains(r.source.Namespaces, AllNamespaces) {
+		return []string{metav1.NamespaceAll}, nil
+	}
+
+	if len(r.source.Namespaces) > 0 {
+		return r.source.Namespaces, nil
+	}
+
+	namespace, err := kube.Namespace(r.source.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{namespace}, nil
+}
+
+// list returns the resources not owned by a controller, page by page
+func (r *Renderer) list(ctx context.Context, resource dynamic.ResourceInterface) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+
+	opts := metav1.ListOptions{
+		LabelSelector: r.source.Selector,
+		Limit:         pageSize,
+	}
+
+	for {
+		list, err := resource.List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range list.Items {
+			if metav1.GetControllerOf(&item) != nil {
+				continue
+			}
+
+			objects = append(objects, clean(item))
+		}
+
+		opts.Continue = list.GetContinue()
+		if opts.Continue == "" {
+			return objects, nil
+		}
+	}
+}
+
+// resolve maps a kind, resource or short name, optionally qualified by
+// group, to its resource
+func resolve(mapper meta.RESTMapper, kind string) (*meta.RESTMapping, error) {
+	gr := schema.ParseGroupResource(strings.ToLower(kind))
+
+	gvk, err := mapper.KindFor(gr.WithVersion(""))
+	if err != nil {
+		return nil, err
+	}
+
+	return mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// clean drops the fields set by the API server, which are not part of the
+// desired state of the object
+func clean(obj unstructured.Unstructured) unstructured.Unstructured {
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetSelfLink("")
+
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		delete(annotations, lastAppliedAnnotation)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		obj.SetAnnotations(annotations)
+	}
+
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	return obj
+}
+
+func namespaceName(namespace string) string {
+	if namespace == metav1.NamespaceAll {
+		return "all namespaces"
+	}
+	return "namespace " + namespace
+}
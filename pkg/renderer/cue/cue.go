@@ -0,0 +1,257 @@
+package cue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	goyaml "gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/sandbox"
+)
+
+// defaultCommand is the cue binary packages are exported with
+const defaultCommand = "cue"
+
+// Renderer exports the Kubernetes objects of CUE packages with cue export,
+// run in the sandbox of run.sandbox. The objects are collected from the
+// exported value wherever they are, e.g. in a list or in nested structs such
+// as deployment: web: {...}, in the order they are exported.
+type Renderer struct {
+	source config.Source
+}
+
+func New(source config.Source) *Renderer {
+	return &Renderer{source: source}
+}
+
+func (r *Renderer) Render(ctx context.Context, path string) ([]unstructured.Unstructured, error) {
+	packagePath := r.source.Path
+	if packagePath == "" {
+		packagePath = path
+	}
+
+	dir, err := workDir(packagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := r.args(dir, packagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	command := r.source.CUECommand
+	if command == "" {
+		command = defaultCommand
+	}
+
+	// like any other binary, cue only runs when allowed by the policy
+	sb, err := sandbox.New(r.source.Sandbox)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := sb.Run(ctx, dir, command, args, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export cue package %s: %w", packagePath, err)
+	}
+
+	objects, err := decode(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cue export of %s: %w", packagePath, err)
+	}
+
+	for i := range objects {
+		annotations := objects[i].GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[linter.SourcePathAnnotation] = packagePath
+		objects[i].SetAnnotations(annotations)
+	}
+
+	progress.Notify(ctx, progress.Event{
+		Source:  config.SourceTypeCUE.String(),
+		Path:    packagePath,
+		Objects: len(objects),
+	})
+
+	return objects, nil
+}
+
+// workDir returns the directory cue runs in: the package directory, or the
+// directory of the package file, which the sandbox confines to its work dir
+func workDir(packagePath string) (string, error) {
+	resolved, err := resolve(packagePath)
+	if err != nil {
+		return "", fmt.Errorf("invalid cue package %s: %w", packagePath, err)
+	}
+
+	fi, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("invalid cue package %s: %w", packagePath, err)
+	}
+
+	if fi.IsDir() {
+		return resolved, nil
+	}
+
+	return filepath.Dir(resolved), nil
+}
+
+// args returns the cue export arguments: the package, unified with the
+// values files, the expression to export and the values injected into the
+// @tag() attributes. Paths are relative to dir, the directory cue runs in.
+func (r *Renderer) args(dir string, packagePath string) ([]string, error) {
+	pkg, err := relative(dir, packagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// cue reads relative paths without a leading ./ as package imports
+	args := []string{"export", local(pkg)}
+
+	for _, values := range r.source.Values {
+		rel, err := relative(dir, values)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, local(rel))
+	}
+
+	args = append(args, "--out", "yaml")
+
+	if r.source.CUEExpression != "" {
+		args = append(args, "--expression", r.source.CUEExpression)
+	}
+
+	for _, set := range r.source.Set {
+		args = append(args, "--inject", set)
+	}
+
+	return args, nil
+}
+
+// decode returns the objects of the exported YAML documents
+func decode(data []byte) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+
+	dec := goyaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc goyaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		if err := collect(&doc, &objects); err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// collect appends the objects found in the node: mappings with an
+// apiVersion and a kind are objects, the others are walked
+func collect(node *goyaml.Node, objects *[]unstructured.Unstructured) error {
+	switch node.Kind {
+	case goyaml.DocumentNode, goyaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := collect(child, objects); err != nil {
+				return err
+			}
+		}
+	case goyaml.MappingNode:
+		if !isObject(node) {
+			for i := 1; i < len(node.Content); i += 2 {
+				if err := collect(node.Content[i], objects); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		var content map[string]interface{}
+		if err := node.Decode(&content); err != nil {
+			return err
+		}
+
+		// the JSON round trip converts the values to the types of
+		// unstructured objects, such as int64 numbers
+		data, err := json.Marshal(content)
+		if err != nil {
+			return err
+		}
+
+		var obj unstructured.Unstructured
+		if err := obj.UnmarshalJSON(data); err != nil {
+			return err
+		}
+
+		*objects = append(*objects, obj)
+	}
+
+	return nil
+}
+
+func isObject(node *goyaml.Node) bool {
+	var apiVersion, kind bool
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind != goyaml.ScalarNode {
+			continue
+		}
+
+		switch key.Value {
+		case "apiVersion":
+			apiVersion = true
+		case "kind":
+			kind = true
+		}
+	}
+
+	return apiVersion && kind
+}
+
+// relative returns the path relative to dir, symlinks are resolved as the
+// sandbox does for the directory commands run in
+func relative(dir string, path string) (string, error) {
+	resolved, err := resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Rel(dir, resolved)
+}
+
+// local prefixes relative paths with ./ unless they start with ..
+func local(path string) string {
+	if path == "." || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return path
+	}
+
+	return "." + string(filepath.Separator) + path
+}
+
+func resolve(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(abs)
+}
@@ -0,0 +1,123 @@
+package cue_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/cue"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/sandbox"
+)
+
+// fakeCue prints a ConfigMap, as cue export --out yaml does, holding the
+// directory it runs in and its arguments
+const fakeCue = `#!/bin/sh
+cat <<EOF
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cue
+data:
+  dir: "$(pwd -P)"
+  args: "$*"
+EOF
+`
+
+func setup(t *testing.T) (string, string) {
+	t.Helper()
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	command := filepath.Join(root, "bin", "cue")
+	if err := os.MkdirAll(filepath.Dir(command), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(command, []byte(fakeCue), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	return root, command
+}
+
+func TestRender(t *testing.T) {
+	root, command := setup(t)
+
+	r := cue.New(config.Source{
+		Type:       config.SourceTypeCUE,
+		Path:       filepath.Join(root, "config"),
+		Values:     []string{filepath.Join(root, "values.yml")},
+		CUECommand: command,
+		Sandbox: config.SandboxConfig{
+			AllowedBinaries: []string{command},
+			WorkDir:         root,
+		},
+	})
+
+	if err := os.WriteFile(filepath.Join(root, "values.yml"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := r.Render(t.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objects))
+	}
+
+	data := objects[0].Object["data"].(map[string]interface{})
+
+	if dir := filepath.Join(root, "config"); data["dir"] != dir {
+		t.Errorf("expected cue to run in %s, got %v", dir, data["dir"])
+	}
+
+	if args := "export . ../values.yml --out yaml"; data["args"] != args {
+		t.Errorf("expected args %q, got %q", args, data["args"])
+	}
+}
+
+// TestRenderNotAllowed does not run cue unless allowed by the policy
+func TestRenderNotAllowed(t *testing.T) {
+	root, command := setup(t)
+
+	r := cue.New(config.Source{
+		Type:       config.SourceTypeCUE,
+		Path:       filepath.Join(root, "config"),
+		CUECommand: command,
+		Sandbox:    config.SandboxConfig{WorkDir: root},
+	})
+
+	if _, err := r.Render(t.Context(), ""); !errors.Is(err, sandbox.ErrBinaryNotAllowed) {
+		t.Fatalf("expected %v, got %v", sandbox.ErrBinaryNotAllowed, err)
+	}
+}
+
+// TestRenderOutsideWorkDir does not run cue on packages outside of the
+// sandbox work dir
+func TestRenderOutsideWorkDir(t *testing.T) {
+	root, command := setup(t)
+
+	r := cue.New(config.Source{
+		Type:       config.SourceTypeCUE,
+		Path:       filepath.Join(root, "config"),
+		CUECommand: command,
+		Sandbox: config.SandboxConfig{
+			AllowedBinaries: []string{command},
+			WorkDir:         filepath.Join(root, "bin"),
+		},
+	})
+
+	if _, err := r.Render(t.Context(), ""); !errors.Is(err, sandbox.ErrOutsideWorkDir) {
+		t.Fatalf("expected %v, got %v", sandbox.ErrOutsideWorkDir, err)
+	}
+}
@@ -0,0 +1,146 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	goyaml "gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/sandbox"
+)
+
+// Renderer runs an arbitrary generator, such as cdk8s synth --stdout, and
+// reads the objects from its standard output. The generator runs in the
+// sandbox of run.sandbox, extended with the env and timeout of the source.
+type Renderer struct {
+	source config.Source
+}
+
+func New(source config.Source) *Renderer {
+	return &Renderer{source: source}
+}
+
+func (r *Renderer) Render(ctx context.Context, path string) ([]unstructured.Unstructured, error) {
+	if len(r.source.Command) == 0 {
+		return nil, fmt.Errorf("a command is required by exec sources")
+	}
+
+	dir := r.source.Path
+	if dir == "" {
+		dir = path
+	}
+
+	command := r.source.Command[0]
+
+	// the command must be allowed by the policy like any other binary,
+	// sources are not trusted to extend the allowlist
+	policy := r.source.Sandbox
+	policy.Env = append(slices.Clone(policy.Env), r.source.Env...)
+	if r.source.Timeout > 0 {
+		policy.Timeout = r.source.Timeout
+	}
+
+	sb, err := sandbox.New(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := sb.Run(ctx, dir, command, r.source.Command[1:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s in %s: %w", command, dir, err)
+	}
+
+	objects, err := decode(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the output of %s: %w", command, err)
+	}
+
+	// objects are attributed to the directory the generator runs in, the
+	// lines of its output do not match the ones of the generator sources
+	for i := range objects {
+		annotations := objects[i].GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[linter.SourcePathAnnotation] = dir
+		objects[i].SetAnnotations(annotations)
+	}
+
+	progress.Notify(ctx, progress.Event{
+		Source:  config.SourceTypeExec.String(),
+		Path:    dir,
+		Objects: len(objects),
+	})
+
+	return objects, nil
+}
+
+// decode returns the objects of the YAML or JSON documents of the output.
+// Documents can be single objects, lists of objects, as a JSON array or a
+// List object, or a mix of both.
+func decode(data []byte) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+
+	dec := goyaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		if err := collect(doc, &objects); err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// collect appends the objects of a document: the items of sequences and of
+// List objects, and the mappings with an apiVersion and a kind
+func collect(doc interface{}, objects *[]unstructured.Unstructured) error {
+	switch v := doc.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if err := collect(item, objects); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if v["apiVersion"] == nil || v["kind"] == nil {
+			return nil
+		}
+
+		if items, ok := v["items"].([]interface{}); ok && v["kind"] == "List" {
+			return collect(items, objects)
+		}
+
+		// the JSON round trip converts the values to the types of
+		// unstructured objects, such as int64 numbers
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		var obj unstructured.Unstructured
+		if err := obj.UnmarshalJSON(data); err != nil {
+			return err
+		}
+
+		*objects = append(*objects, obj)
+	}
+
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/cancel"
 )
 
 type Renderer struct {
@@ -40,10 +42,18 @@ func (r *Renderer) Render(ctx context.Context, path string) ([]unstructured.Unst
 		},
 	})
 
-	objects, err := templateRenderer.Process(ctx)
+	objects, err := cancel.Run(ctx, func() ([]unstructured.Unstructured, error) {
+		return templateRenderer.Process(ctx)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to render go templates: %w", err)
 	}
 
+	progress.Notify(ctx, progress.Event{
+		Source:  config.SourceTypeGoTemplate.String(),
+		Path:    templatePath,
+		Objects: len(objects),
+	})
+
 	return objects, nil
 }
\ No newline at end of file
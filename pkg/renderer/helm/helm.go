@@ -3,11 +3,21 @@ package helm
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
-	"github.com/lburgazzoli/k8s-manifests-lib/pkg/renderer/helm"
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/strvals"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/credentials"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/cancel"
 )
 
 type Renderer struct {
@@ -24,37 +34,243 @@ func (r *Renderer) Render(ctx context.Context, path string) ([]unstructured.Unst
 		chartSource = path
 	}
 
-	values := make(map[string]any)
-	if r.source.Data != nil {
-		values = r.source.Data
+	if r.source.Repo != "" {
+		archive, err := r.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		chartSource = archive
+	} else if strings.HasPrefix(chartSource, registry.OCIScheme+"://") {
+		if r.source.Offline {
+			return nil, fmt.Errorf("helm chart %s cannot be pulled offline", chartSource)
+		}
+
+		dir, err := os.MkdirTemp("", "k8s-manifests-lint-chart-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to pull helm chart: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		archive, err := cancel.Run(ctx, func() (string, error) {
+			return r.pull(chartSource, dir)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		chartSource = archive
 	}
 
 	namespace := "default"
-	if ns, ok := values["namespace"].(string); ok {
+	if ns, ok := r.source.Data["namespace"].(string); ok {
 		namespace = ns
 	}
 
 	releaseName := "release"
-	if name, ok := values["releaseName"].(string); ok {
+	if name, ok := r.source.Data["releaseName"].(string); ok {
 		releaseName = name
 	}
 
-	helmRenderer, err := helm.New([]helm.Data{
-		{
-			ChartSource: chartSource,
-			ReleaseName: releaseName,
-			Namespace:   namespace,
-			Values:      values,
-		},
+	releases := r.source.Releases
+	if len(releases) == 0 {
+		releases = []config.Release{{Name: releaseName, Namespace: namespace}}
+	}
+
+	caps, err := r.capabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []unstructured.Unstructured
+
+	for _, release := range releases {
+		// every release gets its own values, as the set overrides differ and
+		// are applied in place
+		values, err := r.values()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, set := range append(slices.Clone(r.source.Set), release.Set...) {
+			if err := strvals.ParseInto(set, values); err != nil {
+				return nil, fmt.Errorf("failed to parse set %q: %w", set, err)
+			}
+		}
+
+		if release.Name == "" {
+			release.Name = releaseName
+		}
+		if release.Namespace == "" {
+			release.Namespace = namespace
+		}
+
+		rendered, err := cancel.Run(ctx, func() ([]unstructured.Unstructured, error) {
+			return render(chartSource, release, values, caps)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to render helm chart: %w", err)
+		}
+
+		objects = append(objects, rendered...)
+	}
+
+	progress.Notify(ctx, progress.Event{
+		Source:  config.SourceTypeHelm.String(),
+		Path:    chartSource,
+		Objects: len(objects),
 	})
+
+	return objects, nil
+}
+
+// Inputs returns the values files and, for local charts, the chart the
+// objects are rendered from. Charts downloaded from a repository are only
+// cached for an exact version, and the ones pulled from an OCI registry for
+// a digest, as anything else may resolve to another chart.
+func (r *Renderer) Inputs(path string) ([]string, bool) {
+	chartSource := r.source.Chart
+	if chartSource == "" {
+		chartSource = path
+	}
+
+	inputs := slices.Clone(r.source.Values)
+
+	switch {
+	case r.source.Repo != "":
+		if _, err := semver.StrictNewVersion(strings.TrimPrefix(r.source.Version, "v")); err != nil {
+			return nil, false
+		}
+	case strings.HasPrefix(chartSource, registry.OCIScheme+"://"):
+		if !strings.Contains(chartSource, "@sha256:") {
+			return nil, false
+		}
+	default:
+		inputs = append(inputs, chartSource)
+	}
+
+	return inputs, true
+}
+
+// pull downloads the chart from an OCI registry into dir and returns the
+// path of the chart archive. The source credentials are used when set,
+// otherwise the Helm and Docker registry configuration applies.
+func (r *Renderer) pull(ref string, dir string) (string, error) {
+	var opts []registry.ClientOption
+
+	if r.source.Credentials != nil {
+		basic, err := credentials.Resolve(*r.source.Credentials)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve credentials for %s: %w", ref, err)
+		}
+
+		opts = append(opts, registry.ClientOptBasicAuth(basic.Username, basic.Password))
+	}
+
+	client, err := registry.NewClient(opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create helm renderer: %w", err)
+		return "", fmt.Errorf("failed to create registry client: %w", err)
 	}
 
-	objects, err := helmRenderer.Process(ctx)
+	resolved, err := r.resolve(client, strings.TrimPrefix(ref, registry.OCIScheme+"://"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to render helm chart: %w", err)
+		return "", fmt.Errorf("failed to pull helm chart %s: %s", ref, credentials.Redact(err.Error()))
 	}
 
-	return objects, nil
-}
\ No newline at end of file
+	result, err := client.Pull(resolved, registry.PullOptWithChart(true))
+	if err != nil {
+		return "", fmt.Errorf("failed to pull helm chart %s: %s", ref, credentials.Redact(err.Error()))
+	}
+
+	archive := filepath.Join(dir, "chart.tgz")
+	if err := os.WriteFile(archive, result.Chart.Data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to pull helm chart %s: %w", ref, err)
+	}
+
+	return archive, nil
+}
+
+// resolve returns the reference to pull: references with a tag or a digest
+// are used as they are, otherwise the tag is the source version or the
+// latest one matching it, the way helm pull --version does
+func (r *Renderer) resolve(client *registry.Client, ref string) (string, error) {
+	if strings.Contains(ref, "@") {
+		return ref, nil
+	}
+
+	name, tag := ref, ""
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		name, tag = ref[:i], ref[i+1:]
+	}
+
+	version := r.source.Version
+
+	if tag != "" {
+		if version != "" && version != tag {
+			return "", fmt.Errorf("the chart reference tag %s does not match version %s", tag, version)
+		}
+		return ref, nil
+	}
+
+	// OCI tags cannot hold a +, helm publishes build metadata with a _
+	if _, err := semver.StrictNewVersion(version); err == nil {
+		return name + ":" + strings.ReplaceAll(version, "+", "_"), nil
+	}
+
+	tags, err := client.Tags(name)
+	if err != nil {
+		return "", err
+	}
+
+	tag, err = registry.GetTagMatchingVersionOrConstraint(tags, version)
+	if err != nil {
+		return "", err
+	}
+
+	return name + ":" + tag, nil
+}
+
+// values merges the values files in order and the inline data on top of
+// them, the way helm template -f does
+func (r *Renderer) values() (map[string]any, error) {
+	values := make(map[string]any)
+
+	for _, file := range r.source.Values {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file: %w", err)
+		}
+
+		var fileValues map[string]any
+		if err := yaml.Unmarshal(data, &fileValues); err != nil {
+			return nil, fmt.Errorf("failed to parse values file %s: %w", file, err)
+		}
+
+		merge(values, fileValues)
+	}
+
+	merge(values, r.source.Data)
+
+	return values, nil
+}
+
+// merge copies src into dst, maps are merged recursively and any other
+// value of src replaces the one of dst. Maps of src are copied, so that dst
+// can be modified without affecting src.
+func merge(dst map[string]any, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		if !srcIsMap {
+			dst[k] = v
+			continue
+		}
+
+		dstMap, dstIsMap := dst[k].(map[string]any)
+		if !dstIsMap {
+			dstMap = make(map[string]any, len(srcMap))
+			dst[k] = dstMap
+		}
+
+		merge(dstMap, srcMap)
+	}
+}
@@ -0,0 +1,136 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lburgazzoli/k8s-manifests-lib/pkg/util"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// capabilities returns the capabilities the chart templates see as
+// .Capabilities: the Helm defaults, with the source kube-version and the
+// source api-versions added to the default ones, the way helm template
+// --kube-version and --api-versions do
+func (r *Renderer) capabilities() (*chartutil.Capabilities, error) {
+	caps := chartutil.DefaultCapabilities.Copy()
+
+	if r.source.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(r.source.KubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube-version %q: %w", r.source.KubeVersion, err)
+		}
+
+		caps.KubeVersion = *kubeVersion
+	}
+
+	if len(r.source.APIVersions) > 0 {
+		caps.APIVersions = append(caps.APIVersions, r.source.APIVersions...)
+	}
+
+	return caps, nil
+}
+
+// render renders the chart as the release, CRDs first and then the
+// templates sorted by name, so that the objects are always in the same order
+func render(chartSource string, release config.Release, values map[string]any, caps *chartutil.Capabilities) ([]unstructured.Unstructured, error) {
+	chart, err := loader.Load(chartSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+
+	if constraint := chart.Metadata.KubeVersion; constraint != "" {
+		if !chartutil.IsCompatibleRange(constraint, caps.KubeVersion.String()) {
+			return nil, fmt.Errorf("chart %s requires kubeVersion %s, which is incompatible with Kubernetes %s",
+				chart.Name(), constraint, caps.KubeVersion.String())
+		}
+	}
+
+	if err := chartutil.ProcessDependencies(chart, values); err != nil {
+		return nil, fmt.Errorf("failed to process dependencies: %w", err)
+	}
+
+	renderValues, err := chartutil.ToRenderValues(chart, values, chartutil.ReleaseOptions{
+		Name:      release.Name,
+		Namespace: release.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}, caps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare render values: %w", err)
+	}
+
+	files, err := engine.Render(chart, renderValues)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := k8syaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
+
+	var result []unstructured.Unstructured
+
+	for _, crd := range chart.CRDObjects() {
+		objects, err := util.DecodeYAML(decoder, crd.File.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode CRD %s: %w", crd.Name, err)
+		}
+
+		result = append(result, attribute(objects, templateFile(chartSource, chart.Name(), crd.Filename))...)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		objects, err := util.DecodeYAML(decoder, []byte(files[name]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+
+		result = append(result, attribute(objects, templateFile(chartSource, chart.Name(), name))...)
+	}
+
+	return result, nil
+}
+
+// templateFile returns the file of a template, as named by Helm in the
+// # Source comments, within the chart directory, or the Helm name itself
+// for packaged charts
+func templateFile(chartSource string, chartName string, name string) string {
+	if info, err := os.Stat(chartSource); err != nil || !info.IsDir() {
+		return name
+	}
+
+	return filepath.Join(chartSource, filepath.FromSlash(strings.TrimPrefix(name, chartName+"/")))
+}
+
+// attribute records the template the objects have been rendered from, the
+// objects cannot be positioned in it so issues are attributed to the file
+// only
+func attribute(objects []unstructured.Unstructured, file string) []unstructured.Unstructured {
+	for i := range objects {
+		annotations := objects[i].GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[linter.SourceFileAnnotation] = file
+		objects[i].SetAnnotations(annotations)
+	}
+
+	return objects
+}
@@ -0,0 +1,190 @@
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/repo"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/credentials"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/cachedir"
+)
+
+// fetch resolves the chart version matching the source version in the index
+// of the source repository, the way helm template repo/chart --version does,
+// and returns the path of the chart archive. Archives are cached, so that a
+// chart version is only downloaded once. Offline, the cached index and
+// archives are used without downloading anything.
+func (r *Renderer) fetch(ctx context.Context) (string, error) {
+	repoURL := strings.TrimSuffix(r.source.Repo, "/")
+
+	sum := sha256.Sum256([]byte(repoURL))
+
+	cacheDir, err := cachedir.Dir(r.source.CacheDir, "charts", hex.EncodeToString(sum[:8]))
+	if err != nil {
+		return "", err
+	}
+
+	indexFile := filepath.Join(cacheDir, "index.yaml")
+
+	if r.source.Offline {
+		return r.cached(repoURL, indexFile)
+	}
+
+	var basic *credentials.Basic
+	if r.source.Credentials != nil {
+		basic, err = credentials.Resolve(*r.source.Credentials)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve credentials for %s: %w", repoURL, err)
+		}
+	}
+
+	index, err := download(ctx, repoURL+"/index.yaml", basic)
+	if err != nil {
+		return "", fmt.Errorf("failed to download the index of %s: %w", repoURL, err)
+	}
+
+	if err := writeAtomic(indexFile, index); err != nil {
+		return "", fmt.Errorf("failed to cache the index of %s: %w", repoURL, err)
+	}
+
+	idx, err := repo.LoadIndexFile(indexFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load the index of %s: %w", repoURL, err)
+	}
+
+	cv, err := idx.Get(r.source.Chart, r.source.Version)
+	if err != nil {
+		return "", fmt.Errorf("chart %s version %q not found in %s: %w", r.source.Chart, r.source.Version, repoURL, err)
+	}
+
+	if len(cv.URLs) == 0 {
+		return "", fmt.Errorf("chart %s version %s of %s has no download URL", cv.Name, cv.Version, repoURL)
+	}
+
+	archive := filepath.Join(cacheDir, fmt.Sprintf("%s-%s.tgz", cv.Name, cv.Version))
+
+	if data, err := os.ReadFile(archive); err == nil && verify(data, cv.Digest) == nil {
+		return archive, nil
+	}
+
+	chartURL, err := repo.ResolveReferenceURL(repoURL, cv.URLs[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid URL of chart %s version %s: %w", cv.Name, cv.Version, err)
+	}
+
+	// credentials are only sent to the repository host, not to the hosts
+	// charts may be served from, the way helm does unless --pass-credentials
+	// is set
+	if credentials.Host(chartURL) != credentials.Host(repoURL) {
+		basic = nil
+	}
+
+	data, err := download(ctx, chartURL, basic)
+	if err != nil {
+		return "", fmt.Errorf("failed to download chart %s version %s: %w", cv.Name, cv.Version, err)
+	}
+
+	if err := verify(data, cv.Digest); err != nil {
+		return "", fmt.Errorf("failed to download chart %s version %s: %w", cv.Name, cv.Version, err)
+	}
+
+	if err := writeAtomic(archive, data); err != nil {
+		return "", fmt.Errorf("failed to cache chart %s version %s: %w", cv.Name, cv.Version, err)
+	}
+
+	return archive, nil
+}
+
+// cached resolves the chart version from the cached index of the repository
+// and returns the path of the cached archive
+func (r *Renderer) cached(repoURL string, indexFile string) (string, error) {
+	idx, err := repo.LoadIndexFile(indexFile)
+	if err != nil {
+		return "", fmt.Errorf("the index of %s is not cached, it cannot be downloaded offline: %w", repoURL, err)
+	}
+
+	cv, err := idx.Get(r.source.Chart, r.source.Version)
+	if err != nil {
+		return "", fmt.Errorf("chart %s version %q not found in the cached index of %s: %w", r.source.Chart, r.source.Version, repoURL, err)
+	}
+
+	archive := filepath.Join(filepath.Dir(indexFile), fmt.Sprintf("%s-%s.tgz", cv.Name, cv.Version))
+
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		return "", fmt.Errorf("chart %s version %s is not cached, it cannot be downloaded offline", cv.Name, cv.Version)
+	}
+
+	if err := verify(data, cv.Digest); err != nil {
+		return "", fmt.Errorf("cached chart %s version %s: %w", cv.Name, cv.Version, err)
+	}
+
+	return archive, nil
+}
+
+func download(ctx context.Context, target string, basic *credentials.Basic) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if basic != nil {
+		req.SetBasicAuth(basic.Username, basic.Password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// verify checks the data against the sha256 digest of the index, charts
+// without a digest are accepted as they are
+func verify(data []byte, digest string) error {
+	if digest == "" {
+		return nil
+	}
+
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != strings.TrimPrefix(digest, "sha256:") {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", digest, actual)
+	}
+
+	return nil
+}
+
+// writeAtomic writes the file through a temporary file renamed into place,
+// so that concurrent runs never load a partial chart
+func writeAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
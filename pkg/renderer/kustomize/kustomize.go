@@ -3,13 +3,24 @@ package kustomize
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"sort"
 
-	"github.com/lburgazzoli/k8s-manifests-lib/pkg/renderer/kustomize"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kustomizetypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/cancel"
 )
 
+// defaultHelmCommand is the helm binary kustomize inflates charts with
+const defaultHelmCommand = "helm"
+
 type Renderer struct {
 	source config.Source
 }
@@ -19,17 +30,153 @@ func New(source config.Source) *Renderer {
 }
 
 func (r *Renderer) Render(ctx context.Context, path string) ([]unstructured.Unstructured, error) {
+	targets := r.targets(path)
+
+	remotes := newRemotes(ctx, r.source)
+	defer remotes.close()
+
+	fSys := fileSystem{FileSystem: filesys.MakeFsOnDisk(), remotes: remotes}
+	kustomizer := krusty.MakeKustomizer(r.options())
+
+	var objects []unstructured.Unstructured
+
+	for _, target := range targets {
+		built, err := cancel.Run(ctx, func() ([]unstructured.Unstructured, error) {
+			return build(kustomizer, fSys, target)
+		})
+		if err != nil {
+			if failure := remotes.failure(); failure != nil {
+				err = failure
+			}
+			return nil, fmt.Errorf("failed to render kustomize %s: %w", target, err)
+		}
+
+		// objects are attributed to the kustomization producing them, so that
+		// the issues of an overlay can be told apart from the ones of another
+		// and the fixes can be written as patches of the overlay
+		for i := range built {
+			annotations := built[i].GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[linter.SourcePathAnnotation] = target
+			built[i].SetAnnotations(annotations)
+		}
+
+		progress.Notify(ctx, progress.Event{
+			Source:  config.SourceTypeKustomize.String(),
+			Path:    target,
+			Objects: len(built),
+		})
+
+		objects = append(objects, built...)
+	}
+
+	return objects, nil
+}
+
+// targets returns the kustomizations built: every overlay on its own, the
+// source path is only built when there are no overlays
+func (r *Renderer) targets(path string) []string {
 	basePath := r.source.Path
 	if basePath == "" {
 		basePath = path
 	}
 
-	kustomizeRenderer := kustomize.New(basePath)
+	if len(r.source.Overlays) == 0 {
+		return []string{basePath}
+	}
+
+	targets := make([]string, 0, len(r.source.Overlays))
+	for _, overlay := range r.source.Overlays {
+		if !filepath.IsAbs(overlay) {
+			overlay = filepath.Join(basePath, overlay)
+		}
+		targets = append(targets, overlay)
+	}
+
+	return targets
+}
+
+// Inputs returns the directories of the kustomizations built and of the
+// local kustomizations they reference. The objects are only cached when
+// every remote base is pinned to a commit.
+func (r *Renderer) Inputs(path string) ([]string, bool) {
+	remotes := make(map[string][]string)
+	visited := make(map[string]bool)
+
+	for _, target := range r.targets(path) {
+		if err := scan(target, remotes, visited); err != nil {
+			return nil, false
+		}
+	}
+
+	for _, refs := range remotes {
+		if !pinned(refs) {
+			return nil, false
+		}
+	}
+
+	inputs := make([]string, 0, len(visited))
+	for dir := range visited {
+		inputs = append(inputs, dir)
+	}
+	sort.Strings(inputs)
+
+	return inputs, true
+}
+
+// options returns the kustomize build options of the source, resources keep
+// the order of the kustomization files and plugins other than the builtin
+// ones are disabled unless enabled by the source
+func (r *Renderer) options() *krusty.Options {
+	opts := krusty.MakeDefaultOptions()
+
+	if r.source.LoadRestrictor == config.LoadRestrictorNone {
+		opts.LoadRestrictions = kustomizetypes.LoadRestrictionsNone
+	}
+
+	if r.source.EnablePlugins {
+		opts.PluginConfig = kustomizetypes.MakePluginConfig(
+			kustomizetypes.PluginRestrictionsNone,
+			kustomizetypes.BploUseStaticallyLinked)
+	}
 
-	objects, err := kustomizeRenderer.Process(ctx)
+	if r.source.EnableHelm {
+		opts.PluginConfig.HelmConfig = kustomizetypes.HelmConfig{
+			Enabled:     true,
+			Command:     r.source.HelmCommand,
+			KubeVersion: r.source.KubeVersion,
+			ApiVersions: r.source.APIVersions,
+		}
+
+		if opts.PluginConfig.HelmConfig.Command == "" {
+			opts.PluginConfig.HelmConfig.Command = defaultHelmCommand
+		}
+	}
+
+	return opts
+}
+
+func build(kustomizer *krusty.Kustomizer, fSys filesys.FileSystem, target string) ([]unstructured.Unstructured, error) {
+	resMap, err := kustomizer.Run(fSys, target)
 	if err != nil {
-		return nil, fmt.Errorf("failed to render kustomize: %w", err)
+		return nil, err
+	}
+
+	resources := resMap.Resources()
+
+	objects := make([]unstructured.Unstructured, len(resources))
+	for i, res := range resources {
+		m, err := res.Map()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert resource to map: %w", err)
+		}
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &objects[i]); err != nil {
+			return nil, fmt.Errorf("failed to convert map to unstructured: %w", err)
+		}
 	}
 
 	return objects, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,143 @@
+package kustomize_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/kustomize"
+)
+
+const remoteBase = "https://example.com/org/repo"
+
+func write(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+// seed creates the cached mirror of remoteBase, holding a base with a
+// ConfigMap
+func seed(t *testing.T, cacheDir string) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	write(t, filepath.Join(repo, "base", "kustomization.yaml"), "resources: [configmap.yaml]\n")
+	write(t, filepath.Join(repo, "base", "configmap.yaml"), `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`)
+
+	git(t, repo, "init", "--quiet")
+	git(t, repo, "add", ".")
+	git(t, repo, "commit", "--quiet", "-m", "base")
+
+	sum := sha256.Sum256([]byte(remoteBase))
+	mirror := filepath.Join(cacheDir, "kustomize", hex.EncodeToString(sum[:8])+".git")
+
+	git(t, repo, "clone", "--mirror", "--quiet", repo, mirror)
+	git(t, mirror, "config", "uploadpack.allowAnySHA1InWant", "true")
+}
+
+// TestRenderOffline renders a remote base from the cache, other requests of
+// the process must not be refused meanwhile
+func TestRenderOffline(t *testing.T) {
+	cacheDir := t.TempDir()
+	seed(t, cacheDir)
+
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "kustomization.yaml"), `resources:
+  - `+remoteBase+`//base
+namePrefix: dev-
+`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	done := make(chan error)
+	go func() {
+		for range 20 {
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				done <- err
+				return
+			}
+			resp.Body.Close()
+		}
+		done <- nil
+	}()
+
+	r := kustomize.New(config.Source{
+		Type:     config.SourceTypeKustomize,
+		Path:     dir,
+		CacheDir: cacheDir,
+		Offline:  true,
+	})
+
+	objects, err := r.Render(t.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("request refused while rendering offline: %v", err)
+	}
+
+	if len(objects) != 1 || objects[0].GetName() != "dev-settings" {
+		t.Fatalf("expected the dev-settings ConfigMap, got %d object(s)", len(objects))
+	}
+}
+
+func TestRenderOfflineNotCached(t *testing.T) {
+	for name, resource := range map[string]string{
+		"remote base": "https://example.com/org/other//base",
+		"remote file": "https://example.com/org/other/configmap.yaml",
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			write(t, filepath.Join(dir, "kustomization.yaml"), "resources: ["+resource+"]\n")
+
+			r := kustomize.New(config.Source{
+				Type:     config.SourceTypeKustomize,
+				Path:     dir,
+				CacheDir: t.TempDir(),
+				Offline:  true,
+			})
+
+			if _, err := r.Render(t.Context(), ""); err == nil || !strings.Contains(err.Error(), "offline") {
+				t.Fatalf("expected an offline error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,522 @@
+package kustomize
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/cachedir"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/vcs"
+)
+
+var (
+	usernameRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*@`)
+	commitRe   = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// remote is a remote base or component, as a git repository, the ref
+// kustomize checks out and the path of the kustomization in the repository
+type remote struct {
+	clone string
+	ref   string
+	path  string
+}
+
+// remotes checks out the remote bases and components of the kustomizations
+// built from bare mirrors in the cache directory, so that the repositories
+// are only cloned once. Mirrors are updated unless the source is offline, in
+// which case nothing is fetched from the network. The checkouts are removed
+// by close.
+type remotes struct {
+	ctx     context.Context
+	source  config.Source
+	mu      sync.Mutex
+	workDir string
+	// mirrors are the mirror directories by clone URL, updated are the
+	// mirrors cloned or fetched by this build
+	mirrors   map[string]string
+	updated   map[string]bool
+	checkouts map[remote]string
+	// err is the first error resolving a remote, kustomize takes the
+	// kustomization files it fails to read for missing ones
+	err error
+}
+
+func newRemotes(ctx context.Context, source config.Source) *remotes {
+	return &remotes{
+		ctx:       ctx,
+		source:    source,
+		mirrors:   make(map[string]string),
+		updated:   make(map[string]bool),
+		checkouts: make(map[remote]string),
+	}
+}
+
+// close removes the checkouts
+func (rs *remotes) close() error {
+	if rs.workDir == "" {
+		return nil
+	}
+	return os.RemoveAll(rs.workDir)
+}
+
+func (rs *remotes) fail(err error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.err == nil {
+		rs.err = err
+	}
+}
+
+// failure returns the first error resolving a remote
+func (rs *remotes) failure() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.err
+}
+
+// resolve returns the path, relative to dir, of the checkout of the remote
+// base or component referenced by the entry of the kustomization in dir, or
+// an empty string to leave the entry to kustomize: local paths and, online,
+// the repositories which cannot be mirrored, kustomize then clones them
+// itself and reports its own errors
+func (rs *remotes) resolve(dir string, entry string) (string, error) {
+	local := entry
+	if !filepath.IsAbs(local) {
+		local = filepath.Join(dir, entry)
+	}
+	if _, err := os.Stat(local); err == nil {
+		return "", nil
+	}
+
+	if isURL(entry) && isRemoteFile(entry) {
+		if rs.source.Offline {
+			return "", fmt.Errorf("remote file %s cannot be downloaded offline", entry)
+		}
+		return "", nil
+	}
+
+	rm, ok := parseRemote(entry)
+	if !ok {
+		return "", nil
+	}
+
+	checkout, err := rs.checkout(rm)
+	if err != nil {
+		if rs.source.Offline {
+			return "", err
+		}
+		return "", nil
+	}
+
+	return filepath.Rel(dir, filepath.Join(checkout, filepath.FromSlash(rm.path)))
+}
+
+// checkout returns the directory the ref of the remote is checked out in,
+// the way kustomize clones remote bases
+func (rs *remotes) checkout(rm remote) (string, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	key := remote{clone: rm.clone, ref: rm.ref}
+	if dir, ok := rs.checkouts[key]; ok {
+		return dir, nil
+	}
+
+	mirror, err := rs.mirror(rm.clone, rm.ref)
+	if err != nil {
+		return "", err
+	}
+
+	if rs.workDir == "" {
+		if rs.workDir, err = os.MkdirTemp("", "kustomize-"); err != nil {
+			return "", fmt.Errorf("failed to check out remote base %s: %w", rm.clone, err)
+		}
+	}
+
+	dir := filepath.Join(rs.workDir, strconv.Itoa(len(rs.checkouts)))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to check out remote base %s: %w", rm.clone, err)
+	}
+
+	ref := rm.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	// git only reads the local mirror, the submodules are fetched from
+	// their own remotes
+	git := &vcs.Git{Dir: dir, Env: []string{"GIT_ALLOW_PROTOCOL=file"}}
+
+	// relative submodule URLs are resolved against the origin remote
+	if err := git.Init(rs.ctx, rm.clone); err != nil {
+		return "", fmt.Errorf("failed to check out remote base %s: %w", rm.clone, err)
+	}
+	if err := git.Checkout(rs.ctx, "file://"+filepath.ToSlash(mirror), ref); err != nil {
+		return "", fmt.Errorf("failed to check out %s of remote base %s: %w", ref, rm.clone, err)
+	}
+
+	if !rs.source.Offline {
+		if err := (&vcs.Git{Dir: dir}).UpdateSubmodules(rs.ctx); err != nil {
+			return "", fmt.Errorf("failed to check out the submodules of remote base %s: %w", rm.clone, err)
+		}
+	}
+
+	rs.checkouts[key] = dir
+
+	return dir, nil
+}
+
+// mirror returns the mirror of the repository, creating or updating it
+// first. Mirrors are fetched at most once per build, and never for commits
+// which are already in the mirror.
+func (rs *remotes) mirror(clone string, ref string) (string, error) {
+	dir, ok := rs.mirrors[clone]
+	if !ok {
+		cacheDir, err := cachedir.Dir(rs.source.CacheDir, "kustomize")
+		if err != nil {
+			return "", err
+		}
+
+		sum := sha256.Sum256([]byte(clone))
+		dir = filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".git")
+
+		if _, err := os.Stat(dir); err != nil {
+			if rs.source.Offline {
+				return "", fmt.Errorf("remote base %s is not cached, it cannot be cloned offline", clone)
+			}
+
+			if err := cloneMirror(rs.ctx, clone, dir); err != nil {
+				return "", err
+			}
+			rs.updated[clone] = true
+		}
+
+		rs.mirrors[clone] = dir
+	}
+
+	if rs.source.Offline || rs.updated[clone] || pinned([]string{ref}) {
+		return dir, nil
+	}
+
+	if err := (&vcs.Git{Dir: dir}).Fetch(rs.ctx, "origin"); err != nil {
+		return "", fmt.Errorf("failed to update remote base %s: %w", clone, err)
+	}
+	rs.updated[clone] = true
+
+	return dir, nil
+}
+
+// cloneMirror clones the repository into a mirror in dir
+func cloneMirror(ctx context.Context, clone string, dir string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".mirror-")
+	if err != nil {
+		return fmt.Errorf("failed to clone remote base %s: %w", clone, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	mirror := filepath.Join(tmp, "repo.git")
+	if err := (&vcs.Git{Dir: mirror}).Mirror(ctx, clone); err != nil {
+		return fmt.Errorf("failed to clone remote base %s: %w", clone, err)
+	}
+
+	// the ref checked out may be a commit
+	if err := (&vcs.Git{Dir: mirror}).SetConfig(ctx, "uploadpack.allowAnySHA1InWant", "true"); err != nil {
+		return fmt.Errorf("failed to clone remote base %s: %w", clone, err)
+	}
+
+	// the mirror is cloned aside and renamed into place, so that concurrent
+	// runs never use a partial clone; when another run won the race its
+	// mirror is used
+	if err := os.Rename(mirror, dir); err != nil {
+		if _, statErr := os.Stat(dir); statErr != nil {
+			return fmt.Errorf("failed to cache remote base %s: %w", clone, err)
+		}
+	}
+
+	return nil
+}
+
+// pinned reports whether every ref is a commit, which never changes, so
+// that the mirror does not need to be updated
+func pinned(refs []string) bool {
+	for _, ref := range refs {
+		if !commitRe.MatchString(ref) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fileSystem is the file system kustomize builds from: the remote bases and
+// components of the kustomizations it reads are replaced by their checkouts,
+// so that kustomize neither clones them nor needs a git configuration
+// redirecting them to the mirrors
+type fileSystem struct {
+	filesys.FileSystem
+	remotes *remotes
+}
+
+func (fs fileSystem) ReadFile(path string) ([]byte, error) {
+	data, err := fs.FileSystem.ReadFile(path)
+	if err != nil || !isKustomization(path) {
+		return data, err
+	}
+
+	return fs.remotes.rewrite(filepath.Dir(path), data)
+}
+
+// rewrite replaces the remote resources, components and bases of the
+// kustomization in dir by their checkouts. Kustomizations which cannot be
+// parsed are returned as they are for kustomize to report.
+func (rs *remotes) rewrite(dir string, data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil
+	}
+
+	changed := false
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		switch root.Content[i].Value {
+		case "resources", "components", "bases":
+		default:
+			continue
+		}
+
+		if root.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, entry := range root.Content[i+1].Content {
+			if entry.Kind != yaml.ScalarNode {
+				continue
+			}
+
+			local, err := rs.resolve(dir, entry.Value)
+			if err != nil {
+				rs.fail(err)
+				return nil, err
+			}
+
+			if local != "" {
+				entry.Value = filepath.ToSlash(local)
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return data, nil
+	}
+
+	return yaml.Marshal(&doc)
+}
+
+// isKustomization reports whether the file is a kustomization file
+func isKustomization(path string) bool {
+	name := filepath.Base(path)
+	for _, recognized := range konfig.RecognizedKustomizationFileNames() {
+		if name == recognized {
+			return true
+		}
+	}
+	return false
+}
+
+// isURL reports whether the target is an HTTP URL
+func isURL(target string) bool {
+	lower := strings.ToLower(target)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// scan collects the remote resources and components of the kustomization in
+// dir and of the local kustomizations it references, keyed by clone URL.
+// The local directories and files referenced are recorded in visited.
+func scan(dir string, remotes map[string][]string, visited map[string]bool) error {
+	dir = filepath.Clean(dir)
+	if visited[dir] {
+		return nil
+	}
+	visited[dir] = true
+
+	var data []byte
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			data = content
+			break
+		}
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	var k struct {
+		Resources  []string `yaml:"resources"`
+		Components []string `yaml:"components"`
+		Bases      []string `yaml:"bases"`
+	}
+	if err := yaml.Unmarshal(data, &k); err != nil {
+		return fmt.Errorf("failed to parse the kustomization of %s: %w", dir, err)
+	}
+
+	for _, entry := range append(append(k.Resources, k.Components...), k.Bases...) {
+		local := entry
+		if !filepath.IsAbs(local) {
+			local = filepath.Join(dir, entry)
+		}
+
+		if info, err := os.Stat(local); err == nil {
+			if info.IsDir() {
+				if err := scan(local, remotes, visited); err != nil {
+					return err
+				}
+			} else {
+				visited[filepath.Clean(local)] = true
+			}
+			continue
+		}
+
+		if rm, ok := parseRemote(entry); ok {
+			remotes[rm.clone] = append(remotes[rm.clone], rm.ref)
+		}
+	}
+
+	return nil
+}
+
+// parseRemote parses a kustomize remote target, such as
+// https://github.com/org/repo//path?ref=v1, into the clone URL and the ref
+// kustomize uses, following the rules of kustomize
+func parseRemote(target string) (remote, bool) {
+	var rm remote
+
+	target, query, _ := strings.Cut(target, "?")
+
+	if isRemoteFile(target) {
+		return rm, false
+	}
+	for _, param := range strings.Split(query, "&") {
+		key, value, _ := strings.Cut(param, "=")
+		switch {
+		case key == "ref":
+			rm.ref = value
+		case key == "version" && rm.ref == "":
+			rm.ref = value
+		}
+	}
+
+	if prefix := "git::"; len(target) > len(prefix) && strings.EqualFold(target[:len(prefix)], prefix) {
+		target = target[len(prefix):]
+	}
+
+	scheme := ""
+	for _, s := range []string{"ssh://", "https://", "http://"} {
+		if len(target) > len(s) && strings.EqualFold(target[:len(s)], s) {
+			scheme, target = s, target[len(s):]
+			break
+		}
+	}
+
+	username := usernameRe.FindString(target)
+	target = target[len(username):]
+
+	lower := strings.ToLower(target)
+	github := strings.HasPrefix(lower, "github.com/") || strings.HasPrefix(lower, "github.com:")
+	scp := scheme == "" && (username != "" || github)
+
+	if scheme == "" && !scp {
+		return rm, false
+	}
+
+	sep := strings.Index(target, "/")
+	if scp {
+		if colon := strings.Index(target, ":"); sep == -1 || colon > 0 && colon < sep {
+			sep = colon
+		}
+	}
+	if sep < 0 {
+		return rm, false
+	}
+
+	host, rest := target[:sep+1], target[sep+1:]
+	if github {
+		if strings.HasPrefix(scheme, "ssh://") || username != "" {
+			scheme, host = "", "github.com:"
+		} else {
+			scheme, username, host = "https://", "", "github.com/"
+		}
+	}
+
+	var repoPath, kustPath string
+	switch {
+	case strings.Contains(rest, "_git/"):
+		i := strings.Index(rest, "_git/") + len("_git/")
+		segments := strings.Split(rest[i:], "/")
+		repoPath, kustPath = rest[:i]+segments[0], strings.Join(segments[1:], "/")
+	case strings.Contains(rest, "//"):
+		i := strings.Index(rest, "//")
+		repoPath, kustPath = rest[:i], rest[i+len("//"):]
+	case strings.Contains(rest, ".git"):
+		i := strings.Index(rest, ".git") + len(".git")
+		repoPath, kustPath = rest[:i], rest[i:]
+	default:
+		segments := strings.Split(rest, "/")
+		if len(segments) < 2 {
+			return rm, false
+		}
+		repoPath, kustPath = strings.Join(segments[:2], "/"), strings.Join(segments[2:], "/")
+	}
+
+	if repoPath == "" {
+		return rm, false
+	}
+
+	// kustomize refuses paths leading out of the repository
+	kustPath = path.Clean(strings.TrimPrefix(kustPath, "/"))
+	if kustPath == ".." || strings.HasPrefix(kustPath, "../") {
+		return rm, false
+	}
+
+	rm.clone = scheme + username + host + repoPath
+	rm.path = kustPath
+
+	return rm, true
+}
+
+// isRemoteFile reports whether the target is a file kustomize downloads
+// rather than a repository it clones: a manifest without a repository root
+// marker
+func isRemoteFile(target string) bool {
+	switch path.Ext(target) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return false
+	}
+
+	_, rest, found := strings.Cut(target, "://")
+	if !found {
+		rest = target
+	}
+
+	return !strings.Contains(rest, "//") && !strings.Contains(rest, ".git")
+}
@@ -0,0 +1,54 @@
+package renderer
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// ExpandLists replaces the v1 List objects rendered by a source, such as a
+// Helm template wrapping its objects in a List, with their items. The items
+// inherit the internal annotations of the List, so that they are attributed
+// to the file it has been rendered from.
+func ExpandLists(objects []unstructured.Unstructured) []unstructured.Unstructured {
+	var result []unstructured.Unstructured
+
+	for _, obj := range objects {
+		if obj.GetAPIVersion() != "v1" || obj.GetKind() != "List" {
+			result = append(result, obj)
+			continue
+		}
+
+		items, _, _ := unstructured.NestedSlice(obj.Object, "items")
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			expanded := unstructured.Unstructured{Object: m}
+			if expanded.GetKind() == "" {
+				continue
+			}
+
+			annotations := expanded.GetAnnotations()
+			for key, value := range obj.GetAnnotations() {
+				// the position of the List is not the one of its items
+				if !strings.HasPrefix(key, linter.InternalAnnotationPrefix) || key == linter.SourceLineAnnotation || key == linter.SourceFieldsAnnotation {
+					continue
+				}
+				if annotations == nil {
+					annotations = make(map[string]string)
+				}
+				annotations[key] = value
+			}
+			expanded.SetAnnotations(annotations)
+
+			result = append(result, expanded)
+		}
+	}
+
+	return result
+}
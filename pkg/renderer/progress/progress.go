@@ -0,0 +1,39 @@
+package progress
+
+import (
+	"context"
+)
+
+// Event reports the progress of a renderer, emitted for every file loaded by
+// file based renderers and once per source by the others
+type Event struct {
+	// Source is the type of the source being rendered
+	Source string
+	// Path is the path of the source being rendered
+	Path string
+	// File is the file just loaded, empty for renderers processing a source
+	// as a whole
+	File string
+	// Objects is the number of objects rendered from File, or from the whole
+	// source when File is empty
+	Objects int
+}
+
+// Func is called by renderers as they make progress, it is called from the
+// rendering goroutine and must not block
+type Func func(Event)
+
+type contextKey struct{}
+
+// WithFunc returns a context carrying the callback renderers notify progress
+// to
+func WithFunc(ctx context.Context, fn Func) context.Context {
+	return context.WithValue(ctx, contextKey{}, fn)
+}
+
+// Notify calls the callback carried by the context, if any
+func Notify(ctx context.Context, event Event) {
+	if fn, ok := ctx.Value(contextKey{}).(Func); ok && fn != nil {
+		fn(event)
+	}
+}
@@ -3,31 +3,151 @@ package renderer
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/cluster"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/cue"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/exec"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/gotemplate"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/helm"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/kustomize"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/url"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/yaml"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/ytt"
 )
 
+// Renderer renders the objects of a source. Renderers return as soon as the
+// context is done, and notify the progress.Func carried by the context as
+// they load files.
 type Renderer interface {
 	Render(ctx context.Context, path string) ([]unstructured.Unstructured, error)
 }
 
-func NewFromSource(source config.Source) (Renderer, error) {
-	switch source.Type {
-	case config.SourceTypeYAML, "":
+// IssueReporter is implemented by renderers reporting problems, such as
+// skipped files, which do not prevent rendering
+type IssueReporter interface {
+	Issues() []linter.Issue
+}
+
+// Cacheable is implemented by renderers whose objects only depend on the
+// source configuration and on the content of their inputs, so that they can
+// be cached across runs
+type Cacheable interface {
+	// Inputs returns the files and directories the objects of path are
+	// rendered from, false when the objects cannot be cached, such as a
+	// chart resolved from a version range
+	Inputs(path string) ([]string, bool)
+}
+
+// Factory creates a Renderer for the given source
+type Factory func(source config.Source) (Renderer, error)
+
+var (
+	registry = &Registry{
+		factories: make(map[config.SourceType]Factory),
+	}
+)
+
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[config.SourceType]Factory
+}
+
+func init() {
+	Register(config.SourceTypeYAML, func(source config.Source) (Renderer, error) {
 		return yaml.New(source), nil
-	case config.SourceTypeHelm:
+	})
+	Register(config.SourceTypeHelm, func(source config.Source) (Renderer, error) {
 		return helm.New(source), nil
-	case config.SourceTypeKustomize:
+	})
+	Register(config.SourceTypeKustomize, func(source config.Source) (Renderer, error) {
 		return kustomize.New(source), nil
-	case config.SourceTypeGoTemplate, config.SourceTypeTemplate:
+	})
+	Register(config.SourceTypeGoTemplate, func(source config.Source) (Renderer, error) {
+		return gotemplate.New(source), nil
+	})
+	Register(config.SourceTypeTemplate, func(source config.Source) (Renderer, error) {
 		return gotemplate.New(source), nil
-	default:
-		return nil, fmt.Errorf("unsupported source type: %s", source.Type)
+	})
+	Register(config.SourceTypeURL, func(source config.Source) (Renderer, error) {
+		return url.New(source), nil
+	})
+	Register(config.SourceTypeYtt, func(source config.Source) (Renderer, error) {
+		return ytt.New(source), nil
+	})
+	Register(config.SourceTypeCUE, func(source config.Source) (Renderer, error) {
+		return cue.New(source), nil
+	})
+	Register(config.SourceTypeExec, func(source config.Source) (Renderer, error) {
+		return exec.New(source), nil
+	})
+	Register(config.SourceTypeCluster, func(source config.Source) (Renderer, error) {
+		return cluster.New(source), nil
+	})
+}
+
+// Register registers a renderer factory for the given source type
+func Register(sourceType config.SourceType, factory Factory) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.factories[sourceType] = factory
+}
+
+// Types returns the sorted list of registered source types
+func Types() []config.SourceType {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	types := make([]config.SourceType, 0, len(registry.factories))
+	for t := range registry.factories {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+	return types
+}
+
+// Validate checks that every source refers to a registered source type
+func Validate(sources []config.Source) error {
+	for i, source := range sources {
+		if _, err := lookup(source.Type); err != nil {
+			return fmt.Errorf("invalid source at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func NewFromSource(source config.Source) (Renderer, error) {
+	factory, err := lookup(source.Type)
+	if err != nil {
+		return nil, err
 	}
-}
\ No newline at end of file
+	return factory(source)
+}
+
+func lookup(sourceType config.SourceType) (Factory, error) {
+	if sourceType == "" {
+		sourceType = config.SourceTypeYAML
+	}
+
+	registry.mu.RLock()
+	factory, ok := registry.factories[sourceType]
+	registry.mu.RUnlock()
+
+	if !ok {
+		supported := make([]string, 0)
+		for _, t := range Types() {
+			supported = append(supported, t.String())
+		}
+		return nil, fmt.Errorf("unsupported source type %q (supported: %s)", sourceType, strings.Join(supported, ", "))
+	}
+
+	return factory, nil
+}
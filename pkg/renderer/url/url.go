@@ -0,0 +1,254 @@
+package url
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/credentials"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/yaml"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/cachedir"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/envsubst"
+)
+
+// DefaultTimeout bounds the download when the source does not set a timeout
+const DefaultTimeout = time.Minute
+
+// maxRedirects is the number of redirects followed, as by http.DefaultClient
+const maxRedirects = 10
+
+// digestRe matches hex encoded sha256 digests, which name the cached files
+var digestRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Renderer fetches the manifests published at a URL, such as the
+// install.yaml of an operator release, so that they can be reviewed before
+// being applied
+type Renderer struct {
+	source config.Source
+}
+
+func New(source config.Source) *Renderer {
+	return &Renderer{source: source}
+}
+
+// IsURL reports whether the path given on the command line is a URL rather
+// than a local path
+func IsURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+func (r *Renderer) Render(ctx context.Context, path string) ([]unstructured.Unstructured, error) {
+	target := r.source.URL
+	if target == "" {
+		target = path
+	}
+
+	content, err := r.fetch(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+
+	objects, err := yaml.Decode(target, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", target, err)
+	}
+
+	progress.Notify(ctx, progress.Event{
+		Source:  config.SourceTypeURL.String(),
+		Path:    target,
+		File:    target,
+		Objects: len(objects),
+	})
+
+	return objects, nil
+}
+
+// fetch returns the content at the URL. Content pinned by a digest is cached
+// by digest, it is only downloaded once and is available offline.
+func (r *Renderer) fetch(ctx context.Context, target string) ([]byte, error) {
+	digest := strings.TrimPrefix(r.source.SHA256, "sha256:")
+
+	var cached string
+	if digest != "" {
+		if !digestRe.MatchString(digest) {
+			return nil, fmt.Errorf("invalid sha256 %q: 64 lowercase hex characters are required", r.source.SHA256)
+		}
+
+		dir, err := cachedir.Dir(r.source.CacheDir, "manifests")
+		if err != nil {
+			return nil, err
+		}
+
+		cached = filepath.Join(dir, digest+".yaml")
+
+		if data, err := os.ReadFile(cached); err == nil && verify(data, digest) == nil {
+			return data, nil
+		}
+	}
+
+	if r.source.Offline {
+		if digest == "" {
+			return nil, fmt.Errorf("it cannot be downloaded offline, only content pinned with sha256 is cached")
+		}
+		return nil, fmt.Errorf("it is not cached, it cannot be downloaded offline")
+	}
+
+	data, err := r.download(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if digest == "" {
+		return data, nil
+	}
+
+	if err := verify(data, digest); err != nil {
+		return nil, err
+	}
+
+	if err := writeAtomic(cached, data); err != nil {
+		return nil, fmt.Errorf("failed to cache the content: %w", err)
+	}
+
+	return data, nil
+}
+
+func (r *Renderer) download(ctx context.Context, target string) ([]byte, error) {
+	maxSize, err := r.maxSize()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range r.source.Headers {
+		expanded, err := envsubst.Expand([]byte(value), envsubst.Options{Strict: true})
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		req.Header.Set(name, string(expanded))
+	}
+
+	if r.source.Credentials != nil {
+		basic, err := credentials.Resolve(*r.source.Credentials)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve credentials: %w", err)
+		}
+		req.SetBasicAuth(basic.Username, basic.Password)
+	}
+
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+
+	body := io.Reader(resp.Body)
+	if maxSize > 0 {
+		body = io.LimitReader(resp.Body, maxSize+1)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("content exceeds max-file-size %s", resource.NewQuantity(maxSize, resource.BinarySI))
+	}
+
+	return data, nil
+}
+
+// client returns the HTTP client downloading the content, bounded by the
+// timeout of the source. The headers and credentials are only sent to the
+// host of the URL, they are dropped when redirected to another host.
+func (r *Renderer) client() *http.Client {
+	timeout := r.source.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+
+			if req.URL.Host != via[0].URL.Host {
+				for name := range r.source.Headers {
+					req.Header.Del(name)
+				}
+				req.Header.Del("Authorization")
+			}
+
+			return nil
+		},
+	}
+}
+
+func (r *Renderer) maxSize() (int64, error) {
+	size := r.source.MaxFileSize
+	if size == "" {
+		size = yaml.DefaultMaxFileSize
+	}
+
+	q, err := resource.ParseQuantity(size)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max file size %q: %w", size, err)
+	}
+
+	return q.Value(), nil
+}
+
+// verify checks the data against the hex encoded sha256 digest
+func verify(data []byte, digest string) error {
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != digest {
+		return fmt.Errorf("digest mismatch: expected sha256:%s, got sha256:%s", digest, actual)
+	}
+
+	return nil
+}
+
+// writeAtomic writes the file through a temporary file renamed into place,
+// so that concurrent runs never read partial content
+func writeAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,130 @@
+package url_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/url"
+)
+
+const manifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`
+
+func TestRenderInvalidDigest(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(manifest))
+	}))
+	defer server.Close()
+
+	for _, digest := range []string{
+		"../../x",
+		"sha256:../../x",
+		strings.Repeat("A", 64),
+		strings.Repeat("a", 63),
+	} {
+		r := url.New(config.Source{
+			Type:     config.SourceTypeURL,
+			URL:      server.URL,
+			SHA256:   digest,
+			CacheDir: cacheDir,
+		})
+
+		if _, err := r.Render(t.Context(), ""); err == nil || !strings.Contains(err.Error(), "invalid sha256") {
+			t.Errorf("%s: expected an invalid sha256 error, got %v", digest, err)
+		}
+	}
+
+	if entries, err := os.ReadDir(filepath.Dir(cacheDir)); err != nil {
+		t.Fatal(err)
+	} else {
+		for _, e := range entries {
+			if e.Name() == "x.yaml" {
+				t.Errorf("content written outside of the cache directory")
+			}
+		}
+	}
+}
+
+// TestRenderRedirect only sends the headers and credentials to the host of
+// the URL
+func TestRenderRedirect(t *testing.T) {
+	t.Setenv("URL_TEST_TOKEN", "secret")
+	t.Setenv("URL_TEST_PASSWORD", "password")
+
+	var leaked []string
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, name := range []string{"X-Token", "Authorization"} {
+			if r.Header.Get(name) != "" {
+				leaked = append(leaked, name)
+			}
+		}
+		_, _ = w.Write([]byte(manifest))
+	}))
+	defer other.Close()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			t.Errorf("expected the headers to be sent to the host of the URL")
+		}
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	}))
+	defer origin.Close()
+
+	r := url.New(config.Source{
+		Type:    config.SourceTypeURL,
+		URL:     origin.URL,
+		Headers: map[string]string{"X-Token": "${URL_TEST_TOKEN}"},
+		Credentials: &config.Credentials{
+			Username:    "user",
+			PasswordEnv: "URL_TEST_PASSWORD",
+		},
+	})
+
+	objects, err := r.Render(t.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objects))
+	}
+
+	if len(leaked) > 0 {
+		t.Errorf("headers sent to another host: %v", leaked)
+	}
+}
+
+func TestRenderTimeout(t *testing.T) {
+	done := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	r := url.New(config.Source{
+		Type:    config.SourceTypeURL,
+		URL:     server.URL,
+		Timeout: 50 * time.Millisecond,
+	})
+
+	if _, err := r.Render(t.Context(), ""); err == nil {
+		t.Fatal("expected the download to time out")
+	}
+}
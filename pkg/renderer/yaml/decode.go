@@ -0,0 +1,266 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	goyaml "gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+)
+
+// nolintRe matches golangci-lint style comments: a bare "# nolint" disables
+// every linter, "# nolint:a,b" the listed ones. An explanation can follow
+// after "//".
+var nolintRe = regexp.MustCompile(`^#\s*nolint(?::([^\s/]+))?(?:\s+//.*)?\s*$`)
+
+// Decode parses the documents of YAML or JSON content loaded from elsewhere than the
+// file system, such as a URL, the objects are annotated as by the renderer
+// with name as their file
+func Decode(name string, content []byte) ([]unstructured.Unstructured, error) {
+	return decode(yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme), name, content)
+}
+
+// decode parses the documents of a YAML or JSON file and annotates the
+// objects with the file they come from, their document index, the position
+// of the object and of its fields and the linters disabled by # nolint
+// comments in the document. The items of v1 List documents are returned as
+// individual objects, positioned within the list.
+func decode(decoder runtime.Decoder, file string, content []byte) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+
+	isJSON := strings.EqualFold(filepath.Ext(file), ".json")
+
+	yd := goyaml.NewDecoder(bytes.NewReader(content))
+
+	for index := 0; ; index++ {
+		var doc goyaml.Node
+
+		if err := yd.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("unable to decode resource: %w", err)
+		}
+
+		// JSON files next to the manifests, such as a package.json, are
+		// rarely objects and are skipped unless they hold one
+		if isJSON && (len(doc.Content) == 0 || doc.Content[0].Kind != goyaml.MappingNode) {
+			continue
+		}
+
+		if len(doc.Content) > 0 {
+			if items := listItems(doc.Content[0]); items != nil {
+				for item, node := range items.Content {
+					obj, ok, err := decodeNode(decoder, node)
+					if err != nil {
+						return nil, fmt.Errorf("unable to decode item %d of List: %w", item, err)
+					}
+					if !ok {
+						continue
+					}
+
+					if err := annotate(&obj, file, index, node, node); err != nil {
+						return nil, err
+					}
+
+					annotations := obj.GetAnnotations()
+					annotations[linter.SourceItemAnnotation] = strconv.Itoa(item)
+					obj.SetAnnotations(annotations)
+
+					objects = append(objects, obj)
+				}
+
+				continue
+			}
+		}
+
+		obj, ok, err := decodeNode(decoder, &doc)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		var root *goyaml.Node
+		if len(doc.Content) > 0 {
+			root = doc.Content[0]
+		}
+
+		if err := annotate(&obj, file, index, root, &doc); err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// decodeNode decodes the object of a node, false is returned for empty
+// nodes and for the ones without a kind
+func decodeNode(decoder runtime.Decoder, node *goyaml.Node) (unstructured.Unstructured, bool, error) {
+	var obj unstructured.Unstructured
+
+	var out map[string]interface{}
+	if err := node.Decode(&out); err != nil {
+		return obj, false, fmt.Errorf("unable to decode resource: %w", err)
+	}
+
+	if len(out) == 0 {
+		return obj, false, nil
+	}
+
+	encoded, err := goyaml.Marshal(out)
+	if err != nil {
+		return obj, false, fmt.Errorf("unable to marshal resource: %w", err)
+	}
+
+	if _, _, err := decoder.Decode(encoded, nil, &obj); err != nil {
+		if runtime.IsMissingKind(err) {
+			return obj, false, nil
+		}
+
+		return obj, false, fmt.Errorf("unable to decode resource: %w", err)
+	}
+
+	return obj, true, nil
+}
+
+// annotate records the origin of the object: the file and document it comes
+// from, the position of root and of its fields and the linters disabled by
+// the # nolint comments found in scope
+func annotate(obj *unstructured.Unstructured, file string, index int, root *goyaml.Node, scope *goyaml.Node) error {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[linter.SourcePathAnnotation] = file
+	annotations[linter.SourceIndexAnnotation] = strconv.Itoa(index)
+
+	if root != nil {
+		annotations[linter.SourceLineAnnotation] = lineColumn(root)
+
+		fields := make(map[string]string)
+		collectPositions(root, fieldpath.Root, fields)
+
+		encodedFields, err := json.Marshal(fields)
+		if err != nil {
+			return fmt.Errorf("unable to encode field positions: %w", err)
+		}
+		annotations[linter.SourceFieldsAnnotation] = string(encodedFields)
+	}
+
+	if refs := nolint(scope); len(refs) > 0 {
+		annotations[linter.NolintAnnotation] = strings.Join(refs, ",")
+	}
+
+	obj.SetAnnotations(annotations)
+
+	return nil
+}
+
+// listItems returns the sequence of the items of a v1 List, as printed by
+// kubectl get -o yaml, nil when the node is not a List
+func listItems(node *goyaml.Node) *goyaml.Node {
+	if node.Kind != goyaml.MappingNode {
+		return nil
+	}
+
+	var apiVersion, kind string
+	var items *goyaml.Node
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "apiVersion":
+			apiVersion = value.Value
+		case "kind":
+			kind = value.Value
+		case "items":
+			items = value
+		}
+	}
+
+	if apiVersion != "v1" || kind != "List" {
+		return nil
+	}
+
+	// a List without items, such as an empty kubectl output, holds no object
+	if items == nil || items.Kind != goyaml.SequenceNode {
+		return &goyaml.Node{Kind: goyaml.SequenceNode}
+	}
+
+	return items
+}
+
+// collectPositions records the line:column of every field below the node,
+// keyed by JSONPath. Mapping entries point at their key, sequence items at
+// the item itself.
+func collectPositions(node *goyaml.Node, path string, fields map[string]string) {
+	switch node.Kind {
+	case goyaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind != goyaml.ScalarNode {
+				continue
+			}
+
+			p := fieldpath.Join(path, key.Value)
+			fields[p] = lineColumn(key)
+			collectPositions(value, p, fields)
+		}
+	case goyaml.SequenceNode:
+		for i, item := range node.Content {
+			p := fieldpath.Join(path, i)
+			fields[p] = lineColumn(item)
+			collectPositions(item, p, fields)
+		}
+	}
+}
+
+func lineColumn(node *goyaml.Node) string {
+	return strconv.Itoa(node.Line) + ":" + strconv.Itoa(node.Column)
+}
+
+// nolint collects the linters disabled by the # nolint comments found
+// anywhere in the document, "*" stands for all of them
+func nolint(node *goyaml.Node) []string {
+	var refs []string
+
+	for _, comment := range []string{node.HeadComment, node.LineComment, node.FootComment} {
+		for _, line := range strings.Split(comment, "\n") {
+			m := nolintRe.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				continue
+			}
+
+			if m[1] == "" {
+				refs = append(refs, "*")
+				continue
+			}
+
+			refs = append(refs, strings.Split(m[1], ",")...)
+		}
+	}
+
+	for _, child := range node.Content {
+		refs = append(refs, nolint(child)...)
+	}
+
+	return refs
+}
@@ -0,0 +1,265 @@
+package yaml
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+)
+
+// IsManifest reports whether the file has a YAML or JSON extension,
+// regardless of its case so that files such as DEPLOY.YAML are picked up as
+// well
+func IsManifest(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+// fileSet tracks the files already discovered. Files are compared with
+// os.SameFile rather than by path, so the same file reached through a
+// symlink or through a differently cased path on a case-insensitive
+// filesystem is only loaded once.
+type fileSet struct {
+	bySize map[int64][]os.FileInfo
+}
+
+func newFileSet() *fileSet {
+	return &fileSet{bySize: make(map[int64][]os.FileInfo)}
+}
+
+// add records the file and reports whether it was not seen before
+func (s *fileSet) add(info os.FileInfo) bool {
+	for _, other := range s.bySize[info.Size()] {
+		if os.SameFile(info, other) {
+			return false
+		}
+	}
+
+	s.bySize[info.Size()] = append(s.bySize[info.Size()], info)
+
+	return true
+}
+
+// fileFilter selects the files of a directory by their path relative to it
+type fileFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	// skipNames and skipPaths are the skip-dirs globs matched against the
+	// name and the path of a directory
+	skipNames      []*regexp.Regexp
+	skipPaths      []*regexp.Regexp
+	skipHidden     bool
+	followSymlinks bool
+}
+
+func newFileFilter(cfg config.FilesConfig, discovery config.Discovery) (*fileFilter, error) {
+	f := &fileFilter{
+		skipHidden:     discovery.SkipHidden,
+		followSymlinks: discovery.FollowSymlinks,
+	}
+
+	for _, p := range discovery.SkipDirs {
+		re, err := glob.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid skip-dirs glob %q: %w", p, err)
+		}
+
+		if strings.Contains(glob.Clean(p), "/") {
+			f.skipPaths = append(f.skipPaths, re)
+		} else {
+			f.skipNames = append(f.skipNames, re)
+		}
+	}
+
+	for _, p := range cfg.Include {
+		re, err := glob.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include glob %q: %w", p, err)
+		}
+		f.include = append(f.include, re)
+	}
+
+	for _, p := range cfg.Exclude {
+		re, err := glob.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude glob %q: %w", p, err)
+		}
+		f.exclude = append(f.exclude, re)
+	}
+
+	return f, nil
+}
+
+// excluded reports whether the file, or directory, matches an exclude glob
+func (f *fileFilter) excluded(rel string) bool {
+	return slices.ContainsFunc(f.exclude, func(re *regexp.Regexp) bool {
+		return re.MatchString(rel)
+	})
+}
+
+// skipped reports whether the directory is not walked, because hidden or
+// matching skip-dirs
+func (f *fileFilter) skipped(rel string) bool {
+	name := path.Base(rel)
+
+	if f.skipHidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+
+	return slices.ContainsFunc(f.skipNames, func(re *regexp.Regexp) bool {
+		return re.MatchString(name)
+	}) || slices.ContainsFunc(f.skipPaths, func(re *regexp.Regexp) bool {
+		return re.MatchString(rel)
+	})
+}
+
+// selects reports whether the file is loaded
+func (f *fileFilter) selects(rel string) bool {
+	if f.excluded(rel) {
+		return false
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(f.include, func(re *regexp.Regexp) bool {
+		return re.MatchString(rel)
+	})
+}
+
+// discover returns, in lexical order, the YAML and JSON files found in root
+// which is either a file or a directory walked recursively. A file given
+// explicitly is loaded whatever its extension, the files of a directory are
+// selected by the filter. The walk stops as soon as the context is done.
+func discover(ctx context.Context, root string, filter *fileFilter, seen *fileSet) ([]string, error) {
+	info, err := os.Stat(longPath(root))
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat path %q: %w", root, err)
+	}
+
+	if !info.IsDir() {
+		if !seen.add(info) {
+			return nil, nil
+		}
+
+		return []string{root}, nil
+	}
+
+	w := walker{
+		ctx:    ctx,
+		filter: filter,
+		seen:   seen,
+		dirs:   newFileSet(),
+	}
+
+	if err := w.walk(root, root, ""); err != nil {
+		return nil, fmt.Errorf("failed to walk %q: %w", root, err)
+	}
+
+	sort.Strings(w.files)
+
+	return w.files, nil
+}
+
+// walker collects the files of a directory tree
+type walker struct {
+	ctx    context.Context
+	filter *fileFilter
+	seen   *fileSet
+	// dirs are the directories walked when following symlinks, so that a
+	// link to one of its parents does not walk a directory forever
+	dirs  *fileSet
+	files []string
+}
+
+// walk walks dir, found at shown and at prefix relative to the root: they
+// differ from dir when it is the target of a symlink, whose path is the one
+// the files are reported with
+func (w *walker) walk(dir string, shown string, prefix string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		file := filepath.Join(shown, rel)
+		rel = glob.Clean(filepath.Join(prefix, rel))
+
+		// excluded and skipped directories are not walked at all, e.g.
+		// **/crds/** or vendor
+		if d.IsDir() {
+			if rel != "." && (w.filter.excluded(rel) || w.filter.skipped(rel)) {
+				return filepath.SkipDir
+			}
+
+			if w.filter.followSymlinks {
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				if !w.dirs.add(info) {
+					return filepath.SkipDir
+				}
+			}
+
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 && w.filter.followSymlinks {
+			info, err := os.Stat(longPath(path))
+			if err == nil && info.IsDir() {
+				if w.filter.excluded(rel) || w.filter.skipped(rel) {
+					return nil
+				}
+
+				target, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					return fmt.Errorf("failed to resolve %q: %w", file, err)
+				}
+
+				return w.walk(target, file, rel)
+			}
+		}
+
+		if !IsManifest(path) || !w.filter.selects(rel) {
+			return nil
+		}
+
+		// symlinks are resolved by os.Stat, links to directories are only
+		// followed with follow-symlinks
+		info, err := os.Stat(longPath(path))
+		if err != nil {
+			return fmt.Errorf("failed to stat %q: %w", file, err)
+		}
+
+		if !info.Mode().IsRegular() || !w.seen.add(info) {
+			return nil
+		}
+
+		w.files = append(w.files, file)
+
+		return nil
+	})
+}
@@ -0,0 +1,245 @@
+package yaml
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+)
+
+const manifest = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symlink(t *testing.T, target string, link string) {
+	t.Helper()
+
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+}
+
+func TestIsManifest(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "deploy.yaml", want: true},
+		{path: "deploy.yml", want: true},
+		{path: "deploy.json", want: true},
+		{path: "DEPLOY.YAML", want: true},
+		{path: "Deploy.Yml", want: true},
+		{path: "deploy.JSON", want: true},
+		{path: "dir/deploy.YAML", want: true},
+		{path: "deploy.yaml.bak", want: false},
+		{path: "deploy.txt", want: false},
+		{path: "yaml", want: false},
+		{path: "Makefile", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsManifest(tt.path); got != tt.want {
+				t.Errorf("IsManifest(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSetAdd(t *testing.T) {
+	dir := t.TempDir()
+
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.yaml")
+	writeFile(t, a)
+	writeFile(t, b)
+
+	stat := func(path string) os.FileInfo {
+		t.Helper()
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+
+	seen := newFileSet()
+
+	if !seen.add(stat(a)) {
+		t.Error("add(a) = false, want true")
+	}
+	if seen.add(stat(a)) {
+		t.Error("add(a) again = true, want false")
+	}
+	// same size, different file
+	if !seen.add(stat(b)) {
+		t.Error("add(b) = false, want true")
+	}
+
+	hard := filepath.Join(dir, "hard.yaml")
+	if err := os.Link(a, hard); err == nil && seen.add(stat(hard)) {
+		t.Error("add(hard link to a) = true, want false")
+	}
+
+	link := filepath.Join(dir, "link.yaml")
+	symlink(t, a, link)
+
+	if seen.add(stat(link)) {
+		t.Error("add(symlink to a) = true, want false")
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(t *testing.T, dir string)
+		discovery config.Discovery
+		files     config.FilesConfig
+		want      []string
+	}{
+		{
+			name: "extensions in any case",
+			setup: func(t *testing.T, dir string) {
+				for _, name := range []string{"a.yaml", "B.YAML", "c.yml", "D.YML", "e.Json", "f.txt", "g.yaml.orig"} {
+					writeFile(t, filepath.Join(dir, name))
+				}
+			},
+			want: []string{"B.YAML", "D.YML", "a.yaml", "c.yml", "e.Json"},
+		},
+		{
+			name: "include globs",
+			setup: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "app", "deploy.YAML"))
+				writeFile(t, filepath.Join(dir, "other", "deploy.yaml"))
+			},
+			files: config.FilesConfig{Include: []string{"app/**"}},
+			want:  []string{filepath.Join("app", "deploy.YAML")},
+		},
+		{
+			name: "symlinked file",
+			setup: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "a.yaml"))
+				symlink(t, filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml"))
+			},
+			want: []string{"a.yaml"},
+		},
+		{
+			name: "symlinked directory not followed",
+			setup: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "app", "deploy.yaml"))
+				symlink(t, filepath.Join(dir, "app"), filepath.Join(dir, "link"))
+			},
+			want: []string{filepath.Join("app", "deploy.yaml")},
+		},
+		{
+			name: "symlinked directory followed",
+			setup: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "app", "deploy.yaml"))
+				writeFile(t, filepath.Join(dir, "shared", "config.yaml"))
+				symlink(t, filepath.Join(dir, "shared"), filepath.Join(dir, "app", "shared"))
+			},
+			discovery: config.Discovery{FollowSymlinks: true},
+			want: []string{
+				filepath.Join("app", "deploy.yaml"),
+				filepath.Join("app", "shared", "config.yaml"),
+			},
+		},
+		{
+			name: "symlink to a parent directory",
+			setup: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "app", "deploy.yaml"))
+				symlink(t, dir, filepath.Join(dir, "app", "loop"))
+			},
+			discovery: config.Discovery{FollowSymlinks: true},
+			want:      []string{filepath.Join("app", "deploy.yaml")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.setup(t, dir)
+
+			filter, err := newFileFilter(tt.files, tt.discovery)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files, err := discover(context.Background(), dir, filter, newFileSet())
+			if err != nil {
+				t.Fatalf("discover() error = %v", err)
+			}
+
+			got := make([]string, len(files))
+			for i, file := range files {
+				if got[i], err = filepath.Rel(dir, file); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("discover() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverDuplicateRoots(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app", "deploy.yaml")
+	writeFile(t, file)
+
+	filter, err := newFileFilter(config.FilesConfig{}, config.Discovery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := newFileSet()
+
+	for i, root := range []string{dir, filepath.Join(dir, "app"), file, filepath.Join(dir, "app", ".", "deploy.yaml")} {
+		files, err := discover(context.Background(), root, filter, seen)
+		if err != nil {
+			t.Fatalf("discover(%q) error = %v", root, err)
+		}
+
+		want := 0
+		if i == 0 {
+			want = 1
+		}
+
+		if len(files) != want {
+			t.Errorf("discover(%q) = %q, want %d file(s)", root, files, want)
+		}
+	}
+}
+
+func TestDiscoverCanceled(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "deploy.yaml"))
+
+	filter, err := newFileFilter(config.FilesConfig{}, config.Discovery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := discover(ctx, dir, filter, newFileSet()); err == nil {
+		t.Error("discover() with a canceled context expected an error")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+
+package yaml
+
+func longPath(path string) string {
+	return path
+}
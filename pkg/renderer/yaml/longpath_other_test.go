@@ -0,0 +1,27 @@
+//go:build !windows
+
+package yaml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "short", path: "/manifests/deploy.yaml"},
+		{name: "long", path: "/" + strings.Repeat("segment/", 40) + "deploy.yaml"},
+		{name: "long relative", path: strings.Repeat("segment/", 40) + "deploy.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longPath(tt.path); got != tt.path {
+				t.Errorf("longPath(%q) = %q, want it unchanged", tt.path, got)
+			}
+		})
+	}
+}
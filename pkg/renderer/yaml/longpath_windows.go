@@ -0,0 +1,28 @@
+package yaml
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxPath is the legacy Windows path length limit
+const maxPath = 260
+
+// longPath prefixes absolute paths exceeding MAX_PATH with \\?\ so that the
+// Windows file APIs accept them
+func longPath(path string) string {
+	if len(path) < maxPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+
+	return `\\?\` + abs
+}
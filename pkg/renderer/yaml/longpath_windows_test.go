@@ -0,0 +1,38 @@
+//go:build windows
+
+package yaml
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	long := strings.Repeat(`segment\`, 40) + "deploy.yaml"
+
+	relative, err := filepath.Abs(long)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "short", path: `C:\manifests\deploy.yaml`, want: `C:\manifests\deploy.yaml`},
+		{name: "long", path: `C:\` + long, want: `\\?\C:\` + long},
+		{name: "long relative", path: long, want: `\\?\` + relative},
+		{name: "long UNC", path: `\\server\share\` + long, want: `\\?\UNC\server\share\` + long},
+		{name: "already prefixed", path: `\\?\C:\` + long, want: `\\?\C:\` + long},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longPath(tt.path); got != tt.want {
+				t.Errorf("longPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
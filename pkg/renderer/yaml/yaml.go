@@ -1,22 +1,55 @@
 package yaml
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 
-	"github.com/lburgazzoli/k8s-manifests-lib/pkg/renderer/yaml"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
 
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/envsubst"
 )
 
+const (
+	// DefaultMaxFileSize is the size above which files are skipped when the
+	// source does not set one, zero disables the limit
+	DefaultMaxFileSize = "10Mi"
+
+	// sniffLen is the number of leading bytes inspected to detect binary
+	// files, the same heuristic git uses
+	sniffLen = 8000
+)
+
+// Renderer loads the YAML files found in a path. Files already loaded by a
+// previous Render call of the same Renderer are skipped, so overlapping paths
+// do not produce duplicate objects.
 type Renderer struct {
-	source config.Source
+	source  config.Source
+	decoder runtime.Decoder
+	seen    *fileSet
+	issues  []linter.Issue
 }
 
 func New(source config.Source) *Renderer {
-	return &Renderer{source: source}
+	return &Renderer{
+		source:  source,
+		decoder: yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme),
+		seen:    newFileSet(),
+	}
+}
+
+// Issues returns a warning for every file skipped because too large or
+// binary
+func (r *Renderer) Issues() []linter.Issue {
+	return r.issues
 }
 
 func (r *Renderer) Render(ctx context.Context, path string) ([]unstructured.Unstructured, error) {
@@ -25,29 +58,121 @@ func (r *Renderer) Render(ctx context.Context, path string) ([]unstructured.Unst
 		searchPath = r.source.Path
 	}
 
-	info, err := os.Stat(searchPath)
+	maxSize, err := r.maxFileSize()
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat path %q: %w", searchPath, err)
+		return nil, err
 	}
 
-	pattern := searchPath
-	if info.IsDir() {
-		pattern = searchPath + "/**/*.{yaml,yml}"
+	filter, err := newFileFilter(r.source.Files, r.source.Discovery)
+	if err != nil {
+		return nil, err
 	}
 
-	fs := os.DirFS(".")
-
-	yamlRenderer := yaml.New([]yaml.Data{
-		{
-			FS:   fs,
-			Path: pattern,
-		},
-	})
-
-	objects, err := yamlRenderer.Process(ctx)
+	files, err := discover(ctx, searchPath, filter, r.seen)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render YAML: %w", err)
 	}
 
+	var objects []unstructured.Unstructured
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		content, reason, err := readFile(file, maxSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		if reason != "" {
+			r.issues = append(r.issues, skippedIssue(file, reason))
+			continue
+		}
+
+		if r.source.Envsubst {
+			content, err = envsubst.Expand(content, envsubst.Options{
+				Allow:  r.source.EnvsubstVars,
+				Strict: r.source.EnvsubstStrict,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to substitute variables in %s: %w", file, err)
+			}
+		}
+
+		fileObjects, err := decode(r.decoder, file, content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+
+		objects = append(objects, fileObjects...)
+
+		progress.Notify(ctx, progress.Event{
+			Source:  config.SourceTypeYAML.String(),
+			Path:    searchPath,
+			File:    file,
+			Objects: len(fileObjects),
+		})
+	}
+
 	return objects, nil
-}
\ No newline at end of file
+}
+
+func (r *Renderer) maxFileSize() (int64, error) {
+	size := r.source.MaxFileSize
+	if size == "" {
+		size = DefaultMaxFileSize
+	}
+
+	q, err := resource.ParseQuantity(size)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max file size %q: %w", size, err)
+	}
+
+	return q.Value(), nil
+}
+
+// readFile reads the file unless it is larger than maxSize or looks binary,
+// in which case the reason it has been skipped is returned instead
+func readFile(file string, maxSize int64) ([]byte, string, error) {
+	f, err := os.Open(longPath(file))
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if maxSize > 0 && info.Size() > maxSize {
+		return nil, fmt.Sprintf("File skipped, size %s exceeds max-file-size %s",
+			resource.NewQuantity(info.Size(), resource.BinarySI),
+			resource.NewQuantity(maxSize, resource.BinarySI)), nil
+	}
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if bytes.IndexByte(content[:min(len(content), sniffLen)], 0) >= 0 {
+		return nil, "File skipped, it contains binary data", nil
+	}
+
+	return content, "", nil
+}
+
+func skippedIssue(file string, message string) linter.Issue {
+	return linter.Issue{
+		Severity:   linter.SeverityWarning,
+		Linter:     "yaml-renderer",
+		Message:    message,
+		Resource:   linter.ResourceRef{Kind: "File", Name: file},
+		Position:   &linter.Position{File: file},
+		Suggestion: "Remove the file from the source path, or raise max-file-size if it is a legitimate manifest",
+	}
+}
@@ -0,0 +1,173 @@
+package ytt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/lburgazzoli/k8s-manifests-lib/pkg/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/progress"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/sandbox"
+)
+
+// defaultCommand is the ytt binary templates are rendered with
+const defaultCommand = "ytt"
+
+// Renderer renders Carvel ytt templates with the ytt binary, run in the
+// sandbox of run.sandbox: its environment is scrubbed and it is bounded by
+// the sandbox timeout
+type Renderer struct {
+	source config.Source
+}
+
+func New(source config.Source) *Renderer {
+	return &Renderer{source: source}
+}
+
+func (r *Renderer) Render(ctx context.Context, path string) ([]unstructured.Unstructured, error) {
+	templatePath := r.source.Path
+	if templatePath == "" {
+		templatePath = path
+	}
+
+	dir, err := workDir(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := r.args(dir, templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	command := r.source.YttCommand
+	if command == "" {
+		command = defaultCommand
+	}
+
+	// like any other binary, ytt only runs when allowed by the policy
+	sb, err := sandbox.New(r.source.Sandbox)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := sb.Run(ctx, dir, command, args, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render ytt templates %s: %w", templatePath, err)
+	}
+
+	decoder := k8syaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
+
+	objects, err := util.DecodeYAML(decoder, out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ytt output of %s: %w", templatePath, err)
+	}
+
+	// objects are attributed to the templates, the lines of the ytt output
+	// do not match the ones of the templates
+	for i := range objects {
+		annotations := objects[i].GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[linter.SourcePathAnnotation] = templatePath
+		objects[i].SetAnnotations(annotations)
+	}
+
+	progress.Notify(ctx, progress.Event{
+		Source:  config.SourceTypeYtt.String(),
+		Path:    templatePath,
+		Objects: len(objects),
+	})
+
+	return objects, nil
+}
+
+// workDir returns the directory ytt runs in: the template directory, or the
+// directory of the template file, which the sandbox confines to its work dir
+func workDir(templatePath string) (string, error) {
+	resolved, err := resolve(templatePath)
+	if err != nil {
+		return "", fmt.Errorf("invalid ytt templates %s: %w", templatePath, err)
+	}
+
+	fi, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("invalid ytt templates %s: %w", templatePath, err)
+	}
+
+	if fi.IsDir() {
+		return resolved, nil
+	}
+
+	return filepath.Dir(resolved), nil
+}
+
+// args returns the ytt arguments: the templates, the data values files, the
+// inline data values and the set overrides, later ones taking precedence.
+// Paths are relative to dir, the directory ytt runs in.
+func (r *Renderer) args(dir string, templatePath string) ([]string, error) {
+	template, err := relative(dir, templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"--file", template}
+
+	for _, values := range r.source.Values {
+		rel, err := relative(dir, values)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--data-values-file", rel)
+	}
+
+	keys := make([]string, 0, len(r.source.Data))
+	for key := range r.source.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// JSON is valid YAML, so the values keep their type
+	for _, key := range keys {
+		value, err := json.Marshal(r.source.Data[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid data value %s: %w", key, err)
+		}
+		args = append(args, "--data-value-yaml", key+"="+string(value))
+	}
+
+	for _, set := range r.source.Set {
+		args = append(args, "--data-value-yaml", set)
+	}
+
+	return args, nil
+}
+
+// relative returns the path relative to dir, symlinks are resolved as the
+// sandbox does for the directory commands run in
+func relative(dir string, path string) (string, error) {
+	resolved, err := resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Rel(dir, resolved)
+}
+
+func resolve(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(abs)
+}
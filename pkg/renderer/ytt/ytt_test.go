@@ -0,0 +1,123 @@
+package ytt_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/renderer/ytt"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/sandbox"
+)
+
+// fakeYtt prints a ConfigMap holding the directory it runs in and its
+// arguments
+const fakeYtt = `#!/bin/sh
+cat <<EOF
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ytt
+data:
+  dir: "$(pwd -P)"
+  args: "$*"
+EOF
+`
+
+func setup(t *testing.T) (string, string) {
+	t.Helper()
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	command := filepath.Join(root, "bin", "ytt")
+	if err := os.MkdirAll(filepath.Dir(command), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(command, []byte(fakeYtt), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	return root, command
+}
+
+func TestRender(t *testing.T) {
+	root, command := setup(t)
+
+	r := ytt.New(config.Source{
+		Type:       config.SourceTypeYtt,
+		Path:       filepath.Join(root, "config"),
+		Values:     []string{filepath.Join(root, "values.yml")},
+		YttCommand: command,
+		Sandbox: config.SandboxConfig{
+			AllowedBinaries: []string{command},
+			WorkDir:         root,
+		},
+	})
+
+	if err := os.WriteFile(filepath.Join(root, "values.yml"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := r.Render(t.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objects))
+	}
+
+	data := objects[0].Object["data"].(map[string]interface{})
+
+	if dir := filepath.Join(root, "config"); data["dir"] != dir {
+		t.Errorf("expected ytt to run in %s, got %v", dir, data["dir"])
+	}
+
+	if args := "--file . --data-values-file ../values.yml"; data["args"] != args {
+		t.Errorf("expected args %q, got %q", args, data["args"])
+	}
+}
+
+// TestRenderNotAllowed does not run ytt unless allowed by the policy
+func TestRenderNotAllowed(t *testing.T) {
+	root, command := setup(t)
+
+	r := ytt.New(config.Source{
+		Type:       config.SourceTypeYtt,
+		Path:       filepath.Join(root, "config"),
+		YttCommand: command,
+		Sandbox:    config.SandboxConfig{WorkDir: root},
+	})
+
+	if _, err := r.Render(t.Context(), ""); !errors.Is(err, sandbox.ErrBinaryNotAllowed) {
+		t.Fatalf("expected %v, got %v", sandbox.ErrBinaryNotAllowed, err)
+	}
+}
+
+// TestRenderOutsideWorkDir does not run ytt on templates outside of the
+// sandbox work dir
+func TestRenderOutsideWorkDir(t *testing.T) {
+	root, command := setup(t)
+
+	r := ytt.New(config.Source{
+		Type:       config.SourceTypeYtt,
+		Path:       filepath.Join(root, "config"),
+		YttCommand: command,
+		Sandbox: config.SandboxConfig{
+			AllowedBinaries: []string{command},
+			WorkDir:         filepath.Join(root, "bin"),
+		},
+	})
+
+	if _, err := r.Render(t.Context(), ""); !errors.Is(err, sandbox.ErrOutsideWorkDir) {
+		t.Fatalf("expected %v, got %v", sandbox.ErrOutsideWorkDir, err)
+	}
+}
@@ -0,0 +1,114 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// BaselineVersion is the version of the baseline file format
+const BaselineVersion = 1
+
+// DefaultBaselineFile is the name of the baseline file when none is given
+const DefaultBaselineFile = ".k8s-lint-baseline.json"
+
+// Baseline is a snapshot of known issues, runs using it only report the
+// issues that are not part of the snapshot
+type Baseline struct {
+	Version int             `json:"version"`
+	Issues  []BaselineEntry `json:"issues"`
+}
+
+// BaselineEntry identifies a known issue by fingerprint, the other fields
+// are informational and make the file reviewable
+type BaselineEntry struct {
+	Fingerprint string             `json:"fingerprint"`
+	Linter      string             `json:"linter"`
+	Resource    linter.ResourceRef `json:"resource"`
+	Message     string             `json:"message"`
+}
+
+// NewBaseline snapshots the given issues
+func NewBaseline(issues []linter.Issue) *Baseline {
+	b := Baseline{
+		Version: BaselineVersion,
+		Issues:  make([]BaselineEntry, 0, len(issues)),
+	}
+
+	for _, issue := range issues {
+		b.Issues = append(b.Issues, BaselineEntry{
+			Fingerprint: issue.Fingerprint(),
+			Linter:      issue.Linter,
+			Resource:    issue.Resource,
+			Message:     issue.Message,
+		})
+	}
+
+	// sorted so that regenerating the baseline produces minimal diffs
+	sort.SliceStable(b.Issues, func(i, j int) bool {
+		return b.Issues[i].Fingerprint < b.Issues[j].Fingerprint
+	})
+
+	return &b
+}
+
+// LoadBaseline reads a baseline file
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline %q: %w", path, err)
+	}
+
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to decode baseline %q: %w", path, err)
+	}
+
+	if b.Version != BaselineVersion {
+		return nil, fmt.Errorf("unsupported baseline %q version: %d", path, b.Version)
+	}
+
+	return &b, nil
+}
+
+// Save writes the baseline file
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// Filter returns the issues not in the baseline and the number of known
+// issues dropped. An issue reported more times than recorded in the
+// baseline is reported again for the extra occurrences.
+func (b *Baseline) Filter(issues []linter.Issue) ([]linter.Issue, int) {
+	known := make(map[string]int, len(b.Issues))
+	for _, entry := range b.Issues {
+		known[entry.Fingerprint]++
+	}
+
+	result := make([]linter.Issue, 0, len(issues))
+	dropped := 0
+
+	for _, issue := range issues {
+		fp := issue.Fingerprint()
+		if known[fp] > 0 {
+			known[fp]--
+			dropped++
+			continue
+		}
+		result = append(result, issue)
+	}
+
+	return result, dropped
+}
@@ -0,0 +1,92 @@
+package report_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+)
+
+func TestBaselineFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline []linter.Issue
+		issues   []linter.Issue
+		reported []string
+		known    int
+	}{
+		{
+			name:     "still present",
+			baseline: []linter.Issue{issue("web", "a")},
+			issues:   []linter.Issue{issue("web", "a")},
+			reported: []string{},
+			known:    1,
+		},
+		{
+			name:     "fixed",
+			baseline: []linter.Issue{issue("web", "a"), issue("web", "b")},
+			issues:   []linter.Issue{issue("web", "b")},
+			reported: []string{},
+			known:    1,
+		},
+		{
+			name:     "new",
+			baseline: []linter.Issue{issue("web", "a")},
+			issues:   []linter.Issue{issue("web", "a"), issue("web", "b"), issue("api", "a")},
+			reported: []string{"b", "a"},
+			known:    1,
+		},
+		{
+			name:     "different position",
+			baseline: []linter.Issue{withPosition(issue("web", "a"), 3)},
+			issues:   []linter.Issue{withPosition(issue("web", "a"), 12)},
+			reported: []string{},
+			known:    1,
+		},
+		{
+			name:     "more occurrences than recorded",
+			baseline: []linter.Issue{issue("web", "a")},
+			issues:   []linter.Issue{issue("web", "a"), withPosition(issue("web", "a"), 5)},
+			reported: []string{"a"},
+			known:    1,
+		},
+		{
+			name:     "empty baseline",
+			issues:   []linter.Issue{issue("web", "a")},
+			reported: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reported, known := report.NewBaseline(tt.baseline).Filter(tt.issues)
+
+			if got := messages(reported); !slices.Equal(got, tt.reported) {
+				t.Errorf("expected %v to be reported, got %v", tt.reported, got)
+			}
+			if known != tt.known {
+				t.Errorf("expected %d known issue(s), got %d", tt.known, known)
+			}
+		})
+	}
+}
+
+func TestBaselineSaveLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), report.DefaultBaselineFile)
+
+	if err := report.NewBaseline([]linter.Issue{issue("web", "b"), issue("web", "a")}).Save(file); err != nil {
+		t.Fatal(err)
+	}
+
+	baseline, err := report.LoadBaseline(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reported, known := baseline.Filter([]linter.Issue{issue("web", "a"), issue("web", "c")})
+	if got := messages(reported); !slices.Equal(got, []string{"c"}) || known != 1 {
+		t.Errorf("expected c to be reported and 1 known issue, got %v and %d", got, known)
+	}
+}
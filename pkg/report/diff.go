@@ -0,0 +1,53 @@
+package report
+
+import (
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Diff describes how the issues of two reports relate to each other
+type Diff struct {
+	Added     []linter.Issue `json:"added" yaml:"added"`
+	Resolved  []linter.Issue `json:"resolved" yaml:"resolved"`
+	Unchanged []linter.Issue `json:"unchanged" yaml:"unchanged"`
+}
+
+// Compare matches the issues of the old and new reports by fingerprint
+func Compare(oldIssues []linter.Issue, newIssues []linter.Issue) *Diff {
+	diff := Diff{
+		Added:     make([]linter.Issue, 0),
+		Resolved:  make([]linter.Issue, 0),
+		Unchanged: make([]linter.Issue, 0),
+	}
+
+	oldFingerprints := make(map[string]bool, len(oldIssues))
+	for _, issue := range oldIssues {
+		oldFingerprints[issue.Fingerprint()] = true
+	}
+
+	newFingerprints := make(map[string]bool, len(newIssues))
+	for _, issue := range newIssues {
+		fp := issue.Fingerprint()
+		if newFingerprints[fp] {
+			continue
+		}
+		newFingerprints[fp] = true
+
+		if oldFingerprints[fp] {
+			diff.Unchanged = append(diff.Unchanged, issue)
+		} else {
+			diff.Added = append(diff.Added, issue)
+		}
+	}
+
+	for _, issue := range oldIssues {
+		fp := issue.Fingerprint()
+		if newFingerprints[fp] {
+			continue
+		}
+		// mark as seen so duplicated old issues are reported once
+		newFingerprints[fp] = true
+		diff.Resolved = append(diff.Resolved, issue)
+	}
+
+	return &diff
+}
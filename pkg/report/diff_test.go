@@ -0,0 +1,108 @@
+package report_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+)
+
+// issue returns an issue of the deployment name identified by its message
+func issue(name string, message string) linter.Issue {
+	return linter.Issue{
+		Severity: linter.SeverityError,
+		Linter:   "test",
+		Message:  message,
+		Resource: linter.ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: name},
+	}
+}
+
+// withPosition returns the issue positioned at the line
+func withPosition(i linter.Issue, line int) linter.Issue {
+	i.Position = &linter.Position{File: "deployment.yaml", Line: line}
+	return i
+}
+
+// messages returns the messages of the issues, in order
+func messages(issues []linter.Issue) []string {
+	m := make([]string, 0, len(issues))
+	for _, i := range issues {
+		m = append(m, i.Message)
+	}
+	return m
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		old       []linter.Issue
+		new       []linter.Issue
+		added     []string
+		resolved  []string
+		unchanged []string
+	}{
+		{
+			name:      "empty",
+			added:     []string{},
+			resolved:  []string{},
+			unchanged: []string{},
+		},
+		{
+			name:      "added",
+			old:       []linter.Issue{issue("web", "a")},
+			new:       []linter.Issue{issue("web", "a"), issue("web", "b")},
+			added:     []string{"b"},
+			resolved:  []string{},
+			unchanged: []string{"a"},
+		},
+		{
+			name:      "resolved",
+			old:       []linter.Issue{issue("web", "a"), issue("web", "b")},
+			new:       []linter.Issue{issue("web", "b")},
+			added:     []string{},
+			resolved:  []string{"a"},
+			unchanged: []string{"b"},
+		},
+		{
+			name:      "unchanged at another position",
+			old:       []linter.Issue{withPosition(issue("web", "a"), 3)},
+			new:       []linter.Issue{withPosition(issue("web", "a"), 7)},
+			added:     []string{},
+			resolved:  []string{},
+			unchanged: []string{"a"},
+		},
+		{
+			name:      "same message on another resource",
+			old:       []linter.Issue{issue("web", "a")},
+			new:       []linter.Issue{issue("api", "a")},
+			added:     []string{"a"},
+			resolved:  []string{"a"},
+			unchanged: []string{},
+		},
+		{
+			name:      "duplicates reported once",
+			old:       []linter.Issue{issue("web", "a"), issue("web", "a"), issue("web", "c"), issue("web", "c")},
+			new:       []linter.Issue{issue("web", "a"), issue("web", "a"), issue("web", "b"), issue("web", "b")},
+			added:     []string{"b"},
+			resolved:  []string{"c"},
+			unchanged: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := report.Compare(tt.old, tt.new)
+
+			if got := messages(diff.Added); !slices.Equal(got, tt.added) {
+				t.Errorf("added: expected %v, got %v", tt.added, got)
+			}
+			if got := messages(diff.Resolved); !slices.Equal(got, tt.resolved) {
+				t.Errorf("resolved: expected %v, got %v", tt.resolved, got)
+			}
+			if got := messages(diff.Unchanged); !slices.Equal(got, tt.unchanged) {
+				t.Errorf("unchanged: expected %v, got %v", tt.unchanged, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package report
+
+import (
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Limit caps the issues to report: at most maxSame issues sharing the same
+// linter and message, and at most maxIssues in total. Zero, or a negative
+// value, disables a limit. The kept issues and the number of dropped ones are
+// returned.
+func Limit(issues []linter.Issue, maxIssues int, maxSame int) ([]linter.Issue, int) {
+	if maxIssues <= 0 && maxSame <= 0 {
+		return issues, 0
+	}
+
+	type key struct {
+		linter  string
+		message string
+	}
+
+	same := make(map[key]int)
+	kept := make([]linter.Issue, 0, len(issues))
+
+	for _, issue := range issues {
+		if maxIssues > 0 && len(kept) >= maxIssues {
+			break
+		}
+
+		k := key{linter: issue.Linter, message: issue.Message}
+		if maxSame > 0 && same[k] >= maxSame {
+			continue
+		}
+		same[k]++
+
+		kept = append(kept, issue)
+	}
+
+	return kept, len(issues) - len(kept)
+}
@@ -0,0 +1,90 @@
+package report_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+)
+
+func TestLimit(t *testing.T) {
+	other := issue("web", "a")
+	other.Linter = "other"
+
+	issues := []linter.Issue{
+		issue("web", "a"),
+		issue("api", "a"),
+		issue("web", "b"),
+		issue("db", "a"),
+		other,
+		issue("api", "b"),
+	}
+
+	tests := []struct {
+		name      string
+		maxIssues int
+		maxSame   int
+		kept      []string
+		dropped   int
+	}{
+		{
+			name: "unlimited",
+			kept: []string{"test web/a", "test api/a", "test web/b", "test db/a", "other web/a", "test api/b"},
+		},
+		{
+			name:      "negative limits are unlimited",
+			maxIssues: -1,
+			maxSame:   -1,
+			kept:      []string{"test web/a", "test api/a", "test web/b", "test db/a", "other web/a", "test api/b"},
+		},
+		{
+			name:      "max issues",
+			maxIssues: 2,
+			kept:      []string{"test web/a", "test api/a"},
+			dropped:   4,
+		},
+		{
+			name:    "max same issues",
+			maxSame: 1,
+			kept:    []string{"test web/a", "test web/b", "other web/a"},
+			dropped: 3,
+		},
+		{
+			name:    "max same issues of the same linter",
+			maxSame: 2,
+			kept:    []string{"test web/a", "test api/a", "test web/b", "other web/a", "test api/b"},
+			dropped: 1,
+		},
+		{
+			name:      "max same issues applied first",
+			maxIssues: 3,
+			maxSame:   1,
+			kept:      []string{"test web/a", "test web/b", "other web/a"},
+			dropped:   3,
+		},
+		{
+			name:      "max issues above the total",
+			maxIssues: 10,
+			kept:      []string{"test web/a", "test api/a", "test web/b", "test db/a", "other web/a", "test api/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped := report.Limit(issues, tt.maxIssues, tt.maxSame)
+
+			got := make([]string, 0, len(kept))
+			for _, i := range kept {
+				got = append(got, i.Linter+" "+i.Resource.Name+"/"+i.Message)
+			}
+
+			if !slices.Equal(got, tt.kept) {
+				t.Errorf("expected %v to be kept, got %v", tt.kept, got)
+			}
+			if dropped != tt.dropped {
+				t.Errorf("expected %d dropped issue(s), got %d", tt.dropped, dropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"sort"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// DefaultWorstOffenders is the number of resources listed as the worst
+// offenders of a namespace
+const DefaultWorstOffenders = 5
+
+// NamespaceSummary holds the issue counts of a namespace and the resources
+// with the most severe, then the most, issues. Cluster scoped resources are
+// summarized under the empty namespace.
+type NamespaceSummary struct {
+	Namespace      string                  `json:"namespace" yaml:"namespace"`
+	Issues         int                     `json:"issues" yaml:"issues"`
+	BySeverity     map[linter.Severity]int `json:"bySeverity" yaml:"bySeverity"`
+	WorstOffenders []ResourceIssues        `json:"worstOffenders" yaml:"worstOffenders"`
+}
+
+// ResourceIssues is the number of issues of a resource, by severity
+type ResourceIssues struct {
+	Resource   linter.ResourceRef      `json:"resource" yaml:"resource"`
+	Issues     int                     `json:"issues" yaml:"issues"`
+	BySeverity map[linter.Severity]int `json:"bySeverity" yaml:"bySeverity"`
+}
+
+// ByNamespace summarizes the issues per namespace, the namespaces with the
+// most severe issues first, keeping at most worst offenders per namespace
+func ByNamespace(issues []linter.Issue, worst int) []NamespaceSummary {
+	summaries := make(map[string]*NamespaceSummary)
+	resources := make(map[string]map[linter.ResourceRef]*ResourceIssues)
+
+	for _, issue := range issues {
+		ns := issue.Resource.Namespace
+
+		summary, ok := summaries[ns]
+		if !ok {
+			summary = &NamespaceSummary{Namespace: ns, BySeverity: make(map[linter.Severity]int)}
+			summaries[ns] = summary
+			resources[ns] = make(map[linter.ResourceRef]*ResourceIssues)
+		}
+
+		summary.Issues++
+		summary.BySeverity[issue.Severity]++
+
+		r, ok := resources[ns][issue.Resource]
+		if !ok {
+			r = &ResourceIssues{Resource: issue.Resource, BySeverity: make(map[linter.Severity]int)}
+			resources[ns][issue.Resource] = r
+		}
+
+		r.Issues++
+		r.BySeverity[issue.Severity]++
+	}
+
+	result := make([]NamespaceSummary, 0, len(summaries))
+
+	for ns, summary := range summaries {
+		offenders := make([]ResourceIssues, 0, len(resources[ns]))
+		for _, r := range resources[ns] {
+			offenders = append(offenders, *r)
+		}
+
+		sort.Slice(offenders, func(i, j int) bool {
+			if c := worse(offenders[i].BySeverity, offenders[i].Issues, offenders[j].BySeverity, offenders[j].Issues); c != 0 {
+				return c < 0
+			}
+			return resourceKey(offenders[i].Resource) < resourceKey(offenders[j].Resource)
+		})
+
+		if worst > 0 && len(offenders) > worst {
+			offenders = offenders[:worst]
+		}
+
+		summary.WorstOffenders = offenders
+		result = append(result, *summary)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if c := worse(result[i].BySeverity, result[i].Issues, result[j].BySeverity, result[j].Issues); c != 0 {
+			return c < 0
+		}
+		return result[i].Namespace < result[j].Namespace
+	})
+
+	return result
+}
+
+// worse compares two severity breakdowns, returning a negative number when
+// a has more issues of the most severe level they differ on, then when a has
+// more issues in total
+func worse(a map[linter.Severity]int, totalA int, b map[linter.Severity]int, totalB int) int {
+	for _, s := range linter.Severities() {
+		if a[s] != b[s] {
+			return b[s] - a[s]
+		}
+	}
+	return totalB - totalA
+}
+
+func resourceKey(ref linter.ResourceRef) string {
+	return ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+}
@@ -0,0 +1,182 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/provenance"
+)
+
+// Report is the document written by the json formatter
+type Report struct {
+	Issues          []linter.Issue     `json:"issues" yaml:"issues"`
+	Count           int                `json:"count" yaml:"count"`
+	Stats           *Stats             `json:"stats,omitempty" yaml:"stats,omitempty"`
+	Namespaces      []NamespaceSummary `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	Reproducibility *provenance.Report `json:"reproducibility,omitempty" yaml:"reproducibility,omitempty"`
+}
+
+// Stats holds aggregated counters for a set of issues, and for a lint run
+// what has been linted
+type Stats struct {
+	Reports    int                     `json:"reports,omitempty" yaml:"reports,omitempty"`
+	Duplicates int                     `json:"duplicates,omitempty" yaml:"duplicates,omitempty"`
+	Sources    int                     `json:"sources,omitempty" yaml:"sources,omitempty"`
+	Objects    int                     `json:"objects,omitempty" yaml:"objects,omitempty"`
+	Linters    int                     `json:"linters,omitempty" yaml:"linters,omitempty"`
+	Duration   string                  `json:"duration,omitempty" yaml:"duration,omitempty"`
+	BySeverity map[linter.Severity]int `json:"bySeverity" yaml:"bySeverity"`
+	ByLinter   map[string]int          `json:"byLinter" yaml:"byLinter"`
+	ByKind     map[string]int          `json:"byKind" yaml:"byKind"`
+}
+
+// NewStats computes the per severity, per linter and per kind counters of
+// the given issues
+func NewStats(issues []linter.Issue) *Stats {
+	stats := Stats{
+		BySeverity: make(map[linter.Severity]int),
+		ByLinter:   make(map[string]int),
+		ByKind:     make(map[string]int),
+	}
+
+	for _, issue := range issues {
+		stats.BySeverity[issue.Severity]++
+		stats.ByLinter[issue.Linter]++
+		stats.ByKind[issue.Resource.Kind]++
+	}
+
+	return &stats
+}
+
+// Write renders the stats as a human readable summary, the largest counts
+// first
+func (s *Stats) Write(w io.Writer) error {
+	bySeverity := make(map[string]int, len(s.BySeverity))
+	for severity, n := range s.BySeverity {
+		bySeverity[string(severity)] = n
+	}
+
+	total := 0
+	for _, n := range s.ByLinter {
+		total += n
+	}
+
+	fmt.Fprintln(w, "Summary:")
+	fmt.Fprintf(w, "  Sources rendered: %d\n", s.Sources)
+	fmt.Fprintf(w, "  Objects scanned:  %d\n", s.Objects)
+	fmt.Fprintf(w, "  Linters run:      %d\n", s.Linters)
+	fmt.Fprintf(w, "  Issues:           %d\n", total)
+	if s.Duration != "" {
+		fmt.Fprintf(w, "  Duration:         %s\n", s.Duration)
+	}
+
+	for _, section := range []struct {
+		title  string
+		counts map[string]int
+	}{
+		{title: "By severity", counts: bySeverity},
+		{title: "By linter", counts: s.ByLinter},
+		{title: "By kind", counts: s.ByKind},
+	} {
+		if len(section.counts) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		for _, name := range sortedKeys(section.counts) {
+			fmt.Fprintf(w, "  %-40s %d\n", name, section.counts[name])
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+// Load reads the issues from a JSON or SARIF report file
+func Load(path string) ([]linter.Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report %q: %w", path, err)
+	}
+
+	issues, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode report %q: %w", path, err)
+	}
+
+	return issues, nil
+}
+
+// Decode detects the format of the given report and returns its issues
+func Decode(data []byte) ([]linter.Issue, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("report is not a JSON document: %w", err)
+	}
+
+	if _, ok := probe["runs"]; ok {
+		return decodeSARIF(data)
+	}
+
+	if _, ok := probe["issues"]; ok {
+		var r Report
+		dec := json.NewDecoder(bytes.NewReader(data))
+		if err := dec.Decode(&r); err != nil {
+			return nil, err
+		}
+		return r.Issues, nil
+	}
+
+	return nil, fmt.Errorf("unknown report format")
+}
+
+// Merge combines the issues of several reports, dropping issues with the same
+// fingerprint. The order of the first occurrence of each issue is preserved.
+func Merge(reports ...[]linter.Issue) *Report {
+	seen := make(map[string]bool)
+	merged := make([]linter.Issue, 0)
+	duplicates := 0
+
+	for _, issues := range reports {
+		for _, issue := range issues {
+			fp := issue.Fingerprint()
+			if seen[fp] {
+				duplicates++
+				continue
+			}
+
+			seen[fp] = true
+			merged = append(merged, issue)
+		}
+	}
+
+	stats := NewStats(merged)
+	stats.Reports = len(reports)
+	stats.Duplicates = duplicates
+
+	return &Report{
+		Issues: merged,
+		Count:  len(merged),
+		Stats:  stats,
+	}
+}
@@ -0,0 +1,254 @@
+package report_test
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/json"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/sarif"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/report"
+)
+
+func TestDecodeRoundTrip(t *testing.T) {
+	issues := []linter.Issue{
+		{
+			Severity:   linter.SeverityWarning,
+			Linter:     "image-tag",
+			Message:    "image uses the latest tag",
+			Resource:   linter.ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+			Field:      "spec.template.spec.containers[0].image",
+			Value:      "nginx:latest",
+			Suggestion: "pin the image to a digest",
+			Position:   &linter.Position{File: "deploy/web.yaml", Line: 12, Column: 16},
+			File:       "deploy/web.yaml",
+		},
+		{
+			Severity: linter.SeverityInfo,
+			Linter:   "required-labels",
+			Message:  "missing label team",
+			Resource: linter.ResourceRef{APIVersion: "v1", Kind: "Namespace", Name: "apps"},
+		},
+		withPosition(issue("web", "a"), 3),
+	}
+
+	tests := []struct {
+		name   string
+		format func(w *bytes.Buffer, issues []linter.Issue) error
+	}{
+		{
+			name: "json",
+			format: func(w *bytes.Buffer, issues []linter.Issue) error {
+				return (&json.Formatter{}).Format(w, issues)
+			},
+		},
+		{
+			name: "sarif",
+			format: func(w *bytes.Buffer, issues []linter.Issue) error {
+				return (&sarif.Formatter{}).Format(w, issues)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.format(&buf, issues); err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := report.Decode(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(decoded) != len(issues) {
+				t.Fatalf("expected %d issues, got %d", len(issues), len(decoded))
+			}
+			for i := range issues {
+				if !reflect.DeepEqual(decoded[i], issues[i]) {
+					t.Errorf("issue %d: expected %+v, got %+v", i, issues[i], decoded[i])
+				}
+				if decoded[i].Fingerprint() != issues[i].Fingerprint() {
+					t.Errorf("issue %d: fingerprint changed", i)
+				}
+			}
+		})
+	}
+}
+
+// TestDecodeSARIF decodes the results of other tools, without the
+// properties of k8s-manifests-lint
+func TestDecodeSARIF(t *testing.T) {
+	data := []byte(`{
+  "version": "2.1.0",
+  "runs": [{
+    "results": [
+      {
+        "ruleId": "privileged",
+        "level": "warning",
+        "message": {"text": "container is privileged"},
+        "locations": [{"logicalLocations": [{"name": "default/Pod/web"}]}]
+      },
+      {
+        "ruleId": "naming",
+        "level": "note",
+        "message": {"text": "name too long"},
+        "locations": [{"logicalLocations": [{"name": "Namespace/apps"}]}]
+      },
+      {
+        "ruleId": "other",
+        "message": {"text": "no location"}
+      }
+    ]
+  }]
+}`)
+
+	issues, err := report.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []linter.Issue{
+		{
+			Severity: linter.SeverityWarning,
+			Linter:   "privileged",
+			Message:  "container is privileged",
+			Resource: linter.ResourceRef{Namespace: "default", Kind: "Pod", Name: "web"},
+		},
+		{
+			Severity: linter.SeverityInfo,
+			Linter:   "naming",
+			Message:  "name too long",
+			Resource: linter.ResourceRef{Kind: "Namespace", Name: "apps"},
+		},
+		{
+			Severity: linter.SeverityError,
+			Linter:   "other",
+			Message:  "no location",
+		},
+	}
+
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("expected %+v, got %+v", want, issues)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"not json":       "issues: []",
+		"not an object":  "[]",
+		"unknown format": `{"results": []}`,
+	} {
+		if _, err := report.Decode([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name       string
+		reports    [][]linter.Issue
+		merged     []string
+		duplicates int
+	}{
+		{
+			name:    "disjoint",
+			reports: [][]linter.Issue{{issue("web", "a")}, {issue("web", "b")}},
+			merged:  []string{"a", "b"},
+		},
+		{
+			name:       "same fingerprint",
+			reports:    [][]linter.Issue{{issue("web", "a"), issue("web", "b")}, {issue("web", "b"), issue("web", "c")}},
+			merged:     []string{"a", "b", "c"},
+			duplicates: 1,
+		},
+		{
+			name:       "same fingerprint at another position",
+			reports:    [][]linter.Issue{{withPosition(issue("web", "a"), 3)}, {withPosition(issue("web", "a"), 9)}},
+			merged:     []string{"a"},
+			duplicates: 1,
+		},
+		{
+			name:       "duplicates within a report",
+			reports:    [][]linter.Issue{{issue("web", "a"), issue("web", "a")}},
+			merged:     []string{"a"},
+			duplicates: 1,
+		},
+		{
+			name:    "same message on another resource",
+			reports: [][]linter.Issue{{issue("web", "a")}, {issue("api", "a")}},
+			merged:  []string{"a", "a"},
+		},
+		{
+			name:   "none",
+			merged: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := report.Merge(tt.reports...)
+
+			if got := messages(r.Issues); !slices.Equal(got, tt.merged) {
+				t.Errorf("expected %v, got %v", tt.merged, got)
+			}
+			if r.Count != len(tt.merged) {
+				t.Errorf("expected count %d, got %d", len(tt.merged), r.Count)
+			}
+			if r.Stats.Reports != len(tt.reports) || r.Stats.Duplicates != tt.duplicates {
+				t.Errorf("expected %d reports and %d duplicates, got %d and %d", len(tt.reports), tt.duplicates, r.Stats.Reports, r.Stats.Duplicates)
+			}
+		})
+	}
+
+	// the first occurrence is kept
+	r := report.Merge([]linter.Issue{withPosition(issue("web", "a"), 3)}, []linter.Issue{withPosition(issue("web", "a"), 9)})
+	if line := r.Issues[0].Position.Line; line != 3 {
+		t.Errorf("expected the first occurrence to be kept, got line %d", line)
+	}
+}
+
+// TestMergeRoundTrip merges a JSON and a SARIF report of the same issues
+func TestMergeRoundTrip(t *testing.T) {
+	issues := []linter.Issue{issue("web", "a"), issue("api", "b")}
+
+	var j, s bytes.Buffer
+	if err := (&json.Formatter{}).Format(&j, issues); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&sarif.Formatter{}).Format(&s, append(issues, issue("db", "c"))); err != nil {
+		t.Fatal(err)
+	}
+
+	var reports [][]linter.Issue
+	for _, data := range [][]byte{j.Bytes(), s.Bytes()} {
+		decoded, err := report.Decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, decoded)
+	}
+
+	merged := report.Merge(reports...)
+
+	var buf bytes.Buffer
+	if err := json.Encode(&buf, merged); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := report.Decode(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := messages(decoded); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("expected [a b c], got %v", got)
+	}
+	if merged.Stats.Duplicates != 2 {
+		t.Errorf("expected 2 duplicates, got %d", merged.Stats.Duplicates)
+	}
+}
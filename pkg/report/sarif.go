@@ -0,0 +1,91 @@
+package report
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/output/sarif"
+)
+
+type sarifLog struct {
+	Runs []struct {
+		Results []struct {
+			RuleID  string `json:"ruleId"`
+			Level   string `json:"level"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				LogicalLocations []struct {
+					Name string `json:"name"`
+				} `json:"logicalLocations"`
+			} `json:"locations"`
+			Properties *sarif.Properties `json:"properties"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+func decodeSARIF(data []byte) ([]linter.Issue, error) {
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, err
+	}
+
+	var issues []linter.Issue
+	for _, run := range log.Runs {
+		for _, res := range run.Results {
+			issue := linter.Issue{
+				Severity: severityFromLevel(res.Level),
+				Linter:   res.RuleID,
+				Message:  res.Message.Text,
+			}
+
+			if res.Properties != nil {
+				if res.Properties.Severity != "" {
+					issue.Severity = res.Properties.Severity
+				}
+				issue.Resource = res.Properties.Resource
+				issue.Field = res.Properties.Field
+				issue.Value = res.Properties.Value
+				issue.Suggestion = res.Properties.Suggestion
+				issue.DocURL = res.Properties.DocURL
+				issue.Related = res.Properties.Related
+				issue.Position = res.Properties.Position
+				issue.Source = res.Properties.Source
+				issue.File = res.Properties.File
+				issue.Message = strings.TrimSuffix(issue.Message, "\nSuggestion: "+issue.Suggestion)
+			} else if len(res.Locations) > 0 && len(res.Locations[0].LogicalLocations) > 0 {
+				issue.Resource = resourceFromName(res.Locations[0].LogicalLocations[0].Name)
+			}
+
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+func severityFromLevel(level string) linter.Severity {
+	switch level {
+	case "warning":
+		return linter.SeverityWarning
+	case "note", "none":
+		return linter.SeverityInfo
+	default:
+		return linter.SeverityError
+	}
+}
+
+// resourceFromName parses a logical location name in the [namespace/]kind/name form
+func resourceFromName(name string) linter.ResourceRef {
+	parts := strings.Split(name, "/")
+	switch len(parts) {
+	case 2:
+		return linter.ResourceRef{Kind: parts[0], Name: parts[1]}
+	case 3:
+		return linter.ResourceRef{Namespace: parts[0], Kind: parts[1], Name: parts[2]}
+	default:
+		return linter.ResourceRef{Name: name}
+	}
+}
@@ -0,0 +1,224 @@
+// Package sandbox runs the external binaries sources and linters shell out
+// to, such as ytt, cue or the command of exec sources, which are configured
+// by the user or by third party rule packs and are therefore untrusted.
+//
+// git is the one exception: pkg/vcs runs it with fixed arguments, on the
+// working tree being fixed and on the mirrors of the cache directory, both
+// outside of the sandbox work dir, with the environment of the user holding
+// the git credentials and configuration.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+)
+
+// DefaultTimeout is the maximum run time of a command when the policy does
+// not set one
+const DefaultTimeout = time.Minute
+
+var (
+	ErrBinaryNotAllowed = errors.New("binary not allowed by sandbox policy")
+	ErrOutsideWorkDir   = errors.New("directory outside of sandbox work dir")
+)
+
+// Sandbox runs external binaries with a restricted environment: only
+// allowlisted binaries can be executed, the environment is scrubbed down to
+// PATH plus the configured variables, the working directory must be inside
+// the sandbox root and every command is bounded by a timeout
+type Sandbox struct {
+	binaries map[string]bool
+	env      []string
+	root     string
+	timeout  time.Duration
+}
+
+// New creates a sandbox from the given policy, an empty allowlist denies
+// every binary
+func New(cfg config.SandboxConfig) (*Sandbox, error) {
+	root := cfg.WorkDir
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		root = wd
+	}
+
+	root, err := resolve(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sandbox work dir: %w", err)
+	}
+
+	s := &Sandbox{
+		binaries: make(map[string]bool, len(cfg.AllowedBinaries)),
+		env:      scrubEnv(cfg.Env),
+		root:     root,
+		timeout:  cfg.Timeout,
+	}
+
+	if s.timeout <= 0 {
+		s.timeout = DefaultTimeout
+	}
+
+	for _, b := range cfg.AllowedBinaries {
+		s.binaries[b] = true
+	}
+
+	return s, nil
+}
+
+// Root returns the directory commands are confined to
+func (s *Sandbox) Root() string {
+	return s.root
+}
+
+// Run executes the named binary in dir and returns its standard output, the
+// standard error is included in the returned error when the command fails
+func (s *Sandbox) Run(ctx context.Context, dir string, name string, args []string, stdin io.Reader) ([]byte, error) {
+	path, err := s.lookPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := s.workDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = workDir
+	cmd.Env = s.env
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s timed out after %s", name, s.timeout)
+		}
+
+		return nil, fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// lookPath resolves name against the scrubbed PATH, the binary is allowed
+// when either the name as given or its resolved path is allowlisted
+func (s *Sandbox) lookPath(name string) (string, error) {
+	path := name
+	if !strings.ContainsRune(name, filepath.Separator) {
+		p, err := lookPath(name, s.getenv("PATH"))
+		if err != nil {
+			return "", fmt.Errorf("%q: %w", name, err)
+		}
+
+		path = p
+	}
+
+	if s.binaries[name] || s.binaries[path] {
+		return path, nil
+	}
+
+	return "", fmt.Errorf("%q: %w, add it to run.sandbox.allowed-binaries to run it", name, ErrBinaryNotAllowed)
+}
+
+func (s *Sandbox) workDir(dir string) (string, error) {
+	if dir == "" {
+		return s.root, nil
+	}
+
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(s.root, dir)
+	}
+
+	resolved, err := resolve(dir)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(s.root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q: %w", dir, ErrOutsideWorkDir)
+	}
+
+	return resolved, nil
+}
+
+func (s *Sandbox) getenv(key string) string {
+	for _, kv := range s.env {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// scrubEnv keeps the configured variables and PATH, entries in the form
+// NAME=value are set as is while bare names are copied from the current
+// environment when defined
+func scrubEnv(names []string) []string {
+	env := make([]string, 0, len(names)+1)
+	hasPath := false
+
+	for _, n := range names {
+		if k, _, ok := strings.Cut(n, "="); ok {
+			hasPath = hasPath || k == "PATH"
+			env = append(env, n)
+			continue
+		}
+
+		if v, ok := os.LookupEnv(n); ok {
+			hasPath = hasPath || n == "PATH"
+			env = append(env, n+"="+v)
+		}
+	}
+
+	if v, ok := os.LookupEnv("PATH"); ok && !hasPath {
+		env = append(env, "PATH="+v)
+	}
+
+	return env
+}
+
+func lookPath(name string, path string) (string, error) {
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+
+		p := filepath.Join(dir, name)
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() && fi.Mode()&0o111 != 0 {
+			return p, nil
+		}
+	}
+
+	return "", exec.ErrNotFound
+}
+
+func resolve(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(abs)
+}
@@ -0,0 +1,91 @@
+package sandbox_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/sandbox"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		policy  config.SandboxConfig
+		dir     string
+		binary  string
+		args    []string
+		wantErr error
+		want    string
+	}{
+		{
+			name:    "empty allowlist",
+			policy:  config.SandboxConfig{WorkDir: dir},
+			binary:  "echo",
+			args:    []string{"hello"},
+			wantErr: sandbox.ErrBinaryNotAllowed,
+		},
+		{
+			name:    "binary not allowlisted",
+			policy:  config.SandboxConfig{WorkDir: dir, AllowedBinaries: []string{"true"}},
+			binary:  "echo",
+			args:    []string{"hello"},
+			wantErr: sandbox.ErrBinaryNotAllowed,
+		},
+		{
+			name:   "binary allowlisted",
+			policy: config.SandboxConfig{WorkDir: dir, AllowedBinaries: []string{"echo"}},
+			binary: "echo",
+			args:   []string{"hello"},
+			want:   "hello",
+		},
+		{
+			name:    "directory outside of the work dir",
+			policy:  config.SandboxConfig{WorkDir: dir, AllowedBinaries: []string{"echo"}},
+			dir:     "..",
+			binary:  "echo",
+			wantErr: sandbox.ErrOutsideWorkDir,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb, err := sandbox.New(tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := sb.Run(t.Context(), tt.dir, tt.binary, tt.args, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.TrimSpace(string(out)); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestRunNotAllowedError tells what to allow, so that users know how to
+// opt in to run a binary
+func TestRunNotAllowedError(t *testing.T) {
+	sb, err := sandbox.New(config.SandboxConfig{WorkDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sb.Run(t.Context(), "", "echo", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "run.sandbox.allowed-binaries") {
+		t.Fatalf("expected an error naming run.sandbox.allowed-binaries, got %v", err)
+	}
+}
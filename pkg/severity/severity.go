@@ -0,0 +1,89 @@
+// Package severity applies the custom severity levels and the severity
+// overrides of the configuration
+package severity
+
+import (
+	"fmt"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/exclude"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/linter"
+)
+
+// Overrides reports the issues matching a severity rule with the severity of
+// the rule
+type Overrides struct {
+	rules []override
+}
+
+type override struct {
+	rule     *exclude.Rule
+	severity linter.Severity
+}
+
+// New compiles the severity rules of the configuration
+func New(cfg config.SeverityConfig) (*Overrides, error) {
+	o := &Overrides{}
+
+	for i, r := range cfg.Rules {
+		rule, err := exclude.NewRule(r.ExcludeRule)
+		if err != nil {
+			return nil, fmt.Errorf("severity rule %d: %w", i, err)
+		}
+
+		o.rules = append(o.rules, override{rule: rule, severity: linter.Severity(r.Severity)})
+	}
+
+	return o, nil
+}
+
+// Apply sets the severity of the issues matching a rule, the first matching
+// rule wins. Issues reported about files, such as the ones skipped by
+// renderers, match the rules by the path of the file.
+func (o *Overrides) Apply(issues []linter.Issue) []linter.Issue {
+	if len(o.rules) == 0 {
+		return issues
+	}
+
+	for i := range issues {
+		file := ""
+		switch {
+		case issues[i].Position != nil:
+			file = issues[i].Position.File
+		case issues[i].Resource.Kind == "File" && issues[i].Resource.APIVersion == "":
+			file = issues[i].Resource.Name
+		}
+
+		for _, r := range o.rules {
+			if r.rule.Matches(issues[i], file) {
+				issues[i].Severity = r.severity
+				break
+			}
+		}
+	}
+
+	return issues
+}
+
+// Register sets the severity levels of the configuration, the built-in
+// levels are kept when the configuration defines none
+func Register(cfg config.SeverityConfig) error {
+	if len(cfg.Levels) == 0 {
+		return nil
+	}
+
+	levels := make([]linter.SeverityLevel, len(cfg.Levels))
+	for i, l := range cfg.Levels {
+		levels[i] = linter.SeverityLevel{
+			Name:  linter.Severity(l.Name),
+			Like:  linter.Severity(l.Like),
+			Color: l.Color,
+		}
+	}
+
+	if err := linter.SetSeverityLevels(levels); err != nil {
+		return fmt.Errorf("invalid severity.levels: %w", err)
+	}
+
+	return nil
+}
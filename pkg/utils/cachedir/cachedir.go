@@ -0,0 +1,53 @@
+package cachedir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Name is the directory created in the user cache directory when no cache
+// directory is configured
+const Name = "k8s-manifests-lint"
+
+// Dir returns, creating it, the elem subdirectory of the base cache
+// directory, base defaults to Name in the user cache directory
+func Dir(base string, elem ...string) (string, error) {
+	dir, err := path(base, elem...)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create the cache directory: %w", err)
+	}
+
+	return dir, nil
+}
+
+// Remove removes the elem subdirectory of the base cache directory and
+// returns its path, removing a missing directory is not an error
+func Remove(base string, elem ...string) (string, error) {
+	dir, err := path(base, elem...)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("failed to remove the cache directory: %w", err)
+	}
+
+	return dir, nil
+}
+
+func path(base string, elem ...string) (string, error) {
+	if base == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate the cache directory: %w", err)
+		}
+		base = filepath.Join(userDir, Name)
+	}
+
+	return filepath.Join(append([]string{base}, elem...)...), nil
+}
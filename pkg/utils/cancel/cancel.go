@@ -0,0 +1,36 @@
+package cancel
+
+import (
+	"context"
+)
+
+// Run calls fn and returns its result, or the context error as soon as the
+// context is done. It is meant for functions which do not honor the context
+// themselves: fn keeps running in the background until it completes, but
+// its result is discarded.
+func Run[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	var zero T
+
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	type outcome struct {
+		value T
+		err   error
+	}
+
+	done := make(chan outcome, 1)
+
+	go func() {
+		value, err := fn()
+		done <- outcome{value: value, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case o := <-done:
+		return o.value, o.err
+	}
+}
@@ -0,0 +1,67 @@
+package envsubst
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// refRe matches ${VAR} references
+var refRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Options controls the substitution
+type Options struct {
+	// Allow lists the variables which are substituted, references to other
+	// variables are left untouched. An empty list allows every variable.
+	Allow []string
+	// Strict fails when a substituted variable is not set instead of
+	// replacing it with an empty string
+	Strict bool
+	// Lookup resolves a variable, it defaults to os.LookupEnv
+	Lookup func(name string) (string, bool)
+}
+
+// Expand substitutes the ${VAR} references in content with the value of the
+// environment variables, the way envsubst does
+func Expand(content []byte, opts Options) ([]byte, error) {
+	lookup := opts.Lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	allowed := make(map[string]bool, len(opts.Allow))
+	for _, name := range opts.Allow {
+		allowed[name] = true
+	}
+
+	missing := make(map[string]bool)
+
+	out := refRe.ReplaceAllFunc(content, func(ref []byte) []byte {
+		name := string(ref[2 : len(ref)-1])
+
+		if len(allowed) > 0 && !allowed[name] {
+			return ref
+		}
+
+		value, ok := lookup(name)
+		if !ok {
+			missing[name] = true
+		}
+
+		return []byte(value)
+	})
+
+	if opts.Strict && len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("environment variables not set: %s", strings.Join(names, ", "))
+	}
+
+	return out, nil
+}
@@ -0,0 +1,173 @@
+package fieldpath
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Root is the JSONPath expression of the object itself
+const Root = "$"
+
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Path builds a JSONPath expression relative to the object root from the
+// given segments: strings are field names and ints array indexes, e.g.
+// Path("metadata", "labels", "app.kubernetes.io/name") returns
+// $.metadata.labels['app.kubernetes.io/name']
+func Path(segments ...interface{}) string {
+	return Join(Root, segments...)
+}
+
+// Join appends the segments to an existing JSONPath expression
+func Join(base string, segments ...interface{}) string {
+	var sb strings.Builder
+	sb.WriteString(base)
+
+	for _, segment := range segments {
+		switch s := segment.(type) {
+		case int:
+			sb.WriteString("[" + strconv.Itoa(s) + "]")
+		case string:
+			if identRe.MatchString(s) {
+				sb.WriteString("." + s)
+			} else {
+				sb.WriteString("['" + strings.ReplaceAll(s, "'", `\'`) + "']")
+			}
+		default:
+			panic(fmt.Sprintf("unsupported field path segment %T", segment))
+		}
+	}
+
+	return sb.String()
+}
+
+// Normalize turns a dotted field reference such as spec.replicas, as used in
+// custom linter rules, into a JSONPath expression. Expressions already
+// rooted at $ are returned as is.
+func Normalize(field string) string {
+	switch {
+	case field == "" || strings.HasPrefix(field, Root):
+		return field
+	case strings.HasPrefix(field, ".") || strings.HasPrefix(field, "["):
+		return Root + field
+	default:
+		return Root + "." + field
+	}
+}
+
+// Parent returns the path of the parent of the field, the parent of Root is
+// Root itself
+func Parent(path string) string {
+	if path == Root || path == "" {
+		return Root
+	}
+
+	// bracketed segments may contain dots, so they are handled first
+	if strings.HasSuffix(path, "]") {
+		inQuote := false
+		for i := len(path) - 2; i >= 0; i-- {
+			switch path[i] {
+			case '\'':
+				if i == 0 || path[i-1] != '\\' {
+					inQuote = !inQuote
+				}
+			case '[':
+				if !inQuote {
+					return path[:i]
+				}
+			}
+		}
+		return Root
+	}
+
+	if i := strings.LastIndexAny(path, ".]"); i >= 0 {
+		if path[i] == ']' {
+			return path[:i+1]
+		}
+		return path[:i]
+	}
+
+	return Root
+}
+
+// Segments splits a JSONPath expression built by Path or Join back into its
+// segments, field names as strings and array indexes as ints
+func Segments(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, Root) {
+		return nil, fmt.Errorf("field path %q is not rooted at %s", path, Root)
+	}
+
+	var segments []interface{}
+
+	for rest := path[len(Root):]; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, "['"):
+			var sb strings.Builder
+
+			i := 2
+			for ; i < len(rest) && rest[i] != '\''; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				sb.WriteByte(rest[i])
+			}
+
+			if !strings.HasPrefix(rest[i:], "']") {
+				return nil, fmt.Errorf("unterminated segment in field path %q", path)
+			}
+
+			segments = append(segments, sb.String())
+			rest = rest[i+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated segment in field path %q", path)
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index in field path %q: %w", path, err)
+			}
+
+			segments = append(segments, index)
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+
+			segments = append(segments, rest[1:end+1])
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid field path %q", path)
+		}
+	}
+
+	return segments, nil
+}
+
+// Pointer converts a JSONPath expression built by Path or Join into a JSON
+// Pointer (RFC 6901), as used by JSON patch operations
+func Pointer(path string) (string, error) {
+	segments, err := Segments(path)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, segment := range segments {
+		sb.WriteString("/")
+
+		switch s := segment.(type) {
+		case int:
+			sb.WriteString(strconv.Itoa(s))
+		case string:
+			sb.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(s))
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,63 @@
+package glob
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Clean returns the slash separated form of the path, without a leading ./
+func Clean(p string) string {
+	if p == "" {
+		return ""
+	}
+
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "./")
+}
+
+// Compile converts a path glob to a regular expression: ** matches any
+// number of directories, * and ? do not cross directory boundaries. A
+// pattern also matches all the files below the directories it matches.
+func Compile(glob string) (*regexp.Regexp, error) {
+	glob = Clean(glob)
+
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			i++
+			if i+1 < len(glob) && glob[i+1] == '/' {
+				i++
+				sb.WriteString("(?:.*/)?")
+			} else {
+				sb.WriteString(".*")
+			}
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("(?:/.*)?$")
+
+	return regexp.Compile(sb.String())
+}
+
+// MatchAny reports whether s matches one of the path.Match patterns, malformed
+// patterns match nothing
+func MatchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if matched, _ := path.Match(p, s); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,31 @@
+package glob_test
+
+import (
+	"testing"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/glob"
+)
+
+func TestMatchAny(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		s        string
+		want     bool
+	}{
+		{name: "no patterns", s: "app", want: false},
+		{name: "exact", patterns: []string{"web", "app"}, s: "app", want: true},
+		{name: "wildcard", patterns: []string{"checksum/*"}, s: "checksum/config", want: true},
+		{name: "no match", patterns: []string{"checksum/*"}, s: "app", want: false},
+		{name: "malformed pattern", patterns: []string{"[", "app"}, s: "app", want: true},
+		{name: "only malformed pattern", patterns: []string{"["}, s: "[", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := glob.MatchAny(tt.patterns, tt.s); got != tt.want {
+				t.Errorf("MatchAny(%q, %q) = %v, want %v", tt.patterns, tt.s, got, tt.want)
+			}
+		})
+	}
+}
@@ -66,6 +66,12 @@ var (
 		Kind:    "Secret",
 	}
 
+	ServiceAccount = schema.GroupVersionKind{
+		Group:   corev1.SchemeGroupVersion.Group,
+		Version: corev1.SchemeGroupVersion.Version,
+		Kind:    "ServiceAccount",
+	}
+
 	PodDisruptionBudget = schema.GroupVersionKind{
 		Group:   policyv1.SchemeGroupVersion.Group,
 		Version: policyv1.SchemeGroupVersion.Version,
@@ -90,6 +96,30 @@ var (
 		Kind:    "NetworkPolicy",
 	}
 
+	Namespace = schema.GroupVersionKind{
+		Group:   corev1.SchemeGroupVersion.Group,
+		Version: corev1.SchemeGroupVersion.Version,
+		Kind:    "Namespace",
+	}
+
+	CustomResourceDefinition = schema.GroupVersionKind{
+		Group:   "apiextensions.k8s.io",
+		Version: "v1",
+		Kind:    "CustomResourceDefinition",
+	}
+
+	ValidatingWebhookConfiguration = schema.GroupVersionKind{
+		Group:   "admissionregistration.k8s.io",
+		Version: "v1",
+		Kind:    "ValidatingWebhookConfiguration",
+	}
+
+	MutatingWebhookConfiguration = schema.GroupVersionKind{
+		Group:   "admissionregistration.k8s.io",
+		Version: "v1",
+		Kind:    "MutatingWebhookConfiguration",
+	}
+
 	ResourceQuota = schema.GroupVersionKind{
 		Group:   corev1.SchemeGroupVersion.Group,
 		Version: corev1.SchemeGroupVersion.Version,
@@ -0,0 +1,82 @@
+package jq
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CacheSize is the number of compiled queries kept by the cache
+const CacheSize = 256
+
+// InputCacheSize is the number of queried objects whose normalized value is
+// kept by the cache of a run, see WithInputs
+const InputCacheSize = 1024
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// lru is a LRU cache, used so that queries evaluated for every object are
+// parsed and compiled only once and objects queried several times are
+// normalized only once
+type lru[K comparable, V any] struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[K]*list.Element
+}
+
+var compiled = newLRU[string, *Code](CacheSize)
+
+func newLRU[K comparable, V any](size int) *lru[K, V] {
+	return &lru[K, V]{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[K]*list.Element),
+	}
+}
+
+// get returns the value of the key, from the cache when available or else
+// created with create. The lock is not held while creating, concurrent
+// misses of the same key may create it more than once and the first value
+// stored wins.
+func (c *lru[K, V]) get(key K, create func() (V, error)) (V, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*entry[K, V]).value, nil
+	}
+	c.mu.Unlock()
+
+	value, err := create()
+	if err != nil {
+		return value, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*entry[K, V]).value, nil
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry[K, V]).key)
+	}
+
+	return value, nil
+}
+
+// compile returns the compiled query, from the cache when available
+func compile(query string) (*Code, error) {
+	return compiled.get(query, func() (*Code, error) {
+		return Compile(query)
+	})
+}
@@ -0,0 +1,183 @@
+package jq
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/itchyny/gojq"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// names of the functions handing the input and the variables of a frame to
+// the compiled queries
+const (
+	inputFunc    = "_k8s_input"
+	variableFunc = "_k8s_variable"
+)
+
+// identity normalizes values, see NewInput
+var identity = mustCompile(".")
+
+func mustCompile(query string) *gojq.Code {
+	q, err := gojq.Parse(query)
+	if err != nil {
+		panic(err)
+	}
+
+	code, err := gojq.Compile(q)
+	if err != nil {
+		panic(err)
+	}
+
+	return code
+}
+
+// Input is a value prepared for the evaluation of queries.
+//
+// gojq normalizes the numbers of the input and of the variables of every
+// evaluation in place, writing back every element of the maps and slices
+// they hold even when nothing changes, so plain values cannot be shared by
+// concurrent evaluations. An Input is a private copy of the value which is
+// normalized once and never written to again, it can be shared freely.
+type Input struct {
+	value interface{}
+}
+
+// NewInput copies and normalizes the JSON compatible value v
+func NewInput(v interface{}) *Input {
+	// the copy is not shared yet, it is safe to let gojq normalize it
+	value, _ := identity.Run(runtime.DeepCopyJSONValue(v)).Next()
+
+	return &Input{value: value}
+}
+
+// Array returns the array of the inputs, their values are shared rather
+// than copied
+func Array(inputs ...*Input) *Input {
+	values := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		values[i] = input.Value()
+	}
+
+	return &Input{value: values}
+}
+
+// Value returns the normalized value, it must not be modified
+func (in *Input) Value() interface{} {
+	if in == nil {
+		return nil
+	}
+
+	return in.value
+}
+
+// Code is a compiled query which, unlike gojq.Code, can be evaluated by
+// concurrent goroutines against the same inputs
+type Code struct {
+	code      *gojq.Code
+	variables []string
+}
+
+// frame is what the wrapped query is evaluated against: gojq leaves values
+// of unknown types untouched, the input and the variables are only handed
+// to the query of the user by the functions of the frame
+type frame struct {
+	input     *Input
+	variables []*Input
+}
+
+// Compile parses and compiles the query, the variables are the names, such
+// as $object, of the values given to Run
+func Compile(query string, variables ...string) (*Code, error) {
+	for _, name := range variables {
+		if !strings.HasPrefix(name, "$") {
+			return nil, fmt.Errorf("invalid variable name %q: it must start with $", name)
+		}
+	}
+
+	q, err := gojq.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query %q: %w", query, err)
+	}
+
+	code, err := gojq.Compile(wrap(q, variables),
+		gojq.WithFunction(inputFunc, 0, 0, frameInput),
+		gojq.WithFunction(variableFunc, 1, 1, frameVariable),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile query %q: %w", query, err)
+	}
+
+	return &Code{code: code, variables: variables}, nil
+}
+
+// Run evaluates the query against the input, with the values of the
+// variables in the order they were given to Compile. The results share the
+// values of the inputs, they must not be modified.
+func (c *Code) Run(ctx context.Context, input *Input, variables ...*Input) gojq.Iter {
+	if len(variables) != len(c.variables) {
+		return gojq.NewIter(fmt.Errorf("expected %d variable values (%s), got %d",
+			len(c.variables), strings.Join(c.variables, ", "), len(variables)))
+	}
+
+	return c.code.RunWithContext(ctx, &frame{input: input, variables: variables})
+}
+
+// wrap returns the query binding the variables and the input of the frame:
+//
+//	_k8s_variable(0) as $a | ... | _k8s_input | (query)
+func wrap(q *gojq.Query, variables []string) *gojq.Query {
+	// imports are only allowed at the top level
+	inner := *q
+	inner.Meta, inner.Imports = nil, nil
+
+	body := &gojq.Query{
+		Left:  &gojq.Query{Term: &gojq.Term{Type: gojq.TermTypeFunc, Func: &gojq.Func{Name: inputFunc}}},
+		Op:    gojq.OpPipe,
+		Right: &gojq.Query{Term: &gojq.Term{Type: gojq.TermTypeQuery, Query: &inner}},
+	}
+
+	for i := len(variables) - 1; i >= 0; i-- {
+		index := &gojq.Query{Term: &gojq.Term{Type: gojq.TermTypeNumber, Number: strconv.Itoa(i)}}
+
+		body = &gojq.Query{Term: &gojq.Term{
+			Type: gojq.TermTypeFunc,
+			Func: &gojq.Func{Name: variableFunc, Args: []*gojq.Query{index}},
+			SuffixList: []*gojq.Suffix{{
+				Bind: &gojq.Bind{
+					Patterns: []*gojq.Pattern{{Name: variables[i]}},
+					Body:     body,
+				},
+			}},
+		}}
+	}
+
+	body.Meta, body.Imports = q.Meta, q.Imports
+
+	return body
+}
+
+func frameInput(v interface{}, _ []interface{}) interface{} {
+	f, ok := v.(*frame)
+	if !ok {
+		return fmt.Errorf("%s/0 is internal and cannot be called", inputFunc)
+	}
+
+	return f.input.Value()
+}
+
+func frameVariable(v interface{}, args []interface{}) interface{} {
+	f, ok := v.(*frame)
+	if !ok {
+		return fmt.Errorf("%s/1 is internal and cannot be called", variableFunc)
+	}
+
+	i, ok := args[0].(int)
+	if !ok || i < 0 || i >= len(f.variables) {
+		return fmt.Errorf("%s/1: invalid variable index %v", variableFunc, args[0])
+	}
+
+	return f.variables[i].Value()
+}
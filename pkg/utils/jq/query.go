@@ -1,20 +1,61 @@
 package jq
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"strings"
 
-	"github.com/itchyny/gojq"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-// Query executes a jq-style query on an unstructured object
-func Query(obj unstructured.Unstructured, query string) (interface{}, error) {
-	q, err := gojq.Parse(query)
+// inputs caches the normalized values of the queried objects, keyed by the
+// identity of their map
+type inputs = lru[uintptr, cachedInput]
+
+type contextKey struct{}
+
+// WithInputs returns a context carrying a new cache of normalized objects, the
+// queries called with it share the normalized copies until it is dropped
+func WithInputs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, newLRU[uintptr, cachedInput](InputCacheSize))
+}
+
+type cachedInput struct {
+	// object is referenced so that the address of the map is not reused by
+	// another object while cached
+	object map[string]interface{}
+	input  *Input
+}
+
+// objectInput returns the input of the object. Objects queried several times
+// with the cache of a run, as they are by the linters, are only copied and
+// normalized once: they must not be modified during the run.
+func objectInput(ctx context.Context, obj unstructured.Unstructured) *Input {
+	c, ok := ctx.Value(contextKey{}).(*inputs)
+	if !ok {
+		return NewInput(obj.Object)
+	}
+
+	key := reflect.ValueOf(obj.Object).Pointer()
+
+	cached, _ := c.get(key, func() (cachedInput, error) {
+		return cachedInput{object: obj.Object, input: NewInput(obj.Object)}, nil
+	})
+
+	return cached.input
+}
+
+// Query executes a jq-style query on an unstructured object, the result
+// shares the values of the cached copy of the object and must not be
+// modified
+func Query(ctx context.Context, obj unstructured.Unstructured, query string) (interface{}, error) {
+	code, err := compile(query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse query %q: %w", query, err)
+		return nil, err
 	}
 
-	iter := q.Run(obj.Object)
+	iter := code.Run(ctx, objectInput(ctx, obj))
 	v, ok := iter.Next()
 	if !ok {
 		return nil, nil
@@ -28,13 +69,13 @@ func Query(obj unstructured.Unstructured, query string) (interface{}, error) {
 }
 
 // QueryArray executes a jq-style query and returns results as a slice
-func QueryArray(obj unstructured.Unstructured, query string) ([]interface{}, error) {
-	q, err := gojq.Parse(query)
+func QueryArray(ctx context.Context, obj unstructured.Unstructured, query string) ([]interface{}, error) {
+	code, err := compile(query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse query %q: %w", query, err)
+		return nil, err
 	}
 
-	iter := q.Run(obj.Object)
+	iter := code.Run(ctx, objectInput(ctx, obj))
 	var results []interface{}
 
 	for {
@@ -51,9 +92,32 @@ func QueryArray(obj unstructured.Unstructured, query string) ([]interface{}, err
 	return results, nil
 }
 
+// QueryMany evaluates several queries against the object in a single pass and
+// returns, for each of them, the first result or nil, as Query does. It is
+// cheaper than several Query calls as the object is only traversed once to
+// prepare it for evaluation.
+func QueryMany(ctx context.Context, obj unstructured.Unstructured, queries ...string) ([]interface{}, error) {
+	parts := make([]string, len(queries))
+	for i, query := range queries {
+		parts[i] = "([limit(1; " + query + ")] | .[0])"
+	}
+
+	v, err := Query(ctx, obj, "["+strings.Join(parts, ", ")+"]")
+	if err != nil {
+		return nil, err
+	}
+
+	results, ok := v.([]interface{})
+	if !ok || len(results) != len(queries) {
+		return nil, fmt.Errorf("unexpected result %v for queries %q", v, queries)
+	}
+
+	return results, nil
+}
+
 // QueryString executes a jq-style query and returns the result as a string
-func QueryString(obj unstructured.Unstructured, query string) (string, bool, error) {
-	v, err := Query(obj, query)
+func QueryString(ctx context.Context, obj unstructured.Unstructured, query string) (string, bool, error) {
+	v, err := Query(ctx, obj, query)
 	if err != nil {
 		return "", false, err
 	}
@@ -67,8 +131,8 @@ func QueryString(obj unstructured.Unstructured, query string) (string, bool, err
 }
 
 // QueryBool executes a jq-style query and returns the result as a bool
-func QueryBool(obj unstructured.Unstructured, query string) (bool, bool, error) {
-	v, err := Query(obj, query)
+func QueryBool(ctx context.Context, obj unstructured.Unstructured, query string) (bool, bool, error) {
+	v, err := Query(ctx, obj, query)
 	if err != nil {
 		return false, false, err
 	}
@@ -82,8 +146,8 @@ func QueryBool(obj unstructured.Unstructured, query string) (bool, bool, error)
 }
 
 // QueryInt executes a jq-style query and returns the result as an int
-func QueryInt(obj unstructured.Unstructured, query string) (int, bool, error) {
-	v, err := Query(obj, query)
+func QueryInt(ctx context.Context, obj unstructured.Unstructured, query string) (int, bool, error) {
+	v, err := Query(ctx, obj, query)
 	if err != nil {
 		return 0, false, err
 	}
@@ -102,8 +166,8 @@ func QueryInt(obj unstructured.Unstructured, query string) (int, bool, error) {
 }
 
 // QueryExists checks if a field exists (returns non-null value)
-func QueryExists(obj unstructured.Unstructured, query string) (bool, error) {
-	v, err := Query(obj, query)
+func QueryExists(ctx context.Context, obj unstructured.Unstructured, query string) (bool, error) {
+	v, err := Query(ctx, obj, query)
 	if err != nil {
 		return false, err
 	}
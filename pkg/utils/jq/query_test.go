@@ -0,0 +1,180 @@
+package jq_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/jq"
+)
+
+func deployment() unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name": "app",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": "app:1.0.0",
+							"ports": []interface{}{
+								map[string]interface{}{"containerPort": int64(8080)},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func TestQuery(t *testing.T) {
+	obj := deployment()
+
+	tests := []struct {
+		query string
+		want  interface{}
+	}{
+		{query: ".metadata.name", want: "app"},
+		{query: ".spec.replicas", want: 3},
+		{query: ".spec.replicas + 1", want: 4},
+		{query: ".spec.template.spec.containers[0].ports[0].containerPort", want: 8080},
+		{query: "def image: .spec.template.spec.containers[0].image; image", want: "app:1.0.0"},
+		{query: ".missing", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := jq.Query(t.Context(), obj, tt.query)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Query() = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	obj := deployment()
+
+	for _, query := range []string{".[", "$undefined", "_k8s_input", "_k8s_variable(0)", ".metadata.name | keys"} {
+		t.Run(query, func(t *testing.T) {
+			if _, err := jq.Query(t.Context(), obj, query); err == nil {
+				t.Errorf("Query() expected an error")
+			}
+		})
+	}
+}
+
+// TestQueryConcurrent checks, with -race, that concurrent queries of the
+// same object neither race nor modify the object
+func TestQueryConcurrent(t *testing.T) {
+	obj := deployment()
+	ctx := jq.WithInputs(t.Context())
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for range 100 {
+				if _, err := jq.QueryArray(ctx, obj, ".spec.template.spec.containers[].ports[].containerPort"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if replicas := obj.Object["spec"].(map[string]interface{})["replicas"]; replicas != int64(3) {
+		t.Errorf("object modified: replicas = %v (%T), want 3 (int64)", replicas, replicas)
+	}
+}
+
+// TestQueryInputsScope shares the normalized objects within a run only, an
+// object modified between two runs is normalized again
+func TestQueryInputsScope(t *testing.T) {
+	obj := deployment()
+
+	for _, replicas := range []int64{3, 5} {
+		obj.Object["spec"].(map[string]interface{})["replicas"] = replicas
+
+		for _, ctx := range []context.Context{jq.WithInputs(t.Context()), t.Context()} {
+			got, err := jq.Query(ctx, obj, ".spec.replicas")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != int(replicas) {
+				t.Errorf("Query() = %v (%T), want %d", got, got, replicas)
+			}
+		}
+	}
+}
+
+func TestCodeRun(t *testing.T) {
+	code, err := jq.Compile(`[$objects[] | select(.spec.replicas > $min.replicas) | .metadata.name]`, "$objects", "$min")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := deployment()
+	b := deployment()
+	b.SetName("other")
+	b.Object["spec"].(map[string]interface{})["replicas"] = int64(1)
+
+	objects := jq.Array(jq.NewInput(a.Object), jq.NewInput(b.Object))
+	limit := jq.NewInput(map[string]interface{}{"replicas": int64(2)})
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			v, ok := code.Run(context.Background(), nil, objects, limit).Next()
+			if !ok {
+				t.Error("Run() returned no result")
+				return
+			}
+
+			names, ok := v.([]interface{})
+			if !ok || len(names) != 1 || names[0] != "app" {
+				t.Errorf("Run() = %v, want [app]", v)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if v, _ := code.Run(context.Background(), nil, objects).Next(); v == nil {
+		t.Error("Run() with missing variables expected an error")
+	} else if _, ok := v.(error); !ok {
+		t.Errorf("Run() with missing variables = %v, expected an error", v)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	if _, err := jq.Compile(".", "objects"); err == nil {
+		t.Error("Compile() expected an error for a variable name without $")
+	}
+
+	if _, err := jq.Compile("$object", "$objects"); err == nil {
+		t.Error("Compile() expected an error for an undefined variable")
+	}
+}
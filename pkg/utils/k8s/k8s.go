@@ -1,32 +1,79 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
 	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/jq"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-// GetContainers is a helper to get containers from various resource types
-func GetContainers(obj unstructured.Unstructured) ([]interface{}, error) {
-	var query string
+// PodSpecPath returns the JSONPath of the pod spec of the supported workload
+// resource types
+func PodSpecPath(obj unstructured.Unstructured) (string, error) {
 	switch {
 	case gvk.IsGVK(obj, gvk.CronJob):
-		query = ".spec.jobTemplate.spec.template.spec.containers"
+		return fieldpath.Path("spec", "jobTemplate", "spec", "template", "spec"), nil
 	case gvk.IsGVK(obj, gvk.Pod):
-		query = ".spec.containers"
-	case gvk.IsGVK(obj, gvk.Deployment):
-		query = ".spec.template.spec.containers"
+		return fieldpath.Path("spec"), nil
+	case gvk.IsAnyGVK(obj, gvk.Deployment, gvk.StatefulSet, gvk.DaemonSet, gvk.Job):
+		return fieldpath.Path("spec", "template", "spec"), nil
 	default:
-		return nil, fmt.Errorf(
+		return "", fmt.Errorf(
 			"unsuported type: %s:%s",
 			obj.GroupVersionKind().GroupVersion(),
 			obj.GroupVersionKind().Kind,
 		)
 	}
+}
+
+// ContainerPath returns the JSONPath of the container at the given index,
+// followed by the optional segments
+func ContainerPath(obj unstructured.Unstructured, index int, segments ...interface{}) string {
+	return containerPath(obj, "containers", index, segments)
+}
+
+// InitContainerPath returns the JSONPath of the init container at the given
+// index, followed by the optional segments
+func InitContainerPath(obj unstructured.Unstructured, index int, segments ...interface{}) string {
+	return containerPath(obj, "initContainers", index, segments)
+}
+
+func containerPath(obj unstructured.Unstructured, field string, index int, segments []interface{}) string {
+	base, err := PodSpecPath(obj)
+	if err != nil {
+		base = fieldpath.Root
+	}
+
+	return fieldpath.Join(base, append([]interface{}{field, index}, segments...)...)
+}
+
+// GetContainers is a helper to get containers from various resource types
+func GetContainers(ctx context.Context, obj unstructured.Unstructured) ([]interface{}, error) {
+	return getContainers(ctx, obj, "containers")
+}
+
+// GetInitContainers returns the init containers of the same resource types as
+// GetContainers
+func GetInitContainers(ctx context.Context, obj unstructured.Unstructured) ([]interface{}, error) {
+	return getContainers(ctx, obj, "initContainers")
+}
+
+func getContainers(ctx context.Context, obj unstructured.Unstructured, field string) ([]interface{}, error) {
+	specPath, err := PodSpecPath(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSONPath and jq share the syntax of simple field accesses
+	query := strings.TrimPrefix(specPath, fieldpath.Root) + "." + field
 
-	result, err := jq.Query(obj, query)
+	// malformed objects (e.g. a string where a map is expected) are treated
+	// as having no containers rather than failing the whole run
+	result, err := jq.Query(ctx, obj, "("+query+")?")
 	if err != nil {
 		return nil, err
 	}
@@ -41,3 +88,21 @@ func GetContainers(obj unstructured.Unstructured) ([]interface{}, error) {
 
 	return nil, nil
 }
+
+// PodLabels returns the labels of the pods of a workload, or of the Pod
+// itself, false when the labels are malformed
+func PodLabels(obj unstructured.Unstructured) (map[string]string, bool) {
+	var path []string
+
+	switch {
+	case gvk.IsGVK(obj, gvk.Pod):
+		return obj.GetLabels(), true
+	case gvk.IsGVK(obj, gvk.CronJob):
+		path = []string{"spec", "jobTemplate", "spec", "template", "metadata", "labels"}
+	default:
+		path = []string{"spec", "template", "metadata", "labels"}
+	}
+
+	result, _, err := unstructured.NestedStringMap(obj.Object, path...)
+	return result, err == nil
+}
@@ -0,0 +1,127 @@
+package k8s_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/k8s"
+)
+
+func workload(kind schema.GroupVersionKind, podSpec map[string]interface{}) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetGroupVersionKind(kind)
+	obj.SetName("app")
+
+	template := map[string]interface{}{"spec": podSpec}
+
+	switch kind {
+	case gvk.Pod:
+		obj.Object["spec"] = podSpec
+	case gvk.CronJob:
+		obj.Object["spec"] = map[string]interface{}{
+			"jobTemplate": map[string]interface{}{
+				"spec": map[string]interface{}{"template": template},
+			},
+		}
+	default:
+		obj.Object["spec"] = map[string]interface{}{"template": template}
+	}
+
+	return obj
+}
+
+func TestGetContainers(t *testing.T) {
+	containers := []interface{}{
+		map[string]interface{}{"name": "app", "image": "app:1.0.0"},
+		map[string]interface{}{"name": "sidecar", "image": "sidecar:1.0.0"},
+	}
+
+	tests := []struct {
+		name string
+		obj  unstructured.Unstructured
+		want int
+	}{
+		{name: "Pod", obj: workload(gvk.Pod, map[string]interface{}{"containers": containers}), want: 2},
+		{name: "Deployment", obj: workload(gvk.Deployment, map[string]interface{}{"containers": containers}), want: 2},
+		{name: "StatefulSet", obj: workload(gvk.StatefulSet, map[string]interface{}{"containers": containers}), want: 2},
+		{name: "DaemonSet", obj: workload(gvk.DaemonSet, map[string]interface{}{"containers": containers}), want: 2},
+		{name: "Job", obj: workload(gvk.Job, map[string]interface{}{"containers": containers}), want: 2},
+		{name: "CronJob", obj: workload(gvk.CronJob, map[string]interface{}{"containers": containers}), want: 2},
+		{name: "no containers", obj: workload(gvk.Deployment, map[string]interface{}{}), want: 0},
+		{name: "malformed containers", obj: workload(gvk.Deployment, map[string]interface{}{"containers": "app"}), want: 0},
+		{name: "malformed spec", obj: unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "StatefulSet",
+			"spec":       "app",
+		}}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := k8s.GetContainers(t.Context(), tt.obj)
+			if err != nil {
+				t.Fatalf("GetContainers() error = %v", err)
+			}
+
+			if len(got) != tt.want {
+				t.Errorf("GetContainers() returned %d containers, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetContainersUnsupported(t *testing.T) {
+	obj := unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk.ConfigMap)
+
+	if _, err := k8s.GetContainers(t.Context(), obj); err == nil {
+		t.Error("GetContainers() expected an error for a ConfigMap")
+	}
+}
+
+func TestPodLabels(t *testing.T) {
+	withLabels := func(obj unstructured.Unstructured, labels interface{}) unstructured.Unstructured {
+		metadata := map[string]interface{}{"labels": labels}
+
+		switch obj.GroupVersionKind() {
+		case gvk.Pod:
+			obj.Object["metadata"] = metadata
+		case gvk.CronJob:
+			obj.Object["spec"].(map[string]interface{})["jobTemplate"].(map[string]interface{})["spec"].(map[string]interface{})["template"].(map[string]interface{})["metadata"] = metadata
+		default:
+			obj.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["metadata"] = metadata
+		}
+
+		return obj
+	}
+
+	labels := map[string]interface{}{"app": "web"}
+
+	tests := []struct {
+		name string
+		obj  unstructured.Unstructured
+		want map[string]string
+		ok   bool
+	}{
+		{name: "Pod", obj: withLabels(workload(gvk.Pod, nil), labels), want: map[string]string{"app": "web"}, ok: true},
+		{name: "Deployment", obj: withLabels(workload(gvk.Deployment, nil), labels), want: map[string]string{"app": "web"}, ok: true},
+		{name: "CronJob", obj: withLabels(workload(gvk.CronJob, nil), labels), want: map[string]string{"app": "web"}, ok: true},
+		{name: "no labels", obj: workload(gvk.StatefulSet, nil), ok: true},
+		{name: "malformed labels", obj: withLabels(workload(gvk.Deployment, nil), "app=web"), ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := k8s.PodLabels(tt.obj)
+			if ok != tt.ok {
+				t.Fatalf("PodLabels() ok = %v, want %v", ok, tt.ok)
+			}
+			if len(got) != len(tt.want) || got["app"] != tt.want["app"] {
+				t.Errorf("PodLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package kube
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/config"
+)
+
+// RESTConfig builds a client configuration honoring the usual kubeconfig
+// loading rules (KUBECONFIG, ~/.kube/config, in-cluster) with the context,
+// impersonation and timeout overrides of the given cluster configuration
+func RESTConfig(cfg config.ClusterConfig) (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if cfg.Kubeconfig != "" {
+		loadingRules.ExplicitPath = cfg.Kubeconfig
+	}
+
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: cfg.Context,
+	}
+
+	overrides.AuthInfo.Impersonate = cfg.As
+	overrides.AuthInfo.ImpersonateGroups = cfg.AsGroups
+
+	if cfg.RequestTimeout > 0 {
+		overrides.Timeout = cfg.RequestTimeout.String()
+	}
+
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	return restConfig, nil
+}
+
+// Namespace returns the namespace of the selected kubeconfig context
+func Namespace(cfg config.ClusterConfig) (string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if cfg.Kubeconfig != "" {
+		loadingRules.ExplicitPath = cfg.Kubeconfig
+	}
+
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{
+		CurrentContext: cfg.Context,
+	})
+
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return "", fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	return namespace, nil
+}
@@ -0,0 +1,331 @@
+package pss
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/fieldpath"
+)
+
+// Level is a Pod Security Standards level
+type Level string
+
+const (
+	Privileged Level = "privileged"
+	Baseline   Level = "baseline"
+	Restricted Level = "restricted"
+
+	// EnforceLabel is the namespace label selecting the level enforced by
+	// the Pod Security admission
+	EnforceLabel = "pod-security.kubernetes.io/enforce"
+)
+
+// Controls of the Pod Security Standards, as named in the Kubernetes
+// documentation
+const (
+	ControlHostProcess            = "host-process"
+	ControlHostNamespaces         = "host-namespaces"
+	ControlPrivileged             = "privileged"
+	ControlCapabilities           = "capabilities"
+	ControlHostPathVolumes        = "host-path-volumes"
+	ControlHostPorts              = "host-ports"
+	ControlAppArmor               = "apparmor"
+	ControlSELinux                = "selinux"
+	ControlProcMount              = "proc-mount"
+	ControlSeccomp                = "seccomp"
+	ControlSysctls                = "sysctls"
+	ControlVolumeTypes            = "volume-types"
+	ControlPrivilegeEscalation    = "privilege-escalation"
+	ControlRunningAsNonRoot       = "running-as-non-root"
+	ControlRunningAsNonRootUser   = "running-as-non-root-user"
+	ControlSeccompRestricted      = "seccomp-restricted"
+	ControlCapabilitiesRestricted = "capabilities-restricted"
+)
+
+// Controls returns the controls checked by Check, the baseline ones first
+func Controls() []string {
+	return []string{
+		ControlHostProcess, ControlHostNamespaces, ControlPrivileged, ControlCapabilities,
+		ControlHostPathVolumes, ControlHostPorts, ControlAppArmor, ControlSELinux,
+		ControlProcMount, ControlSeccomp, ControlSysctls,
+		ControlVolumeTypes, ControlPrivilegeEscalation, ControlRunningAsNonRoot,
+		ControlRunningAsNonRootUser, ControlSeccompRestricted, ControlCapabilitiesRestricted,
+	}
+}
+
+// baselineCapabilities are the capabilities the baseline level allows to add
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE":      true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"KILL":             true,
+	"MKNOD":            true,
+	"NET_BIND_SERVICE": true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYS_CHROOT":       true,
+}
+
+// baselineSELinuxTypes are the SELinux types the baseline level allows
+var baselineSELinuxTypes = map[string]bool{
+	"":                   true,
+	"container_t":        true,
+	"container_init_t":   true,
+	"container_kvm_t":    true,
+	"container_engine_t": true,
+}
+
+// baselineSysctls are the safe sysctls the baseline level allows
+var baselineSysctls = map[string]bool{
+	"kernel.shm_rmid_forced":              true,
+	"net.ipv4.ip_local_port_range":        true,
+	"net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.tcp_syncookies":             true,
+	"net.ipv4.ping_group_range":           true,
+	"net.ipv4.ip_local_reserved_ports":    true,
+	"net.ipv4.tcp_keepalive_time":         true,
+	"net.ipv4.tcp_fin_timeout":            true,
+	"net.ipv4.tcp_keepalive_intvl":        true,
+	"net.ipv4.tcp_keepalive_probes":       true,
+}
+
+// Violation is a pod spec setting not allowed by a level, Field is the
+// JSONPath of the setting and Control the one of the Pod Security Standards
+// it breaks
+type Violation struct {
+	Level   Level
+	Control string
+	Field   string
+	Message string
+}
+
+// ParseLevel validates a level name
+func ParseLevel(s string) (Level, error) {
+	switch l := Level(s); l {
+	case Privileged, Baseline, Restricted:
+		return l, nil
+	default:
+		return "", fmt.Errorf("unknown pod security level %q", s)
+	}
+}
+
+// Check returns the settings of the pod spec, found at the JSONPath base,
+// not allowed by the given level. It covers the controls of the Pod
+// Security Standards which can be checked statically on a manifest.
+func Check(spec *corev1.PodSpec, base string, level Level) []Violation {
+	var v []Violation
+
+	if level == Privileged {
+		return nil
+	}
+
+	v = append(v, checkBaseline(spec, base)...)
+
+	if level == Restricted {
+		v = append(v, checkRestricted(spec, base)...)
+	}
+
+	return v
+}
+
+func checkBaseline(spec *corev1.PodSpec, base string) []Violation {
+	var v []Violation
+
+	add := func(control string, field string, format string, args ...interface{}) {
+		v = append(v, Violation{Level: Baseline, Control: control, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if spec.HostNetwork {
+		add(ControlHostNamespaces, fieldpath.Join(base, "hostNetwork"), "hostNetwork must not be set")
+	}
+	if spec.HostPID {
+		add(ControlHostNamespaces, fieldpath.Join(base, "hostPID"), "hostPID must not be set")
+	}
+	if spec.HostIPC {
+		add(ControlHostNamespaces, fieldpath.Join(base, "hostIPC"), "hostIPC must not be set")
+	}
+
+	for i, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			add(ControlHostPathVolumes, fieldpath.Join(base, "volumes", i, "hostPath"), "volume %q must not use hostPath", volume.Name)
+		}
+	}
+
+	if pod := spec.SecurityContext; pod != nil {
+		path := fieldpath.Join(base, "securityContext")
+
+		if pod.WindowsOptions != nil && pod.WindowsOptions.HostProcess != nil && *pod.WindowsOptions.HostProcess {
+			add(ControlHostProcess, fieldpath.Join(path, "windowsOptions", "hostProcess"), "pod must not run as a Windows HostProcess")
+		}
+		if pod.SeccompProfile != nil && pod.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			add(ControlSeccomp, fieldpath.Join(path, "seccompProfile", "type"), "pod must not use the Unconfined seccomp profile")
+		}
+		if pod.AppArmorProfile != nil && pod.AppArmorProfile.Type == corev1.AppArmorProfileTypeUnconfined {
+			add(ControlAppArmor, fieldpath.Join(path, "appArmorProfile", "type"), "pod must not use the Unconfined AppArmor profile")
+		}
+		if message := checkSELinux(pod.SELinuxOptions); message != "" {
+			add(ControlSELinux, fieldpath.Join(path, "seLinuxOptions"), "pod %s", message)
+		}
+
+		for i, sysctl := range pod.Sysctls {
+			if !baselineSysctls[sysctl.Name] {
+				add(ControlSysctls, fieldpath.Join(path, "sysctls", i, "name"), "pod must not set the unsafe sysctl %q", sysctl.Name)
+			}
+		}
+	}
+
+	forEachContainer(spec, base, func(c *corev1.Container, path string) {
+		sc := c.SecurityContext
+
+		if sc != nil && sc.Privileged != nil && *sc.Privileged {
+			add(ControlPrivileged, fieldpath.Join(path, "securityContext", "privileged"), "container %q must not be privileged", c.Name)
+		}
+
+		if sc != nil && sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !baselineCapabilities[capability] {
+					add(ControlCapabilities, fieldpath.Join(path, "securityContext", "capabilities", "add"), "container %q must not add capability %q", c.Name, capability)
+				}
+			}
+		}
+
+		for j, port := range c.Ports {
+			if port.HostPort != 0 {
+				add(ControlHostPorts, fieldpath.Join(path, "ports", j, "hostPort"), "container %q must not use hostPort", c.Name)
+			}
+		}
+
+		if sc == nil {
+			return
+		}
+
+		if sc.WindowsOptions != nil && sc.WindowsOptions.HostProcess != nil && *sc.WindowsOptions.HostProcess {
+			add(ControlHostProcess, fieldpath.Join(path, "securityContext", "windowsOptions", "hostProcess"), "container %q must not run as a Windows HostProcess", c.Name)
+		}
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			add(ControlSeccomp, fieldpath.Join(path, "securityContext", "seccompProfile", "type"), "container %q must not use the Unconfined seccomp profile", c.Name)
+		}
+		if sc.AppArmorProfile != nil && sc.AppArmorProfile.Type == corev1.AppArmorProfileTypeUnconfined {
+			add(ControlAppArmor, fieldpath.Join(path, "securityContext", "appArmorProfile", "type"), "container %q must not use the Unconfined AppArmor profile", c.Name)
+		}
+		if message := checkSELinux(sc.SELinuxOptions); message != "" {
+			add(ControlSELinux, fieldpath.Join(path, "securityContext", "seLinuxOptions"), "container %q %s", c.Name, message)
+		}
+		if sc.ProcMount != nil && *sc.ProcMount != corev1.DefaultProcMount {
+			add(ControlProcMount, fieldpath.Join(path, "securityContext", "procMount"), "container %q must use the Default procMount", c.Name)
+		}
+	})
+
+	return v
+}
+
+// checkSELinux returns why the SELinux options are not allowed by the
+// baseline level, empty when they are
+func checkSELinux(options *corev1.SELinuxOptions) string {
+	switch {
+	case options == nil:
+		return ""
+	case !baselineSELinuxTypes[options.Type]:
+		return fmt.Sprintf("must not use the SELinux type %q", options.Type)
+	case options.User != "" || options.Role != "":
+		return "must not set a custom SELinux user or role"
+	default:
+		return ""
+	}
+}
+
+func checkRestricted(spec *corev1.PodSpec, base string) []Violation {
+	var v []Violation
+
+	add := func(control string, field string, format string, args ...interface{}) {
+		v = append(v, Violation{Level: Restricted, Control: control, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for i, volume := range spec.Volumes {
+		// hostPath volumes are already reported by the baseline level
+		if volume.HostPath == nil && !restrictedVolume(volume.VolumeSource) {
+			add(ControlVolumeTypes, fieldpath.Join(base, "volumes", i), "volume %q must use an allowed volume type", volume.Name)
+		}
+	}
+
+	pod := spec.SecurityContext
+	podNonRoot := pod != nil && pod.RunAsNonRoot != nil && *pod.RunAsNonRoot
+	podSeccomp := pod != nil && pod.SeccompProfile != nil && allowedSeccomp(pod.SeccompProfile.Type)
+
+	if pod != nil && pod.RunAsUser != nil && *pod.RunAsUser == 0 {
+		add(ControlRunningAsNonRootUser, fieldpath.Join(base, "securityContext", "runAsUser"), "pod must not set runAsUser to 0")
+	}
+
+	forEachContainer(spec, base, func(c *corev1.Container, path string) {
+		sc := c.SecurityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			add(ControlPrivilegeEscalation, fieldpath.Join(path, "securityContext", "allowPrivilegeEscalation"), "container %q must set allowPrivilegeEscalation to false", c.Name)
+		}
+
+		if sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot || sc.RunAsNonRoot == nil && !podNonRoot {
+			add(ControlRunningAsNonRoot, fieldpath.Join(path, "securityContext", "runAsNonRoot"), "container %q must set runAsNonRoot to true", c.Name)
+		}
+
+		if sc.SeccompProfile != nil && !allowedSeccomp(sc.SeccompProfile.Type) || sc.SeccompProfile == nil && !podSeccomp {
+			add(ControlSeccompRestricted, fieldpath.Join(path, "securityContext", "seccompProfile", "type"), "container %q must use the RuntimeDefault or Localhost seccomp profile", c.Name)
+		}
+
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			add(ControlRunningAsNonRootUser, fieldpath.Join(path, "securityContext", "runAsUser"), "container %q must not set runAsUser to 0", c.Name)
+		}
+
+		dropsAll := false
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Drop {
+				if capability == "ALL" {
+					dropsAll = true
+				}
+			}
+
+			for _, capability := range sc.Capabilities.Add {
+				if capability != "NET_BIND_SERVICE" {
+					add(ControlCapabilitiesRestricted, fieldpath.Join(path, "securityContext", "capabilities", "add"), "container %q may only add NET_BIND_SERVICE, not %q", c.Name, capability)
+				}
+			}
+		}
+
+		if !dropsAll {
+			add(ControlCapabilitiesRestricted, fieldpath.Join(path, "securityContext", "capabilities", "drop"), "container %q must drop ALL capabilities", c.Name)
+		}
+	})
+
+	return v
+}
+
+// restrictedVolume reports whether the volume is of a type the restricted
+// level allows, volumes without a source default to emptyDir
+func restrictedVolume(source corev1.VolumeSource) bool {
+	return source == corev1.VolumeSource{} ||
+		source.ConfigMap != nil || source.CSI != nil || source.DownwardAPI != nil ||
+		source.EmptyDir != nil || source.Ephemeral != nil || source.Image != nil ||
+		source.PersistentVolumeClaim != nil || source.Projected != nil || source.Secret != nil
+}
+
+func allowedSeccomp(t corev1.SeccompProfileType) bool {
+	return t == corev1.SeccompProfileTypeRuntimeDefault || t == corev1.SeccompProfileTypeLocalhost
+}
+
+// forEachContainer calls fn for every init and regular container with its
+// JSONPath
+func forEachContainer(spec *corev1.PodSpec, base string, fn func(c *corev1.Container, path string)) {
+	for i := range spec.InitContainers {
+		fn(&spec.InitContainers[i], fieldpath.Join(base, "initContainers", i))
+	}
+	for i := range spec.Containers {
+		fn(&spec.Containers[i], fieldpath.Join(base, "containers", i))
+	}
+}
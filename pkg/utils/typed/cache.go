@@ -0,0 +1,94 @@
+package typed
+
+import (
+	"container/list"
+	"context"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CacheSize is the number of converted objects kept by the cache of a run
+const CacheSize = 1024
+
+type key struct {
+	object uintptr
+	gvk    schema.GroupVersionKind
+}
+
+type entry struct {
+	key key
+	// source keeps the unstructured content alive while cached, so that its
+	// address cannot be reused by another object
+	source map[string]interface{}
+	value  interface{}
+}
+
+// cache is a LRU cache of typed objects keyed by the identity of the
+// unstructured content, so that every linter looking at the same object
+// shares a single conversion. The objects of a run are not modified while it
+// lints them, a cache is therefore scoped to a single run, see WithCache.
+type cache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[key]*list.Element
+}
+
+type contextKey struct{}
+
+// WithCache returns a context carrying a new cache of typed objects, the
+// accessors called with it share their conversions until it is dropped
+func WithCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, newCache(CacheSize))
+}
+
+func cacheFromContext(ctx context.Context) *cache {
+	c, _ := ctx.Value(contextKey{}).(*cache)
+	return c
+}
+
+func newCache(size int) *cache {
+	return &cache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[key]*list.Element),
+	}
+}
+
+func (c *cache) get(obj unstructured.Unstructured, gvk schema.GroupVersionKind, convert func() (interface{}, error)) (interface{}, error) {
+	k := key{object: reflect.ValueOf(obj.Object).Pointer(), gvk: gvk}
+
+	c.mu.Lock()
+	if e, ok := c.entries[k]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*entry).value, nil
+	}
+	c.mu.Unlock()
+
+	value, err := convert()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[k]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*entry).value, nil
+	}
+
+	c.entries[k] = c.order.PushFront(&entry{key: k, source: obj.Object, value: value})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,128 @@
+package typed
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/gvk"
+)
+
+// Typed objects are shared between linters through the cache of the run
+// carried by the context, see WithCache, and must be treated as read-only.
+
+// Deployment converts obj to a typed Deployment
+func Deployment(ctx context.Context, obj unstructured.Unstructured) (*appsv1.Deployment, error) {
+	return convert[appsv1.Deployment](ctx, obj, gvk.Deployment)
+}
+
+// StatefulSet converts obj to a typed StatefulSet
+func StatefulSet(ctx context.Context, obj unstructured.Unstructured) (*appsv1.StatefulSet, error) {
+	return convert[appsv1.StatefulSet](ctx, obj, gvk.StatefulSet)
+}
+
+// DaemonSet converts obj to a typed DaemonSet
+func DaemonSet(ctx context.Context, obj unstructured.Unstructured) (*appsv1.DaemonSet, error) {
+	return convert[appsv1.DaemonSet](ctx, obj, gvk.DaemonSet)
+}
+
+// Job converts obj to a typed Job
+func Job(ctx context.Context, obj unstructured.Unstructured) (*batchv1.Job, error) {
+	return convert[batchv1.Job](ctx, obj, gvk.Job)
+}
+
+// CronJob converts obj to a typed CronJob
+func CronJob(ctx context.Context, obj unstructured.Unstructured) (*batchv1.CronJob, error) {
+	return convert[batchv1.CronJob](ctx, obj, gvk.CronJob)
+}
+
+// Pod converts obj to a typed Pod
+func Pod(ctx context.Context, obj unstructured.Unstructured) (*corev1.Pod, error) {
+	return convert[corev1.Pod](ctx, obj, gvk.Pod)
+}
+
+// PodSpec returns the typed pod spec of the supported workload resource
+// types, see k8s.PodSpecPath for the matching field path
+func PodSpec(ctx context.Context, obj unstructured.Unstructured) (*corev1.PodSpec, error) {
+	switch obj.GroupVersionKind() {
+	case gvk.Deployment:
+		o, err := Deployment(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		return &o.Spec.Template.Spec, nil
+	case gvk.StatefulSet:
+		o, err := StatefulSet(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		return &o.Spec.Template.Spec, nil
+	case gvk.DaemonSet:
+		o, err := DaemonSet(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		return &o.Spec.Template.Spec, nil
+	case gvk.Job:
+		o, err := Job(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		return &o.Spec.Template.Spec, nil
+	case gvk.CronJob:
+		o, err := CronJob(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		return &o.Spec.JobTemplate.Spec.Template.Spec, nil
+	case gvk.Pod:
+		o, err := Pod(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		return &o.Spec, nil
+	default:
+		return nil, fmt.Errorf(
+			"unsuported type: %s:%s",
+			obj.GroupVersionKind().GroupVersion(),
+			obj.GroupVersionKind().Kind,
+		)
+	}
+}
+
+func convert[T any](ctx context.Context, obj unstructured.Unstructured, expected schema.GroupVersionKind) (*T, error) {
+	if obj.GroupVersionKind() != expected {
+		return nil, fmt.Errorf("expected %s, got %s", expected, obj.GroupVersionKind())
+	}
+
+	fn := func() (interface{}, error) {
+		var out T
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &out); err != nil {
+			return nil, fmt.Errorf("failed to convert %s %q: %w", expected.Kind, obj.GetName(), err)
+		}
+
+		return &out, nil
+	}
+
+	c := cacheFromContext(ctx)
+	if c == nil {
+		value, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return value.(*T), nil
+	}
+
+	value, err := c.get(obj, expected, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*T), nil
+}
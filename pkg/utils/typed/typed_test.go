@@ -0,0 +1,77 @@
+package typed_test
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/utils/typed"
+)
+
+func deployment(image string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "web", "image": image},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func image(ctx context.Context, t *testing.T, obj unstructured.Unstructured) string {
+	t.Helper()
+
+	spec, err := typed.PodSpec(ctx, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return spec.Containers[0].Image
+}
+
+// TestCacheScope shares the conversions within a run only, an object
+// modified between two runs is converted again
+func TestCacheScope(t *testing.T) {
+	obj := deployment("nginx:1.27")
+
+	run := typed.WithCache(t.Context())
+
+	first, err := typed.Deployment(run, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := typed.Deployment(run, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("expected the conversion to be shared within a run")
+	}
+
+	containers := obj.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})
+	containers[0].(map[string]interface{})["image"] = "nginx:1.28"
+
+	if got := image(typed.WithCache(t.Context()), t, obj); got != "nginx:1.28" {
+		t.Errorf("expected the next run to see the modified image, got %s", got)
+	}
+
+	if got := image(t.Context(), t, obj); got != "nginx:1.28" {
+		t.Errorf("expected an uncached conversion of the modified image, got %s", got)
+	}
+}
+
+func TestConvertUnexpectedKind(t *testing.T) {
+	obj := deployment("nginx:1.27")
+
+	if _, err := typed.StatefulSet(t.Context(), obj); err == nil {
+		t.Error("expected an error converting a Deployment to a StatefulSet")
+	}
+}
@@ -0,0 +1,132 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/lburgazzoli/k8s-manifests-lint/pkg/credentials"
+)
+
+// Git runs git commands in a working tree. git is run with fixed arguments
+// and the environment of the user, outside of the sandbox of run.sandbox
+// which is meant for the binaries configured by sources and rule packs.
+type Git struct {
+	Dir string
+	// Env are additional environment variables, such as credentials, git is
+	// run with
+	Env []string
+}
+
+// CurrentBranch returns the name of the checked out branch
+func (g *Git) CurrentBranch(ctx context.Context) (string, error) {
+	return g.run(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// CreateBranch creates and checks out a new branch from HEAD
+func (g *Git) CreateBranch(ctx context.Context, name string) error {
+	_, err := g.run(ctx, "checkout", "-b", name)
+	return err
+}
+
+// Commit stages the given files and commits them with the message
+func (g *Git) Commit(ctx context.Context, message string, files ...string) error {
+	if _, err := g.run(ctx, append([]string{"add", "--"}, files...)...); err != nil {
+		return err
+	}
+
+	_, err := g.run(ctx, "commit", "-m", message)
+	return err
+}
+
+// Push pushes the branch to the remote and sets it as upstream
+func (g *Git) Push(ctx context.Context, remote string, branch string) error {
+	_, err := g.run(ctx, "push", "-u", remote, branch)
+	return err
+}
+
+// RemoteURL returns the URL of the remote
+func (g *Git) RemoteURL(ctx context.Context, remote string) (string, error) {
+	return g.run(ctx, "remote", "get-url", remote)
+}
+
+// Revision returns the commit checked out
+func (g *Git) Revision(ctx context.Context) (string, error) {
+	return g.run(ctx, "rev-parse", "HEAD")
+}
+
+// Dirty tells whether the working tree has uncommitted changes
+func (g *Git) Dirty(ctx context.Context) (bool, error) {
+	out, err := g.run(ctx, "status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// Mirror clones the repository at url into Dir as a bare mirror
+func (g *Git) Mirror(ctx context.Context, url string) error {
+	clone := &Git{Env: g.Env}
+	_, err := clone.run(ctx, "clone", "--mirror", "--quiet", url, g.Dir)
+	return err
+}
+
+// Fetch updates the refs of the remote, removing the deleted ones
+func (g *Git) Fetch(ctx context.Context, remote string) error {
+	_, err := g.run(ctx, "fetch", "--prune", "--quiet", remote)
+	return err
+}
+
+// Init creates an empty repository in Dir with origin as its origin remote
+func (g *Git) Init(ctx context.Context, origin string) error {
+	if _, err := g.run(ctx, "init", "--quiet"); err != nil {
+		return err
+	}
+
+	_, err := g.run(ctx, "remote", "add", "origin", origin)
+	return err
+}
+
+// Checkout fetches the commit of ref from url, without its history, and
+// checks it out
+func (g *Git) Checkout(ctx context.Context, url string, ref string) error {
+	if _, err := g.run(ctx, "fetch", "--depth=1", "--quiet", url, ref); err != nil {
+		return err
+	}
+
+	_, err := g.run(ctx, "checkout", "--quiet", "FETCH_HEAD")
+	return err
+}
+
+// UpdateSubmodules checks out the submodules of the working tree
+func (g *Git) UpdateSubmodules(ctx context.Context) error {
+	_, err := g.run(ctx, "submodule", "update", "--init", "--recursive", "--quiet")
+	return err
+}
+
+// SetConfig sets a configuration value of the repository
+func (g *Git) SetConfig(ctx context.Context, key string, value string) error {
+	_, err := g.run(ctx, "config", key, value)
+	return err
+}
+
+func (g *Git) run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.Dir
+	if len(g.Env) > 0 {
+		cmd.Env = append(os.Environ(), g.Env...)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, credentials.Redact(strings.TrimSpace(stderr.String())))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,217 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// PullRequest describes the pull (or merge) request to open
+type PullRequest struct {
+	Title string
+	Body  string
+	// Head is the branch holding the changes, Base the branch to merge into
+	Head string
+	Base string
+}
+
+// Providers hosting the repositories pull requests are opened on
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// Providers are the supported providers
+var Providers = []string{GitHub, GitLab}
+
+// Repository identifies a repository hosted on GitHub or GitLab
+type Repository struct {
+	Host string
+	// Path is owner/name on GitHub and the full project path on GitLab
+	Path string
+	// Provider is GitHub or GitLab, detected from the host when empty
+	Provider string
+	// API is the base URL of the REST API of the provider, when empty it is
+	// https://api.github.com for github.com, https://<host>/api/v3 for GitHub
+	// Enterprise and https://<host>/api/v4 for GitLab
+	API string
+}
+
+// ParseRemote extracts the repository from a git remote URL, both the
+// https://host/owner/repo.git and git@host:owner/repo.git forms are
+// supported
+func ParseRemote(remote string) (Repository, error) {
+	remote = strings.TrimSuffix(strings.TrimSpace(remote), ".git")
+
+	if !strings.Contains(remote, "://") {
+		// scp-like syntax: [user@]host:path
+		if host, path, ok := strings.Cut(remote, ":"); ok {
+			if _, h, ok := strings.Cut(host, "@"); ok {
+				host = h
+			}
+			return Repository{Host: host, Path: strings.Trim(path, "/")}, nil
+		}
+
+		return Repository{}, fmt.Errorf("unsupported remote URL %q", remote)
+	}
+
+	u, err := url.Parse(remote)
+	if err != nil {
+		return Repository{}, fmt.Errorf("invalid remote URL %q: %w", remote, err)
+	}
+
+	return Repository{Host: u.Hostname(), Path: strings.Trim(u.Path, "/")}, nil
+}
+
+// DetectProvider tells the provider from the host: github.com, gitlab.com
+// and the hosts naming one of them, such as github.example.com, are
+// recognized, the others must be configured explicitly
+func DetectProvider(host string) (string, error) {
+	host = strings.ToLower(host)
+
+	switch {
+	case strings.Contains(host, GitHub):
+		return GitHub, nil
+	case strings.Contains(host, GitLab):
+		return GitLab, nil
+	default:
+		return "", fmt.Errorf("cannot tell whether %s is a GitHub or a GitLab host, the provider must be set explicitly", host)
+	}
+}
+
+// OpenPullRequest opens a pull request on GitHub, authenticated by the
+// GITHUB_TOKEN environment variable, or a merge request on GitLab,
+// authenticated by GITLAB_TOKEN. The URL of the created request is returned.
+func OpenPullRequest(ctx context.Context, repo Repository, pr PullRequest) (string, error) {
+	provider := repo.Provider
+	if provider == "" {
+		p, err := DetectProvider(repo.Host)
+		if err != nil {
+			return "", err
+		}
+		provider = p
+	}
+
+	switch provider {
+	case GitHub:
+		return openGitHub(ctx, repo, pr)
+	case GitLab:
+		return openGitLab(ctx, repo, pr)
+	default:
+		return "", fmt.Errorf("unsupported provider %q, supported: %s", provider, strings.Join(Providers, ", "))
+	}
+}
+
+func openGitHub(ctx context.Context, repo Repository, pr PullRequest) (string, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("GITHUB_TOKEN is not set")
+	}
+
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+
+	api := repo.API
+	if api == "" {
+		api = "https://" + repo.Host + "/api/v3"
+		if repo.Host == "github.com" {
+			api = "https://api.github.com"
+		}
+	}
+
+	err := post(ctx,
+		strings.TrimSuffix(api, "/")+"/repos/"+repo.Path+"/pulls",
+		map[string]string{
+			"Authorization": "Bearer " + token,
+			"Accept":        "application/vnd.github+json",
+		},
+		map[string]string{
+			"title": pr.Title,
+			"body":  pr.Body,
+			"head":  pr.Head,
+			"base":  pr.Base,
+		},
+		&created,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to open pull request: %w", err)
+	}
+
+	return created.HTMLURL, nil
+}
+
+func openGitLab(ctx context.Context, repo Repository, pr PullRequest) (string, error) {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("GITLAB_TOKEN is not set")
+	}
+
+	var created struct {
+		WebURL string `json:"web_url"`
+	}
+
+	api := repo.API
+	if api == "" {
+		api = "https://" + repo.Host + "/api/v4"
+	}
+
+	err := post(ctx,
+		strings.TrimSuffix(api, "/")+"/projects/"+url.PathEscape(repo.Path)+"/merge_requests",
+		map[string]string{
+			"PRIVATE-TOKEN": token,
+		},
+		map[string]string{
+			"title":         pr.Title,
+			"description":   pr.Body,
+			"source_branch": pr.Head,
+			"target_branch": pr.Base,
+		},
+		&created,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to open merge request: %w", err)
+	}
+
+	return created.WebURL, nil
+}
+
+func post(ctx context.Context, endpoint string, headers map[string]string, body interface{}, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(content)))
+	}
+
+	return json.Unmarshal(content, out)
+}
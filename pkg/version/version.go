@@ -0,0 +1,5 @@
+package version
+
+// Version is the version of the tool, set at build time with
+// -ldflags "-X github.com/lburgazzoli/k8s-manifests-lint/pkg/version.Version=..."
+var Version = "0.1.0"